@@ -0,0 +1,85 @@
+package client
+
+import (
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+/*
+System.Account中AccountInfo的格式
+*/
+type AccountInfoType int
+
+const (
+	//根据数据的长度判断，同时适用于runtime升级之前的历史区块
+	AccountInfoAuto AccountInfoType = iota
+	//nonce, refcount(u8或者u32), data
+	AccountInfoLegacy
+	//nonce, consumers, providers[, sufficients], data
+	AccountInfoWithProviders
+)
+
+func (t AccountInfoType) String() string {
+	switch t {
+	case AccountInfoLegacy:
+		return "legacy"
+	case AccountInfoWithProviders:
+		return "with providers"
+	}
+	return "auto"
+}
+
+/*
+设置AccountInfo的格式，默认为AccountInfoAuto
+指定格式后数据的长度与格式不一致时（比如Balance的宽度判断错误）解析返回ErrDecodeFailed，而不是得到错误的nonce以及余额
+*/
+func (c *Client) SetAccountInfoType(t AccountInfoType) {
+	c.runtimeMu.Lock()
+	c.accountInfoType = t
+	c.runtimeMu.Unlock()
+}
+
+/*
+当前runtime的AccountInfo格式，没有通过SetAccountInfoType指定时从metadata中判断，runtime升级后重新判断
+metadata中无法判断时返回AccountInfoAuto
+*/
+func (c *Client) AccountInfoType() AccountInfoType {
+	c.runtimeMu.Lock()
+	defer c.runtimeMu.Unlock()
+	if c.accountInfoType != AccountInfoAuto {
+		return c.accountInfoType
+	}
+	if !c.accountInfoDetected {
+		c.detectedAccountInfo = detectAccountInfoType(c.Meta)
+		c.accountInfoDetected = true
+	}
+	return c.detectedAccountInfo
+}
+
+/*
+SetAccountInfoType设置的格式，没有设置时为AccountInfoAuto
+*/
+func (c *Client) accountInfoLayout() AccountInfoType {
+	c.runtimeMu.RLock()
+	defer c.runtimeMu.RUnlock()
+	return c.accountInfoType
+}
+
+/*
+V14之前的metadata中AccountInfo只有类型名字，不同格式的名字相同，所以根据System中记录refcount迁移的storage判断：
+有UpgradedToTripleRefCount或者UpgradedToDualRefCount时为consumers/providers的格式，
+只有UpgradedToU32RefCount时为旧的格式，都没有时返回AccountInfoAuto
+*/
+func detectAccountInfoType(meta *types.Metadata) AccountInfoType {
+	if meta == nil {
+		return AccountInfoAuto
+	}
+	for _, name := range []string{"UpgradedToTripleRefCount", "UpgradedToDualRefCount"} {
+		if _, err := meta.FindStorageEntryMetadata("System", name); err == nil {
+			return AccountInfoWithProviders
+		}
+	}
+	if _, err := meta.FindStorageEntryMetadata("System", "UpgradedToU32RefCount"); err == nil {
+		return AccountInfoLegacy
+	}
+	return AccountInfoAuto
+}
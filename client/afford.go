@@ -0,0 +1,177 @@
+package client
+
+import (
+	"fmt"
+	"github.com/JFJun/bifrost-go/expand"
+	"github.com/JFJun/bifrost-go/utils"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"math/big"
+)
+
+/*
+预估from发送call的手续费（最小单位），使用空签名构造交易后请求payment_queryInfo，不需要私钥
+*/
+func (c *Client) EstimateFee(from string, call types.Call) (*big.Int, error) {
+	extrinsic, blockHash, err := c.dummySignedExtrinsic(from, call)
+	if err != nil {
+		return nil, err
+	}
+	fee, err := c.GetPartialFee(extrinsic, blockHash)
+	if err != nil {
+		return nil, err
+	}
+	partialFee, ok := new(big.Int).SetString(fee, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid partialFee: %s", fee)
+	}
+	return partialFee, nil
+}
+
+/*
+使用空签名构造from发送call的交易，返回交易的hex以及最新的区块hash，只能用于payment_queryInfo
+*/
+func (c *Client) dummySignedExtrinsic(from string, call types.Call) (extrinsic, blockHash string, err error) {
+	err = c.autoCheckRuntime()
+	if err != nil {
+		return "", "", err
+	}
+	pubHex := utils.AccountToPublicKey(from)
+	if pubHex == "" {
+		return "", "", fmt.Errorf("invalid from address: %s", from)
+	}
+	accountInfo, err := c.getAccountInfo(from)
+	if err != nil {
+		return "", "", err
+	}
+	var nonce uint64
+	if accountInfo != nil {
+		nonce = uint64(accountInfo.Nonce)
+	}
+	//payment_queryInfo不校验签名，只需要长度与真实的交易一致
+	var signer expand.MultiAddress
+	signer.SetTypes(0)
+	signer.AccountId = types.NewAccountID(types.MustHexDecodeString(pubHex))
+	ext := expand.NewExtrinsic(call)
+	ext.Signature = expand.ExtrinsicSignatureV4{
+		Signer:    signer,
+		Signature: types.MultiSignature{IsSr25519: true},
+		Era:       types.ExtrinsicEra{IsImmortalEra: true},
+		Nonce:     types.NewUCompactFromUInt(nonce),
+		Tip:       types.NewUCompactFromUInt(0),
+	}
+	ext.Version |= types.ExtrinsicBitSigned
+	extrinsic, err = types.EncodeToHexString(ext)
+	if err != nil {
+		return "", "", fmt.Errorf("encode extrinsic error: %v", err)
+	}
+	blockHash, _, err = c.GetLatestBlock()
+	if err != nil {
+		return "", "", err
+	}
+	return extrinsic, blockHash, nil
+}
+
+/*
+获取Balances.ExistentialDeposit（最小单位），余额低于该值的账户会被删除
+*/
+func (c *Client) GetExistentialDeposit() (*big.Int, error) {
+	err := c.autoCheckRuntime()
+	if err != nil {
+		return nil, err
+	}
+	me, err := expand.NewMetadataExpand(c.runtime().meta)
+	if err != nil {
+		return nil, err
+	}
+	if c.BalanceWidth() == 8 {
+		var ed types.U64
+		err = decodeConstant(me, "Balances", "ExistentialDeposit", &ed)
+		if err != nil {
+			return nil, err
+		}
+		return new(big.Int).SetUint64(uint64(ed)), nil
+	}
+	var ed types.U128
+	err = decodeConstant(me, "Balances", "ExistentialDeposit", &ed)
+	if err != nil {
+		return nil, err
+	}
+	return ed.Int, nil
+}
+
+/*
+检查from是否能够支付amount以及预估的手续费
+keepAlive为true时（transfer_keep_alive）转账后的余额还需要不低于ED；冻结的余额不能使用；
+收款账户不存在时amount需要不低于ED，否则转账会失败
+不能支付时shortfall为还差的金额，可以支付时为0
+*/
+func (c *Client) CanAfford(from, to string, amount *big.Int, keepAlive bool) (ok bool, shortfall *big.Int, err error) {
+	if amount == nil || amount.Sign() < 0 {
+		return false, nil, fmt.Errorf("invalid transfer amount: %v", amount)
+	}
+	err = c.autoCheckRuntime()
+	if err != nil {
+		return false, nil, err
+	}
+	me, err := expand.NewMetadataExpand(c.runtime().meta)
+	if err != nil {
+		return false, nil, fmt.Errorf("new metadata expand error: %v", err)
+	}
+	call, err := me.BalanceTransferBigCall(to, amount, keepAlive)
+	if err != nil {
+		return false, nil, err
+	}
+	fee, err := c.EstimateFee(from, call)
+	if err != nil {
+		return false, nil, err
+	}
+	ed, err := c.GetExistentialDeposit()
+	if err != nil {
+		return false, nil, err
+	}
+	fromInfo, err := c.getAccountInfo(from)
+	if err != nil {
+		return false, nil, err
+	}
+	free := big.NewInt(0)
+	//转账后free不能低于的值：冻结的余额，keepAlive时还有ED
+	floor := big.NewInt(0)
+	if fromInfo != nil {
+		free = u128ToBig(fromInfo.Data.Free)
+		floor = maxBig(u128ToBig(fromInfo.Data.MiscFrozen), u128ToBig(fromInfo.Data.FreeFrozen))
+	}
+	if keepAlive {
+		floor = maxBig(floor, ed)
+	}
+	required := new(big.Int).Add(amount, fee)
+	required.Add(required, floor)
+	shortfall = new(big.Int).Sub(required, free)
+	if amount.Cmp(ed) < 0 {
+		toInfo, err := c.getAccountInfo(to)
+		if err != nil {
+			return false, nil, err
+		}
+		if toInfo == nil {
+			//新账户至少需要ED
+			shortfall = maxBig(shortfall, new(big.Int).Sub(ed, amount))
+		}
+	}
+	if shortfall.Sign() > 0 {
+		return false, shortfall, nil
+	}
+	return true, big.NewInt(0), nil
+}
+
+func u128ToBig(v types.U128) *big.Int {
+	if v.Int == nil {
+		return big.NewInt(0)
+	}
+	return v.Int
+}
+
+func maxBig(a, b *big.Int) *big.Int {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,125 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"github.com/JFJun/bifrost-go/models"
+	"github.com/shopspring/decimal"
+	"log"
+)
+
+/*
+设置为true时，ExtrinsicResponse的Amount以及Fee会根据链的精度转换为带小数的金额（比如"1.5"）
+原始的最小单位金额保存在RawAmount以及RawFee中，默认为false
+*/
+func (c *Client) SetHumanReadableAmounts(humanReadable bool) {
+	c.humanReadable = humanReadable
+}
+
+/*
+获取链的精度，优先使用节点的system_properties中的tokenDecimals，节点没有提供时使用注册表中的精度
+精度为0也是有效的值，是否已经获取由decimalsResolved记录
+*/
+func (c *Client) chainDecimals() (int, error) {
+	c.decimalsMu.Lock()
+	defer c.decimalsMu.Unlock()
+	if c.decimalsResolved {
+		return c.decimals, nil
+	}
+	var properties map[string]interface{}
+	err := c.caller().Call(&properties, "system_properties")
+	if err == nil {
+		if d, ok := tokenDecimals(properties); ok {
+			c.decimals, c.decimalsResolved = d, true
+			return d, nil
+		}
+	}
+	if c.BasicType != nil {
+		if d, regErr := c.BasicType.GetChainDecimal(c.runtime().chainName); regErr == nil {
+			c.decimals, c.decimalsResolved = d, true
+			return d, nil
+		}
+	}
+	if err != nil {
+		return 0, fmt.Errorf("get system properties error: %w", err)
+	}
+	return 0, errors.New("node does not provide tokenDecimals")
+}
+
+/*
+解析system_properties中的tokenDecimals，多资产的链返回数组，此时使用第一个
+*/
+func tokenDecimals(properties map[string]interface{}) (int, bool) {
+	switch v := properties["tokenDecimals"].(type) {
+	case float64:
+		return int(v), v >= 0
+	case []interface{}:
+		if len(v) > 0 {
+			if d, ok := v[0].(float64); ok {
+				return int(d), d >= 0
+			}
+		}
+	}
+	return 0, false
+}
+
+/*
+记录原始金额，开启SetHumanReadableAmounts时将Amount、Fee以及Tip转换为带精度的金额
+获取不到链的精度时只打印日志，金额保持原始值
+*/
+func (c *Client) formatAmounts(blockResp *models.BlockResponse) error {
+	for _, e := range blockResp.Extrinsic {
+		e.RawAmount = e.Amount
+		e.RawFee = e.Fee
+		e.RawTip = e.Tip
+		for _, t := range e.BatchTransfers {
+			t.RawAmount = t.Amount
+		}
+	}
+	if !c.humanReadable || len(blockResp.Extrinsic) == 0 {
+		return nil
+	}
+	decimals, err := c.chainDecimals()
+	if err != nil {
+		//获取不到精度时不影响区块的解析，金额保持原始的最小单位
+		log.Printf("get %d block chain decimals error,Err=[%v]", blockResp.Height, err)
+		return nil
+	}
+	for _, e := range blockResp.Extrinsic {
+		amountDecimals := decimals
+		if e.Asset != nil {
+			//资产的金额使用Assets.Metadata中的精度
+			amountDecimals = e.Asset.Decimals
+		}
+		e.Amount, err = toTokenUnits(e.RawAmount, amountDecimals)
+		if err != nil {
+			return fmt.Errorf("format amount error: %v", err)
+		}
+		e.Fee, err = toTokenUnits(e.RawFee, decimals)
+		if err != nil {
+			return fmt.Errorf("format fee error: %v", err)
+		}
+		e.Tip, err = toTokenUnits(e.RawTip, decimals)
+		if err != nil {
+			return fmt.Errorf("format tip error: %v", err)
+		}
+		for _, t := range e.BatchTransfers {
+			t.Amount, err = toTokenUnits(t.RawAmount, decimals)
+			if err != nil {
+				return fmt.Errorf("format amount error: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+func toTokenUnits(raw string, decimals int) (string, error) {
+	if raw == "" {
+		return "", nil
+	}
+	d, err := decimal.NewFromString(raw)
+	if err != nil {
+		return "", err
+	}
+	return d.Shift(int32(-decimals)).String(), nil
+}
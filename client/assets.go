@@ -0,0 +1,125 @@
+package client
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/JFJun/bifrost-go/expand"
+	"github.com/JFJun/bifrost-go/models"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"math/big"
+	"strconv"
+)
+
+/*
+资产的元数据，Deposit在orml的链上为existential deposit
+*/
+type AssetMeta struct {
+	Name     string `json:"name"`
+	Symbol   string `json:"symbol"`
+	Decimals int    `json:"decimals"`
+	Deposit  string `json:"deposit"`
+}
+
+/*
+通过Assets.Metadata获取资产的名字、符号、精度以及押金
+*/
+func (c *Client) GetAssetMetadata(assetId uint32) (*AssetMeta, error) {
+	var meta expand.AssetMetadata
+	err := c.getAssetStorage("Assets", "Metadata", assetId, &meta)
+	if err != nil {
+		return nil, err
+	}
+	return &AssetMeta{
+		Name:     string(meta.Name),
+		Symbol:   string(meta.Symbol),
+		Decimals: int(meta.Decimals),
+		Deposit:  meta.Deposit.String(),
+	}, nil
+}
+
+/*
+orml的链通过AssetRegistry.Metadata获取资产的元数据
+*/
+func (c *Client) GetOrmlAssetMetadata(assetId uint32) (*AssetMeta, error) {
+	var meta expand.OrmlAssetMetadata
+	err := c.getAssetStorage("AssetRegistry", "Metadata", assetId, &meta)
+	if err != nil {
+		return nil, err
+	}
+	return &AssetMeta{
+		Name:     string(meta.Name),
+		Symbol:   string(meta.Symbol),
+		Decimals: int(meta.Decimals),
+		Deposit:  meta.ExistentialDeposit.String(),
+	}, nil
+}
+
+func (c *Client) getAssetStorage(module, method string, assetId uint32, target interface{}) error {
+	err := c.autoCheckRuntime()
+	if err != nil {
+		return err
+	}
+	arg, err := types.EncodeToBytes(types.NewU32(assetId))
+	if err != nil {
+		return err
+	}
+	key, err := c.BuildStorageKey(module, method, arg)
+	if err != nil {
+		return err
+	}
+	ok, err := c.caller().GetStorageLatest(key, target)
+	if err != nil {
+		return fmt.Errorf("get %s.%s error: %w", module, method, err)
+	}
+	if !ok {
+		return fmt.Errorf("asset %d metadata is not exist", assetId)
+	}
+	return nil
+}
+
+/*
+解析区块时Assets的call中的资产，Symbol以及Decimals通过GetAssetMetadata获取并缓存，获取失败时为空
+*/
+func (c *Client) assetInfo(id string) *models.AssetInfo {
+	assetId, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		return nil
+	}
+	info := &models.AssetInfo{Id: uint32(assetId)}
+	meta, ok := c.assetMetas.Load(info.Id)
+	if !ok {
+		meta, err = c.GetAssetMetadata(info.Id)
+		if err != nil {
+			return info
+		}
+		c.assetMetas.Store(info.Id, meta)
+	}
+	info.Symbol = meta.(*AssetMeta).Symbol
+	info.Decimals = meta.(*AssetMeta).Decimals
+	return info
+}
+
+/*
+Assets.Issued/Burned按extrinsic、资产以及账户区分
+*/
+type assetEventKey struct {
+	extrinsicIdx int
+	assetId      uint32
+	account      string
+}
+
+func (c *Client) addAssetAmount(amounts map[assetEventKey]*big.Int, phase types.Phase, assetId types.U32,
+	who types.AccountID, amount types.U128) {
+	if !phase.IsApplyExtrinsic {
+		return
+	}
+	account, err := c.encodeAddress(hex.EncodeToString(who[:]))
+	if err != nil {
+		return
+	}
+	key := assetEventKey{int(phase.AsApplyExtrinsic), uint32(assetId), account}
+	if amounts[key] == nil {
+		amounts[key] = new(big.Int)
+	}
+	amounts[key].Add(amounts[key], amount.Int)
+}
@@ -0,0 +1,81 @@
+package client
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/JFJun/bifrost-go/expand"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"math/big"
+)
+
+/*
+根据Balances的event计算指定extrinsic引起的每个账户free余额的变化量，key为地址
+增加为正数，减少为负数，包括Transfer、Deposit、Withdraw、Reserved、Unreserved、ReserveRepatriated以及DustLost
+*/
+func (c *Client) GetExtrinsicBalanceChanges(blockHash string, extrinsicIdx int) (map[string]*big.Int, error) {
+	if !isBlockHash(blockHash) {
+		return nil, fmt.Errorf("expected block hash, got %q", blockHash)
+	}
+	eventsHex, err := c.getEventsHex(blockHash)
+	if err != nil {
+		return nil, err
+	}
+	ier, err := c.decodeEventRecords(c.runtime(), eventsHex)
+	if err != nil {
+		return nil, err
+	}
+	return c.balanceChanges(ier, extrinsicIdx)
+}
+
+func (c *Client) balanceChanges(ier expand.IEventRecords, extrinsicIdx int) (map[string]*big.Int, error) {
+	changes := make(map[string]*big.Int)
+	var err error
+	add := func(phase types.Phase, who types.AccountID, amount types.U128, negative bool) {
+		if err != nil || !phase.IsApplyExtrinsic || int(phase.AsApplyExtrinsic) != extrinsicIdx || amount.Int == nil {
+			return
+		}
+		var address string
+		address, err = c.encodeAddress(hex.EncodeToString(who[:]))
+		if err != nil {
+			err = fmt.Errorf("encode address error: %v", err)
+			return
+		}
+		delta := new(big.Int).Set(amount.Int)
+		if negative {
+			delta.Neg(delta)
+		}
+		if _, ok := changes[address]; !ok {
+			changes[address] = big.NewInt(0)
+		}
+		changes[address].Add(changes[address], delta)
+	}
+	for _, e := range ier.GetBalancesTransfer() {
+		add(e.Phase, e.From, e.Value, true)
+		add(e.Phase, e.To, e.Value, false)
+	}
+	for _, e := range ier.GetBalancesDeposit() {
+		add(e.Phase, e.Who, e.Balance, false)
+	}
+	for _, e := range ier.GetBalancesWithdraw() {
+		add(e.Phase, e.Who, e.Balance, true)
+	}
+	for _, e := range ier.GetBalancesReserved() {
+		add(e.Phase, e.Who, e.Balance, true)
+	}
+	for _, e := range ier.GetBalancesUnreserved() {
+		add(e.Phase, e.Who, e.Balance, false)
+	}
+	for _, e := range ier.GetBalancesReserveRepatriated() {
+		//From减少的是reserved，只有转到To的free时才会改变free余额
+		if e.Status == types.Free {
+			add(e.Phase, e.To, e.Balance, false)
+		}
+	}
+	for _, e := range ier.GetBalancesDustLost() {
+		add(e.Phase, e.Who, e.Balance, true)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
@@ -0,0 +1,42 @@
+package client
+
+import (
+	"github.com/JFJun/bifrost-go/expand"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"math/big"
+)
+
+const defaultBalanceWidth = 16
+
+/*
+链的Balance类型的字节数：u128为16，u64为8
+V14之前的metadata没有类型定义，根据Balances.ExistentialDeposit常量（类型为Balance）编码后的长度判断，
+找不到该常量时默认为u128
+*/
+func (c *Client) BalanceWidth() int {
+	c.runtimeMu.Lock()
+	defer c.runtimeMu.Unlock()
+	if c.balanceWidth == 0 {
+		c.balanceWidth = detectBalanceWidth(c.Meta)
+	}
+	return c.balanceWidth
+}
+
+func detectBalanceWidth(meta *types.Metadata) int {
+	if meta == nil {
+		return defaultBalanceWidth
+	}
+	me, err := expand.NewMetadataExpand(meta)
+	if err != nil {
+		return defaultBalanceWidth
+	}
+	_, value, err := me.MV.GetConstants("Balances", "ExistentialDeposit")
+	if err != nil || len(value) != 8 {
+		return defaultBalanceWidth
+	}
+	return 8
+}
+
+func u64ToU128(v types.U64) types.U128 {
+	return types.NewU128(*new(big.Int).SetUint64(uint64(v)))
+}
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"github.com/JFJun/bifrost-go/models"
+	"math/big"
+)
+
+/*
+设置为true时，同一个extrinsic中的多笔转账（比如Utility.batch）合并为一个ExtrinsicResponse，
+每一笔转账放在BatchTransfers中，Amount为所有转账金额之和，手续费只计算一次
+默认为false，即每一笔转账都是一个单独的ExtrinsicResponse
+*/
+func (c *Client) SetGroupBatchTransfers(group bool) {
+	c.groupBatch = group
+}
+
+func groupBatchTransfers(blockResp *models.BlockResponse) {
+	count := make(map[int]int)
+	for _, e := range blockResp.Extrinsic {
+		if e.Type == "transfer" {
+			count[e.ExtrinsicIndex]++
+		}
+	}
+	grouped := make(map[int]*models.ExtrinsicResponse)
+	var extrinsics []*models.ExtrinsicResponse
+	for _, e := range blockResp.Extrinsic {
+		if e.Type != "transfer" || count[e.ExtrinsicIndex] < 2 {
+			extrinsics = append(extrinsics, e)
+			continue
+		}
+		transfer := &models.BatchTransfer{
+			ToAddress:  e.ToAddress,
+			Amount:     e.Amount,
+			Status:     e.Status,
+			NewAccount: e.NewAccount,
+			Memo:       e.Memo,
+		}
+		g, ok := grouped[e.ExtrinsicIndex]
+		if !ok {
+			g = e
+			grouped[e.ExtrinsicIndex] = g
+			extrinsics = append(extrinsics, g)
+			g.ToAddress = ""
+			g.NewAccount = false
+			g.Memo = ""
+			g.BatchTransfers = nil
+		}
+		g.BatchTransfers = append(g.BatchTransfers, transfer)
+		g.Amount = sumAmounts(g.BatchTransfers)
+		if transfer.Status != "success" {
+			g.Status = "fail"
+		}
+	}
+	blockResp.Extrinsic = extrinsics
+}
+
+func sumAmounts(transfers []*models.BatchTransfer) string {
+	total := big.NewInt(0)
+	for _, t := range transfers {
+		amount, ok := new(big.Int).SetString(t.Amount, 10)
+		if ok {
+			total.Add(total, amount)
+		}
+	}
+	return total.String()
+}
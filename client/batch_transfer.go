@@ -0,0 +1,322 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"github.com/JFJun/bifrost-go/expand"
+	"github.com/JFJun/bifrost-go/tx"
+	"github.com/JFJun/bifrost-go/utils"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+const defaultBatchEraPeriod = 64
+
+/*
+批量转账的一个收款方，Amount为最小单位的金额
+*/
+type BatchRecipient struct {
+	To     string
+	Amount *big.Int
+}
+
+type batchTransferConfig struct {
+	keepAlive bool
+	batchAll  bool
+	eraPeriod uint64
+	tip       uint64
+}
+
+/*
+BuildBatchTransfer的可选参数
+*/
+type BatchTransferOption func(*batchTransferConfig)
+
+/*
+使用Balances.transfer_keep_alive，转账后余额低于ED时交易失败而不是销毁账户
+*/
+func WithKeepAlive() BatchTransferOption {
+	return func(cfg *batchTransferConfig) {
+		cfg.keepAlive = true
+	}
+}
+
+/*
+使用Utility.batch_all，任意一笔转账失败时全部回滚；默认为Utility.batch，失败的转账之后的转账不再执行
+*/
+func WithBatchAll() BatchTransferOption {
+	return func(cfg *batchTransferConfig) {
+		cfg.batchAll = true
+	}
+}
+
+/*
+交易最多存活的区块数，默认为64，设置为0时为immortal交易
+*/
+func WithEraPeriod(period uint64) BatchTransferOption {
+	return func(cfg *batchTransferConfig) {
+		cfg.eraPeriod = period
+	}
+}
+
+/*
+给出块节点的小费
+*/
+func WithTip(tip uint64) BatchTransferOption {
+	return func(cfg *batchTransferConfig) {
+		cfg.tip = tip
+	}
+}
+
+/*
+构建一个包含多笔转账的Utility.batch交易，返回的交易已经从链上设置了nonce、era、版本以及genesis hash，
+直接调用SignTransaction签名即可
+*/
+func (c *Client) BuildBatchTransfer(from string, recipients []BatchRecipient, opts ...BatchTransferOption) (*tx.SubstrateTransaction, error) {
+	if len(recipients) == 0 {
+		return nil, errors.New("recipients is empty")
+	}
+	if utils.AddressToPublicKey(from) == "" {
+		return nil, fmt.Errorf("invalid from address: %s", from)
+	}
+	cfg := batchTransferConfig{eraPeriod: defaultBatchEraPeriod}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	//与NewBalanceTransferCall一致，收款地址按metadata中参数的类型以及SetCallAddressType编码
+	calls := make([]types.Call, 0, len(recipients))
+	for i, r := range recipients {
+		call, err := c.NewBalanceTransferCall(r.To, r.Amount, cfg.keepAlive)
+		if err != nil {
+			return nil, fmt.Errorf("build transfer %d error: %v", i, err)
+		}
+		calls = append(calls, call)
+	}
+	batchCall, err := c.NewBatchCall(calls, cfg.batchAll)
+	if err != nil {
+		return nil, fmt.Errorf("build batch call error: %v", err)
+	}
+	nonces, err := c.GetNoncesBatch([]string{from})
+	if err != nil {
+		return nil, err
+	}
+	transaction := tx.NewSubstrateTransaction(from, nonces[from])
+	transaction.SetCall(batchCall)
+	transaction.SetTip(cfg.tip)
+	transaction.SetEra(0, cfg.eraPeriod)
+	err = transaction.FillFromChain(c)
+	if err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+/*
+按数量把收款方拆分为多组，每组构建一个BuildBatchTransfer交易；maxPerBatch<=0时不拆分
+*/
+func (c *Client) ChunkBatchTransfers(recipients []BatchRecipient, maxPerBatch int) [][]BatchRecipient {
+	if len(recipients) == 0 {
+		return nil
+	}
+	if maxPerBatch <= 0 {
+		maxPerBatch = len(recipients)
+	}
+	chunks := make([][]BatchRecipient, 0, (len(recipients)+maxPerBatch-1)/maxPerBatch)
+	for start := 0; start < len(recipients); start += maxPerBatch {
+		end := start + maxPerBatch
+		if end > len(recipients) {
+			end = len(recipients)
+		}
+		chunks = append(chunks, recipients[start:end])
+	}
+	return chunks
+}
+
+/*
+根据区块的weight以及长度限制拆分收款方，保证每组构建的Utility.batch交易不会因为过大被拒绝
+每笔转账的weight通过payment_queryInfo分别查询包含1笔以及2笔转账的batch得到，
+opts与BuildBatchTransfer一致（只使用WithKeepAlive以及WithBatchAll）
+*/
+func (c *Client) ChunkBatchTransfersByWeight(from string, recipients []BatchRecipient, opts ...BatchTransferOption) ([][]BatchRecipient, error) {
+	if len(recipients) == 0 {
+		return nil, errors.New("recipients is empty")
+	}
+	cfg := batchTransferConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	err := c.autoCheckRuntime()
+	if err != nil {
+		return nil, err
+	}
+	me, err := expand.NewMetadataExpand(c.runtime().meta)
+	if err != nil {
+		return nil, fmt.Errorf("new metadata expand error: %v", err)
+	}
+	maxWeight, maxLength, err := extrinsicLimits(me)
+	if err != nil {
+		return nil, err
+	}
+	//金额越大编码后的长度越长，使用最大的金额估算
+	sample := recipients[0]
+	for _, r := range recipients[1:] {
+		if r.Amount != nil && (sample.Amount == nil || r.Amount.Cmp(sample.Amount) > 0) {
+			sample = r
+		}
+	}
+	var (
+		weights [2]uint64
+		lengths [2]uint64
+	)
+	for i := range weights {
+		calls := make([]types.Call, 0, i+1)
+		for j := 0; j <= i; j++ {
+			call, err := c.NewBalanceTransferCall(sample.To, sample.Amount, cfg.keepAlive)
+			if err != nil {
+				return nil, fmt.Errorf("build transfer error: %v", err)
+			}
+			calls = append(calls, call)
+		}
+		batchCall, err := c.NewBatchCall(calls, cfg.batchAll)
+		if err != nil {
+			return nil, fmt.Errorf("build batch call error: %v", err)
+		}
+		extrinsic, blockHash, err := c.dummySignedExtrinsic(from, batchCall)
+		if err != nil {
+			return nil, err
+		}
+		weights[i], err = c.queryWeight(extrinsic, blockHash)
+		if err != nil {
+			return nil, err
+		}
+		lengths[i] = uint64(len(strings.TrimPrefix(extrinsic, "0x")) / 2)
+	}
+	perWeight, baseWeight := splitBatchCost(weights)
+	perLength, baseLength := splitBatchCost(lengths)
+	if baseWeight+perWeight > maxWeight || baseLength+perLength > maxLength {
+		return nil, errors.New("a single transfer exceeds the extrinsic weight or length limit")
+	}
+	maxPerBatch := (maxWeight - baseWeight) / perWeight
+	if n := (maxLength - baseLength) / perLength; n < maxPerBatch {
+		maxPerBatch = n
+	}
+	return c.ChunkBatchTransfers(recipients, int(maxPerBatch)), nil
+}
+
+/*
+根据包含1笔以及2笔转账的batch的值计算每笔转账的值以及batch本身的值，
+结果不是递增时（比如节点返回固定的weight）每笔转账按照整个batch的值计算
+*/
+func splitBatchCost(costs [2]uint64) (per, base uint64) {
+	if costs[1] <= costs[0] {
+		if costs[0] == 0 {
+			return 1, 0
+		}
+		return costs[0], 0
+	}
+	per = costs[1] - costs[0]
+	if per > costs[0] {
+		return per, 0
+	}
+	return per, costs[0] - per
+}
+
+/*
+单个normal交易的weight以及长度上限
+新的runtime使用System.BlockWeights以及System.BlockLength，旧的runtime使用MaximumBlockWeight、MaximumBlockLength以及AvailableBlockRatio
+*/
+func extrinsicLimits(me *expand.MetadataExpand) (maxWeight, maxLength uint64, err error) {
+	var (
+		blockWeights blockWeightsV1
+		perClass     [3]types.U32
+		blockLength  types.U32
+		ratio        types.U32
+	)
+	if decodeConstant(me, "System", "BlockWeights", &blockWeights) == nil {
+		maxWeight = uint64(blockWeights.MaxBlock)
+		if ok, total := blockWeights.Normal.MaxTotal.Unwrap(); ok {
+			maxWeight = uint64(total)
+		}
+		if ok, extrinsic := blockWeights.Normal.MaxExtrinsic.Unwrap(); ok {
+			maxWeight = uint64(extrinsic)
+		}
+	} else {
+		var blockWeight, extrinsicWeight types.U64
+		if decodeConstant(me, "System", "MaximumExtrinsicWeight", &extrinsicWeight) == nil {
+			maxWeight = uint64(extrinsicWeight)
+		} else {
+			err = decodeConstant(me, "System", "MaximumBlockWeight", &blockWeight)
+			if err != nil {
+				return 0, 0, fmt.Errorf("can not get block weight limit: %v", err)
+			}
+			err = decodeConstant(me, "System", "AvailableBlockRatio", &ratio)
+			if err != nil {
+				return 0, 0, fmt.Errorf("can not get block weight limit: %v", err)
+			}
+			maxWeight = perbill(uint64(blockWeight), ratio)
+		}
+	}
+	//BlockLength为normal、operational以及mandatory交易各自的上限
+	err = decodeConstant(me, "System", "BlockLength", &perClass)
+	if err == nil {
+		return maxWeight, uint64(perClass[0]), nil
+	}
+	err = decodeConstant(me, "System", "MaximumBlockLength", &blockLength)
+	if err != nil {
+		return 0, 0, fmt.Errorf("can not get block length limit: %v", err)
+	}
+	if decodeConstant(me, "System", "AvailableBlockRatio", &ratio) == nil {
+		return maxWeight, perbill(uint64(blockLength), ratio), nil
+	}
+	return maxWeight, uint64(blockLength), nil
+}
+
+func perbill(value uint64, ratio types.U32) uint64 {
+	v := new(big.Int).Mul(new(big.Int).SetUint64(value), big.NewInt(int64(ratio)))
+	return v.Div(v, big.NewInt(1000000000)).Uint64()
+}
+
+/*
+frame_system::limits::BlockWeights（weight为u64的版本）
+*/
+type blockWeightsV1 struct {
+	BaseBlock   types.U64
+	MaxBlock    types.U64
+	Normal      weightsPerClass
+	Operational weightsPerClass
+	Mandatory   weightsPerClass
+}
+
+type weightsPerClass struct {
+	BaseExtrinsic types.U64
+	MaxExtrinsic  types.OptionU64
+	MaxTotal      types.OptionU64
+	Reserved      types.OptionU64
+}
+
+/*
+payment_queryInfo返回的weight，新版本的节点返回{ref_time, proof_size}，只使用ref_time
+*/
+func (c *Client) queryWeight(extrinsic, blockHash string) (uint64, error) {
+	var result map[string]interface{}
+	err := c.caller().Call(&result, "payment_queryInfo", extrinsic, blockHash)
+	if err != nil {
+		return 0, fmt.Errorf("get payment info error: %w", err)
+	}
+	weight := result["weight"]
+	if m, ok := weight.(map[string]interface{}); ok {
+		weight = m["ref_time"]
+		if weight == nil {
+			weight = m["refTime"]
+		}
+	}
+	switch w := weight.(type) {
+	case float64:
+		return uint64(w), nil
+	case string:
+		return strconv.ParseUint(w, 10, 64)
+	}
+	return 0, fmt.Errorf("invalid weight in payment info: %v", result["weight"])
+}
@@ -0,0 +1,93 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"github.com/JFJun/bifrost-go/models"
+	"sort"
+	"strings"
+	"sync"
+)
+
+/*
+GetBlocksByRange中获取或者解析失败的区块，Errors的key为区块高度
+*/
+type BlockRangeError struct {
+	Errors map[int64]error
+}
+
+func (e *BlockRangeError) Error() string {
+	heights := e.Heights()
+	msgs := make([]string, 0, len(heights))
+	for _, height := range heights {
+		msgs = append(msgs, fmt.Sprintf("height %d: %v", height, e.Errors[height]))
+	}
+	return fmt.Sprintf("%d blocks failed: %s", len(heights), strings.Join(msgs, "; "))
+}
+
+/*
+失败的区块高度，从小到大排列
+*/
+func (e *BlockRangeError) Heights() []int64 {
+	heights := make([]int64, 0, len(e.Errors))
+	for height := range e.Errors {
+		heights = append(heights, height)
+	}
+	sort.Slice(heights, func(i, j int) bool { return heights[i] < heights[j] })
+	return heights
+}
+
+/*
+使用concurrency个worker并发地获取以及解析[from,to]之间的区块，返回的第i个区块的高度为from+i
+某个区块失败时不影响其它区块，对应的位置为nil，所有失败的区块通过*BlockRangeError返回
+ctx结束时返回ctx.Err()以及已经获取到的区块；concurrency<=0时为1
+*/
+func (c *Client) GetBlocksByRange(ctx context.Context, from, to int64, concurrency int) ([]*models.BlockResponse, error) {
+	if from < 0 || from > to {
+		return nil, fmt.Errorf("invalid block range [%d, %d]", from, to)
+	}
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	var (
+		blocks   = make([]*models.BlockResponse, to-from+1)
+		heights  = make(chan int64)
+		mu       sync.Mutex
+		failures = make(map[int64]error)
+		wg       sync.WaitGroup
+	)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for height := range heights {
+				block, err := c.parseRawBlock(c.fetchRawBlock(height))
+				if err != nil {
+					mu.Lock()
+					failures[height] = err
+					mu.Unlock()
+					continue
+				}
+				//每个worker写入不同的位置，不需要加锁
+				blocks[height-from] = block
+			}
+		}()
+	}
+send:
+	for height := from; height <= to; height++ {
+		select {
+		case heights <- height:
+		case <-ctx.Done():
+			break send
+		}
+	}
+	close(heights)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return blocks, err
+	}
+	if len(failures) > 0 {
+		return blocks, &BlockRangeError{Errors: failures}
+	}
+	return blocks, nil
+}
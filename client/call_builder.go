@@ -0,0 +1,115 @@
+package client
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/JFJun/bifrost-go/expand"
+	"github.com/JFJun/bifrost-go/utils"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"math/big"
+	"strings"
+)
+
+/*
+设置NewBalanceTransferCall等构造的call中地址参数的类型，默认为expand.MultiAddressId
+支持MultiAddressId、MultiAddressRaw、MultiAddressAddress32以及没有MultiAddress的旧链使用的AddressAccountId，
+AddressAccountId是否有0xff前缀由New的noPalletIndices决定；metadata中参数的类型为AccountId时总是直接使用AccountId
+*/
+func (c *Client) SetCallAddressType(t int) {
+	c.callAddressType = t
+}
+
+/*
+构造Balances.transfer（keepAlive为true时为transfer_keep_alive）的call，可以直接用于SubstrateTransaction.SetCall
+dest为当前链prefix的ss58地址或者公钥的hex，amount为最小单位的金额
+*/
+func (c *Client) NewBalanceTransferCall(dest string, amount *big.Int, keepAlive bool) (types.Call, error) {
+	if amount == nil || amount.Sign() < 0 {
+		return types.Call{}, fmt.Errorf("invalid transfer amount: %v", amount)
+	}
+	me, err := c.metadataExpand()
+	if err != nil {
+		return types.Call{}, err
+	}
+	fn := "transfer"
+	if keepAlive {
+		fn = "transfer_keep_alive"
+	}
+	callIdx, err := me.MV.GetCallIndex("Balances", fn)
+	if err != nil {
+		return types.Call{}, err
+	}
+	address, err := c.callAddressArg(me, callIdx, dest)
+	if err != nil {
+		return types.Call{}, err
+	}
+	return expand.NewCall(callIdx, address, types.NewUCompact(amount))
+}
+
+/*
+构造Utility.batch（batchAll为true时为batch_all，任意一个call失败则全部回滚）的call
+*/
+func (c *Client) NewBatchCall(calls []types.Call, batchAll bool) (types.Call, error) {
+	me, err := c.metadataExpand()
+	if err != nil {
+		return types.Call{}, err
+	}
+	return me.UtilityBatchCall(calls, batchAll)
+}
+
+func (c *Client) metadataExpand() (*expand.MetadataExpand, error) {
+	err := c.autoCheckRuntime()
+	if err != nil {
+		return nil, err
+	}
+	me, err := expand.NewMetadataExpand(c.runtime().meta)
+	if err != nil {
+		return nil, fmt.Errorf("new metadata expand error: %v", err)
+	}
+	return me, nil
+}
+
+/*
+call的第一个账户参数，根据metadata中参数的类型以及SetCallAddressType编码
+*/
+func (c *Client) callAddressArg(me *expand.MetadataExpand, callIdx, address string) (interface{}, error) {
+	pub, err := c.addressToPub(address)
+	if err != nil {
+		return nil, err
+	}
+	args, err := me.MV.FindCallArgs(callIdx)
+	if err == nil && len(args) > 0 && (args[0].Type == "T::AccountId" || args[0].Type == "AccountId") {
+		return types.NewAccountID(pub), nil
+	}
+	var ma expand.MultiAddress
+	ma.SetTypes(c.callAddressType)
+	switch c.callAddressType {
+	case expand.MultiAddressId, expand.AddressAccountId:
+		ma.AccountId = types.NewAccountID(pub)
+	case expand.MultiAddressRaw:
+		ma.Raw = types.NewBytes(pub)
+	case expand.MultiAddressAddress32:
+		ma.Address32 = types.NewH256(pub)
+	default:
+		return nil, fmt.Errorf("unsupported call address type: %d", c.callAddressType)
+	}
+	return ma, nil
+}
+
+/*
+地址转换为公钥，ss58地址的prefix需要与当前链一致，避免把其它链的地址当作收款地址
+*/
+func (c *Client) addressToPub(address string) ([]byte, error) {
+	pubHex := utils.AccountToPublicKey(address)
+	if pubHex == "" {
+		return nil, fmt.Errorf("invalid address: %s", address)
+	}
+	isPubHex := strings.ToLower(utils.Remove0X(address)) == pubHex
+	if !isPubHex && c.addressEncoder == nil && len(c.prefix) > 0 {
+		expected, err := c.encodeAddress(pubHex)
+		if err != nil || expected != address {
+			return nil, fmt.Errorf("address %s does not match chain prefix %x", address, c.prefix)
+		}
+	}
+	return hex.DecodeString(pubHex)
+}
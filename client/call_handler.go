@@ -0,0 +1,495 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/JFJun/bifrost-go/models"
+	"github.com/JFJun/bifrost-go/utils"
+	"strconv"
+)
+
+/*
+RegisterCallHandler注册的解析函数，resp为extrinsic的解码结果，idx为extrinsic在区块中的下标
+返回的ExtrinsicResponse只需要设置Type、FromAddress、ToAddress、Amount、Memo以及Params，
+签名、nonce、era、txid、长度以及下标在解析区块时填充，FromAddress为空时为交易的签名者
+返回nil表示这个extrinsic不产生ExtrinsicResponse，返回error时跳过这个extrinsic并记录日志
+resp中MultiAddress::Index类型的参数已经按所在区块的Indices.Accounts转换为MultiAddress::Id，查询失败时保留原值
+*/
+type CallHandler func(resp models.ExtrinsicDecodeResponse, idx int) (*models.ExtrinsicResponse, error)
+
+/*
+解析区块时使用的call解析函数，一个extrinsic可以产生多个结果（比如Utility.batch中的每一笔转账）
+*/
+type callHandler func(c *Client, rt runtimeSnapshot, resp models.ExtrinsicDecodeResponse, extrinsic string, idx int) ([]parseBlockExtrinsicParams, error)
+
+/*
+内置的解析函数，创建Client时通过setCallHandler注册，之后可以被RegisterCallHandler覆盖或者取消
+*/
+func (c *Client) registerDefaultCallHandlers() {
+	c.setCallHandler("Balances", "transfer", parseBalancesTransfer)
+	c.setCallHandler("Balances", "transfer_keep_alive", parseBalancesTransfer)
+	c.setCallHandler("Utility", "batch", parseUtilityBatch)
+	c.setCallHandler("Utility", "as_derivative", parseUtilityAsDerivative)
+	c.setCallHandler("Utility", "dispatch_as", parseUtilityDispatchAs)
+	c.setCallHandler("Staking", "unbond", parseStaking)
+	c.setCallHandler("Staking", "withdraw_unbonded", parseStaking)
+	for _, module := range []string{"PhragmenElection", "Elections", "ElectionsPhragmen"} {
+		c.setCallHandler(module, "vote", parseCouncilElection)
+		c.setCallHandler(module, "submit_candidacy", parseCouncilElection)
+	}
+	c.setCallHandler("Assets", "mint", parseAssets)
+	c.setCallHandler("Assets", "burn", parseAssets)
+	c.setCallHandler("Assets", "force_transfer", parseAssets)
+	for _, module := range []string{"BagsList", "VoterList"} {
+		c.setCallHandler(module, "rebag", parseBagsList)
+		c.setCallHandler(module, "put_in_front_of", parseBagsList)
+	}
+	for _, module := range []string{"PolkadotXcm", "XcmPallet"} {
+		c.setCallHandler(module, "send", parseXcm)
+		c.setCallHandler(module, "execute", parseXcm)
+	}
+	c.setCallHandler("Proxy", "announce", parseProxyAnnounce)
+	c.setCallHandler("Proxy", "proxy_announced", parseProxyAnnounced)
+}
+
+/*
+注册module.function的解析函数，用于解析库中没有内置的call（比如ORML的Currencies.transfer、Balances.transfer_all），
+也可以覆盖内置的解析；fn为nil时取消注册，内置的解析函数也可以取消
+call的参数需要先能被解码：自定义的参数类型需要通过RegisterType注册，设置了SetCallAllowlist时需要包含这个call
+Type为空时按"transfer"处理，状态根据对应的Balances.Transfer判断，其它Type只根据System.ExtrinsicFailed判断
+*/
+func (c *Client) RegisterCallHandler(module, function string, fn CallHandler) {
+	if fn == nil {
+		c.setCallHandler(module, function, nil)
+		return
+	}
+	c.setCallHandler(module, function, func(c *Client, rt runtimeSnapshot, resp models.ExtrinsicDecodeResponse, extrinsic string, idx int) ([]parseBlockExtrinsicParams, error) {
+		result, err := fn(c.resolveIndexParams(rt, resp), idx)
+		if err != nil || result == nil {
+			return nil, err
+		}
+		blockData := c.newExtrinsicParams(resp, extrinsic, idx)
+		if result.FromAddress != "" {
+			blockData.from = result.FromAddress
+		}
+		blockData.to = result.ToAddress
+		blockData.amount = result.Amount
+		blockData.typ = result.Type
+		blockData.memo = result.Memo
+		blockData.params = result.Params
+		return []parseBlockExtrinsicParams{blockData}, nil
+	})
+}
+
+/*
+内置以及RegisterCallHandler注册的解析函数都通过这里写入，handler为nil时删除
+*/
+func (c *Client) setCallHandler(module, function string, handler callHandler) {
+	c.handlerMu.Lock()
+	defer c.handlerMu.Unlock()
+	key := module + "." + function
+	if handler == nil {
+		delete(c.callHandlers, key)
+		return
+	}
+	if c.callHandlers == nil {
+		c.callHandlers = make(map[string]callHandler)
+	}
+	c.callHandlers[key] = handler
+}
+
+/*
+将解码结果中的账户参数（MultiAddress或者AccountId）转换为当前prefix的地址，可以在CallHandler中使用
+CallHandler收到的参数中账户索引已经按所在区块的状态转换为MultiAddress::Id，
+仍然是MultiAddress::Index时说明这个索引在该区块中不存在，不能按最新的状态查询
+*/
+func (c *Client) ParamAddress(param models.ExtrinsicDecodeParam) (string, error) {
+	raw := rawOrValue(param.ValueRaw, param.Value)
+	if param.Type == "MultiAddress::Index" {
+		return "", fmt.Errorf("account index %s is not exist in the block", raw)
+	}
+	//不是账户索引时不需要查询链上状态，也不需要metadata
+	return c.destToAddress(runtimeSnapshot{}, param.Type, raw)
+}
+
+/*
+将MultiAddress::Index类型的参数按快照所在的区块转换为MultiAddress::Id，不修改原来的resp
+*/
+func (c *Client) resolveIndexParams(rt runtimeSnapshot, resp models.ExtrinsicDecodeResponse) models.ExtrinsicDecodeResponse {
+	params := make([]models.ExtrinsicDecodeParam, len(resp.Params))
+	copy(params, resp.Params)
+	for i, param := range params {
+		if param.Type != "MultiAddress::Index" {
+			continue
+		}
+		index, err := strconv.ParseUint(rawOrValue(param.ValueRaw, param.Value), 10, 32)
+		if err != nil {
+			continue
+		}
+		pub, err := c.accountIndexPub(rt, uint32(index))
+		if err != nil {
+			continue
+		}
+		params[i].Type = "MultiAddress::Id"
+		params[i].Value = pub
+		params[i].ValueRaw = pub
+	}
+	resp.Params = params
+	return resp
+}
+
+func (c *Client) lookupCallHandler(module, function string) (callHandler, bool) {
+	c.handlerMu.RLock()
+	defer c.handlerMu.RUnlock()
+	handler, ok := c.callHandlers[module+"."+function]
+	return handler, ok
+}
+
+/*
+执行解析函数，panic转换为error，一个call解析出错不会导致整个区块的解析失败
+*/
+func (c *Client) runCallHandler(handler callHandler, rt runtimeSnapshot, resp models.ExtrinsicDecodeResponse,
+	extrinsic string, idx int) (params []parseBlockExtrinsicParams, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			params = nil
+			err = fmt.Errorf("call handler panic: %v", r)
+		}
+	}()
+	return handler(c, rt, resp, extrinsic, idx)
+}
+
+/*
+extrinsic的基本信息：签名者、签名、nonce、era、txid、长度以及下标
+*/
+func (c *Client) newExtrinsicParams(resp models.ExtrinsicDecodeResponse, extrinsic string, idx int) parseBlockExtrinsicParams {
+	blockData := parseBlockExtrinsicParams{}
+	blockData.from, _ = c.encodeAddress(resp.AccountId)
+	blockData.era = resp.Era
+	blockData.sig = resp.Signature
+	blockData.nonce = resp.Nonce
+	blockData.extrinsicIdx = idx
+	blockData.txid = c.createTxHash(extrinsic)
+	blockData.length = resp.Length
+	return blockData
+}
+
+/*
+Balances.transfer以及Balances.transfer_keep_alive
+*/
+func parseBalancesTransfer(c *Client, rt runtimeSnapshot, resp models.ExtrinsicDecodeResponse, extrinsic string, idx int) ([]parseBlockExtrinsicParams, error) {
+	blockData := c.newExtrinsicParams(resp, extrinsic, idx)
+	for _, param := range resp.Params {
+		if param.Name == "dest" {
+			blockData.to, _ = c.destToAddress(rt, param.Type, rawOrValue(param.ValueRaw, param.Value))
+		}
+		if param.Name == "value" {
+			blockData.amount, _ = utils.ValueToString(param.Value)
+		}
+	}
+	return []parseBlockExtrinsicParams{blockData}, nil
+}
+
+/*
+Utility.batch中的每一笔Balances.transfer/transfer_keep_alive，System.remark作为转账的memo
+*/
+func parseUtilityBatch(c *Client, rt runtimeSnapshot, resp models.ExtrinsicDecodeResponse, extrinsic string, idx int) ([]parseBlockExtrinsicParams, error) {
+	var params []parseBlockExtrinsicParams
+	for _, param := range resp.Params {
+		if param.Name != "calls" {
+			continue
+		}
+		if _, ok := param.Value.([]interface{}); !ok {
+			continue
+		}
+		d, _ := json.Marshal(param.Value)
+		var values []models.UtilityParamsValue
+		err := unmarshalParams(d, &values)
+		if err != nil {
+			continue
+		}
+
+		var (
+			batchParams []parseBlockExtrinsicParams
+			pendingMemo string //出现在第一笔转账之前的remark
+		)
+		for _, value := range values {
+			if value.CallModule == "System" {
+				//remark作为前一笔转账的memo，前面没有转账时作为下一笔转账的memo
+				for _, arg := range value.CallArgs {
+					if arg.Name != "remark" {
+						continue
+					}
+					memo := decodeMemo(arg.ValueRaw)
+					if n := len(batchParams); n > 0 && batchParams[n-1].memo == "" {
+						batchParams[n-1].memo = memo
+					} else if pendingMemo == "" {
+						pendingMemo = memo
+					}
+				}
+				continue
+			}
+			if value.CallModule != "Balances" ||
+				(value.CallFunction != "transfer" && value.CallFunction != "transfer_keep_alive") {
+				continue
+			}
+			for _, arg := range value.CallArgs {
+				if arg.Name == "dest" {
+					blockData := c.newExtrinsicParams(resp, extrinsic, idx)
+					blockData.to, _ = c.destToAddress(rt, arg.Type, rawOrValue(arg.ValueRaw, arg.Value))
+					//转账成功时以Balances.Transfer中的金额为准
+					blockData.amount = callArgAmount(value.CallArgs)
+					blockData.memo = pendingMemo
+					pendingMemo = ""
+					batchParams = append(batchParams, blockData)
+				}
+			}
+		}
+		params = append(params, batchParams...)
+	}
+	return params, nil
+}
+
+/*
+Utility.as_derivative中的Balances.transfer/transfer_keep_alive，实际的发送者是派生出来的子账户
+*/
+func parseUtilityAsDerivative(c *Client, rt runtimeSnapshot, resp models.ExtrinsicDecodeResponse, extrinsic string, idx int) ([]parseBlockExtrinsicParams, error) {
+	var (
+		index uint16
+		inner models.UtilityParamsValue
+	)
+	for _, param := range resp.Params {
+		if param.Name == "index" {
+			v, _ := utils.ValueToFloat64(param.Value)
+			index = uint16(v)
+		}
+		if param.Name == "call" {
+			d, _ := json.Marshal(param.Value)
+			err := unmarshalParams(d, &inner)
+			if err != nil {
+				continue
+			}
+		}
+	}
+	if inner.CallModule != "Balances" ||
+		(inner.CallFunction != "transfer" && inner.CallFunction != "transfer_keep_alive") {
+		return nil, nil
+	}
+	subPub, err := utils.DeriveSubAccount(resp.AccountId, index)
+	if err != nil {
+		return nil, nil
+	}
+	blockData := c.newExtrinsicParams(resp, extrinsic, idx)
+	blockData.from, _ = c.encodeAddress(subPub)
+	for _, arg := range inner.CallArgs {
+		if arg.Name == "dest" {
+			blockData.to, _ = c.destToAddress(rt, arg.Type, rawOrValue(arg.ValueRaw, arg.Value))
+		}
+		if arg.Name == "value" {
+			blockData.amount, _ = utils.ValueToString(arg.Value)
+		}
+	}
+	return []parseBlockExtrinsicParams{blockData}, nil
+}
+
+/*
+Utility.dispatch_as中的Balances.transfer/transfer_keep_alive，以Signed(account)的身份执行时实际的发送者是该账户
+*/
+func parseUtilityDispatchAs(c *Client, rt runtimeSnapshot, resp models.ExtrinsicDecodeResponse, extrinsic string, idx int) ([]parseBlockExtrinsicParams, error) {
+	var (
+		origin models.ExtrinsicDecodeParam
+		inner  models.UtilityParamsValue
+	)
+	for _, param := range resp.Params {
+		if param.Name == "as_origin" {
+			origin = param
+		}
+		if param.Name == "call" {
+			d, _ := json.Marshal(param.Value)
+			err := unmarshalParams(d, &inner)
+			if err != nil {
+				continue
+			}
+		}
+	}
+	blockData := c.newExtrinsicParams(resp, extrinsic, idx)
+	blockData.dispatchedAs, _ = utils.ValueToString(origin.Value)
+	if blockData.dispatchedAs == "Signed" && origin.ValueRaw != "" {
+		blockData.from, _ = c.encodeAddress(origin.ValueRaw)
+		blockData.dispatchedAs = "Signed(" + blockData.from + ")"
+	}
+	if inner.CallModule != "Balances" ||
+		(inner.CallFunction != "transfer" && inner.CallFunction != "transfer_keep_alive") {
+		if !c.includeUnparsed {
+			return nil, nil
+		}
+		unparsed := c.unparsedExtrinsicParams(rt, resp, extrinsic, idx)
+		unparsed.dispatchedAs = blockData.dispatchedAs
+		return []parseBlockExtrinsicParams{unparsed}, nil
+	}
+	for _, arg := range inner.CallArgs {
+		if arg.Name == "dest" {
+			blockData.to, _ = c.destToAddress(rt, arg.Type, rawOrValue(arg.ValueRaw, arg.Value))
+		}
+		if arg.Name == "value" {
+			blockData.amount, _ = utils.ValueToString(arg.Value)
+		}
+	}
+	return []parseBlockExtrinsicParams{blockData}, nil
+}
+
+/*
+Staking.unbond以及Staking.withdraw_unbonded
+*/
+func parseStaking(c *Client, rt runtimeSnapshot, resp models.ExtrinsicDecodeResponse, extrinsic string, idx int) ([]parseBlockExtrinsicParams, error) {
+	blockData := c.newExtrinsicParams(resp, extrinsic, idx)
+	switch resp.CallModuleFunction {
+	case "unbond":
+		blockData.typ = "staking_unbond"
+	case "withdraw_unbonded":
+		blockData.typ = "staking_withdraw"
+	}
+	for _, param := range resp.Params {
+		if param.Name == "value" {
+			//请求解绑的金额，实际金额以Staking.Unbonded为准
+			blockData.amount, _ = utils.ValueToString(param.Value)
+		}
+	}
+	return []parseBlockExtrinsicParams{blockData}, nil
+}
+
+/*
+议会选举（PhragmenElection、Elections或者ElectionsPhragmen）的vote以及submit_candidacy
+*/
+func parseCouncilElection(c *Client, rt runtimeSnapshot, resp models.ExtrinsicDecodeResponse, extrinsic string, idx int) ([]parseBlockExtrinsicParams, error) {
+	blockData := c.newExtrinsicParams(resp, extrinsic, idx)
+	switch resp.CallModuleFunction {
+	case "vote":
+		blockData.typ = "council_vote"
+	case "submit_candidacy":
+		blockData.typ = "council_candidacy"
+	}
+	for _, param := range resp.Params {
+		switch param.Name {
+		case "value":
+			//投票锁定的金额
+			blockData.amount, _ = utils.ValueToString(param.Value)
+		case "votes":
+			param.Value = c.encodeAddresses(param.Value)
+		}
+		blockData.params = append(blockData.params, param)
+	}
+	return []parseBlockExtrinsicParams{blockData}, nil
+}
+
+/*
+pallet-assets的mint、burn以及force_transfer
+*/
+func parseAssets(c *Client, rt runtimeSnapshot, resp models.ExtrinsicDecodeResponse, extrinsic string, idx int) ([]parseBlockExtrinsicParams, error) {
+	blockData := c.newExtrinsicParams(resp, extrinsic, idx)
+	switch resp.CallModuleFunction {
+	case "mint":
+		blockData.typ = "asset_mint"
+	case "burn":
+		blockData.typ = "asset_burn"
+	case "force_transfer":
+		blockData.typ = "asset_force_transfer"
+	}
+	for _, param := range resp.Params {
+		switch param.Name {
+		case "id":
+			id, _ := utils.ValueToString(param.Value)
+			blockData.asset = c.assetInfo(id)
+		case "beneficiary", "who", "dest":
+			//mint为收款账户，burn为被销毁资产的账户，force_transfer为收款账户
+			blockData.to, _ = c.destToAddress(rt, param.Type, rawOrValue(param.ValueRaw, param.Value))
+		case "source":
+			param.Value, _ = c.destToAddress(rt, param.Type, rawOrValue(param.ValueRaw, param.Value))
+		case "amount":
+			//实际的金额以Assets.Issued/Burned为准
+			blockData.amount, _ = utils.ValueToString(param.Value)
+		}
+		blockData.params = append(blockData.params, param)
+	}
+	return []parseBlockExtrinsicParams{blockData}, nil
+}
+
+/*
+BagsList（VoterList）的rebag以及put_in_front_of，to为被移动的账户：rebag的dislocated或者put_in_front_of的lighter
+*/
+func parseBagsList(c *Client, rt runtimeSnapshot, resp models.ExtrinsicDecodeResponse, extrinsic string, idx int) ([]parseBlockExtrinsicParams, error) {
+	blockData := c.newExtrinsicParams(resp, extrinsic, idx)
+	blockData.typ = "bags_list"
+	for _, param := range resp.Params {
+		if param.Name == "dislocated" || param.Name == "lighter" {
+			blockData.to, _ = c.destToAddress(rt, param.Type, rawOrValue(param.ValueRaw, param.Value))
+		}
+	}
+	blockData.params = resp.Params
+	return []parseBlockExtrinsicParams{blockData}, nil
+}
+
+/*
+PolkadotXcm（XcmPallet）的send以及execute，params为dest以及解析后的xcm消息
+*/
+func parseXcm(c *Client, rt runtimeSnapshot, resp models.ExtrinsicDecodeResponse, extrinsic string, idx int) ([]parseBlockExtrinsicParams, error) {
+	blockData := c.newExtrinsicParams(resp, extrinsic, idx)
+	switch resp.CallModuleFunction {
+	case "send":
+		blockData.typ = "xcm_send"
+	case "execute":
+		blockData.typ = "xcm_execute"
+	}
+	blockData.params = resp.Params
+	return []parseBlockExtrinsicParams{blockData}, nil
+}
+
+/*
+Proxy.announce：延迟代理的声明，to为被代理的账户
+*/
+func parseProxyAnnounce(c *Client, rt runtimeSnapshot, resp models.ExtrinsicDecodeResponse, extrinsic string, idx int) ([]parseBlockExtrinsicParams, error) {
+	blockData := c.newExtrinsicParams(resp, extrinsic, idx)
+	blockData.typ = "proxy_announce"
+	for _, param := range resp.Params {
+		if param.Name == "real" {
+			blockData.to, _ = c.destToAddress(rt, param.Type, rawOrValue(param.ValueRaw, param.Value))
+		}
+		if param.Name == "call_hash" {
+			blockData.callHash, _ = utils.ValueToString(param.Value)
+		}
+	}
+	return []parseBlockExtrinsicParams{blockData}, nil
+}
+
+/*
+Proxy.proxy_announced中的Balances.transfer/transfer_keep_alive：执行声明过的call，实际的发送者是被代理的账户
+*/
+func parseProxyAnnounced(c *Client, rt runtimeSnapshot, resp models.ExtrinsicDecodeResponse, extrinsic string, idx int) ([]parseBlockExtrinsicParams, error) {
+	blockData := c.newExtrinsicParams(resp, extrinsic, idx)
+	var inner models.UtilityParamsValue
+	for _, param := range resp.Params {
+		if param.Name == "real" {
+			blockData.from, _ = c.destToAddress(rt, param.Type, rawOrValue(param.ValueRaw, param.Value))
+		}
+		if param.Name == "call" {
+			d, _ := json.Marshal(param.Value)
+			err := unmarshalParams(d, &inner)
+			if err != nil {
+				continue
+			}
+		}
+	}
+	if inner.CallModule != "Balances" ||
+		(inner.CallFunction != "transfer" && inner.CallFunction != "transfer_keep_alive") {
+		return nil, nil
+	}
+	for _, arg := range inner.CallArgs {
+		if arg.Name == "dest" {
+			blockData.to, _ = c.destToAddress(rt, arg.Type, rawOrValue(arg.ValueRaw, arg.Value))
+		}
+		if arg.Name == "value" {
+			blockData.amount, _ = utils.ValueToString(arg.Value)
+		}
+	}
+	return []parseBlockExtrinsicParams{blockData}, nil
+}
@@ -18,47 +18,119 @@ import (
 	"github.com/stafiprotocol/go-substrate-rpc-client/types"
 	"golang.org/x/crypto/blake2b"
 	"log"
+	"math"
 	"math/big"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode/utf8"
 )
 
 type Client struct {
-	C                  *gsrc.SubstrateAPI
+	C                  *gsrc.SubstrateAPI //websocket的连接，重连后会被替换，需要在rpcMu的保护下读取
+	rpc                RPCCaller
+	rpcMu              sync.RWMutex //保护C、rpc以及rpcGen，websocket断开后重连时替换
+	rpcGen             uint64       //每次重连加1，用于判断出错的连接是否已经被其它goroutine替换
 	Meta               *types.Metadata
 	prefix             []byte //币种的前缀
 	ChainName          string //链名字
 	SpecVersion        int
 	TransactionVersion int
+	genesisMu          sync.Mutex //保护genesisHash以及genesisOverride，GetGenesisHash可能被并发调用
 	genesisHash        string
+	genesisOverride    string //SetGenesisHash设置的genesis hash，优先于节点返回的
 	BasicType          *base.BasicTypes
 	url                string
+	includeUnparsed    bool //是否返回没有解析参数的extrinsic
+	humanReadable      bool //Amount以及Fee是否转换为带精度的金额
+	groupBatch         bool //同一个extrinsic中的多笔转账是否合并为一个ExtrinsicResponse
+	noAutoRuntimeCheck bool //为true时不在每次请求前检查runtime版本
+	noFetchFees        bool //为true时解析区块不请求payment_queryInfo获取手续费
+	addressEncoder     func(pubHex string) (string, error)
+	balanceWidth       int //Balance类型的字节数，0表示还没有从metadata中检测
+	maxEvents          int //一个区块最多允许的event数量
+	prefetchDepth      int //IterateBlocks预先获取的区块数量
+	typeRegistry       *expand.TypeRegistry
+	callAllowlist      map[string]bool //需要解析参数的模块或者call，为空时全部解析
+	batchUnsupported   int32           //节点不支持json-rpc批量请求时为1，使用atomic读写
+	watchMu            sync.RWMutex
+	watched            map[string]bool //WatchAddresses监听的地址的公钥
+	decimals           int
+	decimalsResolved   bool //decimals是否已经获取，精度为0的链decimals为0
+	//metadata中找不到的Module错误的名字，SetModuleErrorResolver
+	errorResolver func(moduleIndex, errorIndex uint8) (string, bool)
+	runtimeMu     sync.RWMutex //保护Meta、SpecVersion、ChainName、TransactionVersion、balanceWidth以及accountInfoType的更新
+	//OnRuntimeUpgrade设置的回调
+	upgradeHandler func(old, new RuntimeInfo)
+	//Assets.Metadata的缓存，asset id -> *AssetMeta
+	assetMetas sync.Map
+	//历史区块的metadata的缓存，spec version -> *types.Metadata
+	blockMetas sync.Map
+	handlerMu  sync.RWMutex
+	//RegisterCallHandler注册的解析函数，"模块.方法" -> 解析函数
+	callHandlers map[string]callHandler
+	//SetAccountInfoType设置的格式，AccountInfoAuto时使用从metadata中判断的格式
+	accountInfoType     AccountInfoType
+	detectedAccountInfo AccountInfoType
+	accountInfoDetected bool
+	decimalsMu          sync.Mutex //保护decimals的懒加载，GetBlocksByRange会并发解析区块
+	callAddressType     int        //NewBalanceTransferCall等构造的call中地址参数的类型，expand.MultiAddressId等
 }
 
 func New(url string, noPalletIndices bool) (*Client, error) {
 	c := new(Client)
 	c.url = url
 	var err error
-	//注册链的基本信息
-	c.BasicType, err = base.InitBasicTypesByHexData()
-	if err != nil {
-		return nil, fmt.Errorf("init base type error: %v", err)
+	//http(s)的节点使用http的json-rpc，不支持订阅
+	if isHTTPURL(url) {
+		c.rpc = newHTTPRPC(url)
+		return c.init(noPalletIndices)
 	}
 	// 初始化rpc客户端
 	c.C, err = gsrc.NewSubstrateAPI(url)
 	if err != nil {
 		return nil, err
 	}
+	c.rpc = newSubstrateRPC(c.C)
+	return c.init(noPalletIndices)
+}
+
+/*
+使用自定义的rpc接口创建Client，主要用于注入mock进行离线测试
+*/
+func NewWithRPCCaller(caller RPCCaller, noPalletIndices bool) (*Client, error) {
+	if caller == nil {
+		return nil, errors.New("rpc caller is nil")
+	}
+	c := new(Client)
+	c.rpc = caller
+	return c.init(noPalletIndices)
+}
+
+func (c *Client) init(noPalletIndices bool) (*Client, error) {
+	var err error
+	c.registerDefaultCallHandlers()
+	//注册链的基本信息
+	c.BasicType, err = base.InitBasicTypesByHexData()
+	if err != nil {
+		return nil, fmt.Errorf("init base type error: %v", err)
+	}
 	//检查当前链运行的版本
 	err = c.checkRuntimeVersion()
 	if err != nil {
 		return nil, err
 	}
 	/*
-		设置prefix
+		设置prefix，优先使用节点返回的ss58Format，获取失败时再从注册表中查找
 	*/
 	if len(c.prefix) == 0 {
-		c.prefix, _ = c.BasicType.GetChainPrefix(c.ChainName)
+		if ss58Format, err := c.FetchPrefixFromNode(); err == nil {
+			c.prefix = prefixToBytes(ss58Format)
+		} else {
+			c.prefix, _ = c.BasicType.GetChainPrefix(c.ChainName)
+		}
 	}
 	//设置默认地址不需要0xff
 	expand.SetSerDeOptions(noPalletIndices)
@@ -80,50 +152,296 @@ func (c *Client) reConnectWs() (*gsrc.SubstrateAPI, error) {
 	}, nil
 }
 
+/*
+当前使用的rpc以及连接的版本，版本在每次重连后加1
+*/
+func (c *Client) connection() (RPCCaller, uint64) {
+	c.rpcMu.RLock()
+	defer c.rpcMu.RUnlock()
+	return c.rpc, c.rpcGen
+}
+
+func (c *Client) caller() RPCCaller {
+	rpc, _ := c.connection()
+	return rpc
+}
+
+/*
+版本为gen的连接断开后重新连接，所有的重连（checkRuntimeVersion以及重新订阅）都通过这里
+连接已经被其它goroutine替换时直接返回新的连接；替换后关闭旧的连接，旧连接上的订阅会收到错误
+实现了Reconnector的RPCCaller使用自己的Reconnect，否则重新连接url中的websocket
+*/
+func (c *Client) reconnect(gen uint64) (RPCCaller, uint64, error) {
+	c.rpcMu.Lock()
+	defer c.rpcMu.Unlock()
+	if gen != c.rpcGen {
+		return c.rpc, c.rpcGen, nil
+	}
+	var (
+		next RPCCaller
+		api  *gsrc.SubstrateAPI
+		err  error
+	)
+	if reconnector, ok := c.rpc.(Reconnector); ok {
+		next, err = reconnector.Reconnect()
+	} else if c.url != "" && !c.IsHTTP() {
+		api, err = c.reConnectWs()
+		if err == nil {
+			next = newSubstrateRPC(api)
+		}
+	} else {
+		err = errors.New("reconnect is not supported")
+	}
+	if err != nil {
+		return nil, gen, err
+	}
+	if closer, ok := c.rpc.(interface{ Close() }); ok {
+		closer.Close()
+	}
+	c.C = api
+	c.rpc = next
+	c.rpcGen++
+	return c.rpc, c.rpcGen, nil
+}
+
 func (c *Client) checkRuntimeVersion() error {
-	v, err := c.C.RPC.State.GetRuntimeVersionLatest()
+	rpc, gen := c.connection()
+	v, err := rpc.GetRuntimeVersionLatest()
 	if err != nil {
-		if !strings.Contains(err.Error(), "tls: use of closed connection") {
-			return fmt.Errorf("init runtime version error,err=%v", err)
+		if !errors.Is(err, ErrConnectionClosed) {
+			return fmt.Errorf("init runtime version error,err=%w", err)
 		}
 		//	重连处理，这是因为第三方包的问题，所以只能这样处理了了
-		cl, err := c.reConnectWs()
+		rpc, _, err = c.reconnect(gen)
 		if err != nil {
-			return fmt.Errorf("reconnect error: %v", err)
+			return fmt.Errorf("%w: reconnect error: %v", ErrConnectionClosed, err)
 		}
-		c.C = cl
-		v, err = c.C.RPC.State.GetRuntimeVersionLatest()
+		v, err = rpc.GetRuntimeVersionLatest()
 		if err != nil {
-			return fmt.Errorf("init runtime version error,aleady reconnect,err: %v", err)
+			return fmt.Errorf("init runtime version error,aleady reconnect,err: %w", err)
 		}
 	}
-	c.TransactionVersion = int(v.TransactionVersion)
-	c.ChainName = v.SpecName
 	specVersion := int(v.SpecVersion)
-	//检查metadata数据是否有升级
-	if specVersion != c.SpecVersion {
-		c.Meta, err = c.C.RPC.State.GetMetadataLatest()
+	//检查metadata数据是否有升级，正在解析的区块使用的是开始解析时的快照（runtime），不受这里替换metadata的影响
+	var meta *types.Metadata
+	if specVersion != c.runtime().specVersion {
+		meta, err = rpc.GetMetadataLatest()
 		if err != nil {
-			return fmt.Errorf("init metadata error: %v", err)
+			return fmt.Errorf("%w: init metadata error: %v", ErrMetadataUnavailable, err)
 		}
+		err = checkMetadata(meta)
+		if err != nil {
+			return err
+		}
+	}
+	c.runtimeMu.Lock()
+	old := RuntimeInfo{SpecName: c.ChainName, SpecVersion: c.SpecVersion, TransactionVersion: c.TransactionVersion}
+	if meta != nil {
+		c.Meta = meta
 		c.SpecVersion = specVersion
+		c.balanceWidth = 0
+		c.accountInfoDetected = false
+	}
+	c.TransactionVersion = int(v.TransactionVersion)
+	c.ChainName = v.SpecName
+	current := RuntimeInfo{SpecName: c.ChainName, SpecVersion: c.SpecVersion, TransactionVersion: c.TransactionVersion}
+	handler := c.upgradeHandler
+	c.runtimeMu.Unlock()
+	//第一次加载runtime时不通知
+	if handler != nil && old.SpecVersion != 0 &&
+		(old.SpecVersion != current.SpecVersion || old.TransactionVersion != current.TransactionVersion) {
+		old.GenesisHash = c.GetGenesisHash()
+		current.GenesisHash = old.GenesisHash
+		handler(old, current)
+	}
+	return nil
+}
+
+/*
+检查metadata是否可用：格式不匹配时metadata可能解码成功但是没有任何模块，
+之后创建storage key以及解析extrinsic时会出现难以定位的错误，所以在加载时直接返回错误
+*/
+func checkMetadata(meta *types.Metadata) error {
+	if meta == nil {
+		return fmt.Errorf("%w: metadata is nil", ErrMetadataUnavailable)
+	}
+	var modules int
+	switch {
+	case meta.IsMetadataV4:
+		modules = len(meta.AsMetadataV4.Modules)
+	case meta.IsMetadataV7:
+		modules = len(meta.AsMetadataV7.Modules)
+	case meta.IsMetadataV8:
+		modules = len(meta.AsMetadataV8.Modules)
+	case meta.IsMetadataV9:
+		modules = len(meta.AsMetadataV9.Modules)
+	case meta.IsMetadataV10:
+		modules = len(meta.AsMetadataV10.Modules)
+	case meta.IsMetadataV11:
+		modules = len(meta.AsMetadataV11.Modules)
+	case meta.IsMetadataV12:
+		modules = len(meta.AsMetadataV12.Modules)
+	case meta.IsMetadataV13:
+		modules = len(meta.AsMetadataV13.Modules)
+	}
+	if modules == 0 {
+		return fmt.Errorf("%w: metadata v%d decoded but appears empty/incompatible: no pallets", ErrMetadataUnavailable, meta.Version)
+	}
+	if _, err := meta.FindStorageEntryMetadata("System", "Account"); err != nil {
+		return fmt.Errorf("%w: metadata v%d decoded but appears empty/incompatible: %v", ErrMetadataUnavailable, meta.Version, err)
+	}
+	return nil
+}
+
+/*
+是否通过http连接节点，http连接不支持订阅
+*/
+func (c *Client) IsHTTP() bool {
+	return isHTTPURL(c.url)
+}
+
+/*
+设置是否在GetBlockByHash、GetAccountInfo等请求前自动检查runtime版本，默认开启
+关闭后节省一次rpc请求，但是runtime升级后metadata不会自动更新，可能导致解析错误，
+需要调用者自己在升级时调用RefreshRuntime
+*/
+func (c *Client) SetAutoRuntimeCheck(enable bool) {
+	c.noAutoRuntimeCheck = !enable
+}
+
+/*
+手动检查runtime版本，版本有变化时重新获取metadata
+*/
+func (c *Client) RefreshRuntime() error {
+	return c.checkRuntimeVersion()
+}
+
+func (c *Client) autoCheckRuntime() error {
+	if c.noAutoRuntimeCheck {
+		return nil
+	}
+	return c.checkRuntimeVersion()
+}
+
+/*
+固定genesis hash，之后GetGenesisHash（以及使用它签名的交易）都返回该值而不再请求节点
+用于fork出来的链或者本地开发链，传入空字符串时取消固定
+*/
+func (c *Client) SetGenesisHash(genesisHash string) error {
+	if genesisHash != "" {
+		if !strings.HasPrefix(genesisHash, "0x") {
+			genesisHash = "0x" + genesisHash
+		}
+		if !isBlockHash(genesisHash) {
+			return fmt.Errorf("expected genesis hash, got %q", genesisHash)
+		}
 	}
+	c.genesisMu.Lock()
+	c.genesisOverride = genesisHash
+	c.genesisMu.Unlock()
 	return nil
 }
 
 /*
-获取创世区块hash
+获取创世区块hash，设置了SetGenesisHash时返回设置的值
 */
 func (c *Client) GetGenesisHash() string {
+	c.genesisMu.Lock()
+	defer c.genesisMu.Unlock()
+	if c.genesisOverride != "" {
+		return c.genesisOverride
+	}
 	if c.genesisHash != "" {
 		return c.genesisHash
 	}
-	hash, err := c.C.RPC.Chain.GetBlockHash(0)
+	//请求失败时不缓存，下次调用重试
+	hash, err := c.caller().GetBlockHash(0)
 	if err != nil {
 		return ""
 	}
 	c.genesisHash = hash.Hex()
-	return hash.Hex()
+	return c.genesisHash
+}
+
+/*
+当前连接的链的runtime信息
+*/
+type RuntimeInfo struct {
+	SpecName           string `json:"spec_name"`
+	SpecVersion        int    `json:"spec_version"`
+	TransactionVersion int    `json:"transaction_version"`
+	GenesisHash        string `json:"genesis_hash"`
+}
+
+/*
+获取当前的runtime信息，可以用来记录解析区块时使用的是哪个runtime版本
+*/
+func (c *Client) RuntimeInfo() RuntimeInfo {
+	c.runtimeMu.RLock()
+	info := RuntimeInfo{
+		SpecName:           c.ChainName,
+		SpecVersion:        c.SpecVersion,
+		TransactionVersion: c.TransactionVersion,
+	}
+	c.runtimeMu.RUnlock()
+	info.GenesisHash = c.GetGenesisHash()
+	return info
+}
+
+/*
+设置runtime升级（spec version或者transaction version变化）时的回调，在checkRuntimeVersion中同步调用
+transaction version是签名内容的一部分，缓存了签名模板（spec version、transaction version）的服务需要在回调中刷新，
+否则升级后签名的交易会被节点拒绝
+*/
+func (c *Client) OnRuntimeUpgrade(handler func(old, new RuntimeInfo)) {
+	c.runtimeMu.Lock()
+	c.upgradeHandler = handler
+	c.runtimeMu.Unlock()
+}
+
+/*
+获取当前链的spec version以及transaction version
+*/
+func (c *Client) GetSpecAndTxVersion() (specVersion, transactionVersion uint32, err error) {
+	err = c.checkRuntimeVersion()
+	if err != nil {
+		return 0, 0, err
+	}
+	c.runtimeMu.RLock()
+	defer c.runtimeMu.RUnlock()
+	return uint32(c.SpecVersion), uint32(c.TransactionVersion), nil
+}
+
+/*
+获取最新的finalized区块的hash以及高度
+mortal交易使用它作为era的起始区块，best区块可能被回滚，以它为起始区块的交易会因为找不到区块而失效
+*/
+func (c *Client) GetLatestBlock() (blockHash string, blockNumber uint64, err error) {
+	err = c.caller().Call(&blockHash, "chain_getFinalizedHead")
+	if err != nil {
+		return "", 0, fmt.Errorf("get finalized head error: %w", err)
+	}
+	var header models.Header
+	err = c.caller().Call(&header, "chain_getHeader", blockHash)
+	if err != nil {
+		return "", 0, fmt.Errorf("get header error: %w", err)
+	}
+	blockNumber, err = utils.ParseBlockNumber(header.Number)
+	if err != nil {
+		return "", 0, fmt.Errorf("parse block number error: %v", err)
+	}
+	return blockHash, blockNumber, nil
+}
+
+/*
+获取最新的finalized区块的高度，可以作为扫块的上限，高度不超过它的区块不会再被回滚
+*/
+func (c *Client) FinalizedHeight() (int64, error) {
+	_, height, err := c.GetLatestBlock()
+	if err != nil {
+		return 0, err
+	}
+	return int64(height), nil
 }
 
 /*
@@ -133,13 +451,107 @@ func (c *Client) SetPrefix(prefix []byte) {
 	c.prefix = prefix
 }
 
+/*
+自定义地址的编码方式，用于不使用SS58地址的链（比如EVM的H160地址）
+pubHex为不带0x的公钥或者AccountId，设置为nil时恢复默认的ss58编码
+*/
+func (c *Client) SetAddressEncoder(encoder func(pubHex string) (string, error)) {
+	c.addressEncoder = encoder
+}
+
+func (c *Client) encodeAddress(pubHex string) (string, error) {
+	if c.addressEncoder != nil {
+		return c.addressEncoder(pubHex)
+	}
+	return ss58.EncodeByPubHex(pubHex, c.prefix)
+}
+
+/*
+从节点的system_properties中获取链的ss58Format
+*/
+func (c *Client) FetchPrefixFromNode() (uint16, error) {
+	var properties map[string]interface{}
+	err := c.caller().Call(&properties, "system_properties")
+	if err != nil {
+		return 0, fmt.Errorf("get system properties error: %v", err)
+	}
+	v, ok := properties["ss58Format"]
+	if !ok || v == nil {
+		return 0, errors.New("node does not provide ss58Format")
+	}
+	format, ok := v.(float64)
+	if !ok || format < 0 || format > 16383 {
+		return 0, fmt.Errorf("invalid ss58Format: %v", v)
+	}
+	return uint16(format), nil
+}
+
+/*
+将ss58Format转换为地址前缀：小于64时为单字节，否则按ss58规范编码为两个字节
+*/
+func prefixToBytes(ss58Format uint16) []byte {
+	if ss58Format < 64 {
+		return []byte{byte(ss58Format)}
+	}
+	return []byte{
+		byte((ss58Format&0xfc)>>2) | 0x40,
+		byte(ss58Format>>8) | byte((ss58Format&0x03)<<6),
+	}
+}
+
+/*
+设置为true时，没有解析参数的extrinsic也会返回，其Type为"module.call"
+区块中的每一个extrinsic（包括inherent）都至少会返回一个ExtrinsicResponse
+*/
+func (c *Client) SetIncludeUnparsed(include bool) {
+	c.includeUnparsed = include
+}
+
+/*
+设置需要解析参数的模块（比如"Balances"、"Utility"）或者call（比如"Balances.transfer"），
+不在其中的extrinsic只识别模块以及方法，跳过参数的解析，用于只关心转账的场景提高解析速度
+Timestamp以及ParachainSystem总是会解析，用于获取区块时间以及中继链高度；为空时解析所有的call（默认）
+*/
+func (c *Client) SetCallAllowlist(allowlist []string) {
+	if len(allowlist) == 0 {
+		c.callAllowlist = nil
+		return
+	}
+	c.callAllowlist = map[string]bool{"Timestamp": true, "ParachainSystem.set_validation_data": true}
+	for _, name := range allowlist {
+		c.callAllowlist[name] = true
+	}
+}
+
+/*
+根据call index查找对应的模块名以及方法名
+*/
+func (c *Client) ResolveCall(moduleIndex, callIndex uint8) (module, call string, err error) {
+	return resolveCall(c.runtime().meta, moduleIndex, callIndex)
+}
+
+func resolveCall(meta *types.Metadata, moduleIndex, callIndex uint8) (module, call string, err error) {
+	me, err := expand.NewMetadataExpand(meta)
+	if err != nil {
+		return "", "", fmt.Errorf("new metadata expand error: %v", err)
+	}
+	callIdx := hex.EncodeToString([]byte{moduleIndex, callIndex})
+	return me.MV.FindNameByCallIndex(callIdx)
+}
+
 /*
 根据height解析block，返回block是否包含交易
 */
 func (c *Client) GetBlockByNumber(height int64) (*models.BlockResponse, error) {
-	hash, err := c.C.RPC.Chain.GetBlockHash(uint64(height))
+	if height < 0 {
+		return nil, fmt.Errorf("invalid block height: %d", height)
+	}
+	hash, err := c.caller().GetBlockHash(uint64(height))
 	if err != nil {
-		return nil, fmt.Errorf("get block hash error:%v,height:%d", err, height)
+		return nil, fmt.Errorf("get block hash error:%w,height:%d", err, height)
+	}
+	if hash == (types.Hash{}) {
+		return nil, fmt.Errorf("%w: height %d", ErrBlockNotFound, height)
 	}
 	blockHash := hash.Hex()
 
@@ -147,9 +559,12 @@ func (c *Client) GetBlockByNumber(height int64) (*models.BlockResponse, error) {
 }
 
 func (c *Client) GetBlockHashByNumber(height int64) (*types.Hash, error) {
-	hash, err := c.C.RPC.Chain.GetBlockHash(uint64(height))
+	hash, err := c.caller().GetBlockHash(uint64(height))
 	if err != nil {
-		return nil, fmt.Errorf("get block hash error:%v,height:%d", err, height)
+		return nil, fmt.Errorf("get block hash error:%w,height:%d", err, height)
+	}
+	if hash == (types.Hash{}) {
+		return nil, fmt.Errorf("%w: height %d", ErrBlockNotFound, height)
 	}
 	return &hash, nil
 }
@@ -158,61 +573,247 @@ func (c *Client) GetBlockHashByNumber(height int64) (*types.Hash, error) {
 根据blockHash解析block，返回block是否包含交易
 */
 func (c *Client) GetBlockByHash(blockHash string) (*models.BlockResponse, error) {
-	var (
-		block *models.SignedBlock
-		err   error
-	)
-	err = c.checkRuntimeVersion()
+	if !isBlockHash(blockHash) {
+		return nil, fmt.Errorf("expected block hash, got %q", blockHash)
+	}
+	err := c.autoCheckRuntime()
 	if err != nil {
 		return nil, err
 	}
-	err = c.C.Client.Call(&block, "chain_getBlock", blockHash)
+	block, eventsHex, err := c.getBlockAndEvents(blockHash)
 	if err != nil {
-		return nil, fmt.Errorf("get block error: %v", err)
+		return nil, err
 	}
-	blockResp := new(models.BlockResponse)
-	number, _ := strconv.ParseInt(utils.RemoveHex0x(block.Block.Header.Number), 16, 64)
-	blockResp.Height = number
-	blockResp.ParentHash = block.Block.Header.ParentHash
-	blockResp.BlockHash = blockHash
+	rt, err := c.blockRuntime(blockHash)
+	if err != nil {
+		return nil, err
+	}
+	return c.parseBlock(rt, blockHash, block, eventsHex)
+}
+
+/*
+获取区块以及System.Events的原始数据，节点支持批量请求时在一次网络往返中完成
+不支持时只获取区块，eventsHex为空，由parseBlock在需要时再获取
+*/
+func (c *Client) getBlockAndEvents(blockHash string) (block *models.SignedBlock, eventsHex string, err error) {
+	batcher, ok := c.caller().(BatchCaller)
+	if ok && atomic.LoadInt32(&c.batchUnsupported) == 0 {
+		//System.Events的key是固定的，不需要metadata
+		var key types.StorageKey
+		key, err = buildStorageKey("System", "Events", nil)
+		if err != nil {
+			return nil, "", err
+		}
+		batch := []BatchElem{
+			{Method: "chain_getBlock", Args: []interface{}{blockHash}, Result: &block},
+			{Method: "state_getStorageAt", Args: []interface{}{key.Hex(), blockHash}, Result: &eventsHex},
+		}
+		err = batcher.BatchCall(batch)
+		switch {
+		case errors.Is(err, errBatchUnsupported):
+			//之后不再尝试批量请求
+			atomic.StoreInt32(&c.batchUnsupported, 1)
+		case err != nil:
+			return nil, "", fmt.Errorf("get block error: %w", err)
+		case batch[0].Error != nil:
+			return nil, "", fmt.Errorf("get block error: %w", batch[0].Error)
+		case block == nil:
+			return nil, "", fmt.Errorf("%w: %s", ErrBlockNotFound, blockHash)
+		case batch[1].Error != nil:
+			return nil, "", fmt.Errorf("get storage data error: %w", batch[1].Error)
+		default:
+			return block, eventsHex, nil
+		}
+	}
+	err = c.caller().Call(&block, "chain_getBlock", blockHash)
+	if err != nil {
+		return nil, "", fmt.Errorf("get block error: %w", err)
+	}
+	if block == nil {
+		return nil, "", fmt.Errorf("%w: %s", ErrBlockNotFound, blockHash)
+	}
+	return block, "", nil
+}
+
+/*
+解析已经获取到的区块，eventsHex为空时从节点获取System.Events
+整个区块都使用rt中的metadata解析，避免解析过程中runtime升级导致同一个区块混用两个版本的metadata
+*/
+func (c *Client) parseBlock(rt runtimeSnapshot, blockHash string, block *models.SignedBlock, eventsHex string) (*models.BlockResponse, error) {
+	var err error
+	rt = rt.atBlock(blockHash)
+	blockResp := newBlockResponse(block.Block.Header, blockHash)
 	if len(block.Block.Extrinsics) > 0 {
-		err = c.parseExtrinsicByDecode(block.Block.Extrinsics, blockResp)
+		err = c.parseExtrinsicByDecode(rt, block.Block.Extrinsics, blockResp)
 		if err != nil {
 			return nil, err
 		}
-		err = c.parseExtrinsicByStorage(blockHash, blockResp)
+		if eventsHex == "" {
+			err = c.parseExtrinsicByStorage(rt, blockHash, blockResp)
+		} else if len(blockResp.Extrinsic) > 0 {
+			err = c.parseEvents(rt, eventsHex, blockResp)
+		}
 		if err != nil {
 			return nil, err
 		}
+		if !c.noFetchFees {
+			//手续费获取失败不影响区块解析，可以之后调用FillFees重试
+			err = c.fillFees(block.Block.Extrinsics, blockResp)
+			if err != nil {
+				log.Printf("fill %d block fees error,Err=[%v]", blockResp.Height, err)
+			}
+		}
+	} else {
+		//部分裁剪过的节点返回的区块没有extrinsics字段，此时从Timestamp.Now读取区块时间
+		blockResp.Extrinsic = []*models.ExtrinsicResponse{}
+		blockResp.Timestamp, err = c.getBlockTimestamp(rt, blockHash)
+		if err != nil {
+			log.Printf("get %d block timestamp error,Err=[%v]", blockResp.Height, err)
+		}
+	}
+	if c.groupBatch {
+		groupBatchTransfers(blockResp)
+	}
+	err = c.formatAmounts(blockResp)
+	if err != nil {
+		return nil, err
 	}
 	return blockResp, nil
 }
 
+func newBlockResponse(header models.Header, blockHash string) *models.BlockResponse {
+	blockResp := new(models.BlockResponse)
+	number, _ := utils.ParseBlockNumber(header.Number)
+	blockResp.Height = int64(number)
+	blockResp.ParentHash = header.ParentHash
+	blockResp.BlockHash = blockHash
+	return blockResp
+}
+
+/*
+直接读取指定区块的Timestamp.Now获取区块时间（毫秒），不需要解析整个区块
+*/
+func (c *Client) GetBlockTimestamp(blockHash string) (int64, error) {
+	return c.getBlockTimestamp(c.runtime(), blockHash)
+}
+
+func (c *Client) getBlockTimestamp(rt runtimeSnapshot, blockHash string) (int64, error) {
+	if !isBlockHash(blockHash) {
+		return 0, fmt.Errorf("expected block hash, got %q", blockHash)
+	}
+	storage, err := buildStorageKeyByMeta(rt.meta, "Timestamp", "Now")
+	if err != nil {
+		return 0, fmt.Errorf("create Timestamp.Now storage key error: %v", err)
+	}
+	var result string
+	err = c.caller().Call(&result, "state_getStorageAt", storage.Hex(), blockHash)
+	if err != nil {
+		return 0, fmt.Errorf("get Timestamp.Now error: %w", err)
+	}
+	if result == "" {
+		return 0, fmt.Errorf("Timestamp.Now is empty at block %s", blockHash)
+	}
+	var now types.U64
+	err = types.DecodeFromHexString(result, &now)
+	if err != nil {
+		return 0, fmt.Errorf("%w: decode Timestamp.Now error: %v", ErrDecodeFailed, err)
+	}
+	return int64(now), nil
+}
+
+/*
+根据区块高度或者区块hash解析block
+numberOrHash: 整数类型的高度、0x开头的区块hash、十进制的高度字符串或者types.Hash
+*/
+func (c *Client) GetBlock(numberOrHash interface{}) (*models.BlockResponse, error) {
+	switch v := numberOrHash.(type) {
+	case int:
+		return c.GetBlockByNumber(int64(v))
+	case int32:
+		return c.GetBlockByNumber(int64(v))
+	case int64:
+		return c.GetBlockByNumber(v)
+	case uint:
+		return c.getBlockByUint64(uint64(v))
+	case uint32:
+		return c.GetBlockByNumber(int64(v))
+	case uint64:
+		return c.getBlockByUint64(v)
+	case types.Hash:
+		return c.GetBlockByHash(v.Hex())
+	case string:
+		if isBlockHash(v) {
+			return c.GetBlockByHash(v)
+		}
+		if v != "" && utils.IsNumberString(v) {
+			height, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("parse block height error: %v", err)
+			}
+			return c.GetBlockByNumber(height)
+		}
+		return nil, fmt.Errorf("expected block hash or height, got %q", v)
+	default:
+		return nil, fmt.Errorf("unsupport block number or hash type: %T", numberOrHash)
+	}
+}
+
+/*
+超过int64的高度转换后会变为负数，直接返回错误
+*/
+func (c *Client) getBlockByUint64(height uint64) (*models.BlockResponse, error) {
+	if height > math.MaxInt64 {
+		return nil, fmt.Errorf("block height %d overflows int64", height)
+	}
+	return c.GetBlockByNumber(int64(height))
+}
+
+/*
+检查是否为0x开头的32字节hex hash
+*/
+func isBlockHash(blockHash string) bool {
+	if !strings.HasPrefix(blockHash, "0x") || len(blockHash) != 66 {
+		return false
+	}
+	_, err := hex.DecodeString(blockHash[2:])
+	return err == nil
+}
+
 type parseBlockExtrinsicParams struct {
 	from, to, sig, era, txid string
-	nonce                    int64
+	nonce                    uint64
 	extrinsicIdx, length     int
 	amount                   string
 	Fee                      string
+	typ                      string
+	memo                     string
+	dispatchedAs             string
+	callHash                 string
+	params                   []models.ExtrinsicDecodeParam
+	asset                    *models.AssetInfo
 }
 
 /*
 解析外部交易extrinsic
 */
-func (c *Client) parseExtrinsicByDecode(extrinsics []string, blockResp *models.BlockResponse) error {
+func (c *Client) parseExtrinsicByDecode(rt runtimeSnapshot, extrinsics []string, blockResp *models.BlockResponse) error {
 	var (
 		params    []parseBlockExtrinsicParams
 		timestamp int64
+		relayNum  int64                       //平行链区块对应的中继链父区块高度
+		unparsed  []parseBlockExtrinsicParams //开启includeUnparsed时每一个extrinsic的基本信息
 		//idx int
 	)
 	defer func() {
 		if err := recover(); err != nil {
 			blockResp.Timestamp = timestamp
+			blockResp.RelayParentNumber = relayNum
 			blockResp.Extrinsic = []*models.ExtrinsicResponse{}
 			log.Printf("parse %d block extrinsic error,Err=[%v]", blockResp.Height, err)
 		}
 	}()
 
+	//i为extrinsic在区块中的下标，与event的ApplyExtrinsic(i)一致，batch中的每一笔转账共用所在extrinsic的下标
 	for i, extrinsic := range extrinsics {
 		extrinsic = utils.Remove0X(extrinsic)
 		data, err := hex.DecodeString(extrinsic)
@@ -220,100 +821,60 @@ func (c *Client) parseExtrinsicByDecode(extrinsics []string, blockResp *models.B
 			return fmt.Errorf("hex.decode extrinsic error: %v", err)
 		}
 		decoder := scale.NewDecoder(bytes.NewReader(data))
-		ed, err := expand.NewExtrinsicDecoder(c.Meta)
+		ed, err := expand.NewExtrinsicDecoder(rt.meta)
 		if err != nil {
 			return fmt.Errorf("new extrinsic decode error: %v", err)
 		}
+		ed.SetTypeRegistry(c.typeRegistry)
+		ed.SetCallAllowlist(c.callAllowlist)
 		err = ed.ProcessExtrinsicDecoder(*decoder)
 		if err != nil {
-			return fmt.Errorf("decode extrinsic error: %v", err)
+			return fmt.Errorf("%w: decode extrinsic error: %v", ErrDecodeFailed, err)
 		}
 		var resp models.ExtrinsicDecodeResponse
 		d, _ := json.Marshal(ed.Value)
 		if len(d) == 0 {
 			return errors.New("unknown extrinsic decode response")
 		}
-		err = json.Unmarshal(d, &resp)
+		err = unmarshalParams(d, &resp)
 		if err != nil {
-			return fmt.Errorf("json unmarshal extrinsic decode error: %v", err)
+			return fmt.Errorf("%w: json unmarshal extrinsic decode error: %v", ErrDecodeFailed, err)
+		}
+		if c.includeUnparsed {
+			unparsed = append(unparsed, c.unparsedExtrinsicParams(rt, resp, extrinsic, i))
+		}
+		if handler, ok := c.lookupCallHandler(resp.CallModule, resp.CallModuleFunction); ok {
+			handled, err := c.runCallHandler(handler, rt, resp, extrinsic, i)
+			if err != nil {
+				//一个call解析失败只跳过这个extrinsic，不影响区块中的其它extrinsic
+				log.Printf("parse %d block extrinsic %d (%s.%s) error,Err=[%v]",
+					blockResp.Height, i, resp.CallModule, resp.CallModuleFunction, err)
+			}
+			params = append(params, handled...)
+			continue
 		}
 		switch resp.CallModule {
 		case "Timestamp":
 			for _, param := range resp.Params {
 				if param.Name == "now" {
-					timestamp = int64(param.Value.(float64))
+					now, _ := utils.ValueToFloat64(param.Value)
+					timestamp = int64(now)
 				}
 			}
-		case "Balances":
-			if resp.CallModuleFunction == "transfer" || resp.CallModuleFunction == "transfer_keep_alive" {
-				blockData := parseBlockExtrinsicParams{}
-				blockData.from, _ = ss58.EncodeByPubHex(resp.AccountId, c.prefix)
-				blockData.era = resp.Era
-				blockData.sig = resp.Signature
-				blockData.nonce = resp.Nonce
-				blockData.extrinsicIdx = i
-				blockData.Fee, err = c.GetPartialFee(extrinsic, blockResp.ParentHash)
-				blockData.txid = c.createTxHash(extrinsic)
-				blockData.length = resp.Length
-				for _, param := range resp.Params {
-					if param.Name == "dest" {
-						blockData.to, _ = ss58.EncodeByPubHex(param.Value.(string), c.prefix)
-					}
-					if param.Name == "value" {
-						blockData.amount = param.Value.(string)
-					}
-				}
-				params = append(params, blockData)
-			}
-
-		case "Utility":
-			if resp.CallModuleFunction == "batch" {
-				for _, param := range resp.Params {
-					if param.Name == "calls" {
-						switch param.Value.(type) {
-						case []interface{}:
-
-							d, _ := json.Marshal(param.Value)
-							var values []models.UtilityParamsValue
-							err = json.Unmarshal(d, &values)
-							if err != nil {
-								continue
-							}
-
-							for _, value := range values {
-								if value.CallModule == "Balances" {
-									if value.CallFunction == "transfer" || value.CallFunction == "transfer_keep_alive" {
-										if len(value.CallArgs) > 0 {
-											for _, arg := range value.CallArgs {
-												if arg.Name == "dest" {
-													blockData := parseBlockExtrinsicParams{}
-													blockData.from, _ = ss58.EncodeByPubHex(resp.AccountId, c.prefix)
-													blockData.era = resp.Era
-													blockData.sig = resp.Signature
-													blockData.nonce = resp.Nonce
-													blockData.extrinsicIdx = i
-													blockData.Fee, _ = c.GetPartialFee(extrinsic, blockResp.ParentHash)
-													blockData.txid = c.createTxHash(extrinsic)
-													blockData.to, _ = ss58.EncodeByPubHex(arg.ValueRaw, c.prefix)
-													params = append(params, blockData)
-												}
-											}
-										}
-									}
-								}
-							}
-						default:
-							continue
-						}
-					}
+		case "ParachainSystem":
+			for _, param := range resp.Params {
+				if param.Name == "relay_parent_number" {
+					number, _ := utils.ValueToFloat64(param.Value)
+					relayNum = int64(number)
 				}
 			}
-		default:
-			//todo  add another call_module 币种不同可能使用的call_module不一样
-			continue
 		}
 	}
 	blockResp.Timestamp = timestamp
+	blockResp.RelayParentNumber = relayNum
+	if c.includeUnparsed {
+		params = fillUnparsedParams(params, unparsed)
+	}
 	//解析params
 	if len(params) == 0 {
 		blockResp.Extrinsic = []*models.ExtrinsicResponse{}
@@ -328,12 +889,22 @@ func (c *Client) parseExtrinsicByDecode(extrinsics []string, blockResp *models.B
 		e.ToAddress = param.to
 		e.Nonce = param.nonce
 		e.Era = param.era
+		e.EraInfo = newEraInfo(param.era, blockResp.Height)
 		e.Fee = param.Fee
 		e.ExtrinsicIndex = param.extrinsicIdx
 		e.Amount = param.amount
 		//e.Txid = txid
 		e.Txid = param.txid
 		e.ExtrinsicLength = param.length
+		e.Memo = param.memo
+		e.DispatchedAs = param.dispatchedAs
+		e.CallHash = param.callHash
+		e.Params = param.params
+		e.Asset = param.asset
+		e.Type = param.typ
+		if e.Type == "" {
+			e.Type = "transfer"
+		}
 		blockResp.Extrinsic[idx] = e
 
 	}
@@ -341,14 +912,56 @@ func (c *Client) parseExtrinsicByDecode(extrinsics []string, blockResp *models.B
 	return nil
 }
 
+/*
+没有产生任何结果的extrinsic（比如inherent、没有转账的batch）使用unparsed中的基本信息补上，
+保证每一个extrinsic至少有一个ExtrinsicResponse，并按extrinsic的下标排序
+*/
+func fillUnparsedParams(params, unparsed []parseBlockExtrinsicParams) []parseBlockExtrinsicParams {
+	covered := make(map[int]bool, len(params))
+	for _, param := range params {
+		covered[param.extrinsicIdx] = true
+	}
+	for _, param := range unparsed {
+		if !covered[param.extrinsicIdx] {
+			params = append(params, param)
+		}
+	}
+	sort.SliceStable(params, func(a, b int) bool {
+		return params[a].extrinsicIdx < params[b].extrinsicIdx
+	})
+	return params
+}
+
+/*
+没有解析参数的extrinsic，只记录基本信息以及"module.call"
+*/
+func (c *Client) unparsedExtrinsicParams(rt runtimeSnapshot, resp models.ExtrinsicDecodeResponse, extrinsic string, idx int) parseBlockExtrinsicParams {
+	blockData := parseBlockExtrinsicParams{}
+	if resp.AccountId != "" {
+		blockData.from, _ = c.encodeAddress(resp.AccountId)
+	}
+	blockData.era = resp.Era
+	blockData.sig = resp.Signature
+	blockData.nonce = resp.Nonce
+	blockData.extrinsicIdx = idx
+	blockData.txid = c.createTxHash(extrinsic)
+	blockData.length = resp.Length
+	blockData.typ = resp.CallCode
+	blockData.params = resp.Params
+	callIdx, err := hex.DecodeString(resp.CallCode)
+	if err == nil && len(callIdx) == 2 {
+		module, call, err := resolveCall(rt.meta, callIdx[0], callIdx[1])
+		if err == nil {
+			blockData.typ = module + "." + call
+		}
+	}
+	return blockData
+}
+
 /*
 解析当前区块的System.event
 */
-func (c *Client) parseExtrinsicByStorage(blockHash string, blockResp *models.BlockResponse) error {
-	var (
-		storage types.StorageKey
-		err     error
-	)
+func (c *Client) parseExtrinsicByStorage(rt runtimeSnapshot, blockHash string, blockResp *models.BlockResponse) (err error) {
 	defer func() {
 		if err1 := recover(); err1 != nil {
 			err = fmt.Errorf("panic decode event: %v", err1)
@@ -358,58 +971,78 @@ func (c *Client) parseExtrinsicByStorage(blockHash string, blockResp *models.Blo
 		//不包含交易就不处理了
 		return nil
 	}
-	// 1. 先创建System.event的storageKey
-	storage, err = types.CreateStorageKey(c.Meta, "System", "Events", nil, nil)
+	var eventsHex string
+	eventsHex, err = c.getEventsHex(blockHash)
+	if err != nil {
+		return err
+	}
+	return c.parseEvents(rt, eventsHex, blockResp)
+}
+
+/*
+获取指定区块System.Events的原始数据
+*/
+func (c *Client) getEventsHex(blockHash string) (string, error) {
+	// 1. 先创建System.event的storageKey，key是固定的，不需要metadata
+	storage, err := buildStorageKey("System", "Events", nil)
 	if err != nil {
-		return fmt.Errorf("create storage key error: %v", err)
+		return "", fmt.Errorf("create storage key error: %v", err)
 	}
 	key := storage.Hex()
-	var result interface{}
+	var result string
 	/*
 		根据storageKey以及blockHash获取当前区块的event信息
 	*/
-	err = c.C.Client.Call(&result, "state_getStorageAt", key, blockHash)
+	err = c.caller().Call(&result, "state_getStorageAt", key, blockHash)
 	if err != nil {
-		return fmt.Errorf("get storage data error: %v", err)
+		return "", fmt.Errorf("get storage data error: %w", err)
 	}
+	return result, nil
+}
 
+/*
+根据System.Events的原始数据解析event，并与已解析的extrinsic关联
+*/
+func (c *Client) parseEvents(rt runtimeSnapshot, eventsHex string, blockResp *models.BlockResponse) (err error) {
+	defer func() {
+		if err1 := recover(); err1 != nil {
+			err = fmt.Errorf("panic decode event: %v", err1)
+		}
+	}()
 	//解析event信息
-	ier, err := expand.DecodeEventRecords(c.Meta, result.(string), c.ChainName)
-
+	ier, err := c.decodeEventRecords(rt, eventsHex)
 	if err != nil {
-		return fmt.Errorf("decode event data error: %v", err)
+		return err
 	}
 	//d,_:=json.Marshal(ier)
 	//fmt.Println(string(d))
 	var res []models.EventResult
 	failedMap := make(map[int]bool)
-	if len(ier.GetBalancesTransfer()) > 0 {
-		//有失败的交易
-		for _, failed := range ier.GetSystemExtrinsicFailed() {
-			if failed.Phase.IsApplyExtrinsic {
-				extrinsicIdx := failed.Phase.AsApplyExtrinsic
-				//记录到失败的map中
-				failedMap[int(extrinsicIdx)] = true
-			}
+	failReasons := make(map[int]string)
+	//有失败的交易
+	for _, failed := range ier.GetSystemExtrinsicFailed() {
+		if failed.Phase.IsApplyExtrinsic {
+			extrinsicIdx := failed.Phase.AsApplyExtrinsic
+			//记录到失败的map中
+			failedMap[int(extrinsicIdx)] = true
+			failReasons[int(extrinsicIdx)] = c.dispatchErrorReason(rt.meta, failed.DispatchError)
 		}
-
+	}
+	if len(ier.GetBalancesTransfer()) > 0 {
 		for _, ebt := range ier.GetBalancesTransfer() {
-
-			if !ebt.Phase.IsApplyExtrinsic {
-				continue
-			}
-			extrinsicIdx := int(ebt.Phase.AsApplyExtrinsic)
-			var r models.EventResult
-			r.ExtrinsicIdx = extrinsicIdx
+			//不是在extrinsic中产生的转账，ExtrinsicIdx为-1，不会与extrinsic关联
+			r := models.EventResult{Module: "Balances", Event: "Transfer"}
+			r.Phase = phaseName(ebt.Phase)
+			r.ExtrinsicIdx = phaseExtrinsicIdx(ebt.Phase)
 			fromHex := hex.EncodeToString(ebt.From[:])
-			r.From, err = ss58.EncodeByPubHex(fromHex, c.prefix)
+			r.From, err = c.encodeAddress(fromHex)
 			if err != nil {
 				r.From = ""
 				continue
 			}
 			toHex := hex.EncodeToString(ebt.To[:])
 
-			r.To, err = ss58.EncodeByPubHex(toHex, c.prefix)
+			r.To, err = c.encodeAddress(toHex)
 			if err != nil {
 				r.To = ""
 				continue
@@ -419,9 +1052,193 @@ func (c *Client) parseExtrinsicByStorage(blockHash string, blockResp *models.Blo
 			res = append(res, r)
 		}
 	}
+	//记录每个extrinsic中新创建的账户
+	endowedMap := make(map[int]map[string]bool)
+	for _, endowed := range ier.GetBalancesEndowed() {
+		if !endowed.Phase.IsApplyExtrinsic {
+			continue
+		}
+		extrinsicIdx := int(endowed.Phase.AsApplyExtrinsic)
+		who, err := c.encodeAddress(hex.EncodeToString(endowed.Who[:]))
+		if err != nil {
+			continue
+		}
+		if endowedMap[extrinsicIdx] == nil {
+			endowedMap[extrinsicIdx] = make(map[string]bool)
+		}
+		endowedMap[extrinsicIdx][who] = true
+	}
+	//交易手续费在执行call之前扣除，每个extrinsic的第一个Balances.Withdraw即为实际支付手续费的账户
+	feePayers := make(map[int]string)
+	for _, withdraw := range ier.GetBalancesWithdraw() {
+		if !withdraw.Phase.IsApplyExtrinsic {
+			continue
+		}
+		extrinsicIdx := int(withdraw.Phase.AsApplyExtrinsic)
+		if _, ok := feePayers[extrinsicIdx]; ok {
+			continue
+		}
+		who, err := c.encodeAddress(hex.EncodeToString(withdraw.Who[:]))
+		if err != nil {
+			continue
+		}
+		feePayers[extrinsicIdx] = who
+	}
+	//TransactionPayment.TransactionFeePaid中实际支付的手续费（包含小费）以及小费，没有这个event时手续费使用payment_queryInfo的预估值
+	paidFees := make(map[int][2]*big.Int)
+	for _, ev := range ier.GetTransactionFeePaid() {
+		if ev.Phase.IsApplyExtrinsic {
+			paidFees[int(ev.Phase.AsApplyExtrinsic)] = [2]*big.Int{u128ToBig(ev.ActualFee), u128ToBig(ev.Tip)}
+		}
+	}
+	//押金的锁定以及释放，先记录Reserved再记录Unreserved
+	reserves := make(map[int][]*models.ReserveEvent)
+	addReserve := func(event string, phase types.Phase, who types.AccountID, amount types.U128) {
+		if !phase.IsApplyExtrinsic {
+			return
+		}
+		account, err := c.encodeAddress(hex.EncodeToString(who[:]))
+		if err != nil {
+			return
+		}
+		idx := int(phase.AsApplyExtrinsic)
+		reserves[idx] = append(reserves[idx], &models.ReserveEvent{Event: event, Account: account, Amount: amount.String()})
+	}
+	for _, ev := range ier.GetBalancesReserved() {
+		addReserve("Reserved", ev.Phase, ev.Who, ev.Balance)
+	}
+	for _, ev := range ier.GetBalancesUnreserved() {
+		addReserve("Unreserved", ev.Phase, ev.Who, ev.Balance)
+	}
+	//Proxy.Announced: extrinsic的下标 -> call hash
+	announcedMap := make(map[int]map[string]bool)
+	for _, announced := range ier.GetProxyAnnounced() {
+		if !announced.Phase.IsApplyExtrinsic {
+			continue
+		}
+		extrinsicIdx := int(announced.Phase.AsApplyExtrinsic)
+		if announcedMap[extrinsicIdx] == nil {
+			announcedMap[extrinsicIdx] = make(map[string]bool)
+		}
+		announcedMap[extrinsicIdx][announced.Hash.Hex()] = true
+	}
+	//Staking.Unbonded以及Staking.Withdrawn的实际金额，to为stash账户
+	unbonded := make(map[int]models.EventResult)
+	for _, ev := range ier.GetStakingUnbonded() {
+		c.addStakingAmount(unbonded, "Unbonded", ev.Phase, ev.Stash, ev.Amount)
+	}
+	withdrawn := make(map[int]models.EventResult)
+	for _, ev := range ier.GetStakingWithdrawn() {
+		c.addStakingAmount(withdrawn, "Withdrawn", ev.Phase, ev.Stash, ev.Amount)
+	}
+	//Assets.Issued以及Assets.Burned中实际增发以及销毁的金额
+	issued := make(map[assetEventKey]*big.Int)
+	for _, ev := range ier.GetAssetsIssued() {
+		c.addAssetAmount(issued, ev.Phase, ev.AssetID, ev.Who, ev.Balance)
+	}
+	burned := make(map[assetEventKey]*big.Int)
+	for _, ev := range ier.GetAssetsBurned() {
+		c.addAssetAmount(burned, ev.Phase, ev.AssetId, ev.Owner, ev.Balance)
+	}
+	//PolkadotXcm.execute的执行结果，以及PolkadotXcm.send是否发送成功
+	xcmOutcomes := make(map[int]string)
+	for _, ev := range ier.GetXcmAttempted() {
+		if ev.Phase.IsApplyExtrinsic {
+			xcmOutcomes[int(ev.Phase.AsApplyExtrinsic)] = ev.Outcome.String()
+		}
+	}
+	for _, ev := range ier.GetXcmSent() {
+		if ev.Phase.IsApplyExtrinsic {
+			xcmOutcomes[int(ev.Phase.AsApplyExtrinsic)] = "Sent"
+		}
+	}
+	//bags-list中账户所在bag的变化，账户已经在正确的bag中时不会产生Rebagged
+	rebagged := make(map[int][]models.ExtrinsicDecodeParam)
+	for _, ev := range ier.GetBagsListRebagged() {
+		if !ev.Phase.IsApplyExtrinsic {
+			continue
+		}
+		who, err := c.encodeAddress(hex.EncodeToString(ev.Who[:]))
+		if err != nil {
+			continue
+		}
+		idx := int(ev.Phase.AsApplyExtrinsic)
+		rebagged[idx] = append(rebagged[idx], models.ExtrinsicDecodeParam{
+			Name: "rebagged",
+			Type: "Rebagged",
+			Value: map[string]interface{}{
+				"who":  who,
+				"from": uint64(ev.From),
+				"to":   uint64(ev.To),
+			},
+		})
+	}
 	for _, e := range blockResp.Extrinsic {
+		e.FailReason = failReasons[e.ExtrinsicIndex]
+		if e.Type == "xcm_send" || e.Type == "xcm_execute" {
+			e.XcmOutcome = xcmOutcomes[e.ExtrinsicIndex]
+		}
+		e.FeePayer = feePayers[e.ExtrinsicIndex]
+		if paid, ok := paidFees[e.ExtrinsicIndex]; ok {
+			e.Fee = paid[0].String()
+			e.Tip = paid[1].String()
+		}
+		e.Reserves = reserves[e.ExtrinsicIndex]
+		if e.Type == "proxy_announce" {
+			//只有产生了对应的Proxy.Announced才算声明成功
+			e.Status = "fail"
+			if !failedMap[e.ExtrinsicIndex] && announcedMap[e.ExtrinsicIndex][e.CallHash] {
+				e.Status = "success"
+			}
+			continue
+		}
+		if e.Type == "bags_list" {
+			e.Params = append(e.Params, rebagged[e.ExtrinsicIndex]...)
+		}
+		if e.Type == "council_candidacy" {
+			//金额为Balances.Reserved中的候选人押金
+			for _, r := range e.Reserves {
+				if r.Event == "Reserved" && r.Account == e.FromAddress {
+					e.Amount = r.Amount
+				}
+			}
+		}
+		if (e.Type == "asset_mint" || e.Type == "asset_burn") && e.Asset != nil {
+			amounts := issued
+			if e.Type == "asset_burn" {
+				amounts = burned
+			}
+			if amount, ok := amounts[assetEventKey{e.ExtrinsicIndex, e.Asset.Id, e.ToAddress}]; ok {
+				e.Amount = amount.String()
+			}
+		}
+		if e.Type == "staking_unbond" || e.Type == "staking_withdraw" {
+			amounts := unbonded
+			if e.Type == "staking_withdraw" {
+				//没有可以提取的解绑金额时不会产生Staking.Withdrawn
+				amounts = withdrawn
+				e.Amount = "0"
+			}
+			if a, ok := amounts[e.ExtrinsicIndex]; ok {
+				e.ToAddress = a.To
+				e.Amount = a.Amount
+			}
+			e.Status = "success"
+			if failedMap[e.ExtrinsicIndex] {
+				e.Status = "fail"
+			}
+			continue
+		}
+		if e.Type != "transfer" {
+			//非转账的extrinsic只根据System.ExtrinsicFailed判断状态
+			if failedMap[e.ExtrinsicIndex] {
+				e.Status = "fail"
+			} else {
+				e.Status = "success"
+			}
+			continue
+		}
 		e.Status = "fail"
-		e.Type = "transfer"
 		if len(res) > 0 {
 			for _, r := range res {
 				if e.ExtrinsicIndex == r.ExtrinsicIdx {
@@ -434,6 +1251,7 @@ func (c *Client) parseExtrinsicByStorage(blockHash string, blockResp *models.Blo
 						e.Type = "transfer"
 						e.Amount = r.Amount
 						e.ToAddress = r.To
+						e.NewAccount = endowedMap[e.ExtrinsicIndex][r.To]
 						//计算手续费
 						//e.Fee = c.calcFee(&events, e.ExtrinsicIndex)
 					}
@@ -445,6 +1263,59 @@ func (c *Client) parseExtrinsicByStorage(blockHash string, blockResp *models.Blo
 	return nil
 }
 
+/*
+将解析出来的公钥列表（json解析后为[]interface{}）转换为当前prefix的地址，无法转换的保留原值
+*/
+func (c *Client) encodeAddresses(value interface{}) []string {
+	list, _ := value.([]interface{})
+	addresses := make([]string, 0, len(list))
+	for _, item := range list {
+		pub, _ := item.(string)
+		address, err := c.encodeAddress(pub)
+		if err != nil {
+			address = pub
+		}
+		addresses = append(addresses, address)
+	}
+	return addresses
+}
+
+/*
+解析extrinsic解码结果的json，数字解析为json.Number而不是float64，超过2^53的金额不会丢失精度
+*/
+func unmarshalParams(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+/*
+call参数中的转账金额（value），返回最小单位的十进制字符串
+*/
+func callArgAmount(args []models.UtilityParamsValueArg) string {
+	for _, arg := range args {
+		if arg.Name == "value" {
+			amount, _ := utils.ValueToString(arg.Value)
+			return amount
+		}
+	}
+	return ""
+}
+
+/*
+remark为合法的utf8字符串时直接返回字符串，否则返回0x开头的hex
+*/
+func decodeMemo(remarkHex string) string {
+	data, err := hex.DecodeString(utils.RemoveHex0x(remarkHex))
+	if err != nil {
+		return remarkHex
+	}
+	if utf8.Valid(data) {
+		return string(data)
+	}
+	return "0x" + hex.EncodeToString(data)
+}
+
 /*
 根据外部交易extrinsic创建txid
 */
@@ -454,21 +1325,124 @@ func (c *Client) createTxHash(extrinsic string) string {
 	return "0x" + hex.EncodeToString(d[:])
 }
 
+/*
+根据MultiAddress的类型将解析出来的dest转换为地址，Index类型需要通过Indices.Accounts查询真实账户
+*/
+func (c *Client) destToAddress(rt runtimeSnapshot, typ, valueRaw string) (string, error) {
+	switch typ {
+	case "MultiAddress::Index":
+		index, err := strconv.ParseUint(valueRaw, 10, 32)
+		if err != nil {
+			return "", fmt.Errorf("parse account index error: %v", err)
+		}
+		return c.lookupAccountIndex(rt, uint32(index))
+	case "MultiAddress::Address20":
+		return "", fmt.Errorf("unsupported dest type: %s", typ)
+	default:
+		return c.encodeAddress(valueRaw)
+	}
+}
+
+/*
+value_raw为空时（比如dest被Option或者enum包装）从value中取出最里面的值
+*/
+func rawOrValue(valueRaw string, value interface{}) string {
+	if valueRaw != "" {
+		return valueRaw
+	}
+	raw, _ := utils.ValueToString(value)
+	return utils.RemoveHex0x(raw)
+}
+
+/*
+通过Indices.Accounts查询账户索引对应的地址
+*/
+func (c *Client) lookupAccountIndex(rt runtimeSnapshot, index uint32) (string, error) {
+	pub, err := c.accountIndexPub(rt, index)
+	if err != nil {
+		return "", err
+	}
+	return c.encodeAddress(pub)
+}
+
+/*
+查询账户索引对应账户的公钥（hex，不带0x）
+索引可以被释放后重新分配，所以解析区块时按区块的hash查询（state_getStorageAt），否则查询最新的状态
+*/
+func (c *Client) accountIndexPub(rt runtimeSnapshot, index uint32) (string, error) {
+	arg, err := types.EncodeToBytes(types.NewU32(index))
+	if err != nil {
+		return "", err
+	}
+	key, err := buildStorageKeyByMeta(rt.meta, "Indices", "Accounts", arg)
+	if err != nil {
+		return "", err
+	}
+	var account types.AccountID
+	var ok bool
+	if rt.blockHash != "" {
+		ok, err = c.getStorageAt(key, rt.blockHash, &account)
+	} else {
+		ok, err = c.caller().GetStorageLatest(key, &account)
+	}
+	if err != nil {
+		return "", fmt.Errorf("get account index %d error: %w", index, err)
+	}
+	if !ok {
+		return "", fmt.Errorf("account index %d is not exist", index)
+	}
+	return hex.EncodeToString(account[:]), nil
+}
+
+/*
+查询指定区块的storage并解码到target中，storage不存在时返回false
+*/
+func (c *Client) getStorageAt(key types.StorageKey, blockHash string, target interface{}) (bool, error) {
+	var result string
+	err := c.caller().Call(&result, "state_getStorageAt", key.Hex(), blockHash)
+	if err != nil {
+		return false, err
+	}
+	if result == "" {
+		return false, nil
+	}
+	data, err := types.HexDecodeString(result)
+	if err != nil {
+		return false, err
+	}
+	return true, types.DecodeFromBytes(data, target)
+}
+
 /*
 根据地址获取地址的账户信息，包括nonce以及余额等
 */
 func (c *Client) GetAccountInfo(address string) (*types.AccountInfo, error) {
+	accountInfo, err := c.getAccountInfo(address)
+	if err != nil {
+		return nil, err
+	}
+	if accountInfo == nil {
+		return nil, fmt.Errorf("get account info error: account %s is not exist", address)
+	}
+	return accountInfo, nil
+}
+
+/*
+与GetAccountInfo相同，账户不存在时返回nil, nil
+*/
+func (c *Client) getAccountInfo(address string) (accountInfo *types.AccountInfo, err error) {
 	var (
 		storage types.StorageKey
-		err     error
 		pub     []byte
 	)
+	//使用命名的返回值，解析中的panic才能作为错误返回，而不是返回nil, nil
 	defer func() {
 		if err1 := recover(); err1 != nil {
-			err = fmt.Errorf("panic decode event: %v", err1)
+			accountInfo = nil
+			err = fmt.Errorf("%w: panic decode account info: %v", ErrDecodeFailed, err1)
 		}
 	}()
-	err = c.checkRuntimeVersion()
+	err = c.autoCheckRuntime()
 	if err != nil {
 		return nil, err
 	}
@@ -476,34 +1450,27 @@ func (c *Client) GetAccountInfo(address string) (*types.AccountInfo, error) {
 	if err != nil {
 		return nil, fmt.Errorf("ss58 decode address error: %v", err)
 	}
-	storage, err = types.CreateStorageKey(c.Meta, "System", "Account", pub, nil)
+	storage, err = types.CreateStorageKey(c.runtime().meta, "System", "Account", pub, nil)
 	if err != nil {
 		return nil, fmt.Errorf("create System.Account storage error: %v", err)
 	}
-	var accountInfo types.AccountInfo
-	var ok bool
-	switch strings.ToLower(c.ChainName) {
-	// todo 目前这里先做硬编码先，后续在进行修改
-	case "polkadot", "kusama":
-		var accountInfoProviders expand.AccountInfoWithProviders
-		ok, err = c.C.RPC.State.GetStorageLatest(storage, &accountInfoProviders)
-		if err != nil || !ok {
-			return nil, fmt.Errorf("get account info error: %v", err)
-		}
-		accountInfo.Nonce = accountInfoProviders.Nonce
-		accountInfo.Refcount = accountInfoProviders.Consumers
-		accountInfo.Data.Free = accountInfoProviders.Data.Free
-		accountInfo.Data.FreeFrozen = accountInfoProviders.Data.FreeFrozen
-		accountInfo.Data.MiscFrozen = accountInfoProviders.Data.MiscFrozen
-		accountInfo.Data.Reserved = accountInfoProviders.Data.Reserved
-	default:
-		ok, err = c.C.RPC.State.GetStorageLatest(storage, &accountInfo)
-		if err != nil || !ok {
-			return nil, fmt.Errorf("get account info error: %v", err)
-		}
+	var result string
+	err = c.caller().Call(&result, "state_getStorage", storage.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("get account info error: %w", err)
 	}
-
-	return &accountInfo, nil
+	if result == "" {
+		return nil, nil
+	}
+	data, err := types.HexDecodeString(result)
+	if err != nil {
+		return nil, fmt.Errorf("get account info error: %v", err)
+	}
+	accountInfo, err = c.decodeAccountInfo(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: decode account info error: %v", ErrDecodeFailed, err)
+	}
+	return accountInfo, nil
 }
 
 /*
@@ -514,9 +1481,9 @@ func (c *Client) GetPartialFee(extrinsic, parentHash string) (string, error) {
 		extrinsic = "0x" + extrinsic
 	}
 	var result map[string]interface{}
-	err := c.C.Client.Call(&result, "payment_queryInfo", extrinsic, parentHash)
+	err := c.caller().Call(&result, "payment_queryInfo", extrinsic, parentHash)
 	if err != nil {
-		return "", fmt.Errorf("get payment info error: %v", err)
+		return "", fmt.Errorf("get payment info error: %w", err)
 	}
 	if result["partialFee"] == nil {
 		return "", errors.New("result partialFee is nil ptr")
@@ -533,9 +1500,9 @@ func (c *Client) GetPartialFeeDetail(extrinsic, parentHash string) (*expand.FeeD
 		extrinsic = "0x" + extrinsic
 	}
 	var result map[string]interface{}
-	err := c.C.Client.Call(&result, "payment_queryFeeDetails", extrinsic, parentHash)
+	err := c.caller().Call(&result, "payment_queryFeeDetails", extrinsic, parentHash)
 	if err != nil {
-		return nil, fmt.Errorf("get payment info error: %v", err)
+		return nil, fmt.Errorf("get payment info error: %w", err)
 	}
 	result = result["inclusionFee"].(map[string]interface{})
 	var resultObj = &expand.FeeDetail{}
@@ -554,3 +1521,18 @@ func (c *Client) GetPartialFeeDetail(extrinsic, parentHash string) (*expand.FeeD
 	resultObj.AdjustedWeightFee = decodeFunc(result["adjustedWeightFee"].(string))
 	return resultObj, nil
 }
+
+/*
+解析交易的era，height为交易所在的区块高度，era无法解析时返回nil
+*/
+func newEraInfo(eraHex string, height int64) *models.EraInfo {
+	mortal, period, phase, err := utils.DecodeEra(eraHex)
+	if err != nil {
+		return nil
+	}
+	info := &models.EraInfo{Mortal: mortal, Period: period, Phase: phase}
+	if mortal && height >= 0 {
+		info.Birth, info.Death = utils.EraRange(period, phase, uint64(height))
+	}
+	return info
+}
@@ -0,0 +1,38 @@
+package client
+
+import (
+	"fmt"
+	"github.com/JFJun/bifrost-go/expand"
+	"github.com/JFJun/bifrost-go/expand/base"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+/*
+设置metadata中找不到的Module错误（缓存的metadata过期或者新增的pallet）的解析方式，返回false时使用默认的
+"module:X error:Y (unknown)"，设置为nil时恢复默认
+*/
+func (c *Client) SetModuleErrorResolver(resolver func(moduleIndex, errorIndex uint8) (string, bool)) {
+	c.errorResolver = resolver
+}
+
+/*
+将DispatchError转换为可读的字符串，Module错误通过metadata查找模块以及错误的名字
+*/
+func (c *Client) dispatchErrorReason(meta *types.Metadata, dispatchErr base.DispatchError) string {
+	if !dispatchErr.IsModule() {
+		return dispatchErr.String()
+	}
+	me, err := expand.NewMetadataExpand(meta)
+	if err == nil {
+		module, name, err := me.MV.FindModuleError(dispatchErr.Module.Index, dispatchErr.Module.Error)
+		if err == nil {
+			return fmt.Sprintf("Module(%s.%s)", module, name)
+		}
+	}
+	if c.errorResolver != nil {
+		if reason, ok := c.errorResolver(dispatchErr.Module.Index, dispatchErr.Module.Error); ok {
+			return reason
+		}
+	}
+	return fmt.Sprintf("module:%d error:%d (unknown)", dispatchErr.Module.Index, dispatchErr.Module.Error)
+}
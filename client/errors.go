@@ -0,0 +1,35 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+/*
+常见的错误类型，返回的错误都使用%w包装，可以通过errors.Is判断错误类型
+*/
+var (
+	ErrConnectionClosed    = errors.New("connection closed")
+	ErrBlockNotFound       = errors.New("block not found")
+	ErrDecodeFailed        = errors.New("decode failed")
+	ErrMetadataUnavailable = errors.New("metadata unavailable")
+	//http连接时调用订阅相关的方法返回该错误
+	ErrSubscriptionUnsupported = errors.New("subscription is not supported over http")
+	//交易被节点丢弃、判定为非法、被替代或者没有在规定时间内finalized
+	ErrExtrinsicRejected = errors.New("extrinsic rejected")
+)
+
+/*
+第三方包连接断开时只返回字符串错误，这里统一转换为ErrConnectionClosed
+*/
+func wrapRPCError(err error) error {
+	if err == nil || errors.Is(err, ErrConnectionClosed) {
+		return err
+	}
+	if strings.Contains(err.Error(), "use of closed connection") ||
+		strings.Contains(err.Error(), "use of closed network connection") {
+		return fmt.Errorf("%w: %v", ErrConnectionClosed, err)
+	}
+	return err
+}
@@ -0,0 +1,62 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/JFJun/bifrost-go/expand"
+	"github.com/stafiprotocol/go-substrate-rpc-client/scale"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+const (
+	defaultMaxEvents = 100000
+	//每个event至少包含phase(1)、event id(2)以及topics(1)
+	minEventRecordSize = 4
+)
+
+/*
+设置一个区块最多允许的event数量，超过时解析event返回错误，n<=0时使用默认值100000
+同时限制events数据的字节数，避免不可信的节点返回异常数据导致解析时占用大量内存
+*/
+func (c *Client) SetMaxEvents(n int) {
+	c.maxEvents = n
+}
+
+func (c *Client) maxEventCount() int {
+	if c.maxEvents <= 0 {
+		return defaultMaxEvents
+	}
+	return c.maxEvents
+}
+
+/*
+检查events数据的大小以及event数量后再解析
+*/
+func (c *Client) decodeEventRecords(rt runtimeSnapshot, eventsHex string) (expand.IEventRecords, error) {
+	data, err := types.HexDecodeString(eventsHex)
+	if err != nil {
+		return nil, fmt.Errorf("%w: hex decode event data error: %v", ErrDecodeFailed, err)
+	}
+	maxEvents := c.maxEventCount()
+	//event的参数没有固定的长度，按每个event平均1KB估算字节数的上限
+	if len(data) > maxEvents*1024 {
+		return nil, fmt.Errorf("%w: event data is too large: %d bytes", ErrDecodeFailed, len(data))
+	}
+	decoder := scale.NewDecoder(bytes.NewReader(data))
+	count, err := decoder.DecodeUintCompact()
+	if err != nil {
+		return nil, fmt.Errorf("%w: decode event count error: %v", ErrDecodeFailed, err)
+	}
+	if count.Uint64() > uint64(maxEvents) {
+		return nil, fmt.Errorf("%w: too many events: %d, max is %d", ErrDecodeFailed, count.Uint64(), maxEvents)
+	}
+	if count.Uint64()*minEventRecordSize > uint64(len(data)) {
+		return nil, fmt.Errorf("%w: event count %d does not match data length %d", ErrDecodeFailed, count.Uint64(), len(data))
+	}
+	//event中Balance参数的长度与区块所在runtime的Balance类型一致
+	ier, err := expand.DecodeEventRecordsWithBalanceWidth(rt.meta, eventsHex, rt.chainName, detectBalanceWidth(rt.meta))
+	if err != nil {
+		return nil, fmt.Errorf("%w: decode event data error: %v", ErrDecodeFailed, err)
+	}
+	return ier, nil
+}
@@ -0,0 +1,116 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"github.com/JFJun/bifrost-go/models"
+)
+
+const feeRetryTimes = 2
+
+/*
+设置解析区块时是否请求payment_queryInfo获取手续费，默认开启
+手续费不是必须的数据，关闭后GetBlockByHash返回的Fee为空，可以之后调用FillFees单独获取
+*/
+func (c *Client) SetFetchFees(fetch bool) {
+	c.noFetchFees = !fetch
+}
+
+/*
+为已经解析好的区块补充手续费，只处理Fee为空的交易
+可以在SetFetchFees(false)之后使用，或者在GetBlockByHash获取手续费失败之后重试
+*/
+func (c *Client) FillFees(blockResp *models.BlockResponse) error {
+	if blockResp == nil || len(blockResp.Extrinsic) == 0 {
+		return nil
+	}
+	if !isBlockHash(blockResp.BlockHash) {
+		return fmt.Errorf("expected block hash, got %q", blockResp.BlockHash)
+	}
+	var block *models.SignedBlock
+	err := c.caller().Call(&block, "chain_getBlock", blockResp.BlockHash)
+	if err != nil {
+		return fmt.Errorf("get block error: %w", err)
+	}
+	if block == nil {
+		return fmt.Errorf("%w: %s", ErrBlockNotFound, blockResp.BlockHash)
+	}
+	fillErr := c.fillFees(block.Block.Extrinsics, blockResp)
+	//区块已经经过formatAmounts处理，新获取的手续费需要单独记录原始值以及转换精度
+	var (
+		decimals         int
+		decimalsResolved bool
+	)
+	for _, e := range blockResp.Extrinsic {
+		if e.RawFee != "" || e.Fee == "" {
+			continue
+		}
+		e.RawFee = e.Fee
+		if !c.humanReadable {
+			continue
+		}
+		if !decimalsResolved {
+			decimals, err = c.chainDecimals()
+			if err != nil {
+				return err
+			}
+			decimalsResolved = true
+		}
+		e.Fee, err = toTokenUnits(e.RawFee, decimals)
+		if err != nil {
+			return fmt.Errorf("format fee error: %v", err)
+		}
+	}
+	return fillErr
+}
+
+/*
+按extrinsic请求手续费并写入Fee为空的交易，同一个extrinsic中的多笔转账只请求一次
+单个extrinsic失败时会重试，仍然失败则跳过，最后返回第一个错误
+*/
+func (c *Client) fillFees(extrinsics []string, blockResp *models.BlockResponse) error {
+	var (
+		fees     = make(map[int]string)
+		failed   = make(map[int]bool)
+		firstErr error
+	)
+	for _, e := range blockResp.Extrinsic {
+		if e.Fee != "" || failed[e.ExtrinsicIndex] {
+			continue
+		}
+		fee, ok := fees[e.ExtrinsicIndex]
+		if !ok {
+			if e.ExtrinsicIndex < 0 || e.ExtrinsicIndex >= len(extrinsics) {
+				continue
+			}
+			var err error
+			fee, err = c.getPartialFeeWithRetry(extrinsics[e.ExtrinsicIndex], blockResp.ParentHash)
+			if err != nil {
+				failed[e.ExtrinsicIndex] = true
+				if firstErr == nil {
+					firstErr = fmt.Errorf("extrinsic %d: %w", e.ExtrinsicIndex, err)
+				}
+				continue
+			}
+			fees[e.ExtrinsicIndex] = fee
+		}
+		e.Fee = fee
+	}
+	return firstErr
+}
+
+func (c *Client) getPartialFeeWithRetry(extrinsic, parentHash string) (string, error) {
+	var err error
+	for i := 0; i < feeRetryTimes; i++ {
+		var fee string
+		fee, err = c.GetPartialFee(extrinsic, parentHash)
+		if err == nil {
+			return fee, nil
+		}
+		//离线模式下重试没有意义
+		if errors.Is(err, errOffline) {
+			break
+		}
+	}
+	return "", err
+}
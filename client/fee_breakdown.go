@@ -0,0 +1,186 @@
+package client
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/JFJun/bifrost-go/expand"
+	"github.com/JFJun/bifrost-go/utils"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"math/big"
+)
+
+/*
+交易实际支付的手续费以及组成部分，金额都是最小单位
+Total = BaseFee + LenFee + WeightFee + Tip，无法根据metadata的常量重新计算时BaseFee、LenFee以及WeightFee为nil
+*/
+type FeeBreakdown struct {
+	Total     *big.Int
+	Tip       *big.Int
+	BaseFee   *big.Int //WeightToFee(System.ExtrinsicBaseWeight)
+	LenFee    *big.Int //TransactionPayment.TransactionByteFee * Length
+	WeightFee *big.Int //按NextFeeMultiplier调整后的权重手续费，为Total减去其它部分
+	Weight    uint64   //System.ExtrinsicSuccess/ExtrinsicFailed中的权重
+	Length    int      //extrinsic的字节数
+}
+
+/*
+获取区块中指定extrinsic实际支付的手续费以及组成部分
+总额优先使用TransactionPayment.TransactionFeePaid，旧的runtime没有这个event时使用手续费账户的Balances.Withdraw减去退回的Balances.Deposit
+*/
+func (c *Client) GetFeeBreakdown(blockHash string, extrinsicIdx int) (*FeeBreakdown, error) {
+	if !isBlockHash(blockHash) {
+		return nil, fmt.Errorf("expected block hash, got %q", blockHash)
+	}
+	err := c.autoCheckRuntime()
+	if err != nil {
+		return nil, err
+	}
+	block, eventsHex, err := c.getBlockAndEvents(blockHash)
+	if err != nil {
+		return nil, err
+	}
+	if extrinsicIdx < 0 || extrinsicIdx >= len(block.Block.Extrinsics) {
+		return nil, fmt.Errorf("extrinsic %d is not in block %s", extrinsicIdx, blockHash)
+	}
+	if eventsHex == "" {
+		eventsHex, err = c.getEventsHex(blockHash)
+		if err != nil {
+			return nil, err
+		}
+	}
+	rt := c.runtime()
+	ier, err := c.decodeEventRecords(rt, eventsHex)
+	if err != nil {
+		return nil, err
+	}
+	data, err := hex.DecodeString(utils.Remove0X(block.Block.Extrinsics[extrinsicIdx]))
+	if err != nil {
+		return nil, fmt.Errorf("hex.decode extrinsic error: %v", err)
+	}
+	var ext expand.Extrinsic
+	err = types.DecodeFromBytes(data, &ext)
+	if err != nil {
+		return nil, fmt.Errorf("%w: decode extrinsic error: %v", ErrDecodeFailed, err)
+	}
+	if !ext.IsSigned() {
+		return nil, fmt.Errorf("extrinsic %d is not signed and pays no fee", extrinsicIdx)
+	}
+	breakdown := &FeeBreakdown{Length: len(data)}
+	breakdown.Total, breakdown.Tip = extrinsicFee(ier, &ext, extrinsicIdx)
+	if breakdown.Total == nil {
+		return nil, fmt.Errorf("no fee event for extrinsic %d in block %s", extrinsicIdx, blockHash)
+	}
+	for _, ev := range ier.GetSystemExtrinsicSuccess() {
+		if isExtrinsicPhase(ev.Phase, extrinsicIdx) {
+			breakdown.Weight = uint64(ev.DispatchInfo.Weight)
+		}
+	}
+	for _, ev := range ier.GetSystemExtrinsicFailed() {
+		if isExtrinsicPhase(ev.Phase, extrinsicIdx) {
+			breakdown.Weight = uint64(ev.DispatchInfo.Weight)
+		}
+	}
+	c.fillFeeComponents(rt, breakdown)
+	return breakdown, nil
+}
+
+/*
+签名的extrinsic实际支付的手续费（包含小费）以及小费，优先使用TransactionPayment.TransactionFeePaid，
+没有这个event时使用手续费账户的Balances.Withdraw减去退回的Balances.Deposit，都没有时total为nil
+*/
+func extrinsicFee(ier expand.IEventRecords, ext *expand.Extrinsic, extrinsicIdx int) (total, tip *big.Int) {
+	for _, ev := range ier.GetTransactionFeePaid() {
+		if isExtrinsicPhase(ev.Phase, extrinsicIdx) {
+			return new(big.Int).Set(ev.ActualFee.Int), new(big.Int).Set(ev.Tip.Int)
+		}
+	}
+	return withdrawnFee(ier, extrinsicIdx), utils.UCompactToBigInt(ext.Signature.Tip)
+}
+
+func isExtrinsicPhase(phase types.Phase, extrinsicIdx int) bool {
+	return phase.IsApplyExtrinsic && int(phase.AsApplyExtrinsic) == extrinsicIdx
+}
+
+/*
+手续费在执行call之前通过第一个Balances.Withdraw扣除，多扣的部分执行之后通过Balances.Deposit退回给同一个账户
+*/
+func withdrawnFee(ier expand.IEventRecords, extrinsicIdx int) *big.Int {
+	var (
+		fee   *big.Int
+		payer types.AccountID
+	)
+	for _, ev := range ier.GetBalancesWithdraw() {
+		if isExtrinsicPhase(ev.Phase, extrinsicIdx) {
+			fee = new(big.Int).Set(ev.Balance.Int)
+			payer = ev.Who
+			break
+		}
+	}
+	if fee == nil {
+		return nil
+	}
+	for _, ev := range ier.GetBalancesDeposit() {
+		if isExtrinsicPhase(ev.Phase, extrinsicIdx) && ev.Who == payer {
+			fee.Sub(fee, ev.Balance.Int)
+		}
+	}
+	if fee.Sign() < 0 {
+		fee.SetInt64(0)
+	}
+	return fee
+}
+
+/*
+根据metadata中的常量计算BaseFee以及LenFee，WeightFee为剩下的部分
+runtime没有这些常量（比如使用System.BlockWeights的新版本）或者结果不一致时不计算
+*/
+func (c *Client) fillFeeComponents(rt runtimeSnapshot, breakdown *FeeBreakdown) {
+	me, err := expand.NewMetadataExpand(rt.meta)
+	if err != nil {
+		return
+	}
+	var (
+		byteFee    types.U128
+		baseWeight types.U64
+		coeffs     []expand.WeightToFeeCoefficient
+	)
+	if decodeConstant(me, "TransactionPayment", "TransactionByteFee", &byteFee) != nil ||
+		decodeConstant(me, "System", "ExtrinsicBaseWeight", &baseWeight) != nil ||
+		decodeConstant(me, "TransactionPayment", "WeightToFee", &coeffs) != nil {
+		return
+	}
+	baseFee := weightToFee(coeffs, uint64(baseWeight))
+	lenFee := new(big.Int).Mul(byteFee.Int, big.NewInt(int64(breakdown.Length)))
+	weightFee := new(big.Int).Sub(breakdown.Total, breakdown.Tip)
+	weightFee.Sub(weightFee, baseFee)
+	weightFee.Sub(weightFee, lenFee)
+	if weightFee.Sign() < 0 {
+		return
+	}
+	breakdown.BaseFee = baseFee
+	breakdown.LenFee = lenFee
+	breakdown.WeightFee = weightFee
+}
+
+/*
+与substrate的WeightToFeePolynomial一致：sum(±(integer + frac/10^9) * weight^degree)，结果小于0时为0
+*/
+func weightToFee(coeffs []expand.WeightToFeeCoefficient, weight uint64) *big.Int {
+	fee := new(big.Int)
+	w := new(big.Int).SetUint64(weight)
+	for _, coeff := range coeffs {
+		power := new(big.Int).Exp(w, big.NewInt(int64(coeff.Degree)), nil)
+		term := new(big.Int).Mul(power, coeff.CoeffInteger.Int)
+		frac := new(big.Int).Mul(power, big.NewInt(int64(coeff.CoeffFrac.Value)))
+		term.Add(term, frac.Div(frac, big.NewInt(1000000000)))
+		if coeff.Negative {
+			fee.Sub(fee, term)
+		} else {
+			fee.Add(fee, term)
+		}
+	}
+	if fee.Sign() < 0 {
+		fee.SetInt64(0)
+	}
+	return fee
+}
@@ -0,0 +1,140 @@
+package client
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/JFJun/bifrost-go/expand"
+	"github.com/JFJun/bifrost-go/utils"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"math/big"
+	"sort"
+)
+
+/*
+一组金额的分布，百分位使用nearest-rank计算，金额都是最小单位
+*/
+type FeeDistribution struct {
+	Min    *big.Int `json:"min"`
+	P25    *big.Int `json:"p25"`
+	Median *big.Int `json:"median"`
+	P75    *big.Int `json:"p75"`
+	P90    *big.Int `json:"p90"`
+	Max    *big.Int `json:"max"`
+}
+
+/*
+最近的区块中签名交易实际支付的手续费（包含小费）以及小费的统计，没有签名交易时Fee以及Tip为nil
+*/
+type FeeStats struct {
+	StartHeight int64            `json:"start_height"`
+	EndHeight   int64            `json:"end_height"`
+	Extrinsics  int              `json:"extrinsics"` //统计的签名交易数量
+	Fee         *FeeDistribution `json:"fee"`
+	Tip         *FeeDistribution `json:"tip"`
+}
+
+/*
+统计最新的blocks个区块中每个签名交易的手续费以及小费，用于估算手续费以及建议小费
+区块并发地预先获取（SetPrefetchDepth），手续费从event中获取（与GetFeeBreakdown一致），不请求payment_queryInfo
+*/
+func (c *Client) RecentFeeStats(blocks int) (*FeeStats, error) {
+	if blocks <= 0 {
+		return nil, fmt.Errorf("invalid block count: %d", blocks)
+	}
+	_, latest, err := c.GetLatestBlock()
+	if err != nil {
+		return nil, err
+	}
+	end := int64(latest)
+	start := end - int64(blocks) + 1
+	if start < 0 {
+		start = 0
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var fees, tips []*big.Int
+	for raw := range c.prefetchBlocks(ctx, start, end) {
+		if raw.err != nil {
+			return nil, raw.err
+		}
+		err = c.autoCheckRuntime()
+		if err != nil {
+			return nil, err
+		}
+		blockFees, blockTips, err := c.blockFees(c.runtime(), raw)
+		if err != nil {
+			return nil, fmt.Errorf("get fees of block %d error: %w", raw.height, err)
+		}
+		fees = append(fees, blockFees...)
+		tips = append(tips, blockTips...)
+	}
+	stats := &FeeStats{StartHeight: start, EndHeight: end, Extrinsics: len(fees)}
+	if len(fees) > 0 {
+		stats.Fee = newFeeDistribution(fees)
+		stats.Tip = newFeeDistribution(tips)
+	}
+	return stats, nil
+}
+
+/*
+区块中每个签名交易通过event得到的手续费以及小费，没有手续费event的交易（比如手续费被代付）不统计
+*/
+func (c *Client) blockFees(rt runtimeSnapshot, raw rawBlock) (fees, tips []*big.Int, err error) {
+	if raw.block == nil {
+		return nil, nil, errors.New("block is nil")
+	}
+	ier, err := c.decodeEventRecords(rt, raw.eventsHex)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i, extrinsic := range raw.block.Block.Extrinsics {
+		data, err := hex.DecodeString(utils.Remove0X(extrinsic))
+		if err != nil {
+			return nil, nil, fmt.Errorf("hex.decode extrinsic %d error: %v", i, err)
+		}
+		var ext expand.Extrinsic
+		err = types.DecodeFromBytes(data, &ext)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: decode extrinsic %d error: %v", ErrDecodeFailed, i, err)
+		}
+		if !ext.IsSigned() {
+			continue
+		}
+		fee, tip := extrinsicFee(ier, &ext, i)
+		if fee == nil {
+			continue
+		}
+		fees = append(fees, fee)
+		tips = append(tips, tip)
+	}
+	return fees, tips, nil
+}
+
+func newFeeDistribution(values []*big.Int) *FeeDistribution {
+	sorted := make([]*big.Int, len(values))
+	copy(sorted, values)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Cmp(sorted[j]) < 0
+	})
+	return &FeeDistribution{
+		Min:    sorted[0],
+		P25:    percentile(sorted, 25),
+		Median: percentile(sorted, 50),
+		P75:    percentile(sorted, 75),
+		P90:    percentile(sorted, 90),
+		Max:    sorted[len(sorted)-1],
+	}
+}
+
+/*
+nearest-rank：第ceil(p/100*n)个值
+*/
+func percentile(sorted []*big.Int, p int) *big.Int {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,146 @@
+package client
+
+import (
+	"fmt"
+	"github.com/JFJun/bifrost-go/expand"
+	"github.com/JFJun/go-substrate-crypto/ss58"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+/*
+账户在ConvictionVoting中的锁定以及每个class的投票信息
+*/
+type ConvictionVotingLocks struct {
+	ClassLocks []expand.ClassLock                  `json:"class_locks"`
+	Voting     map[uint16]*expand.ConvictionVoting `json:"voting"` //key为class
+}
+
+/*
+获取地址在OpenGov中因为投票而锁定的金额（ConvictionVoting.ClassLocksFor）以及每个class的投票（ConvictionVoting.VotingFor）
+*/
+func (c *Client) GetConvictionVotingLocks(address string) (*ConvictionVotingLocks, error) {
+	err := c.autoCheckRuntime()
+	if err != nil {
+		return nil, err
+	}
+	pub, err := ss58.DecodeToPub(address)
+	if err != nil {
+		return nil, fmt.Errorf("ss58 decode address error: %v", err)
+	}
+	key, err := c.BuildStorageKey("ConvictionVoting", "ClassLocksFor", pub)
+	if err != nil {
+		return nil, err
+	}
+	result := &ConvictionVotingLocks{Voting: make(map[uint16]*expand.ConvictionVoting)}
+	_, err = c.caller().GetStorageLatest(key, &result.ClassLocks)
+	if err != nil {
+		return nil, fmt.Errorf("get ConvictionVoting.ClassLocksFor error: %w", err)
+	}
+	for _, lock := range result.ClassLocks {
+		class, err := types.EncodeToBytes(lock.Class)
+		if err != nil {
+			return nil, err
+		}
+		key, err = c.BuildStorageKey("ConvictionVoting", "VotingFor", pub, class)
+		if err != nil {
+			return nil, err
+		}
+		var voting expand.ConvictionVoting
+		ok, err := c.caller().GetStorageLatest(key, &voting)
+		if err != nil {
+			return nil, fmt.Errorf("get ConvictionVoting.VotingFor error: %w", err)
+		}
+		if ok {
+			result.Voting[uint16(lock.Class)] = &voting
+		}
+	}
+	return result, nil
+}
+
+/*
+获取preimage中保存的call，hash为preimage的hash（比如Democracy.Proposed、Referenda.Submitted中的proposal hash）
+Preimage.PreimageFor旧版本的key为hash，新版本（bounded call）的key为(hash, len)，
+两者的key都以hash开头（Identity），所以通过state_getKeysPaged按前缀查找，不需要知道长度
+没有Preimage模块时使用Democracy.Preimages（PreimageStatus::Available中的data）
+*/
+func (c *Client) GetPreimage(hash string) (types.Call, error) {
+	var call types.Call
+	err := c.autoCheckRuntime()
+	if err != nil {
+		return call, err
+	}
+	h, err := types.NewHashFromHexString(hash)
+	if err != nil {
+		return call, fmt.Errorf("invalid preimage hash %q: %v", hash, err)
+	}
+	meta := c.runtime().meta
+	if meta == nil {
+		return call, fmt.Errorf("%w: metadata is nil", ErrMetadataUnavailable)
+	}
+	var data []byte
+	if _, err = meta.FindStorageEntryMetadata("Preimage", "PreimageFor"); err == nil {
+		data, err = c.getPreimageFor(h)
+	} else {
+		data, err = c.getDemocracyPreimage(h)
+	}
+	if err != nil {
+		return call, err
+	}
+	if len(data) < 2 {
+		return call, fmt.Errorf("%w: preimage is too short to be a call", ErrDecodeFailed)
+	}
+	_, _, err = c.ResolveCall(data[0], data[1])
+	if err != nil {
+		return call, fmt.Errorf("%w: preimage is not a call: %v", ErrDecodeFailed, err)
+	}
+	call.CallIndex = types.CallIndex{SectionIndex: data[0], MethodIndex: data[1]}
+	call.Args = data[2:]
+	return call, nil
+}
+
+func (c *Client) getPreimageFor(h types.Hash) ([]byte, error) {
+	prefix, err := c.BuildStorageKey("Preimage", "PreimageFor", h[:])
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	err = c.caller().Call(&keys, "state_getKeysPaged", prefix.Hex(), 1, prefix.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("get Preimage.PreimageFor keys error: %w", err)
+	}
+	//startKey不包含自身，旧版本的key与前缀相同，需要单独查询
+	key := prefix.Hex()
+	if len(keys) > 0 {
+		key = keys[0]
+	}
+	var result string
+	err = c.caller().Call(&result, "state_getStorage", key)
+	if err != nil {
+		return nil, fmt.Errorf("get Preimage.PreimageFor error: %w", err)
+	}
+	if result == "" {
+		return nil, fmt.Errorf("preimage %s is not found", h.Hex())
+	}
+	var data types.Bytes
+	err = types.DecodeFromHexString(result, &data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: decode Preimage.PreimageFor error: %v", ErrDecodeFailed, err)
+	}
+	return data, nil
+}
+
+func (c *Client) getDemocracyPreimage(h types.Hash) ([]byte, error) {
+	key, err := c.BuildStorageKey("Democracy", "Preimages", h[:])
+	if err != nil {
+		return nil, err
+	}
+	var status expand.DemocracyPreimageStatus
+	ok, err := c.caller().GetStorageLatest(key, &status)
+	if err != nil {
+		return nil, fmt.Errorf("get Democracy.Preimages error: %w", err)
+	}
+	if !ok || !status.IsAvailable {
+		return nil, fmt.Errorf("preimage %s is not found", h.Hex())
+	}
+	return status.Data, nil
+}
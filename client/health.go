@@ -0,0 +1,60 @@
+package client
+
+import (
+	"fmt"
+)
+
+/*
+节点的健康状态，来自system_health以及system_syncState
+HighestBlock为节点已知的最高区块，节点还不知道时为0
+*/
+type NodeHealth struct {
+	Peers           int    `json:"peers"`
+	IsSyncing       bool   `json:"is_syncing"`
+	ShouldHavePeers bool   `json:"should_have_peers"`
+	StartingBlock   uint64 `json:"starting_block"`
+	CurrentBlock    uint64 `json:"current_block"`
+	HighestBlock    uint64 `json:"highest_block"`
+}
+
+/*
+节点是否可以用来查询：没有在同步，并且需要节点时有节点连接
+*/
+func (h *NodeHealth) IsHealthy() bool {
+	return !h.IsSyncing && (h.Peers > 0 || !h.ShouldHavePeers)
+}
+
+/*
+获取节点的连接数以及同步状态，可以在索引之前检查节点是否已经同步完成
+*/
+func (c *Client) Health() (*NodeHealth, error) {
+	var health struct {
+		Peers           int  `json:"peers"`
+		IsSyncing       bool `json:"isSyncing"`
+		ShouldHavePeers bool `json:"shouldHavePeers"`
+	}
+	err := c.caller().Call(&health, "system_health")
+	if err != nil {
+		return nil, fmt.Errorf("get system health error: %w", err)
+	}
+	var syncState struct {
+		StartingBlock uint64  `json:"startingBlock"`
+		CurrentBlock  uint64  `json:"currentBlock"`
+		HighestBlock  *uint64 `json:"highestBlock"`
+	}
+	err = c.caller().Call(&syncState, "system_syncState")
+	if err != nil {
+		return nil, fmt.Errorf("get system sync state error: %w", err)
+	}
+	h := &NodeHealth{
+		Peers:           health.Peers,
+		IsSyncing:       health.IsSyncing,
+		ShouldHavePeers: health.ShouldHavePeers,
+		StartingBlock:   syncState.StartingBlock,
+		CurrentBlock:    syncState.CurrentBlock,
+	}
+	if syncState.HighestBlock != nil {
+		h.HighestBlock = *syncState.HighestBlock
+	}
+	return h, nil
+}
@@ -0,0 +1,208 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+/*
+判断是否为http(s)的节点地址
+*/
+func isHTTPURL(url string) bool {
+	u := strings.ToLower(url)
+	return strings.HasPrefix(u, "http://") || strings.HasPrefix(u, "https://")
+}
+
+/*
+基于http的json-rpc，只支持普通的请求，不支持订阅
+*/
+type httpRPC struct {
+	url    string
+	client *http.Client
+	id     uint64
+}
+
+func newHTTPRPC(url string) *httpRPC {
+	return &httpRPC{
+		url:    url,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+type jsonRPCRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      uint64        `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type jsonRPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type jsonRPCResponse struct {
+	ID     uint64          `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *jsonRPCError   `json:"error"`
+}
+
+func (h *httpRPC) Call(result interface{}, method string, args ...interface{}) error {
+	if args == nil {
+		args = []interface{}{}
+	}
+	body, err := json.Marshal(jsonRPCRequest{
+		JSONRPC: "2.0",
+		ID:      atomic.AddUint64(&h.id, 1),
+		Method:  method,
+		Params:  args,
+	})
+	if err != nil {
+		return err
+	}
+	resp, err := h.client.Post(h.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("http rpc %s error: status=%d,body=%s", method, resp.StatusCode, string(data))
+	}
+	var rpcResp jsonRPCResponse
+	err = json.Unmarshal(data, &rpcResp)
+	if err != nil {
+		return fmt.Errorf("unmarshal http rpc response error: %v", err)
+	}
+	if rpcResp.Error != nil {
+		return fmt.Errorf("http rpc %s error: code=%d,message=%s", method, rpcResp.Error.Code, rpcResp.Error.Message)
+	}
+	if result == nil || len(rpcResp.Result) == 0 {
+		return nil
+	}
+	return json.Unmarshal(rpcResp.Result, result)
+}
+
+/*
+一次http请求发送多个调用，节点不支持批量请求（返回的不是数组）时返回errBatchUnsupported
+*/
+func (h *httpRPC) BatchCall(batch []BatchElem) error {
+	if len(batch) == 0 {
+		return nil
+	}
+	requests := make([]jsonRPCRequest, len(batch))
+	for i, e := range batch {
+		args := e.Args
+		if args == nil {
+			args = []interface{}{}
+		}
+		requests[i] = jsonRPCRequest{
+			JSONRPC: "2.0",
+			ID:      atomic.AddUint64(&h.id, 1),
+			Method:  e.Method,
+			Params:  args,
+		}
+	}
+	body, err := json.Marshal(requests)
+	if err != nil {
+		return err
+	}
+	resp, err := h.client.Post(h.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: status=%d,body=%s", errBatchUnsupported, resp.StatusCode, string(data))
+	}
+	var responses []jsonRPCResponse
+	err = json.Unmarshal(data, &responses)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errBatchUnsupported, err)
+	}
+	//返回的顺序不一定与请求一致，通过id对应
+	byID := make(map[uint64]jsonRPCResponse, len(responses))
+	for _, r := range responses {
+		byID[r.ID] = r
+	}
+	for i := range batch {
+		r, ok := byID[requests[i].ID]
+		switch {
+		case !ok:
+			batch[i].Error = fmt.Errorf("http rpc %s error: no response", batch[i].Method)
+		case r.Error != nil:
+			batch[i].Error = fmt.Errorf("http rpc %s error: code=%d,message=%s", batch[i].Method, r.Error.Code, r.Error.Message)
+		case batch[i].Result != nil && len(r.Result) > 0:
+			batch[i].Error = json.Unmarshal(r.Result, batch[i].Result)
+		}
+	}
+	return nil
+}
+
+func (h *httpRPC) GetRuntimeVersionLatest() (*types.RuntimeVersion, error) {
+	var v types.RuntimeVersion
+	err := h.Call(&v, "state_getRuntimeVersion")
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func (h *httpRPC) GetMetadataLatest() (*types.Metadata, error) {
+	var res string
+	err := h.Call(&res, "state_getMetadata")
+	if err != nil {
+		return nil, err
+	}
+	var metadata types.Metadata
+	err = types.DecodeFromHexString(res, &metadata)
+	if err != nil {
+		return nil, err
+	}
+	return &metadata, nil
+}
+
+func (h *httpRPC) GetStorageLatest(key types.StorageKey, target interface{}) (bool, error) {
+	var res string
+	err := h.Call(&res, "state_getStorage", key.Hex())
+	if err != nil {
+		return false, err
+	}
+	if res == "" {
+		return false, nil
+	}
+	data, err := types.HexDecodeString(res)
+	if err != nil {
+		return false, err
+	}
+	if len(data) == 0 {
+		return false, nil
+	}
+	return true, types.DecodeFromBytes(data, target)
+}
+
+func (h *httpRPC) GetBlockHash(blockNumber uint64) (types.Hash, error) {
+	var res string
+	err := h.Call(&res, "chain_getBlockHash", blockNumber)
+	if err != nil {
+		return types.Hash{}, err
+	}
+	if res == "" {
+		return types.Hash{}, nil
+	}
+	return types.NewHashFromHexString(res)
+}
@@ -0,0 +1,154 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"github.com/JFJun/bifrost-go/models"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+const defaultPrefetchDepth = 4
+
+/*
+IterateBlocks返回的每一个区块，Err不为空时Block为nil
+*/
+type BlockResult struct {
+	Height int64
+	Block  *models.BlockResponse
+	Err    error
+}
+
+/*
+设置IterateBlocks最多预先获取的区块数量，depth<=0时使用默认值4
+*/
+func (c *Client) SetPrefetchDepth(depth int) {
+	c.prefetchDepth = depth
+}
+
+/*
+按高度顺序逐个返回[start,end]之间的区块，返回的chan在所有区块返回完或者ctx结束后关闭
+区块以及event的原始数据会并发地预先获取，最多领先depth个区块，解析仍然按顺序进行，所以内存占用是固定的
+某个区块出错时会返回带Err的BlockResult并继续下一个区块，调用者可以通过取消ctx提前结束
+*/
+func (c *Client) IterateBlocks(ctx context.Context, start, end int64) <-chan BlockResult {
+	results := make(chan BlockResult)
+	raws := c.prefetchBlocks(ctx, start, end)
+	go func() {
+		defer close(results)
+		for raw := range raws {
+			result := BlockResult{Height: raw.height}
+			result.Block, result.Err = c.parseRawBlock(raw)
+			select {
+			case results <- result:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return results
+}
+
+/*
+并发地获取[start,end]之间的区块以及event的原始数据，按高度顺序返回，最多领先depth（SetPrefetchDepth）个区块
+返回的chan在所有区块返回完或者ctx结束后关闭
+*/
+func (c *Client) prefetchBlocks(ctx context.Context, start, end int64) <-chan rawBlock {
+	depth := c.prefetchDepth
+	if depth <= 0 {
+		depth = defaultPrefetchDepth
+	}
+	raws := make(chan rawBlock)
+	pending := make(chan chan rawBlock, depth)
+	go func() {
+		defer close(pending)
+		for height := start; height <= end; height++ {
+			fetched := make(chan rawBlock, 1)
+			select {
+			case pending <- fetched:
+			case <-ctx.Done():
+				return
+			}
+			go func(height int64) {
+				fetched <- c.fetchRawBlock(height)
+			}(height)
+		}
+	}()
+	go func() {
+		defer close(raws)
+		for fetched := range pending {
+			var raw rawBlock
+			select {
+			case raw = <-fetched:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case raws <- raw:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return raws
+}
+
+/*
+解析预先获取的区块，使用区块所在runtime的快照，可以并发调用
+*/
+func (c *Client) parseRawBlock(raw rawBlock) (*models.BlockResponse, error) {
+	if raw.err != nil {
+		return nil, raw.err
+	}
+	err := c.autoCheckRuntime()
+	if err != nil {
+		return nil, err
+	}
+	rt, err := c.blockRuntime(raw.hash)
+	if err != nil {
+		return nil, err
+	}
+	return c.parseBlock(rt, raw.hash, raw.block, raw.eventsHex)
+}
+
+/*
+预先获取的区块原始数据
+*/
+type rawBlock struct {
+	height    int64
+	hash      string
+	block     *models.SignedBlock
+	eventsHex string
+	err       error
+}
+
+/*
+只请求节点获取区块以及System.Events的原始数据，不读取metadata，可以并发调用
+*/
+func (c *Client) fetchRawBlock(height int64) rawBlock {
+	raw := rawBlock{height: height}
+	hash, err := c.caller().GetBlockHash(uint64(height))
+	if err != nil {
+		raw.err = fmt.Errorf("get block hash error:%w,height:%d", err, height)
+		return raw
+	}
+	if hash == (types.Hash{}) {
+		raw.err = fmt.Errorf("%w: height %d", ErrBlockNotFound, height)
+		return raw
+	}
+	raw.hash = hash.Hex()
+	raw.block, raw.eventsHex, raw.err = c.getBlockAndEvents(raw.hash)
+	if raw.err != nil || raw.eventsHex != "" {
+		return raw
+	}
+	//不支持批量请求时单独获取event，System.Events的key是固定的，不需要metadata
+	key, err := buildStorageKey("System", "Events", nil)
+	if err != nil {
+		raw.err = err
+		return raw
+	}
+	err = c.caller().Call(&raw.eventsHex, "state_getStorageAt", key.Hex(), raw.hash)
+	if err != nil {
+		raw.err = fmt.Errorf("get storage data error: %w", err)
+	}
+	return raw
+}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"fmt"
+	"github.com/JFJun/go-substrate-crypto/ss58"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+/*
+一次state_queryStorageAt请求获取多个地址的nonce，key为地址
+AccountInfo的第一个字段即为nonce，只解码这个字段，没有链上记录的地址nonce为0
+*/
+func (c *Client) GetNoncesBatch(addresses []string) (map[string]uint64, error) {
+	nonces := make(map[string]uint64, len(addresses))
+	if len(addresses) == 0 {
+		return nonces, nil
+	}
+	err := c.autoCheckRuntime()
+	if err != nil {
+		return nil, err
+	}
+	var (
+		keys      []string
+		keyToAddr = make(map[string][]string, len(addresses)) //不同格式的地址可能对应同一个key
+	)
+	for _, address := range addresses {
+		pub, err := ss58.DecodeToPub(address)
+		if err != nil {
+			return nil, fmt.Errorf("ss58 decode address %s error: %v", address, err)
+		}
+		key, err := c.BuildStorageKey("System", "Account", pub)
+		if err != nil {
+			return nil, err
+		}
+		nonces[address] = 0
+		if _, ok := keyToAddr[key.Hex()]; !ok {
+			keys = append(keys, key.Hex())
+		}
+		keyToAddr[key.Hex()] = append(keyToAddr[key.Hex()], address)
+	}
+	var changeSets []types.StorageChangeSet
+	err = c.caller().Call(&changeSets, "state_queryStorageAt", keys)
+	if err != nil {
+		return nil, fmt.Errorf("query System.Account storage error: %w", err)
+	}
+	for _, changeSet := range changeSets {
+		for _, change := range changeSet.Changes {
+			addrs, ok := keyToAddr[change.StorageKey.Hex()]
+			if !ok || !change.HasStorageData {
+				continue
+			}
+			var nonce types.U32
+			err = types.DecodeFromBytes(change.StorageData, &nonce)
+			if err != nil {
+				return nil, fmt.Errorf("%w: decode nonce of %s error: %v", ErrDecodeFailed, addrs[0], err)
+			}
+			for _, address := range addrs {
+				nonces[address] = uint64(nonce)
+			}
+		}
+	}
+	return nonces, nil
+}
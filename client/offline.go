@@ -0,0 +1,103 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"github.com/JFJun/bifrost-go/models"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"strings"
+)
+
+var errOffline = errors.New("client is offline")
+
+/*
+离线模式下的rpc，所有的调用都直接返回错误
+*/
+type offlineRPC struct{}
+
+func (offlineRPC) Call(result interface{}, method string, args ...interface{}) error {
+	return errOffline
+}
+
+func (offlineRPC) GetRuntimeVersionLatest() (*types.RuntimeVersion, error) {
+	return nil, errOffline
+}
+
+func (offlineRPC) GetMetadataLatest() (*types.Metadata, error) {
+	return nil, errOffline
+}
+
+func (offlineRPC) GetStorageLatest(key types.StorageKey, target interface{}) (bool, error) {
+	return false, errOffline
+}
+
+func (offlineRPC) GetBlockHash(blockNumber uint64) (types.Hash, error) {
+	return types.Hash{}, errOffline
+}
+
+/*
+离线解析区块：根据metadata、区块的extrinsic以及System.Events的原始数据解析出完整的BlockResponse
+不会请求任何节点，所以手续费（payment_queryInfo）为空
+*/
+func ParseBlockOffline(meta *types.Metadata, prefix []byte, chainName string, extrinsics []string,
+	eventsHex string, header models.Header) (*models.BlockResponse, error) {
+	if meta == nil {
+		return nil, fmt.Errorf("%w: metadata is nil", ErrMetadataUnavailable)
+	}
+	c := new(Client)
+	c.rpc = offlineRPC{}
+	c.Meta = meta
+	c.prefix = prefix
+	c.ChainName = chainName
+	c.registerDefaultCallHandlers()
+	blockResp := newBlockResponse(header, "")
+	rt := c.runtime()
+	if len(extrinsics) > 0 {
+		err := c.parseExtrinsicByDecode(rt, extrinsics, blockResp)
+		if err != nil {
+			return nil, err
+		}
+		if len(blockResp.Extrinsic) > 0 && eventsHex != "" {
+			err = c.parseEvents(rt, eventsHex, blockResp)
+			if err != nil {
+				return nil, fmt.Errorf("parse events error: %v", err)
+			}
+		}
+	}
+	err := c.formatAmounts(blockResp)
+	if err != nil {
+		return nil, err
+	}
+	return blockResp, nil
+}
+
+/*
+返回当前加载的metadata的scale编码，0x开头的hex
+可以保存下来，之后通过DecodeMetadataHex加载并用于ParseBlockOffline
+*/
+func (c *Client) MetadataHex() (string, error) {
+	meta := c.runtime().meta
+	if meta == nil {
+		return "", fmt.Errorf("%w: metadata is not loaded", ErrMetadataUnavailable)
+	}
+	metaHex, err := types.EncodeToHexString(meta)
+	if err != nil {
+		return "", fmt.Errorf("encode metadata error: %v", err)
+	}
+	return metaHex, nil
+}
+
+/*
+从MetadataHex或者state_getMetadata返回的hex中加载metadata
+*/
+func DecodeMetadataHex(metadataHex string) (*types.Metadata, error) {
+	if !strings.HasPrefix(metadataHex, "0x") {
+		metadataHex = "0x" + metadataHex
+	}
+	var meta types.Metadata
+	err := types.DecodeFromHexString(metadataHex, &meta)
+	if err != nil {
+		return nil, fmt.Errorf("%w: decode metadata error: %v", ErrDecodeFailed, err)
+	}
+	return &meta, nil
+}
@@ -0,0 +1,96 @@
+package client
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/JFJun/bifrost-go/expand"
+	"github.com/JFJun/bifrost-go/expand/base"
+	"github.com/JFJun/bifrost-go/models"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"strings"
+)
+
+/*
+根据交易hash获取交易在指定区块中产生的所有event，不需要解析整个区块
+Balances.Transfer会填充From、To以及Amount，Balances.Withdraw会填充From以及Amount，Balances.Deposit会填充To以及Amount，
+System.ExtrinsicSuccess/ExtrinsicFailed会填充Status以及Weight
+*/
+func (c *Client) GetEventsByExtrinsicHash(txHash, blockHash string) ([]models.EventResult, error) {
+	if !isBlockHash(blockHash) {
+		return nil, fmt.Errorf("expected block hash, got %q", blockHash)
+	}
+	var block *models.SignedBlock
+	err := c.caller().Call(&block, "chain_getBlock", blockHash)
+	if err != nil {
+		return nil, fmt.Errorf("get block error: %w", err)
+	}
+	if block == nil {
+		return nil, fmt.Errorf("%w: %s", ErrBlockNotFound, blockHash)
+	}
+	extrinsicIdx := -1
+	for i, extrinsic := range block.Block.Extrinsics {
+		if strings.EqualFold(c.createTxHash(extrinsic), txHash) {
+			extrinsicIdx = i
+			break
+		}
+	}
+	if extrinsicIdx < 0 {
+		return nil, fmt.Errorf("extrinsic %s is not in block %s", txHash, blockHash)
+	}
+	eventsHex, err := c.getEventsHex(blockHash)
+	if err != nil {
+		return nil, err
+	}
+	ier, err := c.decodeEventRecords(c.runtime(), eventsHex)
+	if err != nil {
+		return nil, err
+	}
+	return c.extrinsicEventResults(ier, extrinsicIdx)
+}
+
+func (c *Client) extrinsicEventResults(ier expand.IEventRecords, extrinsicIdx int) ([]models.EventResult, error) {
+	var result []models.EventResult
+	for _, e := range expand.EventsOfExtrinsic(ier, extrinsicIdx) {
+		r := models.EventResult{
+			Module:       e.Module,
+			Event:        e.Event,
+			ExtrinsicIdx: extrinsicIdx,
+			Phase:        "ApplyExtrinsic",
+		}
+		switch data := e.Data.(type) {
+		case types.EventBalancesTransfer:
+			var err error
+			r.From, err = c.encodeAddress(hex.EncodeToString(data.From[:]))
+			if err != nil {
+				return nil, fmt.Errorf("encode address error: %v", err)
+			}
+			r.To, err = c.encodeAddress(hex.EncodeToString(data.To[:]))
+			if err != nil {
+				return nil, fmt.Errorf("encode address error: %v", err)
+			}
+			r.Amount = data.Value.String()
+		case base.EventBalancesWithdraw:
+			var err error
+			r.From, err = c.encodeAddress(hex.EncodeToString(data.Who[:]))
+			if err != nil {
+				return nil, fmt.Errorf("encode address error: %v", err)
+			}
+			r.Amount = data.Balance.String()
+		case types.EventBalancesDeposit:
+			var err error
+			r.To, err = c.encodeAddress(hex.EncodeToString(data.Who[:]))
+			if err != nil {
+				return nil, fmt.Errorf("encode address error: %v", err)
+			}
+			r.Amount = data.Balance.String()
+		case types.EventSystemExtrinsicSuccess:
+			r.Status = "success"
+			r.Weight = int64(data.DispatchInfo.Weight)
+		case base.EventSystemExtrinsicFailed:
+			r.Status = "fail"
+			r.Weight = int64(data.DispatchInfo.Weight)
+		}
+		result = append(result, r)
+	}
+	return result, nil
+}
@@ -0,0 +1,29 @@
+package client
+
+import (
+	"github.com/JFJun/bifrost-go/expand"
+)
+
+/*
+注册自定义pallet使用的类型，之后解析区块时没有硬编码的call会使用注册的类型解析参数
+definition可以是go的值（比如types.U32(0)或者自定义的struct）或者已注册类型的名字（别名），
+name为metadata中参数的类型名，T::以及<T>等修饰可以省略
+解析后的参数在SetIncludeUnparsed(true)时出现在ExtrinsicResponse.Params中
+*/
+func (c *Client) RegisterType(name string, definition interface{}) error {
+	return c.registry().Register(name, definition)
+}
+
+/*
+从json中加载类型定义，格式见expand.TypeRegistry.LoadJSON
+*/
+func (c *Client) LoadTypeDefinitions(data []byte) error {
+	return c.registry().LoadJSON(data)
+}
+
+func (c *Client) registry() *expand.TypeRegistry {
+	if c.typeRegistry == nil {
+		c.typeRegistry = expand.NewTypeRegistry()
+	}
+	return c.typeRegistry
+}
@@ -0,0 +1,90 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/JFJun/bifrost-go/expand"
+	"github.com/stafiprotocol/go-substrate-rpc-client/scale"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"math/big"
+)
+
+/*
+估算call执行时会reserve的押金，不会reserve押金的call返回0
+目前支持：
+	Identity.set_identity: BasicDeposit + FieldDeposit * additional的个数
+	Proxy.add_proxy、Proxy.anonymous、Proxy.create_pure: ProxyDepositBase + ProxyDepositFactor（按第一个代理计算）
+	Proxy.announce: AnnouncementDepositBase + AnnouncementDepositFactor
+	Multisig.as_multi、Multisig.approve_as_multi: DepositBase + DepositFactor * threshold（发起多签时才会reserve）
+*/
+func (c *Client) EstimateReserve(call types.Call) (*big.Int, error) {
+	me, err := expand.NewMetadataExpand(c.runtime().meta)
+	if err != nil {
+		return nil, fmt.Errorf("new metadata expand error: %v", err)
+	}
+	callIdx := fmt.Sprintf("%02x%02x", call.CallIndex.SectionIndex, call.CallIndex.MethodIndex)
+	module, fn, err := me.MV.FindNameByCallIndex(callIdx)
+	if err != nil {
+		return nil, fmt.Errorf("find call by index %s error: %v", callIdx, err)
+	}
+	decoder := scale.NewDecoder(bytes.NewReader(call.Args))
+	switch {
+	case module == "Identity" && fn == "set_identity":
+		// info.additional: Vec<(Data, Data)>
+		fields, err := decoder.DecodeUintCompact()
+		if err != nil {
+			return nil, fmt.Errorf("decode Identity.set_identity additional length error: %v", err)
+		}
+		return depositWithFactor(me, module, "BasicDeposit", "FieldDeposit", fields.Uint64())
+	case module == "Proxy" && (fn == "add_proxy" || fn == "anonymous" || fn == "create_pure"):
+		return depositWithFactor(me, module, "ProxyDepositBase", "ProxyDepositFactor", 1)
+	case module == "Proxy" && fn == "announce":
+		return depositWithFactor(me, module, "AnnouncementDepositBase", "AnnouncementDepositFactor", 1)
+	case module == "Multisig" && (fn == "as_multi" || fn == "approve_as_multi"):
+		var threshold types.U16
+		err = decoder.Decode(&threshold)
+		if err != nil {
+			return nil, fmt.Errorf("decode Multisig.%s threshold error: %v", fn, err)
+		}
+		return depositWithFactor(me, module, "DepositBase", "DepositFactor", uint64(threshold))
+	}
+	return big.NewInt(0), nil
+}
+
+/*
+base + factor * count
+*/
+func depositWithFactor(me *expand.MetadataExpand, module, base, factor string, count uint64) (*big.Int, error) {
+	baseValue, err := balanceConstant(me, module, base)
+	if err != nil {
+		return nil, err
+	}
+	factorValue, err := balanceConstant(me, module, factor)
+	if err != nil {
+		return nil, err
+	}
+	deposit := new(big.Int).Mul(factorValue, new(big.Int).SetUint64(count))
+	return deposit.Add(deposit, baseValue), nil
+}
+
+func balanceConstant(me *expand.MetadataExpand, module, name string) (*big.Int, error) {
+	_, value, err := me.MV.GetConstants(module, name)
+	if err != nil {
+		return nil, err
+	}
+	//Balance为u64的链常量只有8个字节
+	if len(value) == 8 {
+		var balance types.U64
+		err = types.DecodeFromBytes(value, &balance)
+		if err != nil {
+			return nil, fmt.Errorf("decode constant %s.%s error: %v", module, name, err)
+		}
+		return new(big.Int).SetUint64(uint64(balance)), nil
+	}
+	var balance types.U128
+	err = types.DecodeFromBytes(value, &balance)
+	if err != nil {
+		return nil, fmt.Errorf("decode constant %s.%s error: %v", module, name, err)
+	}
+	return balance.Int, nil
+}
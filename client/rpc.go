@@ -0,0 +1,141 @@
+package client
+
+import (
+	"context"
+	"errors"
+	gsrc "github.com/stafiprotocol/go-substrate-rpc-client"
+	gethrpc "github.com/stafiprotocol/go-substrate-rpc-client/gethrpc"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+/*
+Client依赖的rpc接口，只包含Client实际用到的方法
+默认使用gsrc.SubstrateAPI实现，测试时可以注入自己实现的mock
+*/
+type RPCCaller interface {
+	Call(result interface{}, method string, args ...interface{}) error
+	GetRuntimeVersionLatest() (*types.RuntimeVersion, error)
+	GetMetadataLatest() (*types.Metadata, error)
+	GetStorageLatest(key types.StorageKey, target interface{}) (bool, error)
+	GetBlockHash(blockNumber uint64) (types.Hash, error)
+}
+
+/*
+对gsrc.SubstrateAPI的封装
+*/
+type substrateRPC struct {
+	api *gsrc.SubstrateAPI
+}
+
+func newSubstrateRPC(api *gsrc.SubstrateAPI) *substrateRPC {
+	return &substrateRPC{api: api}
+}
+
+func (s *substrateRPC) Call(result interface{}, method string, args ...interface{}) error {
+	return wrapRPCError(s.api.Client.Call(result, method, args...))
+}
+
+func (s *substrateRPC) GetRuntimeVersionLatest() (*types.RuntimeVersion, error) {
+	v, err := s.api.RPC.State.GetRuntimeVersionLatest()
+	return v, wrapRPCError(err)
+}
+
+func (s *substrateRPC) GetMetadataLatest() (*types.Metadata, error) {
+	meta, err := s.api.RPC.State.GetMetadataLatest()
+	return meta, wrapRPCError(err)
+}
+
+func (s *substrateRPC) GetStorageLatest(key types.StorageKey, target interface{}) (bool, error) {
+	ok, err := s.api.RPC.State.GetStorageLatest(key, target)
+	return ok, wrapRPCError(err)
+}
+
+func (s *substrateRPC) GetBlockHash(blockNumber uint64) (types.Hash, error) {
+	hash, err := s.api.RPC.Chain.GetBlockHash(blockNumber)
+	return hash, wrapRPCError(err)
+}
+
+/*
+json-rpc批量请求中的一个调用，Result必须为指针，单个调用的错误写入Error
+*/
+type BatchElem struct {
+	Method string
+	Args   []interface{}
+	Result interface{}
+	Error  error
+}
+
+/*
+RPCCaller可以选择实现的批量请求接口，batch中的所有调用在一次网络往返中完成
+返回的错误为网络等整体的错误，没有实现该接口时Client会逐个请求
+*/
+type BatchCaller interface {
+	BatchCall(batch []BatchElem) error
+}
+
+var errBatchUnsupported = errors.New("batch call is not supported")
+
+func (s *substrateRPC) BatchCall(batch []BatchElem) error {
+	batcher, ok := s.api.Client.(interface {
+		BatchCall(b []gethrpc.BatchElem) error
+	})
+	if !ok {
+		return errBatchUnsupported
+	}
+	elems := make([]gethrpc.BatchElem, len(batch))
+	for i, e := range batch {
+		elems[i] = gethrpc.BatchElem{Method: e.Method, Args: e.Args, Result: e.Result}
+	}
+	err := batcher.BatchCall(elems)
+	if err != nil {
+		return wrapRPCError(err)
+	}
+	for i := range batch {
+		batch[i].Error = wrapRPCError(elems[i].Error)
+	}
+	return nil
+}
+
+/*
+订阅，与gethrpc.ClientSubscription一致，Err在订阅出错或者连接断开时返回错误
+*/
+type Subscription interface {
+	Err() <-chan error
+	Unsubscribe()
+}
+
+/*
+RPCCaller可以选择实现的订阅接口（需要websocket），节点推送的通知写入channel
+没有实现该接口时订阅返回ErrSubscriptionUnsupported
+*/
+type Subscriber interface {
+	Subscribe(ctx context.Context, namespace, subscribeMethod, unsubscribeMethod, notificationMethod string,
+		channel interface{}, args ...interface{}) (Subscription, error)
+}
+
+func (s *substrateRPC) Subscribe(ctx context.Context, namespace, subscribeMethod, unsubscribeMethod,
+	notificationMethod string, channel interface{}, args ...interface{}) (Subscription, error) {
+	sub, err := s.api.Client.Subscribe(ctx, namespace, subscribeMethod, unsubscribeMethod, notificationMethod,
+		channel, args...)
+	if err != nil {
+		return nil, wrapRPCError(err)
+	}
+	return sub, nil
+}
+
+/*
+RPCCaller可以选择实现的重连接口，连接断开后Client通过Reconnect创建新的连接
+旧的连接实现了Close时会被关闭；没有实现该接口时Client重新连接New中的websocket地址
+*/
+type Reconnector interface {
+	Reconnect() (RPCCaller, error)
+}
+
+/*
+关闭websocket连接，重连后旧的连接不再使用
+*/
+func (s *substrateRPC) Close() {
+	if closer, ok := s.api.Client.(interface{ Close() }); ok {
+		closer.Close()
+	}
+}
@@ -0,0 +1,69 @@
+package client
+
+import (
+	"fmt"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+/*
+解析一个区块时使用的runtime信息，在开始解析时获取，解析过程中不受runtime升级（checkRuntimeVersion替换metadata）的影响
+*/
+type runtimeSnapshot struct {
+	meta        *types.Metadata
+	specVersion int
+	chainName   string
+	//正在解析的区块hash，链上状态（比如Indices.Accounts）按这个区块查询，为空时查询最新的状态
+	blockHash string
+}
+
+/*
+返回绑定到指定区块的快照
+*/
+func (rt runtimeSnapshot) atBlock(blockHash string) runtimeSnapshot {
+	rt.blockHash = blockHash
+	return rt
+}
+
+/*
+获取当前metadata、spec version以及链名字的一致的快照
+*/
+func (c *Client) runtime() runtimeSnapshot {
+	c.runtimeMu.RLock()
+	defer c.runtimeMu.RUnlock()
+	return runtimeSnapshot{meta: c.Meta, specVersion: c.SpecVersion, chainName: c.ChainName}
+}
+
+/*
+解析区块时使用的runtime快照：按区块的spec version选择metadata
+与当前的runtime相同时直接使用当前的快照，否则通过state_getMetadata获取该区块的metadata，并按spec version缓存
+*/
+func (c *Client) blockRuntime(blockHash string) (runtimeSnapshot, error) {
+	rt := c.runtime()
+	var version types.RuntimeVersion
+	err := c.caller().Call(&version, "state_getRuntimeVersion", blockHash)
+	if err != nil {
+		return rt, fmt.Errorf("get runtime version of block %s error: %w", blockHash, err)
+	}
+	specVersion := int(version.SpecVersion)
+	if specVersion == rt.specVersion {
+		return rt, nil
+	}
+	if meta, ok := c.blockMetas.Load(specVersion); ok {
+		return runtimeSnapshot{meta: meta.(*types.Metadata), specVersion: specVersion, chainName: version.SpecName}, nil
+	}
+	var metaHex string
+	err = c.caller().Call(&metaHex, "state_getMetadata", blockHash)
+	if err != nil {
+		return rt, fmt.Errorf("%w: get metadata of block %s error: %v", ErrMetadataUnavailable, blockHash, err)
+	}
+	meta, err := DecodeMetadataHex(metaHex)
+	if err != nil {
+		return rt, err
+	}
+	err = checkMetadata(meta)
+	if err != nil {
+		return rt, err
+	}
+	c.blockMetas.Store(specVersion, meta)
+	return runtimeSnapshot{meta: meta, specVersion: specVersion, chainName: version.SpecName}, nil
+}
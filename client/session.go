@@ -0,0 +1,100 @@
+package client
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/JFJun/bifrost-go/utils"
+	"strings"
+)
+
+/*
+Session.NextKeys中的一个key，KeyType为4个字节的KeyTypeId，比如gran、babe、imon
+*/
+type SessionKey struct {
+	KeyType   string
+	PublicKey string //公钥的hex，不带0x
+}
+
+/*
+各个链的SessionKeys中key的顺序，与runtime中impl_opaque_keys!定义的字段顺序一致
+v13及之前的metadata中没有SessionKeys的定义，所以只能按链名硬编码
+*/
+var sessionKeyTypes = map[string][]string{
+	//grandpa、babe、im_online、para_validator、para_assignment、authority_discovery
+	"polkadot": {"gran", "babe", "imon", "para", "asgn", "audi"},
+	"kusama":   {"gran", "babe", "imon", "para", "asgn", "audi"},
+}
+
+/*
+平行链的collator只有aura
+*/
+var defaultSessionKeyTypes = []string{"aura"}
+
+/*
+获取验证人在Session.NextKeys中注册的session key，返回所有key拼接在一起的原始数据（与author_rotateKeys的返回值一致）
+验证人没有设置session key时返回nil
+*/
+func (c *Client) GetSessionKeys(validator string) ([]byte, error) {
+	err := c.autoCheckRuntime()
+	if err != nil {
+		return nil, err
+	}
+	pub := utils.AccountToPublicKey(validator)
+	if pub == "" {
+		return nil, fmt.Errorf("invalid validator account: %s", validator)
+	}
+	pubBytes, _ := hex.DecodeString(pub)
+	key, err := c.BuildStorageKey("Session", "NextKeys", pubBytes)
+	if err != nil {
+		return nil, err
+	}
+	var result string
+	err = c.caller().Call(&result, "state_getStorage", key.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("get Session.NextKeys error: %w", err)
+	}
+	if result == "" {
+		return nil, nil
+	}
+	keys, err := hex.DecodeString(utils.RemoveHex0x(result))
+	if err != nil {
+		return nil, fmt.Errorf("%w: decode Session.NextKeys error: %v", ErrDecodeFailed, err)
+	}
+	return keys, nil
+}
+
+/*
+当前链的SessionKeys中key的顺序，用于SplitSessionKeys
+*/
+func (c *Client) SessionKeyTypes() []string {
+	if keyTypes, ok := sessionKeyTypes[strings.ToLower(c.runtime().chainName)]; ok {
+		return keyTypes
+	}
+	return defaultSessionKeyTypes
+}
+
+/*
+将GetSessionKeys返回的数据按keyTypes的顺序拆分为每一个共识引擎的key
+ecdsa的key（beef）为33个字节，其它的key都是32个字节
+*/
+func SplitSessionKeys(keys []byte, keyTypes []string) ([]SessionKey, error) {
+	var result []SessionKey
+	for _, keyType := range keyTypes {
+		if len(keyType) != 4 {
+			return nil, fmt.Errorf("invalid key type id: %s", keyType)
+		}
+		size := 32
+		if keyType == "beef" {
+			size = 33
+		}
+		if len(keys) < size {
+			return nil, fmt.Errorf("session keys are too short for key type %s", keyType)
+		}
+		result = append(result, SessionKey{KeyType: keyType, PublicKey: hex.EncodeToString(keys[:size])})
+		keys = keys[size:]
+	}
+	if len(keys) != 0 {
+		return nil, fmt.Errorf("session keys have %d unexpected bytes left", len(keys))
+	}
+	return result, nil
+}
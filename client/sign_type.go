@@ -0,0 +1,33 @@
+package client
+
+import (
+	"github.com/JFJun/go-substrate-crypto/crypto"
+	"strings"
+)
+
+//账户为20字节的以太坊地址，使用ecdsa签名的链
+var ecdsaChains = []string{"moonbeam", "moonriver", "moonbase"}
+
+/*
+根据链推断默认的签名类型：注册表中standardAccount为secp256k1或者已知的EVM链使用ecdsa，其他使用sr25519
+*/
+func (c *Client) DefaultSignType() int {
+	chainName := strings.ToLower(c.runtime().chainName)
+	for _, name := range ecdsaChains {
+		if strings.HasPrefix(chainName, name) {
+			return crypto.EcdsaType
+		}
+	}
+	if c.BasicType != nil {
+		for _, reg := range c.BasicType.Registry {
+			if strings.ToLower(reg.Network) != chainName {
+				continue
+			}
+			if strings.ToLower(reg.StandardAccount) == "secp256k1" {
+				return crypto.EcdsaType
+			}
+			break
+		}
+	}
+	return crypto.Sr25519Type
+}
@@ -0,0 +1,90 @@
+package client
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"github.com/JFJun/bifrost-go/expand"
+	"github.com/JFJun/bifrost-go/models"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+/*
+获取指定区块中验证人被惩罚的event，包括Staking.Slash、Offences.Offence以及ImOnline.SomeOffline
+*/
+func (c *Client) GetSlashEvents(blockHash string) ([]models.SlashEvent, error) {
+	if !isBlockHash(blockHash) {
+		return nil, fmt.Errorf("expected block hash, got %q", blockHash)
+	}
+	eventsHex, err := c.getEventsHex(blockHash)
+	if err != nil {
+		return nil, err
+	}
+	ier, err := c.decodeEventRecords(c.runtime(), eventsHex)
+	if err != nil {
+		return nil, err
+	}
+	return c.slashEvents(ier)
+}
+
+func (c *Client) slashEvents(ier expand.IEventRecords) ([]models.SlashEvent, error) {
+	var result []models.SlashEvent
+	for _, e := range ier.GetStakingSlash() {
+		account, err := c.encodeAddress(hex.EncodeToString(e.AccountID[:]))
+		if err != nil {
+			return nil, fmt.Errorf("encode address error: %v", err)
+		}
+		result = append(result, models.SlashEvent{
+			Type:         "slash",
+			Account:      account,
+			Amount:       e.Balance.String(),
+			ExtrinsicIdx: phaseExtrinsicIdx(e.Phase),
+			Phase:        phaseName(e.Phase),
+		})
+	}
+	for _, e := range ier.GetOffencesOffence() {
+		result = append(result, models.SlashEvent{
+			Type:         "offence",
+			Kind:         string(bytes.TrimRight(e.Kind[:], "\x00")),
+			TimeSlot:     types.HexEncodeToString(e.OpaqueTimeSlot),
+			ExtrinsicIdx: phaseExtrinsicIdx(e.Phase),
+			Phase:        phaseName(e.Phase),
+		})
+	}
+	for _, e := range ier.GetImOnlineSomeOffline() {
+		for _, tuple := range e.IdentificationTuples {
+			account, err := c.encodeAddress(hex.EncodeToString(tuple.ValidatorID[:]))
+			if err != nil {
+				return nil, fmt.Errorf("encode address error: %v", err)
+			}
+			result = append(result, models.SlashEvent{
+				Type:         "offline",
+				Account:      account,
+				ExtrinsicIdx: phaseExtrinsicIdx(e.Phase),
+				Phase:        phaseName(e.Phase),
+			})
+		}
+	}
+	return result, nil
+}
+
+/*
+event产生的阶段：ApplyExtrinsic、Initialization或者Finalization
+types.Phase解码时遇到Initialization不会设置任何标志位，所以两者都为false即为Initialization
+*/
+func phaseName(phase types.Phase) string {
+	switch {
+	case phase.IsApplyExtrinsic:
+		return "ApplyExtrinsic"
+	case phase.IsFinalization:
+		return "Finalization"
+	}
+	return "Initialization"
+}
+
+func phaseExtrinsicIdx(phase types.Phase) int {
+	if !phase.IsApplyExtrinsic {
+		return -1
+	}
+	return int(phase.AsApplyExtrinsic)
+}
@@ -0,0 +1,150 @@
+package client
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/stafiprotocol/go-substrate-rpc-client/scale"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"math/big"
+)
+
+const defaultSnapshotPageSize = 1000
+
+/*
+获取指定区块所有账户的free余额，key为地址
+账户较多时会占用大量内存，可以使用SnapshotBalancesFunc逐个处理
+*/
+func (c *Client) SnapshotBalances(blockHash string, pageSize int) (map[string]*big.Int, error) {
+	balances := make(map[string]*big.Int)
+	err := c.SnapshotBalancesFunc(blockHash, pageSize, func(address string, free *big.Int) error {
+		balances[address] = free
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return balances, nil
+}
+
+/*
+通过state_getKeysPaged分页遍历指定区块的System.Account，每解析出一个账户就调用一次fn
+fn返回错误时停止遍历
+*/
+func (c *Client) SnapshotBalancesFunc(blockHash string, pageSize int, fn func(address string, free *big.Int) error) error {
+	if fn == nil {
+		return errors.New("snapshot callback is nil")
+	}
+	if !isBlockHash(blockHash) {
+		return fmt.Errorf("expected block hash, got %q", blockHash)
+	}
+	if pageSize <= 0 {
+		pageSize = defaultSnapshotPageSize
+	}
+	prefix, err := buildStorageKey("System", "Account", nil)
+	if err != nil {
+		return err
+	}
+	prefixHex := prefix.Hex()
+	startKey := prefixHex
+	for {
+		var keys []string
+		err = c.caller().Call(&keys, "state_getKeysPaged", prefixHex, pageSize, startKey, blockHash)
+		if err != nil {
+			return fmt.Errorf("get System.Account keys error: %w", err)
+		}
+		if len(keys) == 0 {
+			return nil
+		}
+		var changeSets []types.StorageChangeSet
+		err = c.caller().Call(&changeSets, "state_queryStorageAt", keys, blockHash)
+		if err != nil {
+			return fmt.Errorf("query System.Account storage error: %w", err)
+		}
+		for _, changeSet := range changeSets {
+			for _, change := range changeSet.Changes {
+				if !change.HasStorageData || len(change.StorageKey) < 32 {
+					continue
+				}
+				//Blake2_128Concat的key最后32字节即为AccountId
+				pub := change.StorageKey[len(change.StorageKey)-32:]
+				address, err := c.encodeAddress(hex.EncodeToString(pub))
+				if err != nil {
+					return fmt.Errorf("encode address error: %v", err)
+				}
+				accountInfo, err := c.decodeAccountInfo(change.StorageData)
+				if err != nil {
+					return fmt.Errorf("%w: decode account info of %s error: %v", ErrDecodeFailed, address, err)
+				}
+				err = fn(address, accountInfo.Data.Free.Int)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		if len(keys) < pageSize {
+			return nil
+		}
+		startKey = keys[len(keys)-1]
+	}
+}
+
+/*
+解码System.Account的数据，根据数据的长度判断AccountInfo的结构，因此也适用于历史区块：
+nonce(u32) + refcount + AccountData(4个Balance)，refcount按runtime的版本依次为：
+u8（最早的版本）、u32、consumers+providers、consumers+providers+sufficients
+*/
+func (c *Client) decodeAccountInfo(data []byte) (*types.AccountInfo, error) {
+	width := c.BalanceWidth()
+	decoder := scale.NewDecoder(bytes.NewReader(data))
+	var accountInfo types.AccountInfo
+	err := decoder.Decode(&accountInfo.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	//指定了格式时数据的长度必须与格式一致，否则按长度判断（历史区块可能是升级之前的格式）
+	layout := c.accountInfoLayout()
+	refcountLen := len(data) - 4 - 4*width
+	switch {
+	case layout == AccountInfoLegacy && refcountLen != 1 && refcountLen != 4,
+		layout == AccountInfoWithProviders && refcountLen != 8 && refcountLen != 12:
+		return nil, fmt.Errorf("AccountInfo is %d bytes with %d bytes balance, not %s layout", len(data), width, layout)
+	}
+	switch refcountLen {
+	case 1:
+		var refcount types.U8
+		err = decoder.Decode(&refcount)
+		accountInfo.Refcount = types.U32(refcount)
+	case 4:
+		err = decoder.Decode(&accountInfo.Refcount)
+	case 8, 12:
+		//consumers, providers[, sufficients]，Refcount记录consumers
+		var refcounts []types.U32
+		for i := 0; i < (len(data)-4-4*width)/4 && err == nil; i++ {
+			var refcount types.U32
+			err = decoder.Decode(&refcount)
+			refcounts = append(refcounts, refcount)
+		}
+		accountInfo.Refcount = refcounts[0]
+	default:
+		return nil, fmt.Errorf("unknown AccountInfo layout: %d bytes with %d bytes balance", len(data), width)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, balance := range []*types.U128{&accountInfo.Data.Free, &accountInfo.Data.Reserved,
+		&accountInfo.Data.MiscFrozen, &accountInfo.Data.FreeFrozen} {
+		if width == 8 {
+			var v types.U64
+			err = decoder.Decode(&v)
+			*balance = u64ToU128(v)
+		} else {
+			err = decoder.Decode(balance)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &accountInfo, nil
+}
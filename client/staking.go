@@ -0,0 +1,296 @@
+package client
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/JFJun/bifrost-go/expand"
+	"github.com/JFJun/bifrost-go/models"
+	"github.com/JFJun/bifrost-go/utils"
+	"github.com/JFJun/go-substrate-crypto/ss58"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"math/big"
+	"time"
+)
+
+/*
+获取当前的active era，startMillis为era开始的时间戳（毫秒），未知时为0
+*/
+func (c *Client) GetActiveEra() (index uint32, startMillis uint64, err error) {
+	err = c.autoCheckRuntime()
+	if err != nil {
+		return 0, 0, err
+	}
+	storage, err := types.CreateStorageKey(c.runtime().meta, "Staking", "ActiveEra", nil, nil)
+	if err != nil {
+		return 0, 0, fmt.Errorf("create Staking.ActiveEra storage key error: %v", err)
+	}
+	var activeEra expand.ActiveEraInfo
+	ok, err := c.caller().GetStorageLatest(storage, &activeEra)
+	if err != nil {
+		return 0, 0, fmt.Errorf("get Staking.ActiveEra error: %w", err)
+	}
+	if !ok {
+		return 0, 0, errors.New("Staking.ActiveEra is not set")
+	}
+	if hasStart, start := activeEra.Start.Unwrap(); hasStart {
+		startMillis = uint64(start)
+	}
+	return uint32(activeEra.Index), startMillis, nil
+}
+
+/*
+获取当前的session index
+*/
+func (c *Client) GetCurrentSession() (uint32, error) {
+	err := c.autoCheckRuntime()
+	if err != nil {
+		return 0, err
+	}
+	storage, err := types.CreateStorageKey(c.runtime().meta, "Session", "CurrentIndex", nil, nil)
+	if err != nil {
+		return 0, fmt.Errorf("create Session.CurrentIndex storage key error: %v", err)
+	}
+	var index types.U32
+	_, err = c.caller().GetStorageLatest(storage, &index)
+	if err != nil {
+		return 0, fmt.Errorf("get Session.CurrentIndex error: %w", err)
+	}
+	return uint32(index), nil
+}
+
+/*
+估算当前era结束（下一个era开始）还需要的区块数以及大概的时间，用于安排payout_stakers
+era开始的session为Staking.ErasStartSessionIndex(active era)，每个era有SessionsPerEra个session，
+每个session（BABE的epoch）有EpochDuration个slot，当前session已经过去的slot根据Babe.CurrentSlot计算
+一个slot按一个区块估算，区块时间使用Babe.ExpectedBlockTime，没有时使用Timestamp.MinimumPeriod的两倍
+*/
+func (c *Client) TimeUntilNextEra() (blocks uint64, approxDuration time.Duration, err error) {
+	activeEra, _, err := c.GetActiveEra()
+	if err != nil {
+		return 0, 0, err
+	}
+	currentSession, err := c.GetCurrentSession()
+	if err != nil {
+		return 0, 0, err
+	}
+	eraArg, err := types.EncodeToBytes(types.NewU32(activeEra))
+	if err != nil {
+		return 0, 0, err
+	}
+	var eraStartSession types.U32
+	err = c.getStorageValue(&eraStartSession, "Staking", "ErasStartSessionIndex", eraArg)
+	if err != nil {
+		return 0, 0, err
+	}
+	me, err := expand.NewMetadataExpand(c.runtime().meta)
+	if err != nil {
+		return 0, 0, err
+	}
+	var sessionsPerEra types.U32
+	err = decodeConstant(me, "Staking", "SessionsPerEra", &sessionsPerEra)
+	if err != nil {
+		return 0, 0, err
+	}
+	var epochDuration types.U64
+	err = decodeConstant(me, "Babe", "EpochDuration", &epochDuration)
+	if err != nil {
+		return 0, 0, err
+	}
+	var genesisSlot, currentSlot, epochIndex types.U64
+	err = c.getStorageValue(&genesisSlot, "Babe", "GenesisSlot")
+	if err != nil {
+		return 0, 0, err
+	}
+	err = c.getStorageValue(&currentSlot, "Babe", "CurrentSlot")
+	if err != nil {
+		return 0, 0, err
+	}
+	err = c.getStorageValue(&epochIndex, "Babe", "EpochIndex")
+	if err != nil {
+		return 0, 0, err
+	}
+	//当前epoch剩余的slot
+	epochStart := uint64(genesisSlot) + uint64(epochIndex)*uint64(epochDuration)
+	if uint64(currentSlot) >= epochStart && uint64(currentSlot)-epochStart < uint64(epochDuration) {
+		blocks = uint64(epochDuration) - (uint64(currentSlot) - epochStart)
+	}
+	//当前session之后era中还剩下的session
+	elapsed := uint64(currentSession) - uint64(eraStartSession) + 1
+	if uint64(currentSession) >= uint64(eraStartSession) && elapsed < uint64(sessionsPerEra) {
+		blocks += (uint64(sessionsPerEra) - elapsed) * uint64(epochDuration)
+	}
+	blockTime, err := expectedBlockTime(me)
+	if err != nil {
+		return 0, 0, err
+	}
+	return blocks, time.Duration(blocks) * blockTime, nil
+}
+
+/*
+读取最新的storage，不存在时返回错误
+*/
+func (c *Client) getStorageValue(target interface{}, module, method string, args ...[]byte) error {
+	key, err := c.BuildStorageKey(module, method, args...)
+	if err != nil {
+		return err
+	}
+	ok, err := c.caller().GetStorageLatest(key, target)
+	if err != nil {
+		return fmt.Errorf("get %s.%s error: %w", module, method, err)
+	}
+	if !ok {
+		return fmt.Errorf("%s.%s is not set", module, method)
+	}
+	return nil
+}
+
+func decodeConstant(me *expand.MetadataExpand, module, name string, target interface{}) error {
+	_, value, err := me.MV.GetConstants(module, name)
+	if err != nil {
+		return err
+	}
+	err = types.DecodeFromBytes(value, target)
+	if err != nil {
+		return fmt.Errorf("decode constant %s.%s error: %v", module, name, err)
+	}
+	return nil
+}
+
+func expectedBlockTime(me *expand.MetadataExpand) (time.Duration, error) {
+	var millis types.U64
+	err := decodeConstant(me, "Babe", "ExpectedBlockTime", &millis)
+	if err == nil {
+		return time.Duration(millis) * time.Millisecond, nil
+	}
+	err = decodeConstant(me, "Timestamp", "MinimumPeriod", &millis)
+	if err != nil {
+		return 0, fmt.Errorf("can not get block time: %v", err)
+	}
+	return 2 * time.Duration(millis) * time.Millisecond, nil
+}
+
+/*
+记录extrinsic中Staking.Unbonded或者Staking.Withdrawn的金额，同一个extrinsic中有多个时金额相加
+*/
+func (c *Client) addStakingAmount(amounts map[int]models.EventResult, event string, phase types.Phase,
+	stash types.AccountID, amount types.U128) {
+	if !phase.IsApplyExtrinsic {
+		return
+	}
+	idx := int(phase.AsApplyExtrinsic)
+	r, ok := amounts[idx]
+	if !ok {
+		r = models.EventResult{Module: "Staking", Event: event, Phase: phaseName(phase), ExtrinsicIdx: idx, Amount: "0"}
+		r.To, _ = c.encodeAddress(hex.EncodeToString(stash[:]))
+	}
+	total, _ := new(big.Int).SetString(r.Amount, 10)
+	r.Amount = total.Add(total, amount.Int).String()
+	amounts[idx] = r
+}
+
+/*
+Staking.Ledger中的解绑计划，Value在Era之后可以通过withdraw_unbonded提取
+*/
+type UnlockChunk struct {
+	Value string `json:"value"`
+	Era   uint32 `json:"era"`
+}
+
+/*
+Staking.Ledger的前几个字段，之后的字段（claimed_rewards等）因runtime版本而不同，这里不解析
+*/
+type stakingLedgerPrefix struct {
+	Stash     types.AccountID
+	Total     types.UCompact
+	Active    types.UCompact
+	Unlocking []struct {
+		Value types.UCompact
+		Era   types.UCompact
+	}
+}
+
+/*
+获取controller账户正在解绑的金额以及可以提取的era，与GetActiveEra比较即可知道何时可以withdraw_unbonded
+*/
+func (c *Client) GetUnlockChunks(controller string) ([]UnlockChunk, error) {
+	err := c.autoCheckRuntime()
+	if err != nil {
+		return nil, err
+	}
+	pub, err := ss58.DecodeToPub(controller)
+	if err != nil {
+		return nil, fmt.Errorf("ss58 decode address error: %v", err)
+	}
+	key, err := c.BuildStorageKey("Staking", "Ledger", pub)
+	if err != nil {
+		return nil, err
+	}
+	var ledger stakingLedgerPrefix
+	ok, err := c.caller().GetStorageLatest(key, &ledger)
+	if err != nil {
+		return nil, fmt.Errorf("get Staking.Ledger error: %w", err)
+	}
+	if !ok {
+		//没有bond或者已经全部提取
+		return nil, nil
+	}
+	chunks := make([]UnlockChunk, len(ledger.Unlocking))
+	for i, chunk := range ledger.Unlocking {
+		chunks[i] = UnlockChunk{
+			Value: utils.UCompactToBigInt(chunk.Value).String(),
+			Era:   uint32(utils.UCompactToBigInt(chunk.Era).Uint64()),
+		}
+	}
+	return chunks, nil
+}
+
+/*
+获取全网的总发行量（Balances.TotalIssuance），最小单位
+*/
+func (c *Client) GetTotalIssuance() (*big.Int, error) {
+	err := c.autoCheckRuntime()
+	if err != nil {
+		return nil, err
+	}
+	key, err := c.BuildStorageKey("Balances", "TotalIssuance")
+	if err != nil {
+		return nil, err
+	}
+	var issuance types.U128
+	ok, err := c.caller().GetStorageLatest(key, &issuance)
+	if err != nil {
+		return nil, fmt.Errorf("get Balances.TotalIssuance error: %w", err)
+	}
+	if !ok || issuance.Int == nil {
+		return big.NewInt(0), nil
+	}
+	return issuance.Int, nil
+}
+
+/*
+获取指定era的总质押量（Staking.ErasTotalStake），最小单位，只保留最近HistoryDepth个era，之前的era返回0
+*/
+func (c *Client) GetTotalStaked(era uint32) (*big.Int, error) {
+	err := c.autoCheckRuntime()
+	if err != nil {
+		return nil, err
+	}
+	eraArg, err := types.EncodeToBytes(types.NewU32(era))
+	if err != nil {
+		return nil, err
+	}
+	key, err := c.BuildStorageKey("Staking", "ErasTotalStake", eraArg)
+	if err != nil {
+		return nil, err
+	}
+	var staked types.U128
+	ok, err := c.caller().GetStorageLatest(key, &staked)
+	if err != nil {
+		return nil, fmt.Errorf("get Staking.ErasTotalStake error: %w", err)
+	}
+	if !ok || staked.Int == nil {
+		return big.NewInt(0), nil
+	}
+	return staked.Int, nil
+}
@@ -0,0 +1,104 @@
+package client
+
+import (
+	"fmt"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"github.com/stafiprotocol/go-substrate-rpc-client/xxhash"
+	"hash"
+)
+
+/*
+根据metadata中声明的hasher创建storage key，支持plain、map、double map以及V13的NMap
+args为每一个key SCALE编码后的数据，个数必须与storage的key个数一致
+*/
+func (c *Client) BuildStorageKey(module, method string, args ...[]byte) (types.StorageKey, error) {
+	return buildStorageKeyByMeta(c.runtime().meta, module, method, args...)
+}
+
+/*
+使用指定的metadata创建storage key，解析区块时使用区块对应的runtime快照中的metadata
+*/
+func buildStorageKeyByMeta(meta *types.Metadata, module, method string, args ...[]byte) (types.StorageKey, error) {
+	if meta == nil {
+		return nil, fmt.Errorf("%w: metadata is nil", ErrMetadataUnavailable)
+	}
+	entry, err := meta.FindStorageEntryMetadata(module, method)
+	if err != nil {
+		return nil, fmt.Errorf("find storage %s.%s error: %v", module, method, err)
+	}
+	return BuildStorageKeyFromEntry(module, method, entry, args...)
+}
+
+/*
+V13的metadata中NMap的storage entry，每一个key都有自己的hasher
+*/
+type nMapStorageEntry interface {
+	IsNMap() bool
+	Hashers() ([]hash.Hash, error)
+}
+
+/*
+使用已经获取到的storage entry中声明的hasher创建storage key
+*/
+func BuildStorageKeyFromEntry(module, method string, entry types.StorageEntryMetadata, args ...[]byte) (types.StorageKey, error) {
+	var hashers []hash.Hash
+	if nMap, ok := entry.(nMapStorageEntry); ok && nMap.IsNMap() {
+		hs, err := nMap.Hashers()
+		if err != nil {
+			return nil, fmt.Errorf("storage %s.%s hashers error: %v", module, method, err)
+		}
+		return buildStorageKey(module, method, hs, args...)
+	}
+	switch {
+	case entry.IsPlain():
+	case entry.IsMap():
+		h, err := entry.Hasher()
+		if err != nil {
+			return nil, err
+		}
+		hashers = append(hashers, h)
+	case entry.IsDoubleMap():
+		h1, err := entry.Hasher()
+		if err != nil {
+			return nil, err
+		}
+		h2, err := entry.Hasher2()
+		if err != nil {
+			return nil, err
+		}
+		hashers = append(hashers, h1, h2)
+	default:
+		return nil, fmt.Errorf("storage %s.%s type is not support,use BuildStorageKeyWithHashers instead", module, method)
+	}
+	return buildStorageKey(module, method, hashers, args...)
+}
+
+/*
+使用指定的hasher创建storage key，用于metadata中无法直接获取hasher的storage
+*/
+func BuildStorageKeyWithHashers(module, method string, hashers []types.StorageHasherV10, args ...[]byte) (types.StorageKey, error) {
+	var hs []hash.Hash
+	for _, hasher := range hashers {
+		h, err := hasher.HashFunc()
+		if err != nil {
+			return nil, err
+		}
+		hs = append(hs, h)
+	}
+	return buildStorageKey(module, method, hs, args...)
+}
+
+func buildStorageKey(module, method string, hashers []hash.Hash, args ...[]byte) (types.StorageKey, error) {
+	if len(args) != len(hashers) {
+		return nil, fmt.Errorf("storage %s.%s requires %d keys,but got %d", module, method, len(hashers), len(args))
+	}
+	key := append(xxhash.New128([]byte(module)).Sum(nil), xxhash.New128([]byte(method)).Sum(nil)...)
+	for i, arg := range args {
+		_, err := hashers[i].Write(arg)
+		if err != nil {
+			return nil, fmt.Errorf("hash storage key error: %v", err)
+		}
+		key = append(key, hashers[i].Sum(nil)...)
+	}
+	return key, nil
+}
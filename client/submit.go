@@ -0,0 +1,244 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/JFJun/bifrost-go/expand"
+	"github.com/JFJun/bifrost-go/models"
+	"github.com/stafiprotocol/go-substrate-rpc-client/config"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"strings"
+)
+
+/*
+author_submitAndWatchExtrinsic推送的交易状态
+Status: Future、Ready、Broadcast、InBlock、Retracted、FinalityTimeout、Finalized、Usurped、Dropped、Invalid，
+订阅出错时为Error并且Err不为空
+*/
+type TxStatus struct {
+	Status    string
+	BlockHash string   //InBlock、Retracted、FinalityTimeout以及Finalized时所在的区块
+	Usurper   string   //Usurped时替代这笔交易的交易hash
+	Peers     []string //Broadcast时广播到的节点
+	Err       error
+}
+
+/*
+交易是否已经到了最终状态，之后节点不会再推送新的状态
+*/
+func (s TxStatus) IsFinal() bool {
+	switch s.Status {
+	case "Finalized", "FinalityTimeout", "Usurped", "Dropped", "Invalid", "Error":
+		return true
+	}
+	return false
+}
+
+/*
+提交已签名的交易，并将节点推送的每一个状态依次写入返回的chan
+交易到达最终状态（Finalized、FinalityTimeout、Usurped、Dropped、Invalid）或者订阅出错后chan会被关闭，
+调用者可以根据Retracted、Usurped重新提交，或者在Dropped时提高tip
+ctx结束时取消订阅并关闭chan，调用者不再读取chan时应结束ctx，避免goroutine阻塞
+*/
+func (c *Client) SubmitAndTrack(ctx context.Context, signedHex string) (<-chan TxStatus, error) {
+	updates, sub, err := c.watchExtrinsic(ctx, signedHex)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make(chan TxStatus, 16)
+	go func() {
+		defer close(statuses)
+		defer sub.Unsubscribe()
+		send := func(status TxStatus) bool {
+			select {
+			case statuses <- status:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		for {
+			select {
+			case update := <-updates:
+				status := newTxStatus(update)
+				if !send(status) || status.IsFinal() {
+					return
+				}
+			case err := <-sub.Err():
+				if err != nil {
+					send(TxStatus{Status: "Error", Err: wrapRPCError(err)})
+				}
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return statuses, nil
+}
+
+/*
+SubmitAndWatchExtrinsic的选项
+*/
+type SubmitOption func(*submitConfig)
+
+type submitConfig struct {
+	waitFinalized bool
+}
+
+/*
+等待交易所在的区块finalized后再返回，默认在交易进入区块（InBlock）后返回
+*/
+func WaitFinalized() SubmitOption {
+	return func(cfg *submitConfig) {
+		cfg.waitFinalized = true
+	}
+}
+
+/*
+提交已签名的交易（author_submitExtrinsic），返回交易hash
+*/
+func (c *Client) SubmitExtrinsic(signedHex string) (string, error) {
+	if !strings.HasPrefix(signedHex, "0x") {
+		signedHex = "0x" + signedHex
+	}
+	err := c.caller().Call(nil, "author_submitExtrinsic", signedHex)
+	if err != nil {
+		return "", fmt.Errorf("submit extrinsic error: %w", err)
+	}
+	return c.createTxHash(signedHex), nil
+}
+
+/*
+提交已签名的交易并等待交易进入区块（使用WaitFinalized时等待finalized），返回交易所在的区块
+Dropped、Invalid、Usurped以及FinalityTimeout返回ErrExtrinsicRejected；Retracted后会继续等待交易重新进入区块
+ctx结束时取消订阅并返回ctx.Err()
+*/
+func (c *Client) SubmitAndWatchExtrinsic(ctx context.Context, signedHex string, opts ...SubmitOption) (*models.ExtrinsicStatus, error) {
+	var cfg submitConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	updates, sub, err := c.watchExtrinsic(ctx, signedHex)
+	if err != nil {
+		return nil, err
+	}
+	defer sub.Unsubscribe()
+	txHash := c.createTxHash(signedHex)
+	for {
+		select {
+		case update := <-updates:
+			status := newTxStatus(update)
+			switch status.Status {
+			case "InBlock":
+				if cfg.waitFinalized {
+					continue
+				}
+			case "Finalized":
+			case "FinalityTimeout", "Usurped", "Dropped", "Invalid":
+				return nil, fmt.Errorf("%w: extrinsic %s is %s", ErrExtrinsicRejected, txHash, status.Status)
+			default:
+				continue
+			}
+			return &models.ExtrinsicStatus{TxHash: txHash, Status: status.Status, BlockHash: status.BlockHash}, nil
+		case err := <-sub.Err():
+			if err == nil {
+				err = errors.New("subscription closed")
+			}
+			return nil, fmt.Errorf("watch extrinsic %s error: %w", txHash, wrapRPCError(err))
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+/*
+author_submitAndWatchExtrinsic的订阅，节点推送的状态写入返回的chan
+*/
+func (c *Client) watchExtrinsic(ctx context.Context, signedHex string) (chan types.ExtrinsicStatus, Subscription, error) {
+	subscriber, err := c.subscriber()
+	if err != nil {
+		return nil, nil, err
+	}
+	if !strings.HasPrefix(signedHex, "0x") {
+		signedHex = "0x" + signedHex
+	}
+	subCtx, cancel := context.WithTimeout(ctx, config.Default().SubscribeTimeout)
+	defer cancel()
+	updates := make(chan types.ExtrinsicStatus)
+	sub, err := subscriber.Subscribe(subCtx, "author", "submitAndWatchExtrinsic", "unwatchExtrinsic",
+		"extrinsicUpdate", updates, signedHex)
+	if err != nil {
+		return nil, nil, fmt.Errorf("submit and watch extrinsic error: %w", wrapRPCError(err))
+	}
+	return updates, sub, nil
+}
+
+/*
+http的节点以及没有实现Subscriber的RPCCaller不支持订阅
+*/
+func (c *Client) subscriber() (Subscriber, error) {
+	if c.IsHTTP() {
+		return nil, ErrSubscriptionUnsupported
+	}
+	subscriber, ok := c.caller().(Subscriber)
+	if !ok {
+		return nil, ErrSubscriptionUnsupported
+	}
+	return subscriber, nil
+}
+
+/*
+提交未签名的extrinsic（tx.NewUnsignedExtrinsic），返回交易hash
+交易是否合法由模块的ValidateUnsigned决定，节点拒绝时返回错误
+*/
+func (c *Client) SubmitUnsigned(unsignedHex string) (string, error) {
+	if !strings.HasPrefix(unsignedHex, "0x") {
+		unsignedHex = "0x" + unsignedHex
+	}
+	var ext expand.Extrinsic
+	err := types.DecodeFromHexString(unsignedHex, &ext)
+	if err != nil {
+		return "", fmt.Errorf("%w: decode extrinsic error: %v", ErrDecodeFailed, err)
+	}
+	if ext.IsSigned() {
+		return "", errors.New("extrinsic is signed")
+	}
+	var txHash string
+	err = c.caller().Call(&txHash, "author_submitExtrinsic", unsignedHex)
+	if err != nil {
+		return "", fmt.Errorf("submit unsigned extrinsic error: %w", err)
+	}
+	return txHash, nil
+}
+
+func newTxStatus(s types.ExtrinsicStatus) TxStatus {
+	switch {
+	case s.IsFuture:
+		return TxStatus{Status: "Future"}
+	case s.IsReady:
+		return TxStatus{Status: "Ready"}
+	case s.IsBroadcast:
+		peers := make([]string, len(s.AsBroadcast))
+		for i, peer := range s.AsBroadcast {
+			peers[i] = string(peer)
+		}
+		return TxStatus{Status: "Broadcast", Peers: peers}
+	case s.IsInBlock:
+		return TxStatus{Status: "InBlock", BlockHash: s.AsInBlock.Hex()}
+	case s.IsRetracted:
+		return TxStatus{Status: "Retracted", BlockHash: s.AsRetracted.Hex()}
+	case s.IsFinalityTimeout:
+		return TxStatus{Status: "FinalityTimeout", BlockHash: s.AsFinalityTimeout.Hex()}
+	case s.IsFinalized:
+		return TxStatus{Status: "Finalized", BlockHash: s.AsFinalized.Hex()}
+	case s.IsUsurped:
+		return TxStatus{Status: "Usurped", Usurper: s.AsUsurped.Hex()}
+	case s.IsDropped:
+		return TxStatus{Status: "Dropped"}
+	case s.IsInvalid:
+		return TxStatus{Status: "Invalid"}
+	}
+	return TxStatus{Status: "Unknown"}
+}
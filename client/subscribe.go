@@ -0,0 +1,169 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"github.com/JFJun/bifrost-go/models"
+	"github.com/stafiprotocol/go-substrate-rpc-client/config"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"golang.org/x/crypto/blake2b"
+	"log"
+	"time"
+)
+
+const (
+	//重新订阅的间隔，每次失败后翻倍，最大为maxResubscribeDelay
+	minResubscribeDelay = time.Second
+	maxResubscribeDelay = 30 * time.Second
+)
+
+/*
+订阅新的区块头（chain_subscribeNewHeads），每个区块头通过GetBlockByHash解析后写入返回的chan
+新区块可能在分叉上，同一个高度可能收到多个区块；某个区块获取失败时会被跳过
+websocket断开时会自动重连并重新订阅，ctx结束后chan会被关闭，订阅的goroutine退出
+*/
+func (c *Client) SubscribeNewHeads(ctx context.Context) (<-chan *models.BlockResponse, error) {
+	return c.subscribeHeads(ctx, "subscribeNewHeads", "unsubscribeNewHeads", "newHead", false)
+}
+
+/*
+订阅finalized的区块头（chain_subscribeFinalizedHeads），解析后的区块按高度顺序写入返回的chan
+finalized的区块可能一次跳过多个高度，中间的区块（包括重连期间错过的区块）会按顺序补上
+websocket断开时会自动重连并重新订阅，ctx结束后chan会被关闭，订阅的goroutine退出
+*/
+func (c *Client) SubscribeFinalizedHeads(ctx context.Context) (<-chan *models.BlockResponse, error) {
+	return c.subscribeHeads(ctx, "subscribeFinalizedHeads", "unsubscribeFinalizedHeads", "finalizedHead", true)
+}
+
+type headSubscription struct {
+	heads chan types.Header
+	err   <-chan error
+	stop  func()
+	gen   uint64 //订阅所在的连接的版本，订阅出错时重连这个连接
+}
+
+func (c *Client) subscribeHeads(ctx context.Context, method, unsubscribeMethod, notification string,
+	finalized bool) (<-chan *models.BlockResponse, error) {
+	if _, err := c.subscriber(); err != nil {
+		return nil, err
+	}
+	subscribe := func(rpc RPCCaller, gen uint64) (*headSubscription, error) {
+		subscriber, ok := rpc.(Subscriber)
+		if !ok {
+			return nil, ErrSubscriptionUnsupported
+		}
+		subCtx, cancel := context.WithTimeout(ctx, config.Default().SubscribeTimeout)
+		defer cancel()
+		heads := make(chan types.Header)
+		sub, err := subscriber.Subscribe(subCtx, "chain", method, unsubscribeMethod, notification, heads)
+		if err != nil {
+			return nil, fmt.Errorf("chain_%s error: %w", method, wrapRPCError(err))
+		}
+		return &headSubscription{heads: heads, err: sub.Err(), stop: sub.Unsubscribe, gen: gen}, nil
+	}
+	sub, err := subscribe(c.connection())
+	if err != nil {
+		return nil, err
+	}
+	blocks := make(chan *models.BlockResponse, 16)
+	go func() {
+		defer close(blocks)
+		defer func() {
+			if sub != nil {
+				sub.stop()
+			}
+		}()
+		send := func(block *models.BlockResponse) bool {
+			select {
+			case blocks <- block:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		var last int64 = -1
+		for {
+			select {
+			case head := <-sub.heads:
+				if !finalized {
+					block, err := c.getBlockByHeader(&head)
+					if err != nil {
+						log.Printf("subscribe new heads: get block %d error: %v", head.Number, err)
+						continue
+					}
+					if !send(block) {
+						return
+					}
+					continue
+				}
+				height := int64(head.Number)
+				if last < 0 || last >= height {
+					last = height - 1
+				}
+				for last < height {
+					block, err := c.GetBlockByNumber(last + 1)
+					if err != nil {
+						log.Printf("subscribe finalized heads: get block %d error: %v", last+1, err)
+						break
+					}
+					last++
+					if !send(block) {
+						return
+					}
+				}
+			case err := <-sub.err:
+				log.Printf("chain_%s: subscription error: %v", method, wrapRPCError(err))
+				sub.stop()
+				sub = c.resubscribeHeads(ctx, method, sub.gen, subscribe)
+				if sub == nil {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return blocks, nil
+}
+
+/*
+重连websocket后重新订阅，第一次立即重试，之后失败时按指数退避重试，ctx结束时返回nil
+gen为出错的订阅所在的连接的版本，连接已经被其它goroutine替换时直接在新的连接上订阅
+*/
+func (c *Client) resubscribeHeads(ctx context.Context, method string, gen uint64,
+	subscribe func(rpc RPCCaller, gen uint64) (*headSubscription, error)) *headSubscription {
+	delay := minResubscribeDelay
+	for {
+		rpc, next, err := c.reconnect(gen)
+		if err == nil {
+			gen = next
+			var sub *headSubscription
+			sub, err = subscribe(rpc, gen)
+			if err == nil {
+				return sub
+			}
+		}
+		log.Printf("chain_%s: resubscribe error: %v", method, err)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil
+		}
+		delay *= 2
+		if delay > maxResubscribeDelay {
+			delay = maxResubscribeDelay
+		}
+	}
+}
+
+/*
+区块hash为SCALE编码后的区块头的blake2b-256
+*/
+func (c *Client) getBlockByHeader(head *types.Header) (*models.BlockResponse, error) {
+	data, err := types.EncodeToBytes(head)
+	if err != nil {
+		return nil, fmt.Errorf("encode header error: %v", err)
+	}
+	hash := blake2b.Sum256(data)
+	return c.GetBlockByHash(types.NewHash(hash[:]).Hex())
+}
@@ -0,0 +1,97 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"github.com/JFJun/bifrost-go/expand"
+	"github.com/JFJun/bifrost-go/tx"
+	"github.com/JFJun/bifrost-go/utils"
+	"math/big"
+)
+
+/*
+TransferAndConfirm的结果
+Status为success或者fail，失败时Amount为空，失败的原因可以通过GetBlockByHash获取
+*/
+type TransferReceipt struct {
+	TxHash    string
+	BlockHash string //交易finalized时所在的区块
+	Status    string
+	Fee       string //实际支付的手续费：Balances.Withdraw减去退回的Balances.Deposit
+	Amount    string //Balances.Transfer中实际转账的金额
+}
+
+/*
+构建、签名并提交一笔Balances.transfer，等待交易finalized后根据event返回实际的手续费以及转账金额
+需要ws的节点；交易没有finalized（Dropped、Invalid、Usurped等）时返回错误
+*/
+func (c *Client) TransferAndConfirm(from, to string, amount *big.Int, privateKey string, signType int) (*TransferReceipt, error) {
+	err := c.autoCheckRuntime()
+	if err != nil {
+		return nil, err
+	}
+	if utils.AddressToPublicKey(from) == "" {
+		return nil, fmt.Errorf("invalid from address: %s", from)
+	}
+	me, err := expand.NewMetadataExpand(c.runtime().meta)
+	if err != nil {
+		return nil, fmt.Errorf("new metadata expand error: %v", err)
+	}
+	call, err := me.BalanceTransferBigCall(to, amount, false)
+	if err != nil {
+		return nil, fmt.Errorf("build transfer call error: %v", err)
+	}
+	nonces, err := c.GetNoncesBatch([]string{from})
+	if err != nil {
+		return nil, err
+	}
+	transaction := tx.NewSubstrateTransaction(from, nonces[from])
+	transaction.SetCall(call)
+	transaction.SetEra(0, defaultBatchEraPeriod)
+	err = transaction.FillFromChain(c)
+	if err != nil {
+		return nil, err
+	}
+	signed, err := transaction.SignTransaction(privateKey, signType)
+	if err != nil {
+		return nil, fmt.Errorf("sign transaction error: %v", err)
+	}
+	statuses, err := c.SubmitAndTrack(context.Background(), signed)
+	if err != nil {
+		return nil, err
+	}
+	var final TxStatus
+	for status := range statuses {
+		final = status
+	}
+	if final.Status != "Finalized" {
+		if final.Err != nil {
+			return nil, final.Err
+		}
+		return nil, fmt.Errorf("transaction is not finalized: %s", final.Status)
+	}
+	receipt := &TransferReceipt{TxHash: c.createTxHash(signed), BlockHash: final.BlockHash}
+	events, err := c.GetEventsByExtrinsicHash(receipt.TxHash, receipt.BlockHash)
+	if err != nil {
+		return nil, err
+	}
+	fromPub := utils.AddressToPublicKey(from)
+	toPub := utils.AccountToPublicKey(to)
+	fee := new(big.Int)
+	for _, e := range events {
+		value, ok := new(big.Int).SetString(e.Amount, 10)
+		switch {
+		case e.Status != "":
+			receipt.Status = e.Status
+		case !ok || e.Module != "Balances":
+		case e.Event == "Transfer" && utils.AddressToPublicKey(e.To) == toPub:
+			receipt.Amount = value.String()
+		case e.Event == "Withdraw" && utils.AddressToPublicKey(e.From) == fromPub:
+			fee.Add(fee, value)
+		case e.Event == "Deposit" && utils.AddressToPublicKey(e.To) == fromPub:
+			fee.Sub(fee, value)
+		}
+	}
+	receipt.Fee = fee.String()
+	return receipt, nil
+}
@@ -0,0 +1,40 @@
+package client
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/JFJun/bifrost-go/models"
+	"github.com/JFJun/bifrost-go/utils"
+	"strings"
+)
+
+/*
+验证区块中extrinsic的原始数据（包含长度前缀，即节点返回的hex）与区块头的extrinsicsRoot是否一致，可以用来检查不完全可信的节点返回的数据
+RuntimeVersion中没有state_version，所以先按state_version=0计算，不一致时再按state_version=1计算，
+两者的trie root不可能碰撞，任意一个一致即认为验证通过
+*/
+func (c *Client) VerifyExtrinsicsRoot(block *models.SignedBlock) (bool, error) {
+	if block == nil {
+		return false, errors.New("block is nil")
+	}
+	expected := strings.ToLower(utils.Remove0X(block.Block.Header.ExtrinsicsRoot))
+	if len(expected) != 64 {
+		return false, fmt.Errorf("invalid extrinsics root %q", block.Block.Header.ExtrinsicsRoot)
+	}
+	values := make([][]byte, len(block.Block.Extrinsics))
+	for i, extrinsic := range block.Block.Extrinsics {
+		data, err := hex.DecodeString(utils.Remove0X(extrinsic))
+		if err != nil {
+			return false, fmt.Errorf("hex.decode extrinsic %d error: %v", i, err)
+		}
+		values[i] = data
+	}
+	for _, hashLargeValues := range []bool{false, true} {
+		root := utils.OrderedTrieRoot(values, hashLargeValues)
+		if utils.BytesToHex(root[:]) == expected {
+			return true, nil
+		}
+	}
+	return false, nil
+}
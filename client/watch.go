@@ -0,0 +1,156 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"github.com/JFJun/bifrost-go/models"
+	"github.com/JFJun/bifrost-go/utils"
+	"github.com/stafiprotocol/go-substrate-rpc-client/config"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"log"
+)
+
+/*
+订阅finalized区块，将from或者to为监听地址的转账写入返回的chan，地址按公钥比较，所以不受ss58前缀的影响
+运行过程中可以通过WatchAddress以及UnwatchAddress增加或者删除监听的地址，这些地址对所有的WatchAddresses都生效
+finalized的区块可能一次跳过多个高度，中间的区块会按顺序补上；某个区块获取失败时会在下一个区块到来时重试
+ctx结束或者订阅出错后chan会被关闭
+*/
+func (c *Client) WatchAddresses(ctx context.Context, addresses []string) (<-chan models.EventResult, error) {
+	subscriber, err := c.subscriber()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.WatchAddress(addresses...); err != nil {
+		return nil, err
+	}
+	subCtx, cancel := context.WithTimeout(ctx, config.Default().SubscribeTimeout)
+	defer cancel()
+	heads := make(chan types.Header)
+	sub, err := subscriber.Subscribe(subCtx, "chain", "subscribeFinalizedHeads", "unsubscribeFinalizedHeads",
+		"finalizedHead", heads)
+	if err != nil {
+		return nil, fmt.Errorf("subscribe finalized heads error: %w", wrapRPCError(err))
+	}
+	results := make(chan models.EventResult, 16)
+	go func() {
+		defer close(results)
+		defer sub.Unsubscribe()
+		var last int64 = -1
+		for {
+			select {
+			case head := <-heads:
+				height := int64(head.Number)
+				if last < 0 || last >= height {
+					last = height - 1
+				}
+				for last < height {
+					block, err := c.GetBlockByNumber(last + 1)
+					if err != nil {
+						log.Printf("watch addresses: get block %d error: %v", last+1, err)
+						break
+					}
+					last++
+					for _, r := range c.watchedTransfers(block) {
+						select {
+						case results <- r:
+						case <-ctx.Done():
+							return
+						}
+					}
+				}
+			case err := <-sub.Err():
+				if err != nil {
+					log.Printf("watch addresses: subscription error: %v", wrapRPCError(err))
+				}
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return results, nil
+}
+
+/*
+增加监听的地址，地址可以是ss58地址或者公钥的hex
+*/
+func (c *Client) WatchAddress(addresses ...string) error {
+	keys := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		pub := utils.AccountToPublicKey(address)
+		if pub == "" {
+			return fmt.Errorf("invalid watch address: %s", address)
+		}
+		keys = append(keys, pub)
+	}
+	c.watchMu.Lock()
+	defer c.watchMu.Unlock()
+	if c.watched == nil {
+		c.watched = make(map[string]bool)
+	}
+	for _, key := range keys {
+		c.watched[key] = true
+	}
+	return nil
+}
+
+/*
+取消监听的地址，不在监听列表中的地址会被忽略
+*/
+func (c *Client) UnwatchAddress(addresses ...string) {
+	c.watchMu.Lock()
+	defer c.watchMu.Unlock()
+	for _, address := range addresses {
+		delete(c.watched, utils.AccountToPublicKey(address))
+	}
+}
+
+func (c *Client) isWatched(address string) bool {
+	if address == "" {
+		return false
+	}
+	pub := utils.AccountToPublicKey(address)
+	c.watchMu.RLock()
+	defer c.watchMu.RUnlock()
+	return pub != "" && c.watched[pub]
+}
+
+/*
+区块中与监听地址相关的转账，SetGroupBatchTransfers合并的转账会被展开
+*/
+func (c *Client) watchedTransfers(block *models.BlockResponse) []models.EventResult {
+	var result []models.EventResult
+	add := func(e *models.ExtrinsicResponse, to, amount, rawAmount string) {
+		if !c.isWatched(e.FromAddress) && !c.isWatched(to) {
+			return
+		}
+		if rawAmount != "" {
+			amount = rawAmount
+		}
+		result = append(result, models.EventResult{
+			Module:       "Balances",
+			Event:        "Transfer",
+			From:         e.FromAddress,
+			To:           to,
+			Amount:       amount,
+			ExtrinsicIdx: e.ExtrinsicIndex,
+			EventIdx:     e.EventIndex,
+			Status:       e.Status,
+			Phase:        "ApplyExtrinsic",
+		})
+	}
+	for _, e := range block.Extrinsic {
+		if e == nil || e.Type != "transfer" {
+			continue
+		}
+		if len(e.BatchTransfers) > 0 {
+			for _, t := range e.BatchTransfers {
+				add(e, t.ToAddress, t.Amount, t.RawAmount)
+			}
+			continue
+		}
+		add(e, e.ToAddress, e.Amount, e.RawAmount)
+	}
+	return result
+}
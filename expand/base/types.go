@@ -34,8 +34,23 @@ type BaseEventRecords struct {
 	Multisig_MultisigExecuted  []types.EventMultisigExecuted
 	Multisig_MultisigCancelled []types.EventMultisigCancelled
 
+	System_ExtrinsicFailed      []EventSystemExtrinsicFailed
 	Balances_ReserveRepatriated []EventBalancesReserveRepatriated
+	Balances_Withdraw           []EventBalancesWithdraw
+	Staking_Slashed             []types.EventStakingSlash
 	Proxy_Announced             []EventProxyAnnounced
+
+	PolkadotXcm_Attempted []EventXcmAttempted
+	PolkadotXcm_Sent      []EventXcmSent
+	XcmPallet_Attempted   []EventXcmAttempted
+	XcmPallet_Sent        []EventXcmSent
+
+	BagsList_Rebagged  []EventBagsListRebagged
+	VoterList_Rebagged []EventBagsListRebagged
+
+	TransactionPayment_TransactionFeePaid []EventTransactionFeePaid
+
+	Assets_Burned []EventAssetsBurned
 }
 
 func (d *BaseEventRecords) GetBalancesTransfer() []types.EventBalancesTransfer {
@@ -44,9 +59,79 @@ func (d *BaseEventRecords) GetBalancesTransfer() []types.EventBalancesTransfer {
 func (d *BaseEventRecords) GetSystemExtrinsicSuccess() []types.EventSystemExtrinsicSuccess {
 	return d.System_ExtrinsicSuccess
 }
-func (d *BaseEventRecords) GetSystemExtrinsicFailed() []types.EventSystemExtrinsicFailed {
+func (d *BaseEventRecords) GetSystemExtrinsicFailed() []EventSystemExtrinsicFailed {
 	return d.System_ExtrinsicFailed
 }
+func (d *BaseEventRecords) GetBalancesEndowed() []types.EventBalancesEndowed {
+	return d.Balances_Endowed
+}
+func (d *BaseEventRecords) GetBalancesDeposit() []types.EventBalancesDeposit {
+	return d.Balances_Deposit
+}
+func (d *BaseEventRecords) GetBalancesWithdraw() []EventBalancesWithdraw {
+	return d.Balances_Withdraw
+}
+func (d *BaseEventRecords) GetBalancesReserved() []types.EventBalancesReserved {
+	return d.Balances_Reserved
+}
+func (d *BaseEventRecords) GetBalancesUnreserved() []types.EventBalancesUnreserved {
+	return d.Balances_Unreserved
+}
+func (d *BaseEventRecords) GetBalancesReserveRepatriated() []EventBalancesReserveRepatriated {
+	return d.Balances_ReserveRepatriated
+}
+func (d *BaseEventRecords) GetBalancesDustLost() []types.EventBalancesDustLost {
+	return d.Balances_DustLost
+}
+
+/*
+旧版本的runtime中为Staking.Slash，新版本改名为Staking.Slashed
+*/
+func (d *BaseEventRecords) GetStakingSlash() []types.EventStakingSlash {
+	return append(append([]types.EventStakingSlash{}, d.Staking_Slash...), d.Staking_Slashed...)
+}
+func (d *BaseEventRecords) GetOffencesOffence() []types.EventOffencesOffence {
+	return d.Offences_Offence
+}
+func (d *BaseEventRecords) GetImOnlineSomeOffline() []types.EventImOnlineSomeOffline {
+	return d.ImOnline_SomeOffline
+}
+func (d *BaseEventRecords) GetProxyAnnounced() []EventProxyAnnounced {
+	return d.Proxy_Announced
+}
+func (d *BaseEventRecords) GetStakingUnbonded() []types.EventStakingUnbonded {
+	return d.Staking_Unbonded
+}
+func (d *BaseEventRecords) GetStakingWithdrawn() []types.EventStakingWithdrawn {
+	return d.Staking_Withdrawn
+}
+
+/*
+平行链中为PolkadotXcm，中继链中为XcmPallet
+*/
+func (d *BaseEventRecords) GetXcmAttempted() []EventXcmAttempted {
+	return append(append([]EventXcmAttempted{}, d.PolkadotXcm_Attempted...), d.XcmPallet_Attempted...)
+}
+func (d *BaseEventRecords) GetXcmSent() []EventXcmSent {
+	return append(append([]EventXcmSent{}, d.PolkadotXcm_Sent...), d.XcmPallet_Sent...)
+}
+
+func (d *BaseEventRecords) GetTransactionFeePaid() []EventTransactionFeePaid {
+	return d.TransactionPayment_TransactionFeePaid
+}
+func (d *BaseEventRecords) GetAssetsIssued() []types.EventAssetIssued {
+	return d.Assets_Issued
+}
+func (d *BaseEventRecords) GetAssetsBurned() []EventAssetsBurned {
+	return d.Assets_Burned
+}
+
+/*
+bags-list模块在早期的runtime中为BagsList，之后改名为VoterList
+*/
+func (d *BaseEventRecords) GetBagsListRebagged() []EventBagsListRebagged {
+	return append(append([]EventBagsListRebagged{}, d.BagsList_Rebagged...), d.VoterList_Rebagged...)
+}
 
 type EventClaimsClaimed struct {
 	Phase           types.Phase
@@ -168,6 +253,22 @@ type EventBalancesReserveRepatriated struct {
 	Status  types.BalanceStatus
 	Topics  []types.Hash
 }
+
+/*
+types.EventSystemExtrinsicFailed中的DispatchError只能解析Module，其他变体会多读一个字节导致后面的数据错位
+*/
+type EventSystemExtrinsicFailed struct {
+	Phase         types.Phase
+	DispatchError DispatchError
+	DispatchInfo  types.DispatchInfo
+	Topics        []types.Hash
+}
+type EventBalancesWithdraw struct {
+	Phase   types.Phase
+	Who     types.AccountID
+	Balance types.U128
+	Topics  []types.Hash
+}
 type EventProxyAnnounced struct {
 	Phase  types.Phase
 	Who    types.AccountID
@@ -176,6 +277,59 @@ type EventProxyAnnounced struct {
 	Topics []types.Hash
 }
 
+/*
+PolkadotXcm.execute的执行结果
+*/
+type EventXcmAttempted struct {
+	Phase   types.Phase
+	Outcome XcmOutcomeV2
+	Topics  []types.Hash
+}
+
+/*
+PolkadotXcm.send发送的消息：origin、destination以及message
+*/
+type EventXcmSent struct {
+	Phase       types.Phase
+	Origin      MultiLocationV2
+	Destination MultiLocationV2
+	Message     XcmV2
+	Topics      []types.Hash
+}
+
+/*
+交易实际支付的手续费，ActualFee包含了Tip
+*/
+type EventTransactionFeePaid struct {
+	Phase     types.Phase
+	Who       types.AccountID
+	ActualFee types.U128
+	Tip       types.U128
+	Topics    []types.Hash
+}
+
+/*
+Assets.Burned，Balance为实际销毁的金额，账户余额不足时小于Assets.burn中的amount
+*/
+type EventAssetsBurned struct {
+	Phase   types.Phase
+	AssetId types.U32
+	Owner   types.AccountID
+	Balance types.U128
+	Topics  []types.Hash
+}
+
+/*
+账户从一个bag移到了另一个bag，From和To为bag的上限（VoteWeight）
+*/
+type EventBagsListRebagged struct {
+	Phase  types.Phase
+	Who    types.AccountID
+	From   types.U64
+	To     types.U64
+	Topics []types.Hash
+}
+
 type CurrencyId types.U32
 
 /*
@@ -292,3 +446,107 @@ func (d *Log) Decode(decoder scale.Decoder) error {
 https://github.com/polkadot-js/api/blob/master/packages/types/src/interfaces/collective/types.ts
 */
 type MemberCount types.U32
+
+/*
+https://github.com/paritytech/substrate/blob/master/primitives/runtime/src/lib.rs
+Variant为enum的下标，Module、Token、Arithmetic以及Transactional带有额外的数据
+*/
+type DispatchError struct {
+	Variant       uint8
+	Module        ModuleError
+	Token         uint8
+	Arithmetic    uint8
+	Transactional uint8
+}
+
+type ModuleError struct {
+	Index uint8
+	Error uint8
+}
+
+var (
+	dispatchErrorVariants = []string{"Other", "CannotLookup", "BadOrigin", "Module", "ConsumerRemaining",
+		"NoProviders", "TooManyConsumers", "Token", "Arithmetic", "Transactional", "Exhausted", "Corruption", "Unavailable"}
+	tokenErrors         = []string{"NoFunds", "WouldDie", "BelowMinimum", "CannotCreate", "UnknownAsset", "Frozen", "Unsupported"}
+	arithmeticErrors    = []string{"Underflow", "Overflow", "DivisionByZero"}
+	transactionalErrors = []string{"LimitReached", "NoLayer"}
+)
+
+func (d *DispatchError) Decode(decoder scale.Decoder) error {
+	err := decoder.Decode(&d.Variant)
+	if err != nil {
+		return err
+	}
+	switch d.Variant {
+	case 3:
+		err = decoder.Decode(&d.Module.Index)
+		if err != nil {
+			return err
+		}
+		return decoder.Decode(&d.Module.Error)
+	case 7:
+		return decoder.Decode(&d.Token)
+	case 8:
+		return decoder.Decode(&d.Arithmetic)
+	case 9:
+		return decoder.Decode(&d.Transactional)
+	}
+	if int(d.Variant) >= len(dispatchErrorVariants) {
+		return fmt.Errorf("unknown DispatchError variant: %d", d.Variant)
+	}
+	return nil
+}
+
+func (d DispatchError) Encode(encoder scale.Encoder) error {
+	err := encoder.PushByte(d.Variant)
+	if err != nil {
+		return err
+	}
+	switch d.Variant {
+	case 3:
+		err = encoder.PushByte(d.Module.Index)
+		if err != nil {
+			return err
+		}
+		return encoder.PushByte(d.Module.Error)
+	case 7:
+		return encoder.PushByte(d.Token)
+	case 8:
+		return encoder.PushByte(d.Arithmetic)
+	case 9:
+		return encoder.PushByte(d.Transactional)
+	}
+	return nil
+}
+
+func (d DispatchError) IsModule() bool {
+	return d.Variant == 3
+}
+
+/*
+可读的错误信息，比如"BadOrigin"、"Token.NoFunds"，Module错误需要metadata才能得到名字，这里只返回下标
+*/
+func (d DispatchError) String() string {
+	if int(d.Variant) >= len(dispatchErrorVariants) {
+		return fmt.Sprintf("Unknown(%d)", d.Variant)
+	}
+	name := dispatchErrorVariants[d.Variant]
+	switch d.Variant {
+	case 3:
+		return fmt.Sprintf("%s(index=%d,error=%d)", name, d.Module.Index, d.Module.Error)
+	case 7:
+		return name + "." + variantName(tokenErrors, d.Token)
+	case 8:
+		return name + "." + variantName(arithmeticErrors, d.Arithmetic)
+	case 9:
+		return name + "." + variantName(transactionalErrors, d.Transactional)
+	}
+	return name
+}
+
+func variantName(names []string, idx uint8) string {
+	if int(idx) < len(names) {
+		return names[idx]
+	}
+	return fmt.Sprintf("Unknown(%d)", idx)
+}
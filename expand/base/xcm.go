@@ -0,0 +1,632 @@
+package base
+
+import (
+	"fmt"
+	"github.com/JFJun/bifrost-go/utils"
+	"github.com/stafiprotocol/go-substrate-rpc-client/scale"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+/*
+扩展：XCM中的MultiLocation以及MultiAssets解析
+https://github.com/paritytech/polkadot/tree/master/xcm/src
+目前支持V0、V1(V2与V1编码一致)以及V3
+*/
+
+const (
+	XcmVersion0 = 0
+	XcmVersion1 = 1
+	XcmVersion2 = 2
+	XcmVersion3 = 3
+)
+
+type Junction struct {
+	Type    string `json:"type"`
+	Network string `json:"network,omitempty"`
+	Value   string `json:"value,omitempty"`
+}
+
+type MultiLocation struct {
+	Parents  uint8      `json:"parents"`
+	Interior []Junction `json:"interior"`
+}
+
+type MultiAsset struct {
+	Concrete *MultiLocation `json:"concrete,omitempty"` //资产id为MultiLocation
+	Abstract string         `json:"abstract,omitempty"` //资产id为bytes
+	Fungible bool           `json:"fungible"`
+	Amount   string         `json:"amount,omitempty"`
+	Instance string         `json:"instance,omitempty"`
+	Wildcard string         `json:"wildcard,omitempty"` //V0中的All/AllFungible等
+}
+
+type VersionedMultiLocation struct {
+	Version  uint8         `json:"version"`
+	Location MultiLocation `json:"location"`
+}
+
+func (v *VersionedMultiLocation) Decode(decoder scale.Decoder) error {
+	b, err := decoder.ReadOneByte()
+	if err != nil {
+		return fmt.Errorf("decode VersionedMultiLocation: read version error: %v", err)
+	}
+	err = checkVersionedTag(b)
+	if err != nil {
+		return fmt.Errorf("decode VersionedMultiLocation: %v", err)
+	}
+	v.Version = b
+	v.Location, err = decodeMultiLocation(decoder, b)
+	return err
+}
+
+type VersionedMultiAssets struct {
+	Version uint8        `json:"version"`
+	Assets  []MultiAsset `json:"assets"`
+}
+
+func (v *VersionedMultiAssets) Decode(decoder scale.Decoder) error {
+	b, err := decoder.ReadOneByte()
+	if err != nil {
+		return fmt.Errorf("decode VersionedMultiAssets: read version error: %v", err)
+	}
+	err = checkVersionedTag(b)
+	if err != nil {
+		return fmt.Errorf("decode VersionedMultiAssets: %v", err)
+	}
+	v.Version = b
+	v.Assets, err = decodeMultiAssets(decoder, b)
+	if err != nil {
+		return fmt.Errorf("decode VersionedMultiAssets: %v", err)
+	}
+	return nil
+}
+
+/*
+VersionedMultiLocation以及VersionedMultiAssets只有V0、V1、V3三个变体，V2与V1共用tag 1
+*/
+func checkVersionedTag(b byte) error {
+	switch b {
+	case XcmVersion0, XcmVersion1, XcmVersion3:
+		return nil
+	}
+	return fmt.Errorf("unsupport versioned tag %d", b)
+}
+
+func decodeMultiAssets(decoder scale.Decoder, version uint8) ([]MultiAsset, error) {
+	length, err := decodeCompactLength(decoder)
+	if err != nil {
+		return nil, fmt.Errorf("get length error: %v", err)
+	}
+	var assets []MultiAsset
+	for i := 0; i < length; i++ {
+		asset, err := decodeMultiAsset(decoder, version)
+		if err != nil {
+			return nil, fmt.Errorf("decode asset %d error: %v", i, err)
+		}
+		assets = append(assets, asset)
+	}
+	return assets, nil
+}
+
+func decodeCompactLength(decoder scale.Decoder) (int, error) {
+	var u types.UCompact
+	err := decoder.Decode(&u)
+	if err != nil {
+		return 0, err
+	}
+	length := int(utils.UCompactToBigInt(u).Int64())
+	if length > 5000 {
+		return 0, fmt.Errorf("length %d exceeds %d", length, 5000)
+	}
+	return length, nil
+}
+
+func decodeCompactString(decoder scale.Decoder) (string, error) {
+	var u types.UCompact
+	err := decoder.Decode(&u)
+	if err != nil {
+		return "", err
+	}
+	return utils.UCompactToBigInt(u).String(), nil
+}
+
+func decodeFixedHex(decoder scale.Decoder, n int) (string, error) {
+	data := make([]byte, n)
+	err := decoder.Read(data)
+	if err != nil {
+		return "", err
+	}
+	return utils.BytesToHex(data), nil
+}
+
+func decodeBytesHex(decoder scale.Decoder) (string, error) {
+	var b types.Bytes
+	err := decoder.Decode(&b)
+	if err != nil {
+		return "", err
+	}
+	return utils.BytesToHex(b), nil
+}
+
+func decodeMultiLocation(decoder scale.Decoder, version uint8) (MultiLocation, error) {
+	var ml MultiLocation
+	switch version {
+	case XcmVersion0:
+		//V0中没有parents，Parent作为junction存在
+		n, err := decoder.ReadOneByte()
+		if err != nil {
+			return ml, fmt.Errorf("decode MultiLocationV0 error: %v", err)
+		}
+		if n > 8 {
+			return ml, fmt.Errorf("decode MultiLocationV0: unsupport type=%d", n)
+		}
+		for i := 0; i < int(n); i++ {
+			j, err := decodeJunctionV0(decoder)
+			if err != nil {
+				return ml, err
+			}
+			if j.Type == "Parent" {
+				ml.Parents++
+				continue
+			}
+			ml.Interior = append(ml.Interior, j)
+		}
+		return ml, nil
+	case XcmVersion1, XcmVersion2, XcmVersion3:
+		parents, err := decoder.ReadOneByte()
+		if err != nil {
+			return ml, fmt.Errorf("decode MultiLocation: read parents error: %v", err)
+		}
+		ml.Parents = parents
+		ml.Interior, err = decodeJunctions(decoder, version)
+		if err != nil {
+			return ml, fmt.Errorf("decode MultiLocation: %v", err)
+		}
+		return ml, nil
+	default:
+		return ml, fmt.Errorf("decode MultiLocation: unsupport xcm version %d", version)
+	}
+}
+
+/*
+V1及以上的Junctions（Here、X1~X8），即MultiLocation的interior
+*/
+func decodeJunctions(decoder scale.Decoder, version uint8) ([]Junction, error) {
+	n, err := decoder.ReadOneByte()
+	if err != nil {
+		return nil, fmt.Errorf("read junctions error: %v", err)
+	}
+	if n > 8 {
+		return nil, fmt.Errorf("unsupport junctions type=%d", n)
+	}
+	var junctions []Junction
+	for i := 0; i < int(n); i++ {
+		var j Junction
+		if version == XcmVersion3 {
+			j, err = decodeJunctionV3(decoder)
+		} else {
+			j, err = decodeJunctionV1(decoder)
+		}
+		if err != nil {
+			return nil, err
+		}
+		junctions = append(junctions, j)
+	}
+	return junctions, nil
+}
+
+func decodeJunctionV0(decoder scale.Decoder) (Junction, error) {
+	b, err := decoder.ReadOneByte()
+	if err != nil {
+		return Junction{}, fmt.Errorf("decode JunctionV0 error: %v", err)
+	}
+	if b == 0 {
+		return Junction{Type: "Parent"}, nil
+	}
+	//除了Parent，V0与V1的junction只是索引相差1
+	return decodeJunctionV1Body(decoder, b-1)
+}
+
+func decodeJunctionV1(decoder scale.Decoder) (Junction, error) {
+	b, err := decoder.ReadOneByte()
+	if err != nil {
+		return Junction{}, fmt.Errorf("decode Junction error: %v", err)
+	}
+	return decodeJunctionV1Body(decoder, b)
+}
+
+func decodeJunctionV1Body(decoder scale.Decoder, b byte) (Junction, error) {
+	var (
+		j   Junction
+		err error
+	)
+	switch b {
+	case 0:
+		j.Type = "Parachain"
+		j.Value, err = decodeCompactString(decoder)
+	case 1:
+		j.Type = "AccountId32"
+		j.Network, err = decodeNetworkIdV1(decoder)
+		if err == nil {
+			j.Value, err = decodeFixedHex(decoder, 32)
+		}
+	case 2:
+		j.Type = "AccountIndex64"
+		j.Network, err = decodeNetworkIdV1(decoder)
+		if err == nil {
+			j.Value, err = decodeCompactString(decoder)
+		}
+	case 3:
+		j.Type = "AccountKey20"
+		j.Network, err = decodeNetworkIdV1(decoder)
+		if err == nil {
+			j.Value, err = decodeFixedHex(decoder, 20)
+		}
+	case 4:
+		j.Type = "PalletInstance"
+		var p byte
+		p, err = decoder.ReadOneByte()
+		j.Value = fmt.Sprintf("%d", p)
+	case 5:
+		j.Type = "GeneralIndex"
+		j.Value, err = decodeCompactString(decoder)
+	case 6:
+		j.Type = "GeneralKey"
+		j.Value, err = decodeBytesHex(decoder)
+	case 7:
+		j.Type = "OnlyChild"
+	case 8:
+		j.Type = "Plurality"
+		j.Value, err = decodePlurality(decoder, XcmVersion1)
+	default:
+		err = fmt.Errorf("unsupport junction type=%d", b)
+	}
+	if err != nil {
+		return j, fmt.Errorf("decode Junction %s error: %v", j.Type, err)
+	}
+	return j, nil
+}
+
+func decodeJunctionV3(decoder scale.Decoder) (Junction, error) {
+	b, err := decoder.ReadOneByte()
+	if err != nil {
+		return Junction{}, fmt.Errorf("decode JunctionV3 error: %v", err)
+	}
+	var j Junction
+	switch b {
+	case 0:
+		j.Type = "Parachain"
+		j.Value, err = decodeCompactString(decoder)
+	case 1:
+		j.Type = "AccountId32"
+		j.Network, err = decodeOptionNetworkIdV3(decoder)
+		if err == nil {
+			j.Value, err = decodeFixedHex(decoder, 32)
+		}
+	case 2:
+		j.Type = "AccountIndex64"
+		j.Network, err = decodeOptionNetworkIdV3(decoder)
+		if err == nil {
+			j.Value, err = decodeCompactString(decoder)
+		}
+	case 3:
+		j.Type = "AccountKey20"
+		j.Network, err = decodeOptionNetworkIdV3(decoder)
+		if err == nil {
+			j.Value, err = decodeFixedHex(decoder, 20)
+		}
+	case 4:
+		j.Type = "PalletInstance"
+		var p byte
+		p, err = decoder.ReadOneByte()
+		j.Value = fmt.Sprintf("%d", p)
+	case 5:
+		j.Type = "GeneralIndex"
+		j.Value, err = decodeCompactString(decoder)
+	case 6:
+		j.Type = "GeneralKey"
+		var length byte
+		length, err = decoder.ReadOneByte()
+		if err == nil {
+			var data string
+			data, err = decodeFixedHex(decoder, 32)
+			if err == nil && int(length) <= 32 {
+				j.Value = data[:int(length)*2]
+			}
+		}
+	case 7:
+		j.Type = "OnlyChild"
+	case 8:
+		j.Type = "Plurality"
+		j.Value, err = decodePlurality(decoder, XcmVersion3)
+	case 9:
+		j.Type = "GlobalConsensus"
+		j.Network, err = decodeNetworkIdV3(decoder)
+	default:
+		err = fmt.Errorf("unsupport junction type=%d", b)
+	}
+	if err != nil {
+		return j, fmt.Errorf("decode JunctionV3 %s error: %v", j.Type, err)
+	}
+	return j, nil
+}
+
+func decodeNetworkIdV1(decoder scale.Decoder) (string, error) {
+	b, err := decoder.ReadOneByte()
+	if err != nil {
+		return "", err
+	}
+	switch b {
+	case 0:
+		return "Any", nil
+	case 1:
+		name, err := decodeBytesHex(decoder)
+		return "Named:" + name, err
+	case 2:
+		return "Polkadot", nil
+	case 3:
+		return "Kusama", nil
+	default:
+		return "", fmt.Errorf("unsupport network id type=%d", b)
+	}
+}
+
+func decodeOptionNetworkIdV3(decoder scale.Decoder) (string, error) {
+	b, err := decoder.ReadOneByte()
+	if err != nil {
+		return "", err
+	}
+	if b == 0 {
+		return "", nil
+	}
+	return decodeNetworkIdV3(decoder)
+}
+
+func decodeNetworkIdV3(decoder scale.Decoder) (string, error) {
+	b, err := decoder.ReadOneByte()
+	if err != nil {
+		return "", err
+	}
+	switch b {
+	case 0:
+		genesis, err := decodeFixedHex(decoder, 32)
+		return "ByGenesis:" + genesis, err
+	case 1:
+		var number types.U64
+		err = decoder.Decode(&number)
+		if err != nil {
+			return "", err
+		}
+		hash, err := decodeFixedHex(decoder, 32)
+		return fmt.Sprintf("ByFork:%d:%s", number, hash), err
+	case 2:
+		return "Polkadot", nil
+	case 3:
+		return "Kusama", nil
+	case 4:
+		return "Westend", nil
+	case 5:
+		return "Rococo", nil
+	case 6:
+		return "Wococo", nil
+	case 7:
+		chainId, err := decodeCompactString(decoder)
+		return "Ethereum:" + chainId, err
+	case 8:
+		return "BitcoinCore", nil
+	case 9:
+		return "BitcoinCash", nil
+	default:
+		return "", fmt.Errorf("unsupport network id type=%d", b)
+	}
+}
+
+/*
+Plurality的BodyId以及BodyPart，V3中BodyId的Named(Vec<u8>)改为Moniker([u8;4])
+*/
+func decodePlurality(decoder scale.Decoder, version uint8) (string, error) {
+	// BodyId
+	b, err := decoder.ReadOneByte()
+	if err != nil {
+		return "", err
+	}
+	var id string
+	switch b {
+	case 0:
+		id = "Unit"
+	case 1:
+		var name string
+		if version == XcmVersion3 {
+			name, err = decodeFixedHex(decoder, 4)
+			id = "Moniker:" + name
+		} else {
+			name, err = decodeBytesHex(decoder)
+			id = "Named:" + name
+		}
+	case 2:
+		var index string
+		index, err = decodeCompactString(decoder)
+		id = "Index:" + index
+	case 3:
+		id = "Executive"
+	case 4:
+		id = "Technical"
+	case 5:
+		id = "Legislative"
+	case 6:
+		id = "Judicial"
+	case 7:
+		id = "Defense"
+	case 8:
+		id = "Administration"
+	case 9:
+		id = "Treasury"
+	default:
+		return "", fmt.Errorf("unsupport body id type=%d", b)
+	}
+	if err != nil {
+		return "", err
+	}
+	// BodyPart
+	b, err = decoder.ReadOneByte()
+	if err != nil {
+		return "", err
+	}
+	var part string
+	switch b {
+	case 0:
+		part = "Voice"
+	case 1:
+		var count string
+		count, err = decodeCompactString(decoder)
+		part = "Members:" + count
+	case 2, 3, 4:
+		var nom, denom string
+		nom, err = decodeCompactString(decoder)
+		if err == nil {
+			denom, err = decodeCompactString(decoder)
+		}
+		part = []string{"Fraction", "AtLeastProportion", "MoreThanProportion"}[b-2] + ":" + nom + "/" + denom
+	default:
+		return "", fmt.Errorf("unsupport body part type=%d", b)
+	}
+	if err != nil {
+		return "", err
+	}
+	return id + "," + part, nil
+}
+
+/*
+V3的AssetInstance中没有Blob
+*/
+func decodeAssetInstance(decoder scale.Decoder, version uint8) (string, error) {
+	b, err := decoder.ReadOneByte()
+	if err != nil {
+		return "", err
+	}
+	switch b {
+	case 0:
+		return "Undefined", nil
+	case 1:
+		return decodeCompactString(decoder)
+	case 2:
+		return decodeFixedHex(decoder, 4)
+	case 3:
+		return decodeFixedHex(decoder, 8)
+	case 4:
+		return decodeFixedHex(decoder, 16)
+	case 5:
+		return decodeFixedHex(decoder, 32)
+	case 6:
+		if version == XcmVersion3 {
+			return "", fmt.Errorf("unsupport asset instance type=%d in xcm v3", b)
+		}
+		return decodeBytesHex(decoder)
+	default:
+		return "", fmt.Errorf("unsupport asset instance type=%d", b)
+	}
+}
+
+func decodeMultiAsset(decoder scale.Decoder, version uint8) (MultiAsset, error) {
+	if version == XcmVersion0 {
+		return decodeMultiAssetV0(decoder)
+	}
+	var asset MultiAsset
+	err := decodeAssetId(decoder, version, &asset)
+	if err != nil {
+		return asset, err
+	}
+	// Fungibility
+	b, err := decoder.ReadOneByte()
+	if err != nil {
+		return asset, err
+	}
+	switch b {
+	case 0:
+		asset.Fungible = true
+		asset.Amount, err = decodeCompactString(decoder)
+	case 1:
+		asset.Instance, err = decodeAssetInstance(decoder, version)
+	default:
+		return asset, fmt.Errorf("unsupport fungibility type=%d", b)
+	}
+	return asset, err
+}
+
+/*
+AssetId，解析结果写入asset的Concrete或者Abstract
+*/
+func decodeAssetId(decoder scale.Decoder, version uint8, asset *MultiAsset) error {
+	b, err := decoder.ReadOneByte()
+	if err != nil {
+		return err
+	}
+	switch b {
+	case 0:
+		var ml MultiLocation
+		ml, err = decodeMultiLocation(decoder, version)
+		asset.Concrete = &ml
+	case 1:
+		if version == XcmVersion3 {
+			asset.Abstract, err = decodeFixedHex(decoder, 32)
+		} else {
+			asset.Abstract, err = decodeBytesHex(decoder)
+		}
+	default:
+		return fmt.Errorf("unsupport asset id type=%d", b)
+	}
+	return err
+}
+
+func decodeMultiAssetV0(decoder scale.Decoder) (MultiAsset, error) {
+	var (
+		asset MultiAsset
+		err   error
+		ml    MultiLocation
+	)
+	b, err := decoder.ReadOneByte()
+	if err != nil {
+		return asset, err
+	}
+	switch b {
+	case 0, 1, 2, 3:
+		asset.Wildcard = []string{"None", "All", "AllFungible", "AllNonFungible"}[b]
+	case 4:
+		asset.Wildcard = "AllAbstractFungible"
+		asset.Abstract, err = decodeBytesHex(decoder)
+	case 5:
+		asset.Wildcard = "AllAbstractNonFungible"
+		asset.Abstract, err = decodeBytesHex(decoder)
+	case 6, 7:
+		asset.Wildcard = []string{"AllConcreteFungible", "AllConcreteNonFungible"}[b-6]
+		ml, err = decodeMultiLocation(decoder, XcmVersion0)
+		asset.Concrete = &ml
+	case 8:
+		asset.Fungible = true
+		asset.Abstract, err = decodeBytesHex(decoder)
+		if err == nil {
+			asset.Amount, err = decodeCompactString(decoder)
+		}
+	case 9:
+		asset.Abstract, err = decodeBytesHex(decoder)
+		if err == nil {
+			asset.Instance, err = decodeAssetInstance(decoder, XcmVersion0)
+		}
+	case 10:
+		asset.Fungible = true
+		ml, err = decodeMultiLocation(decoder, XcmVersion0)
+		asset.Concrete = &ml
+		if err == nil {
+			asset.Amount, err = decodeCompactString(decoder)
+		}
+	case 11:
+		ml, err = decodeMultiLocation(decoder, XcmVersion0)
+		asset.Concrete = &ml
+		if err == nil {
+			asset.Instance, err = decodeAssetInstance(decoder, XcmVersion0)
+		}
+	default:
+		return asset, fmt.Errorf("unsupport MultiAssetV0 type=%d", b)
+	}
+	return asset, err
+}
@@ -0,0 +1,635 @@
+package base
+
+import (
+	"fmt"
+	"github.com/stafiprotocol/go-substrate-rpc-client/scale"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+/*
+扩展：XCM消息（PolkadotXcm.send/execute中的VersionedXcm）以及执行结果的解析
+V2与V3的消息为指令的列表，V0、V1的消息结构完全不同，暂不支持
+*/
+
+type XcmInstruction struct {
+	Instruction string           `json:"instruction"`
+	Assets      []MultiAsset     `json:"assets,omitempty"`   //指令操作的资产，ExchangeAsset中为give，BuyExecution中为fees
+	Want        []MultiAsset     `json:"want,omitempty"`     //ExchangeAsset中希望换到的资产
+	Location    *MultiLocation   `json:"location,omitempty"` //beneficiary、dest、reserve等
+	Xcm         []XcmInstruction `json:"xcm,omitempty"`      //嵌套的消息，比如InitiateReserveWithdraw中发往reserve的消息
+	Value       string           `json:"value,omitempty"`    //其它参数，比如weight_limit、Transact的call
+}
+
+type VersionedXcm struct {
+	Version      uint8            `json:"version"`
+	Instructions []XcmInstruction `json:"instructions"`
+}
+
+func (v *VersionedXcm) Decode(decoder scale.Decoder) error {
+	b, err := decoder.ReadOneByte()
+	if err != nil {
+		return fmt.Errorf("decode VersionedXcm: read version error: %v", err)
+	}
+	v.Version = b
+	if b != XcmVersion2 && b != XcmVersion3 {
+		return fmt.Errorf("decode VersionedXcm: unsupport xcm version %d", b)
+	}
+	v.Instructions, err = decodeXcm(decoder, b)
+	if err != nil {
+		return fmt.Errorf("decode VersionedXcm: %v", err)
+	}
+	return nil
+}
+
+/*
+event中没有版本号的Xcm<()>，metadata v13的runtime中最新的xcm版本为V2
+*/
+type XcmV2 struct {
+	Instructions []XcmInstruction
+}
+
+func (x *XcmV2) Decode(decoder scale.Decoder) error {
+	var err error
+	x.Instructions, err = decodeXcm(decoder, XcmVersion2)
+	if err != nil {
+		return fmt.Errorf("decode Xcm: %v", err)
+	}
+	return nil
+}
+
+/*
+event中没有版本号的MultiLocation，V1与V2的编码一致
+*/
+type MultiLocationV2 struct {
+	MultiLocation
+}
+
+func (m *MultiLocationV2) Decode(decoder scale.Decoder) error {
+	var err error
+	m.MultiLocation, err = decodeMultiLocation(decoder, XcmVersion2)
+	return err
+}
+
+/*
+xcm执行的结果：Complete(Weight)、Incomplete(Weight, Error)、Error(Error)，Weight为u64
+*/
+type XcmOutcomeV2 struct {
+	Variant uint8
+	Weight  uint64
+	Error   string
+}
+
+func (o *XcmOutcomeV2) Decode(decoder scale.Decoder) error {
+	b, err := decoder.ReadOneByte()
+	if err != nil {
+		return fmt.Errorf("decode Outcome error: %v", err)
+	}
+	o.Variant = b
+	switch b {
+	case 0, 1:
+		var weight types.U64
+		err = decoder.Decode(&weight)
+		if err != nil {
+			return fmt.Errorf("decode Outcome weight error: %v", err)
+		}
+		o.Weight = uint64(weight)
+		if b == 0 {
+			return nil
+		}
+	case 2:
+	default:
+		return fmt.Errorf("unsupport Outcome type=%d", b)
+	}
+	o.Error, err = decodeXcmError(decoder, XcmVersion2)
+	return err
+}
+
+/*
+可读的执行结果，比如"Complete"、"Incomplete(TooExpensive)"、"Error(Barrier)"
+*/
+func (o XcmOutcomeV2) String() string {
+	switch o.Variant {
+	case 0:
+		return "Complete"
+	case 1:
+		return fmt.Sprintf("Incomplete(%s)", o.Error)
+	case 2:
+		return fmt.Sprintf("Error(%s)", o.Error)
+	}
+	return fmt.Sprintf("Unknown(%d)", o.Variant)
+}
+
+var (
+	xcmInstructionsV2 = []string{"WithdrawAsset", "ReserveAssetDeposited", "ReceiveTeleportedAsset", "QueryResponse",
+		"TransferAsset", "TransferReserveAsset", "Transact", "HrmpNewChannelOpenRequest", "HrmpChannelAccepted",
+		"HrmpChannelClosing", "ClearOrigin", "DescendOrigin", "ReportError", "DepositAsset", "DepositReserveAsset",
+		"ExchangeAsset", "InitiateReserveWithdraw", "InitiateTeleport", "QueryHolding", "BuyExecution", "RefundSurplus",
+		"SetErrorHandler", "SetAppendix", "ClearError", "ClaimAsset", "Trap", "SubscribeVersion", "UnsubscribeVersion"}
+	//V3在V2的基础上新增了28之后的指令，18由QueryHolding改为ReportHolding
+	xcmInstructionsV3 = append(append(append([]string{}, xcmInstructionsV2[:18]...), "ReportHolding"),
+		append(append([]string{}, xcmInstructionsV2[19:]...), "BurnAsset", "ExpectAsset", "ExpectOrigin",
+			"ExpectError", "ExpectTransactStatus", "QueryPallet", "ExpectPallet", "ReportTransactStatus",
+			"ClearTransactStatus", "UniversalOrigin", "ExportMessage", "LockAsset", "UnlockAsset", "NoteUnlockable",
+			"RequestUnlock", "SetFeesMode", "SetTopic", "ClearTopic", "AliasOrigin", "UnpaidExecution")...)
+	xcmErrorsV2 = []string{"Overflow", "Unimplemented", "UntrustedReserveLocation", "UntrustedTeleportLocation",
+		"MultiLocationFull", "MultiLocationNotInvertible", "BadOrigin", "InvalidLocation", "AssetNotFound",
+		"FailedToTransactAsset", "NotWithdrawable", "LocationCannotHold", "ExceedsMaxMessageSize",
+		"DestinationUnsupported", "Transport", "Unroutable", "UnknownClaim", "FailedToDecode", "MaxWeightInvalid",
+		"NotHoldingFees", "TooExpensive", "Trap", "UnhandledXcmVersion", "WeightLimitReached", "Barrier",
+		"WeightNotComputable"}
+	xcmErrorsV3 = append(append(append(append([]string{}, xcmErrorsV2[:4]...), "LocationFull", "LocationNotInvertible"),
+		xcmErrorsV2[6:22]...), "ExpectationFalse", "PalletNotFound",
+		"NameMismatch", "VersionIncompatible", "HoldingWouldOverflow", "ExportError", "ReanchorFailed", "NoDeal",
+		"FeesNotMet", "LockError", "NoPermission", "Unanchored", "NotDepositable", "UnhandledXcmVersion",
+		"WeightLimitReached", "Barrier", "WeightNotComputable", "ExceedsStackLimit")
+)
+
+func decodeXcm(decoder scale.Decoder, version uint8) ([]XcmInstruction, error) {
+	length, err := decodeCompactLength(decoder)
+	if err != nil {
+		return nil, fmt.Errorf("get instructions length error: %v", err)
+	}
+	var instructions []XcmInstruction
+	for i := 0; i < length; i++ {
+		instruction, err := decodeXcmInstruction(decoder, version)
+		if err != nil {
+			return nil, fmt.Errorf("decode instruction %d error: %v", i, err)
+		}
+		instructions = append(instructions, instruction)
+	}
+	return instructions, nil
+}
+
+func decodeXcmInstruction(decoder scale.Decoder, version uint8) (XcmInstruction, error) {
+	var ins XcmInstruction
+	b, err := decoder.ReadOneByte()
+	if err != nil {
+		return ins, err
+	}
+	names := xcmInstructionsV2
+	if version == XcmVersion3 {
+		names = xcmInstructionsV3
+	}
+	if int(b) >= len(names) {
+		return ins, fmt.Errorf("unsupport instruction type=%d", b)
+	}
+	ins.Instruction = names[b]
+	switch ins.Instruction {
+	case "WithdrawAsset", "ReserveAssetDeposited", "ReceiveTeleportedAsset", "BurnAsset", "ExpectAsset":
+		ins.Assets, err = decodeMultiAssets(decoder, version)
+	case "QueryResponse":
+		ins.Value, err = decodeQueryResponse(decoder, version)
+	case "TransferAsset", "ClaimAsset":
+		ins.Assets, err = decodeMultiAssets(decoder, version)
+		if err == nil {
+			ins.Location, err = decodeLocationPtr(decoder, version)
+		}
+	case "TransferReserveAsset":
+		ins.Assets, err = decodeMultiAssets(decoder, version)
+		if err == nil {
+			ins.Location, err = decodeLocationPtr(decoder, version)
+		}
+		if err == nil {
+			ins.Xcm, err = decodeXcm(decoder, version)
+		}
+	case "Transact":
+		ins.Value, err = decodeTransact(decoder, version)
+	case "HrmpNewChannelOpenRequest", "HrmpChannelClosing":
+		ins.Value, err = decodeCompactList(decoder, 3)
+	case "HrmpChannelAccepted", "Trap":
+		ins.Value, err = decodeCompactString(decoder)
+	case "ClearOrigin", "RefundSurplus", "ClearError", "UnsubscribeVersion", "ClearTransactStatus", "ClearTopic":
+	case "DescendOrigin":
+		var interior []Junction
+		interior, err = decodeJunctions(decoder, version)
+		ins.Location = &MultiLocation{Interior: interior}
+	case "ReportError", "ReportTransactStatus":
+		ins.Location, ins.Value, err = decodeReportInfo(decoder, version)
+	case "DepositAsset", "DepositReserveAsset", "InitiateReserveWithdraw", "InitiateTeleport":
+		ins.Assets, err = decodeMultiAssetFilter(decoder, version)
+		if err == nil && version == XcmVersion2 && (ins.Instruction == "DepositAsset" || ins.Instruction == "DepositReserveAsset") {
+			ins.Value, err = decodeCompactString(decoder) // max_assets
+		}
+		if err == nil {
+			ins.Location, err = decodeLocationPtr(decoder, version)
+		}
+		if err == nil && ins.Instruction != "DepositAsset" {
+			ins.Xcm, err = decodeXcm(decoder, version)
+		}
+	case "ExchangeAsset":
+		ins.Assets, err = decodeMultiAssetFilter(decoder, version)
+		if err == nil {
+			ins.Want, err = decodeMultiAssets(decoder, version)
+		}
+		if err == nil && version == XcmVersion3 {
+			var maximal types.Bool
+			err = decoder.Decode(&maximal)
+			ins.Value = fmt.Sprintf("maximal=%v", maximal)
+		}
+	case "QueryHolding":
+		var queryId string
+		queryId, err = decodeCompactString(decoder)
+		if err == nil {
+			ins.Location, err = decodeLocationPtr(decoder, version)
+		}
+		if err == nil {
+			ins.Assets, err = decodeMultiAssetFilter(decoder, version)
+		}
+		if err == nil {
+			var weight string
+			weight, err = decodeWeight(decoder, version)
+			ins.Value = fmt.Sprintf("query_id=%s,max_response_weight=%s", queryId, weight)
+		}
+	case "ReportHolding":
+		ins.Location, ins.Value, err = decodeReportInfo(decoder, version)
+		if err == nil {
+			ins.Assets, err = decodeMultiAssetFilter(decoder, version)
+		}
+	case "BuyExecution":
+		var fees MultiAsset
+		fees, err = decodeMultiAsset(decoder, version)
+		ins.Assets = []MultiAsset{fees}
+		if err == nil {
+			ins.Value, err = decodeWeightLimit(decoder, version)
+		}
+	case "SetErrorHandler", "SetAppendix":
+		ins.Xcm, err = decodeXcm(decoder, version)
+	case "SubscribeVersion":
+		var queryId, weight string
+		queryId, err = decodeCompactString(decoder)
+		if err == nil {
+			weight, err = decodeWeight(decoder, version)
+		}
+		ins.Value = fmt.Sprintf("query_id=%s,max_response_weight=%s", queryId, weight)
+	case "ExpectOrigin":
+		ins.Location, err = decodeOptionLocation(decoder, version)
+	case "ExpectError":
+		ins.Value, err = decodeOptionXcmError(decoder, version)
+	case "ExpectTransactStatus":
+		ins.Value, err = decodeMaybeErrorCode(decoder)
+	case "QueryPallet":
+		var moduleName string
+		moduleName, err = decodeBytesHex(decoder)
+		if err == nil {
+			ins.Location, ins.Value, err = decodeReportInfo(decoder, version)
+			ins.Value = fmt.Sprintf("module_name=%s,%s", moduleName, ins.Value)
+		}
+	case "ExpectPallet":
+		ins.Value, err = decodeExpectPallet(decoder)
+	case "UniversalOrigin":
+		var j Junction
+		j, err = decodeJunctionV3(decoder)
+		ins.Location = &MultiLocation{Interior: []Junction{j}}
+	case "ExportMessage":
+		var network string
+		network, err = decodeNetworkIdV3(decoder)
+		ins.Value = network
+		if err == nil {
+			var interior []Junction
+			interior, err = decodeJunctions(decoder, version)
+			ins.Location = &MultiLocation{Interior: interior}
+		}
+		if err == nil {
+			ins.Xcm, err = decodeXcm(decoder, version)
+		}
+	case "LockAsset", "UnlockAsset", "NoteUnlockable", "RequestUnlock":
+		var asset MultiAsset
+		asset, err = decodeMultiAsset(decoder, version)
+		ins.Assets = []MultiAsset{asset}
+		if err == nil {
+			ins.Location, err = decodeLocationPtr(decoder, version)
+		}
+	case "SetFeesMode":
+		var jitWithdraw types.Bool
+		err = decoder.Decode(&jitWithdraw)
+		ins.Value = fmt.Sprintf("jit_withdraw=%v", jitWithdraw)
+	case "SetTopic":
+		ins.Value, err = decodeFixedHex(decoder, 32)
+	case "AliasOrigin":
+		ins.Location, err = decodeLocationPtr(decoder, version)
+	case "UnpaidExecution":
+		ins.Value, err = decodeWeightLimit(decoder, version)
+		if err == nil {
+			ins.Location, err = decodeOptionLocation(decoder, version)
+		}
+	default:
+		return ins, fmt.Errorf("unsupport instruction %s", ins.Instruction)
+	}
+	if err != nil {
+		return ins, fmt.Errorf("decode %s error: %v", ins.Instruction, err)
+	}
+	return ins, nil
+}
+
+func decodeLocationPtr(decoder scale.Decoder, version uint8) (*MultiLocation, error) {
+	ml, err := decodeMultiLocation(decoder, version)
+	if err != nil {
+		return nil, err
+	}
+	return &ml, nil
+}
+
+func decodeOptionLocation(decoder scale.Decoder, version uint8) (*MultiLocation, error) {
+	b, err := decoder.ReadOneByte()
+	if err != nil {
+		return nil, err
+	}
+	if b == 0 {
+		return nil, nil
+	}
+	return decodeLocationPtr(decoder, version)
+}
+
+func decodeCompactList(decoder scale.Decoder, n int) (string, error) {
+	value := ""
+	for i := 0; i < n; i++ {
+		v, err := decodeCompactString(decoder)
+		if err != nil {
+			return "", err
+		}
+		if i > 0 {
+			value += ","
+		}
+		value += v
+	}
+	return value, nil
+}
+
+/*
+V2的Weight为Compact<u64>，V3为{ref_time: Compact<u64>, proof_size: Compact<u64>}
+*/
+func decodeWeight(decoder scale.Decoder, version uint8) (string, error) {
+	if version != XcmVersion3 {
+		return decodeCompactString(decoder)
+	}
+	refTime, err := decodeCompactString(decoder)
+	if err != nil {
+		return "", err
+	}
+	proofSize, err := decodeCompactString(decoder)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/%s", refTime, proofSize), nil
+}
+
+func decodeWeightLimit(decoder scale.Decoder, version uint8) (string, error) {
+	b, err := decoder.ReadOneByte()
+	if err != nil {
+		return "", err
+	}
+	switch b {
+	case 0:
+		return "Unlimited", nil
+	case 1:
+		weight, err := decodeWeight(decoder, version)
+		return fmt.Sprintf("Limited(%s)", weight), err
+	default:
+		return "", fmt.Errorf("unsupport weight limit type=%d", b)
+	}
+}
+
+/*
+Transact的call为编码后的bytes，结果为origin_kind、weight以及call的hex
+*/
+func decodeTransact(decoder scale.Decoder, version uint8) (string, error) {
+	originKind, err := decoder.ReadOneByte()
+	if err != nil {
+		return "", err
+	}
+	weight, err := decodeWeight(decoder, version)
+	if err != nil {
+		return "", err
+	}
+	call, err := decodeBytesHex(decoder)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("origin_kind=%d,require_weight_at_most=%s,call=0x%s", originKind, weight, call), nil
+}
+
+/*
+V2的ReportError为{query_id, dest, max_response_weight}，V3为QueryResponseInfo{destination, query_id, max_weight}
+*/
+func decodeReportInfo(decoder scale.Decoder, version uint8) (*MultiLocation, string, error) {
+	var (
+		dest    *MultiLocation
+		queryId string
+		err     error
+	)
+	if version == XcmVersion3 {
+		dest, err = decodeLocationPtr(decoder, version)
+		if err == nil {
+			queryId, err = decodeCompactString(decoder)
+		}
+	} else {
+		queryId, err = decodeCompactString(decoder)
+		if err == nil {
+			dest, err = decodeLocationPtr(decoder, version)
+		}
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	weight, err := decodeWeight(decoder, version)
+	if err != nil {
+		return nil, "", err
+	}
+	return dest, fmt.Sprintf("query_id=%s,max_weight=%s", queryId, weight), nil
+}
+
+func decodeMultiAssetFilter(decoder scale.Decoder, version uint8) ([]MultiAsset, error) {
+	b, err := decoder.ReadOneByte()
+	if err != nil {
+		return nil, err
+	}
+	switch b {
+	case 0:
+		return decodeMultiAssets(decoder, version)
+	case 1:
+		asset, err := decodeWildMultiAsset(decoder, version)
+		if err != nil {
+			return nil, err
+		}
+		return []MultiAsset{asset}, nil
+	default:
+		return nil, fmt.Errorf("unsupport MultiAssetFilter type=%d", b)
+	}
+}
+
+/*
+Wild的资产，Wildcard为All、AllOf、AllCounted:n或者AllOfCounted:n
+*/
+func decodeWildMultiAsset(decoder scale.Decoder, version uint8) (MultiAsset, error) {
+	var asset MultiAsset
+	b, err := decoder.ReadOneByte()
+	if err != nil {
+		return asset, err
+	}
+	if b > 1 && version != XcmVersion3 || b > 3 {
+		return asset, fmt.Errorf("unsupport WildMultiAsset type=%d", b)
+	}
+	asset.Wildcard = []string{"All", "AllOf", "AllCounted", "AllOfCounted"}[b]
+	if b == 1 || b == 3 {
+		err = decodeAssetId(decoder, version, &asset)
+		if err != nil {
+			return asset, err
+		}
+		fun, err := decoder.ReadOneByte()
+		if err != nil {
+			return asset, err
+		}
+		asset.Fungible = fun == 0
+	}
+	if b == 2 || b == 3 {
+		count, err := decodeCompactString(decoder)
+		if err != nil {
+			return asset, err
+		}
+		asset.Wildcard += ":" + count
+	}
+	return asset, nil
+}
+
+func decodeXcmError(decoder scale.Decoder, version uint8) (string, error) {
+	b, err := decoder.ReadOneByte()
+	if err != nil {
+		return "", err
+	}
+	names := xcmErrorsV2
+	if version == XcmVersion3 {
+		names = xcmErrorsV3
+	}
+	if int(b) >= len(names) {
+		return "", fmt.Errorf("unsupport xcm error type=%d", b)
+	}
+	name := names[b]
+	switch name {
+	case "Trap":
+		var code types.U64
+		err = decoder.Decode(&code)
+		return fmt.Sprintf("Trap(%d)", code), err
+	case "WeightLimitReached":
+		var weight string
+		if version == XcmVersion3 {
+			weight, err = decodeWeight(decoder, version)
+		} else {
+			var w types.U64
+			err = decoder.Decode(&w)
+			weight = fmt.Sprintf("%d", w)
+		}
+		return fmt.Sprintf("WeightLimitReached(%s)", weight), err
+	}
+	return name, nil
+}
+
+/*
+Option<(u32, Error)>，u32为出错的指令下标
+*/
+func decodeOptionXcmError(decoder scale.Decoder, version uint8) (string, error) {
+	b, err := decoder.ReadOneByte()
+	if err != nil {
+		return "", err
+	}
+	if b == 0 {
+		return "", nil
+	}
+	var index types.U32
+	err = decoder.Decode(&index)
+	if err != nil {
+		return "", err
+	}
+	name, err := decodeXcmError(decoder, version)
+	return fmt.Sprintf("%d:%s", index, name), err
+}
+
+func decodeMaybeErrorCode(decoder scale.Decoder) (string, error) {
+	b, err := decoder.ReadOneByte()
+	if err != nil {
+		return "", err
+	}
+	switch b {
+	case 0:
+		return "Success", nil
+	case 1, 2:
+		data, err := decodeBytesHex(decoder)
+		return fmt.Sprintf("%s(0x%s)", []string{"Error", "TruncatedError"}[b-1], data), err
+	default:
+		return "", fmt.Errorf("unsupport MaybeErrorCode type=%d", b)
+	}
+}
+
+func decodeExpectPallet(decoder scale.Decoder) (string, error) {
+	index, err := decodeCompactString(decoder)
+	if err != nil {
+		return "", err
+	}
+	name, err := decodeBytesHex(decoder)
+	if err != nil {
+		return "", err
+	}
+	moduleName, err := decodeBytesHex(decoder)
+	if err != nil {
+		return "", err
+	}
+	versions, err := decodeCompactList(decoder, 2)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("index=%s,name=%s,module_name=%s,crate_version=%s", index, name, moduleName, versions), nil
+}
+
+/*
+QueryResponse{query_id, response, max_weight}，V3最后还有querier: Option<MultiLocation>
+Response中的PalletsInfo暂不支持
+*/
+func decodeQueryResponse(decoder scale.Decoder, version uint8) (string, error) {
+	queryId, err := decodeCompactString(decoder)
+	if err != nil {
+		return "", err
+	}
+	b, err := decoder.ReadOneByte()
+	if err != nil {
+		return "", err
+	}
+	var response string
+	switch b {
+	case 0:
+		response = "Null"
+	case 1:
+		var assets []MultiAsset
+		assets, err = decodeMultiAssets(decoder, version)
+		response = fmt.Sprintf("Assets(%d)", len(assets))
+	case 2:
+		var result string
+		result, err = decodeOptionXcmError(decoder, version)
+		response = fmt.Sprintf("ExecutionResult(%s)", result)
+	case 3:
+		var v types.U32
+		err = decoder.Decode(&v)
+		response = fmt.Sprintf("Version(%d)", v)
+	case 5:
+		if version != XcmVersion3 {
+			return "", fmt.Errorf("unsupport response type=%d", b)
+		}
+		var result string
+		result, err = decodeMaybeErrorCode(decoder)
+		response = fmt.Sprintf("DispatchResult(%s)", result)
+	default:
+		return "", fmt.Errorf("unsupport response type=%d", b)
+	}
+	if err != nil {
+		return "", err
+	}
+	weight, err := decodeWeight(decoder, version)
+	if err != nil {
+		return "", err
+	}
+	if version == XcmVersion3 {
+		_, err = decodeOptionLocation(decoder, version)
+		if err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("query_id=%s,response=%s,max_weight=%s", queryId, response, weight), nil
+}
@@ -0,0 +1,149 @@
+package expand
+
+import (
+	"fmt"
+	"github.com/stafiprotocol/go-substrate-rpc-client/scale"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+/*
+扩展：OpenGov中ConvictionVoting的锁定以及投票信息
+https://github.com/paritytech/substrate/tree/master/frame/conviction-voting/src
+*/
+
+/*
+ConvictionVoting.ClassLocksFor中的一项，表示某个class锁定的金额
+*/
+type ClassLock struct {
+	Class   types.U16  `json:"class"`
+	Balance types.U128 `json:"balance"`
+}
+
+type Delegations struct {
+	Votes   types.U128 `json:"votes"`
+	Capital types.U128 `json:"capital"`
+}
+
+/*
+之前的投票在unlockAt之前锁定的金额
+*/
+type PriorLock struct {
+	UnlockAt types.U32  `json:"unlock_at"`
+	Balance  types.U128 `json:"balance"`
+}
+
+/*
+AccountVote：Standard、Split以及SplitAbstain
+Standard中vote的最高位为aye，低7位为conviction
+*/
+type AccountVote struct {
+	Type       string     `json:"type"`
+	Aye        bool       `json:"aye,omitempty"`
+	Conviction uint8      `json:"conviction,omitempty"`
+	Balance    types.U128 `json:"balance"`
+	AyeAmount  types.U128 `json:"aye_amount"`
+	NayAmount  types.U128 `json:"nay_amount"`
+	Abstain    types.U128 `json:"abstain"`
+}
+
+func (d *AccountVote) Decode(decoder scale.Decoder) error {
+	b, err := decoder.ReadOneByte()
+	if err != nil {
+		return fmt.Errorf("decode AccountVote: read type error: %v", err)
+	}
+	switch b {
+	case 0:
+		d.Type = "Standard"
+		vote, err := decoder.ReadOneByte()
+		if err != nil {
+			return fmt.Errorf("decode AccountVote: read vote error: %v", err)
+		}
+		d.Aye = vote&0x80 == 0x80
+		d.Conviction = vote & 0x7f
+		err = decoder.Decode(&d.Balance)
+		if err != nil {
+			return fmt.Errorf("decode AccountVote: decode balance error: %v", err)
+		}
+	case 1, 2:
+		d.Type = "Split"
+		err = decoder.Decode(&d.AyeAmount)
+		if err != nil {
+			return fmt.Errorf("decode AccountVote: decode aye error: %v", err)
+		}
+		err = decoder.Decode(&d.NayAmount)
+		if err != nil {
+			return fmt.Errorf("decode AccountVote: decode nay error: %v", err)
+		}
+		if b == 2 {
+			d.Type = "SplitAbstain"
+			err = decoder.Decode(&d.Abstain)
+			if err != nil {
+				return fmt.Errorf("decode AccountVote: decode abstain error: %v", err)
+			}
+		}
+	default:
+		return fmt.Errorf("decode AccountVote: unsupport type=%d", b)
+	}
+	return nil
+}
+
+type ReferendumVote struct {
+	PollIndex types.U32   `json:"poll_index"`
+	Vote      AccountVote `json:"vote"`
+}
+
+/*
+ConvictionVoting.VotingFor的值，是一个枚举：Casting(直接投票)或者Delegating(委托投票)
+*/
+type ConvictionVoting struct {
+	IsCasting    bool             `json:"is_casting"`
+	IsDelegating bool             `json:"is_delegating"`
+	Votes        []ReferendumVote `json:"votes,omitempty"`
+	Balance      types.U128       `json:"balance"`              //Delegating时委托的金额
+	Target       string           `json:"target,omitempty"`     //Delegating时委托对象的公钥
+	Conviction   uint8            `json:"conviction,omitempty"` //Delegating时的conviction
+	Delegations  Delegations      `json:"delegations"`
+	Prior        PriorLock        `json:"prior"`
+}
+
+func (d *ConvictionVoting) Decode(decoder scale.Decoder) error {
+	b, err := decoder.ReadOneByte()
+	if err != nil {
+		return fmt.Errorf("decode ConvictionVoting: read type error: %v", err)
+	}
+	switch b {
+	case 0:
+		d.IsCasting = true
+		err = decoder.Decode(&d.Votes)
+		if err != nil {
+			return fmt.Errorf("decode ConvictionVoting: decode votes error: %v", err)
+		}
+	case 1:
+		d.IsDelegating = true
+		err = decoder.Decode(&d.Balance)
+		if err != nil {
+			return fmt.Errorf("decode ConvictionVoting: decode balance error: %v", err)
+		}
+		var target types.AccountID
+		err = decoder.Decode(&target)
+		if err != nil {
+			return fmt.Errorf("decode ConvictionVoting: decode target error: %v", err)
+		}
+		d.Target = types.HexEncodeToString(target[:])
+		d.Conviction, err = decoder.ReadOneByte()
+		if err != nil {
+			return fmt.Errorf("decode ConvictionVoting: read conviction error: %v", err)
+		}
+	default:
+		return fmt.Errorf("decode ConvictionVoting: unsupport type=%d", b)
+	}
+	err = decoder.Decode(&d.Delegations)
+	if err != nil {
+		return fmt.Errorf("decode ConvictionVoting: decode delegations error: %v", err)
+	}
+	err = decoder.Decode(&d.Prior)
+	if err != nil {
+		return fmt.Errorf("decode ConvictionVoting: decode prior error: %v", err)
+	}
+	return nil
+}
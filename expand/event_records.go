@@ -2,10 +2,12 @@ package expand
 
 import (
 	"fmt"
+	"github.com/JFJun/bifrost-go/expand/base"
 	"github.com/JFJun/bifrost-go/expand/bifrost"
 	"github.com/JFJun/bifrost-go/expand/kusama"
 	"github.com/JFJun/bifrost-go/expand/polkadot"
 	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"math/big"
 	"reflect"
 	"strings"
 )
@@ -13,14 +15,37 @@ import (
 type IEventRecords interface {
 	GetBalancesTransfer() []types.EventBalancesTransfer
 	GetSystemExtrinsicSuccess() []types.EventSystemExtrinsicSuccess
-	GetSystemExtrinsicFailed() []types.EventSystemExtrinsicFailed
+	GetSystemExtrinsicFailed() []base.EventSystemExtrinsicFailed
+	GetBalancesEndowed() []types.EventBalancesEndowed
+	GetBalancesDeposit() []types.EventBalancesDeposit
+	GetBalancesWithdraw() []base.EventBalancesWithdraw
+	GetBalancesReserved() []types.EventBalancesReserved
+	GetBalancesUnreserved() []types.EventBalancesUnreserved
+	GetBalancesReserveRepatriated() []base.EventBalancesReserveRepatriated
+	GetBalancesDustLost() []types.EventBalancesDustLost
+	GetStakingSlash() []types.EventStakingSlash
+	GetOffencesOffence() []types.EventOffencesOffence
+	GetImOnlineSomeOffline() []types.EventImOnlineSomeOffline
+	GetProxyAnnounced() []base.EventProxyAnnounced
+	GetStakingUnbonded() []types.EventStakingUnbonded
+	GetStakingWithdrawn() []types.EventStakingWithdrawn
+	GetXcmAttempted() []base.EventXcmAttempted
+	GetXcmSent() []base.EventXcmSent
+	GetBagsListRebagged() []base.EventBagsListRebagged
+	GetTransactionFeePaid() []base.EventTransactionFeePaid
+	GetAssetsIssued() []types.EventAssetIssued
+	GetAssetsBurned() []base.EventAssetsBurned
 }
 
 /*
 扩展： 解析event
 */
 func DecodeEventRecords(meta *types.Metadata, rawData string, chainName string) (IEventRecords, error) {
-	e := types.EventRecordsRaw(types.MustHexDecodeString(rawData))
+	data, err := types.HexDecodeString(rawData)
+	if err != nil {
+		return nil, err
+	}
+	e := types.EventRecordsRaw(data)
 	var ier IEventRecords
 	switch strings.ToLower(chainName) {
 	case "polkadot":
@@ -50,6 +75,248 @@ func DecodeEventRecords(meta *types.Metadata, rawData string, chainName string)
 	return ier, nil
 }
 
+/*
+Balance为u64的链上，这些模块的event中的Balance参数为u64
+*/
+var nativeBalanceEventModules = map[string]bool{
+	"Balances":           true,
+	"TransactionPayment": true,
+	"Staking":            true,
+}
+
+/*
+按链的Balance类型的字节数（u128为16，u64为8）解析event
+为8时Balances、TransactionPayment以及Staking的event中types.U128的参数按u64解析，之后转换为types.U128，
+返回的结构与DecodeEventRecords一致
+*/
+func DecodeEventRecordsWithBalanceWidth(meta *types.Metadata, rawData string, chainName string, balanceWidth int) (IEventRecords, error) {
+	if balanceWidth != 8 {
+		return DecodeEventRecords(meta, rawData, chainName)
+	}
+	data, err := types.HexDecodeString(rawData)
+	if err != nil {
+		return nil, err
+	}
+	recordsType := eventRecordsType(chainName)
+	fields := flattenEventFields(recordsType)
+	narrowed := make([]reflect.StructField, len(fields))
+	for i, field := range fields {
+		narrowed[i] = field
+		if module := strings.SplitN(field.Name, "_", 2)[0]; nativeBalanceEventModules[module] {
+			narrowed[i].Type = reflect.SliceOf(narrowBalanceType(field.Type.Elem()))
+		}
+	}
+	decoded := reflect.New(reflect.StructOf(narrowed))
+	e := types.EventRecordsRaw(data)
+	err = e.DecodeEventRecords(meta, decoded.Interface())
+	if err != nil {
+		return nil, err
+	}
+	records := reflect.New(recordsType)
+	for _, field := range fields {
+		src := decoded.Elem().FieldByName(field.Name)
+		dst := records.Elem().FieldByName(field.Name)
+		if src.Type() == dst.Type() {
+			dst.Set(src)
+			continue
+		}
+		values := reflect.MakeSlice(dst.Type(), src.Len(), src.Len())
+		for i := 0; i < src.Len(); i++ {
+			widenBalanceValue(src.Index(i), values.Index(i))
+		}
+		dst.Set(values)
+	}
+	ier, ok := records.Interface().(IEventRecords)
+	if !ok {
+		return nil, fmt.Errorf("%v does not implement IEventRecords", recordsType)
+	}
+	return ier, nil
+}
+
+var (
+	u64Type  = reflect.TypeOf(types.U64(0))
+	u128Type = reflect.TypeOf(types.U128{})
+)
+
+/*
+将event结构中types.U128的字段替换为types.U64，没有这样的字段时返回原来的类型
+*/
+func narrowBalanceType(t reflect.Type) reflect.Type {
+	if t.Kind() != reflect.Struct {
+		return t
+	}
+	fields := make([]reflect.StructField, t.NumField())
+	changed := false
+	for i := 0; i < t.NumField(); i++ {
+		fields[i] = t.Field(i)
+		if fields[i].PkgPath != "" {
+			return t
+		}
+		if fields[i].Type == u128Type {
+			fields[i].Type = u64Type
+			changed = true
+		}
+	}
+	if !changed {
+		return t
+	}
+	return reflect.StructOf(fields)
+}
+
+/*
+将narrowBalanceType生成的结构的值复制到原来的event结构中，u64转换为types.U128
+*/
+func widenBalanceValue(src, dst reflect.Value) {
+	for i := 0; i < dst.NumField(); i++ {
+		value := src.Field(i)
+		if value.Type() == u64Type {
+			dst.Field(i).Set(reflect.ValueOf(types.NewU128(*new(big.Int).SetUint64(value.Uint()))))
+			continue
+		}
+		dst.Field(i).Set(value)
+	}
+}
+
+/*
+解析指定名字的event，返回的每一项与target的类型一致（target为指针时返回的也是指针）
+target必须是struct，第一个字段为types.Phase，最后一个字段为[]types.Hash（Topics），中间为event的参数
+可以用来解析库中没有实现的event
+*/
+func DecodeEventInto(meta *types.Metadata, eventsHex, chainName, module, event string, target interface{}) ([]interface{}, error) {
+	if target == nil {
+		return nil, fmt.Errorf("target is nil")
+	}
+	targetType := reflect.TypeOf(target)
+	isPtr := targetType.Kind() == reflect.Ptr
+	if isPtr {
+		targetType = targetType.Elem()
+	}
+	if targetType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("target must be a struct, but is %v", targetType)
+	}
+	name := fmt.Sprintf("%s_%s", module, event)
+	//使用链的event records中的所有event，并将指定event的类型替换为target的类型
+	var fields []reflect.StructField
+	found := false
+	for _, field := range flattenEventFields(eventRecordsType(chainName)) {
+		if field.Name == name {
+			field.Type = reflect.SliceOf(targetType)
+			found = true
+		}
+		fields = append(fields, field)
+	}
+	if !found {
+		fields = append(fields, reflect.StructField{Name: name, Type: reflect.SliceOf(targetType)})
+	}
+	data, err := types.HexDecodeString(eventsHex)
+	if err != nil {
+		return nil, err
+	}
+	records := reflect.New(reflect.StructOf(fields))
+	err = types.EventRecordsRaw(data).DecodeEventRecords(meta, records.Interface())
+	if err != nil {
+		return nil, err
+	}
+	values := records.Elem().FieldByName(name)
+	var result []interface{}
+	for i := 0; i < values.Len(); i++ {
+		if isPtr {
+			result = append(result, values.Index(i).Addr().Interface())
+		} else {
+			result = append(result, values.Index(i).Interface())
+		}
+	}
+	return result, nil
+}
+
+func eventRecordsType(chainName string) reflect.Type {
+	switch strings.ToLower(chainName) {
+	case "polkadot":
+		return reflect.TypeOf(polkadot.PolkadotEventRecords{})
+	case "kusama":
+		return reflect.TypeOf(kusama.KusamaEventRecords{})
+	default:
+		return reflect.TypeOf(bifrost.BifrostEventRecords{})
+	}
+}
+
+/*
+将嵌套的event records展开为一层的字段，同名字段与go的规则一致，层级浅的优先
+*/
+func flattenEventFields(tp reflect.Type) []reflect.StructField {
+	var (
+		fields []reflect.StructField
+		seen   = make(map[string]bool)
+		level  = []reflect.Type{tp}
+	)
+	for len(level) > 0 {
+		var next []reflect.Type
+		for _, t := range level {
+			for i := 0; i < t.NumField(); i++ {
+				field := t.Field(i)
+				if field.Anonymous && field.Type.Kind() == reflect.Struct {
+					next = append(next, field.Type)
+					continue
+				}
+				if field.PkgPath != "" || field.Type.Kind() != reflect.Slice || seen[field.Name] {
+					continue
+				}
+				seen[field.Name] = true
+				fields = append(fields, reflect.StructField{Name: field.Name, Type: field.Type})
+			}
+		}
+		level = next
+	}
+	return fields
+}
+
+/*
+由指定extrinsic产生的一个event，Data为event的具体类型（比如types.EventBalancesTransfer）
+*/
+type ExtrinsicEvent struct {
+	Module string
+	Event  string
+	Data   interface{}
+}
+
+/*
+从已解析的event records中找出Phase为ApplyExtrinsic(extrinsicIdx)的所有event
+event records按类型分组保存，所以返回的顺序为字段的顺序而不是event在区块中的顺序
+*/
+func EventsOfExtrinsic(ier IEventRecords, extrinsicIdx int) []ExtrinsicEvent {
+	records := reflect.ValueOf(ier)
+	if records.Kind() == reflect.Ptr {
+		records = records.Elem()
+	}
+	if records.Kind() != reflect.Struct {
+		return nil
+	}
+	var result []ExtrinsicEvent
+	for _, field := range flattenEventFields(records.Type()) {
+		values := records.FieldByName(field.Name)
+		parts := strings.SplitN(field.Name, "_", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		for i := 0; i < values.Len(); i++ {
+			value := values.Index(i)
+			if value.Kind() != reflect.Struct {
+				continue
+			}
+			phaseField := value.FieldByName("Phase")
+			if !phaseField.IsValid() {
+				continue
+			}
+			phase, ok := phaseField.Interface().(types.Phase)
+			if !ok || !phase.IsApplyExtrinsic || int(phase.AsApplyExtrinsic) != extrinsicIdx {
+				continue
+			}
+			result = append(result, ExtrinsicEvent{Module: parts[0], Event: parts[1], Data: value.Interface()})
+		}
+	}
+	return result
+}
+
 /*
 	func:检查指定结构是否实现了Meta中的所有Event
 */
@@ -66,12 +333,9 @@ func CheckIsImplementedAllEvent(meta *types.Metadata, eventRecordType reflect.Ty
 	}
 
 	// 获取所有没有实现的事件
-	for _, moduleItem := range meta.AsMetadataV13.Modules {
-		for _, eventItem := range moduleItem.Events {
-			eventName := fmt.Sprintf("%v_%v", moduleItem.Name, eventItem.Name)
-			if existFunc(eventName) == false {
-				noImplementedEvent = append(noImplementedEvent, eventName)
-			}
+	for _, eventName := range metadataEventNames(meta) {
+		if existFunc(eventName) == false {
+			noImplementedEvent = append(noImplementedEvent, eventName)
 		}
 	}
 
@@ -79,6 +343,45 @@ func CheckIsImplementedAllEvent(meta *types.Metadata, eventRecordType reflect.Ty
 	return
 }
 
+/*
+根据metadata的版本获取所有的event，格式为Module_Event
+*/
+func metadataEventNames(meta *types.Metadata) []string {
+	var names []string
+	add := func(module types.Text, events []types.EventMetadataV4) {
+		for _, eventItem := range events {
+			names = append(names, fmt.Sprintf("%v_%v", module, eventItem.Name))
+		}
+	}
+	switch {
+	case meta.IsMetadataV8:
+		for _, moduleItem := range meta.AsMetadataV8.Modules {
+			add(moduleItem.Name, moduleItem.Events)
+		}
+	case meta.IsMetadataV9:
+		for _, moduleItem := range meta.AsMetadataV9.Modules {
+			add(moduleItem.Name, moduleItem.Events)
+		}
+	case meta.IsMetadataV10:
+		for _, moduleItem := range meta.AsMetadataV10.Modules {
+			add(moduleItem.Name, moduleItem.Events)
+		}
+	case meta.IsMetadataV11:
+		for _, moduleItem := range meta.AsMetadataV11.Modules {
+			add(moduleItem.Name, moduleItem.Events)
+		}
+	case meta.IsMetadataV12:
+		for _, moduleItem := range meta.AsMetadataV12.Modules {
+			add(moduleItem.Name, moduleItem.Events)
+		}
+	case meta.IsMetadataV13:
+		for _, moduleItem := range meta.AsMetadataV13.Modules {
+			add(moduleItem.Name, moduleItem.Events)
+		}
+	}
+	return names
+}
+
 func GetAllImplementedEventList(tp reflect.Type) []string {
 	eventList := []string{}
 
@@ -6,11 +6,14 @@ package expand
 	这里编写都是为了与github.com/JFJun/substrate-go保持一制，所以会显得有点混乱
 */
 import (
+	"bytes"
 	"fmt"
 	"github.com/JFJun/bifrost-go/utils"
 	"github.com/huandu/xstrings"
 	"github.com/stafiprotocol/go-substrate-rpc-client/scale"
 	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"io"
+	"reflect"
 )
 
 type ExtrinsicDecoder struct {
@@ -20,15 +23,21 @@ type ExtrinsicDecoder struct {
 	Address             string           `json:"address"`
 	Signature           string           `json:"signature"`
 	SignatureVersion    int              `json:"signature_version"`
-	Nonce               int              `json:"nonce"`
+	Nonce               uint64           `json:"nonce"`
 	Era                 string           `json:"era"`
 	Tip                 string           `json:"tip"`
 	CallIndex           string           `json:"call_index"`
 	CallModule          string           `json:"call_module"`
 	CallModuleFunction  string           `json:"call_module_function"`
 	Params              []ExtrinsicParam `json:"params"`
-	me                  *MetadataExpand
-	Value               interface{}
+	//签名扩展无法解析时为true，此时era、nonce以及tip为空
+	SignatureUndecodable bool `json:"signature_undecodable"`
+	me                   *MetadataExpand
+	registry             *TypeRegistry
+	allowlist            map[string]bool
+	//call中不需要解析的剩余数据被直接读完时为true，此时读到末尾不代表call被截断
+	readToEnd bool
+	Value     interface{}
 }
 
 type ExtrinsicParam struct {
@@ -48,6 +57,22 @@ func NewExtrinsicDecoder(meta *types.Metadata) (*ExtrinsicDecoder, error) {
 	return ed, nil
 }
 
+/*
+设置自定义类型的注册表，没有硬编码的call会尝试使用注册的类型解析参数
+*/
+func (ed *ExtrinsicDecoder) SetTypeRegistry(registry *TypeRegistry) {
+	ed.registry = registry
+}
+
+/*
+设置需要解析参数的模块（比如"Balances"）或者call（比如"Balances.transfer"），
+不在其中的call只解析出模块名以及方法名，不再解析参数；为空时解析所有的call
+只对extrinsic最外层的call生效，Utility.batch等包装中的call总是完整解析
+*/
+func (ed *ExtrinsicDecoder) SetCallAllowlist(allowlist map[string]bool) {
+	ed.allowlist = allowlist
+}
+
 func (ed *ExtrinsicDecoder) ProcessExtrinsicDecoder(decoder scale.Decoder) error {
 	var length types.UCompact
 	err := decoder.Decode(&length)
@@ -96,33 +121,20 @@ func (ed *ExtrinsicDecoder) ProcessExtrinsicDecoder(decoder scale.Decoder) error
 				}
 				ed.Signature = sig.Hex()
 			}
-			// 4. 解析era
-			var era types.ExtrinsicEra
-			err = decoder.Decode(&era)
-			if err != nil {
-				return fmt.Errorf("decode extrinsic: decode era error: %v", err)
-			}
-			if era.IsMortalEra {
-				eraBytes := []byte{era.AsMortalEra.First, era.AsMortalEra.Second}
-				ed.Era = utils.BytesToHex(eraBytes)
-			}
-			//5. 解析nonce
-			var nonce types.UCompact
-			err = decoder.Decode(&nonce)
+			// 4. 解析签名扩展(era,nonce,tip)，解析失败时尝试直接定位call，避免丢失call的信息
+			rest, err := readRemaining(decoder)
 			if err != nil {
-				return fmt.Errorf("decode extrinsic: decode nonce error: %v", err)
+				return fmt.Errorf("decode extrinsic: read signed extra error: %v", err)
 			}
-			//new
-
-			ed.Nonce = int(utils.UCompactToBigInt(nonce).Int64())
-			// 6.解析tip
-			var tip types.UCompact
-
-			err = decoder.Decode(&tip)
+			callData, err := ed.decodeSignedExtra(rest)
 			if err != nil {
-				return fmt.Errorf("decode tip error: %v", err)
+				callData = ed.findCall(rest)
+				if callData == nil {
+					return fmt.Errorf("decode extrinsic: %v", err)
+				}
+				ed.SignatureUndecodable = true
 			}
-			ed.Tip = fmt.Sprintf("%d", utils.UCompactToBigInt(tip).Int64())
+			decoder = *scale.NewDecoder(bytes.NewReader(callData))
 		}
 		//处理callIndex
 		callIndex := make([]byte, 2)
@@ -130,16 +142,15 @@ func (ed *ExtrinsicDecoder) ProcessExtrinsicDecoder(decoder scale.Decoder) error
 		if err != nil {
 			return fmt.Errorf("decode extrinsic: read call index bytes error: %v", err)
 		}
-		ed.CallIndex = xstrings.RightJustify(utils.IntToHex(callIndex[0]), 2, "0") +
-			xstrings.RightJustify(utils.IntToHex(callIndex[1]), 2, "0")
+		ed.CallIndex = callIndexHex(callIndex[0], callIndex[1])
 	} else {
 		return fmt.Errorf("extrinsics version %s is not support", ed.VersionInfo)
 	}
 	if ed.CallIndex != "" {
-		_ = ed.decodeCallIndex(decoder)
-		//if err != nil {
-		//	return err
-		//}
+		err = ed.decodeCallIndex(decoder)
+		if err != nil {
+			return fmt.Errorf("decode extrinsic: %v", err)
+		}
 	}
 	result := map[string]interface{}{
 		"extrinsic_length": ed.ExtrinsicLength,
@@ -150,6 +161,7 @@ func (ed *ExtrinsicDecoder) ProcessExtrinsicDecoder(decoder scale.Decoder) error
 		result["signature"] = ed.Signature
 		result["nonce"] = ed.Nonce
 		result["era"] = ed.Era
+		result["signature_undecodable"] = ed.SignatureUndecodable
 	}
 	if ed.CallIndex != "" {
 		result["call_code"] = ed.CallIndex
@@ -165,8 +177,130 @@ func (ed *ExtrinsicDecoder) ProcessExtrinsicDecoder(decoder scale.Decoder) error
 	return nil
 }
 
-func (ed *ExtrinsicDecoder) decodeCallIndex(decoder scale.Decoder) error {
-	var err error
+/*
+解析签名扩展中的era、nonce以及tip，返回剩下的call数据
+*/
+func (ed *ExtrinsicDecoder) decodeSignedExtra(data []byte) ([]byte, error) {
+	reader := bytes.NewReader(data)
+	decoder := scale.NewDecoder(reader)
+	// 1. 解析era
+	var era types.ExtrinsicEra
+	err := decoder.Decode(&era)
+	if err != nil {
+		return nil, fmt.Errorf("decode era error: %v", err)
+	}
+	// 2. 解析nonce
+	var nonce types.UCompact
+	err = decoder.Decode(&nonce)
+	if err != nil {
+		return nil, fmt.Errorf("decode nonce error: %v", err)
+	}
+	// 3. 解析tip
+	var tip types.UCompact
+	err = decoder.Decode(&tip)
+	if err != nil {
+		return nil, fmt.Errorf("decode tip error: %v", err)
+	}
+	callData := data[len(data)-reader.Len():]
+	//存在未知的签名扩展时，紧跟着的数据就不是合法的call index
+	if len(callData) < 2 {
+		return nil, fmt.Errorf("decode signed extra error: call data is too short")
+	}
+	_, _, err = ed.me.MV.FindNameByCallIndex(callIndexHex(callData[0], callData[1]))
+	if err != nil {
+		return nil, fmt.Errorf("decode signed extra error: unknown call index after tip: %v", err)
+	}
+	//未知签名扩展的第一个字节为0x00时，后面的数据也会被当作合法的call index（System模块），
+	//所以tip之后的数据不能被完整解析为call、而在其后面能找到完整的call时，认为存在未知的签名扩展
+	if !ed.decodesCall(callData) {
+		if ed.findCall(callData[1:]) != nil {
+			return nil, fmt.Errorf("decode signed extra error: call data after tip is not a complete call")
+		}
+	}
+	if era.IsMortalEra {
+		eraBytes := []byte{era.AsMortalEra.First, era.AsMortalEra.Second}
+		ed.Era = utils.BytesToHex(eraBytes)
+	}
+	ed.Nonce = utils.UCompactToBigInt(nonce).Uint64()
+	ed.Tip = fmt.Sprintf("%d", utils.UCompactToBigInt(tip).Int64())
+	return callData, nil
+}
+
+/*
+签名扩展无法解析时，逐个位置尝试解析call，只有call的参数能被完整解析时才认为找到了call
+找不到时返回nil
+*/
+func (ed *ExtrinsicDecoder) findCall(data []byte) []byte {
+	for offset := 0; offset+2 <= len(data); offset++ {
+		if ed.decodesCall(data[offset:]) {
+			return data[offset:]
+		}
+	}
+	return nil
+}
+
+/*
+data是否刚好是一个能被完整解析的call：call index存在，参数被解析出来，并且没有读到末尾之后也没有剩余的数据
+*/
+func (ed *ExtrinsicDecoder) decodesCall(data []byte) bool {
+	if len(data) < 2 {
+		return false
+	}
+	callIndex := callIndexHex(data[0], data[1])
+	if _, _, err := ed.me.MV.FindNameByCallIndex(callIndex); err != nil {
+		return false
+	}
+	trial := &ExtrinsicDecoder{me: ed.me, registry: ed.registry, CallIndex: callIndex}
+	reader := &eofReader{Reader: bytes.NewReader(data[2:])}
+	err := trial.decodeCallIndex(*scale.NewDecoder(reader))
+	return err == nil && (!reader.eof || trial.readToEnd) && reader.Len() == 0 && len(trial.Params) > 0
+}
+
+/*
+记录是否读到了数据的末尾之后：scale解析Compact时会忽略第一个字节的EOF（结果为0），
+不检查的话被截断的call也会被当作完整解析
+*/
+type eofReader struct {
+	*bytes.Reader
+	eof bool
+}
+
+func (r *eofReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if err == io.EOF && len(p) > 0 {
+		r.eof = true
+	}
+	return n, err
+}
+
+func callIndexHex(moduleIndex, callIndex byte) string {
+	return xstrings.RightJustify(utils.IntToHex(moduleIndex), 2, "0") +
+		xstrings.RightJustify(utils.IntToHex(callIndex), 2, "0")
+}
+
+func readRemaining(decoder scale.Decoder) ([]byte, error) {
+	var data []byte
+	for {
+		b, err := decoder.ReadOneByte()
+		if err == io.EOF {
+			return data, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, b)
+	}
+}
+
+/*
+读取call中剩下的所有数据
+*/
+func (ed *ExtrinsicDecoder) readCallRemaining(decoder scale.Decoder) ([]byte, error) {
+	ed.readToEnd = true
+	return readRemaining(decoder)
+}
+
+func (ed *ExtrinsicDecoder) decodeCallIndex(decoder scale.Decoder) (err error) {
 	//避免指针为空
 	defer func() {
 		if errs := recover(); errs != nil {
@@ -182,6 +316,9 @@ func (ed *ExtrinsicDecoder) decodeCallIndex(decoder scale.Decoder) error {
 	}
 	ed.CallModule = modName
 	ed.CallModuleFunction = callName
+	if len(ed.allowlist) > 0 && !ed.allowlist[modName] && !ed.allowlist[modName+"."+callName] {
+		return nil
+	}
 	switch modName {
 	case "Timestamp":
 		if callName == "set" {
@@ -199,24 +336,62 @@ func (ed *ExtrinsicDecoder) decodeCallIndex(decoder scale.Decoder) error {
 					Value: utils.UCompactToBigInt(u).Int64(),
 				})
 		}
+	case "ParachainSystem":
+		if callName == "set_validation_data" {
+			//ParachainInherentData的第一个字段为PersistedValidationData，
+			//只解析其中的parent_head、relay_parent_number以及relay_parent_storage_root，后面的relay_chain_state等跳过
+			var parentHead types.Bytes
+			err = decoder.Decode(&parentHead)
+			if err != nil {
+				return fmt.Errorf("decode call: decode ParachainSystem.set_validation_data parent_head error: %v", err)
+			}
+			var number types.U32
+			err = decoder.Decode(&number)
+			if err != nil {
+				return fmt.Errorf("decode call: decode ParachainSystem.set_validation_data relay_parent_number error: %v", err)
+			}
+			var root types.Hash
+			err = decoder.Decode(&root)
+			if err != nil {
+				return fmt.Errorf("decode call: decode ParachainSystem.set_validation_data relay_parent_storage_root error: %v", err)
+			}
+			ed.Params = append(ed.Params,
+				ExtrinsicParam{
+					Name:  "relay_parent_number",
+					Type:  "RelayChainBlockNumber",
+					Value: uint32(number),
+				},
+				ExtrinsicParam{
+					Name:  "relay_parent_storage_root",
+					Type:  "H256",
+					Value: root.Hex(),
+				})
+		}
+	case "System":
+		if callName == "remark" || callName == "remark_with_event" {
+			// 0 ---> Bytes
+			var remark types.Bytes
+			err = decoder.Decode(&remark)
+			if err != nil {
+				return fmt.Errorf("decode call: decode System.remark error: %v", err)
+			}
+			ed.Params = append(ed.Params,
+				ExtrinsicParam{
+					Name:     "remark",
+					Type:     "Bytes",
+					Value:    utils.BytesToHex(remark),
+					ValueRaw: utils.BytesToHex(remark),
+				})
+		}
 	case "Balances":
 		if callName == "transfer" || callName == "transfer_keep_alive" {
 			// 0 ---> 	Address
-			var addrValue string
 			var address MultiAddress
 			err = decoder.Decode(&address)
 			if err != nil {
 				return fmt.Errorf("decode call: decode Balances.transfer.Address error: %v", err)
 			}
-			addrValue = utils.BytesToHex(address.AccountId[:])
-
-			ed.Params = append(ed.Params,
-				ExtrinsicParam{
-					Name:     "dest",
-					Type:     "Address",
-					Value:    addrValue,
-					ValueRaw: addrValue,
-				})
+			ed.Params = append(ed.Params, address.ToParam("dest"))
 			// 1 ----> Compact<Balance>
 			var b types.UCompact
 			err = decoder.Decode(&b)
@@ -234,50 +409,539 @@ func (ed *ExtrinsicDecoder) decodeCallIndex(decoder scale.Decoder) error {
 	case "Utility":
 		if callName == "batch" {
 			// 0--> calls   Vec<Call>
-			var tc TransferCall
-			vec := new(Vec)
-			err := vec.ProcessVec(decoder, tc)
+			var u types.UCompact
+			err = decoder.Decode(&u)
 			if err != nil {
-				return fmt.Errorf("decode call: decode Utility.batch error: %v", err)
+				return fmt.Errorf("decode call: decode Utility.batch length error: %v", err)
+			}
+			length := int(utils.UCompactToBigInt(u).Int64())
+			if length > 5000 {
+				return fmt.Errorf("decode call: Utility.batch length %d exceeds %d", length, 5000)
 			}
-			//utils.CheckStructData(vec.Value)
 			ep := ExtrinsicParam{}
 			ep.Name = "calls"
 			ep.Type = "Vec<Call>"
 			var result []interface{}
-			for _, value := range vec.Value {
-				tcv := value.(*TransferCall)
-				//检查一下是否为BalanceTransfer
-				data := tcv.Value.(map[string]interface{})
-				callIndex := data["call_index"].(string)
-				btCallIdx, err := ed.me.MV.GetCallIndex("Balances", "transfer")
-				if err != nil {
-					return fmt.Errorf("decode Utility.batch: get  Balances.transfer call index error: %v", err)
-				}
-				btkaCallIdx, err := ed.me.MV.GetCallIndex("Balances", "transfer_keep_alive")
+			for i := 0; i < length; i++ {
+				data, err := ed.decodeInnerCall(decoder)
 				if err != nil {
-					return fmt.Errorf("decode Utility.batch: get  Balances.transfer_keep_alive call index error: %v", err)
+					return fmt.Errorf("decode call: decode Utility.batch call %d error: %v", i, err)
 				}
-				if callIndex == btCallIdx || callIndex == btkaCallIdx {
-					mn, cn, err := ed.me.MV.FindNameByCallIndex(callIndex)
-					if err != nil {
-						return fmt.Errorf("decode Utility.batch: get call index error: %v", err)
-					}
-					if mn != "Balances" {
-						return fmt.Errorf("decode Utility.batch:  call module name is not 'Balances' ,NAME=%s", mn)
-					}
-					data["call_function"] = cn
-					data["call_module"] = mn
-					result = append(result, data)
+				//只保留转账以及remark(memo)，其他的call无法确定长度，后面的call也无法继续解析
+				if !isBatchSupportedCall(data["call_module"].(string), data["call_function"].(string)) {
+					break
 				}
+				result = append(result, data)
 			}
 			ep.Value = result
 			ed.Params = append(ed.Params, ep)
 		}
+		if callName == "as_derivative" {
+			// 0--> index  u16
+			var index types.U16
+			err = decoder.Decode(&index)
+			if err != nil {
+				return fmt.Errorf("decode call: decode Utility.as_derivative.index error: %v", err)
+			}
+			ed.Params = append(ed.Params,
+				ExtrinsicParam{
+					Name:  "index",
+					Type:  "u16",
+					Value: uint16(index),
+				})
+			// 1--> call  Call
+			innerCall, err := ed.decodeInnerCall(decoder)
+			if err != nil {
+				return fmt.Errorf("decode call: decode Utility.as_derivative.call error: %v", err)
+			}
+			ed.Params = append(ed.Params,
+				ExtrinsicParam{
+					Name:  "call",
+					Type:  "Call",
+					Value: innerCall,
+				})
+		}
+		if callName == "dispatch_as" {
+			// 0--> as_origin  Box<PalletsOrigin>
+			data, err := ed.readCallRemaining(decoder)
+			if err != nil {
+				return fmt.Errorf("decode call: read Utility.dispatch_as data error: %v", err)
+			}
+			origin, callData, err := ed.decodeDispatchOrigin(data)
+			if err != nil {
+				return fmt.Errorf("decode call: decode Utility.dispatch_as.as_origin error: %v", err)
+			}
+			ed.Params = append(ed.Params, origin)
+			// 1--> call  Call
+			innerCall, err := ed.decodeInnerCall(*scale.NewDecoder(bytes.NewReader(callData)))
+			if err != nil {
+				return fmt.Errorf("decode call: decode Utility.dispatch_as.call error: %v", err)
+			}
+			ed.Params = append(ed.Params,
+				ExtrinsicParam{
+					Name:  "call",
+					Type:  "Call",
+					Value: innerCall,
+				})
+		}
+	case "Proxy":
+		if callName == "announce" {
+			// 0--> real  Address
+			var real MultiAddress
+			err = decoder.Decode(&real)
+			if err != nil {
+				return fmt.Errorf("decode call: decode Proxy.announce.real error: %v", err)
+			}
+			ed.Params = append(ed.Params, real.ToParam("real"))
+			// 1--> call_hash  CallHashOf
+			var callHash types.Hash
+			err = decoder.Decode(&callHash)
+			if err != nil {
+				return fmt.Errorf("decode call: decode Proxy.announce.call_hash error: %v", err)
+			}
+			ed.Params = append(ed.Params,
+				ExtrinsicParam{
+					Name:     "call_hash",
+					Type:     "CallHashOf",
+					Value:    callHash.Hex(),
+					ValueRaw: utils.BytesToHex(callHash[:]),
+				})
+		}
+		if callName == "proxy_announced" {
+			// 0--> delegate  Address
+			var delegate MultiAddress
+			err = decoder.Decode(&delegate)
+			if err != nil {
+				return fmt.Errorf("decode call: decode Proxy.proxy_announced.delegate error: %v", err)
+			}
+			ed.Params = append(ed.Params, delegate.ToParam("delegate"))
+			// 1--> real  Address
+			var real MultiAddress
+			err = decoder.Decode(&real)
+			if err != nil {
+				return fmt.Errorf("decode call: decode Proxy.proxy_announced.real error: %v", err)
+			}
+			ed.Params = append(ed.Params, real.ToParam("real"))
+			// 2--> force_proxy_type  Option<ProxyType>，ProxyType为只有下标的enum
+			var hasProxyType bool
+			err = decoder.Decode(&hasProxyType)
+			if err != nil {
+				return fmt.Errorf("decode call: decode Proxy.proxy_announced.force_proxy_type error: %v", err)
+			}
+			forceProxyType := ExtrinsicParam{Name: "force_proxy_type", Type: "Option<ProxyType>"}
+			if hasProxyType {
+				var proxyType types.U8
+				err = decoder.Decode(&proxyType)
+				if err != nil {
+					return fmt.Errorf("decode call: decode Proxy.proxy_announced.force_proxy_type error: %v", err)
+				}
+				forceProxyType.Value = uint8(proxyType)
+			}
+			ed.Params = append(ed.Params, forceProxyType)
+			// 3--> call  Call
+			innerCall, err := ed.decodeInnerCall(decoder)
+			if err != nil {
+				return fmt.Errorf("decode call: decode Proxy.proxy_announced.call error: %v", err)
+			}
+			ed.Params = append(ed.Params,
+				ExtrinsicParam{
+					Name:  "call",
+					Type:  "Call",
+					Value: innerCall,
+				})
+		}
+	case "Staking":
+		if callName == "unbond" {
+			// 0--> value  Compact<BalanceOf>
+			var value types.UCompact
+			err = decoder.Decode(&value)
+			if err != nil {
+				return fmt.Errorf("decode call: decode Staking.unbond.value error: %v", err)
+			}
+			ed.Params = append(ed.Params,
+				ExtrinsicParam{
+					Name:  "value",
+					Type:  "Compact<BalanceOf>",
+					Value: utils.UCompactToBigInt(value).String(),
+				})
+		}
+		if callName == "withdraw_unbonded" {
+			// 0--> num_slashing_spans  u32
+			var spans types.U32
+			err = decoder.Decode(&spans)
+			if err != nil {
+				return fmt.Errorf("decode call: decode Staking.withdraw_unbonded.num_slashing_spans error: %v", err)
+			}
+			ed.Params = append(ed.Params,
+				ExtrinsicParam{
+					Name:  "num_slashing_spans",
+					Type:  "u32",
+					Value: uint32(spans),
+				})
+		}
+		if callName == "set_payee" {
+			// 0--> payee  RewardDestination
+			var payee RewardDestination
+			err = decoder.Decode(&payee)
+			if err != nil {
+				return fmt.Errorf("decode call: decode Staking.set_payee.payee error: %v", err)
+			}
+			ed.Params = append(ed.Params,
+				ExtrinsicParam{
+					Name:     "payee",
+					Type:     "RewardDestination",
+					Value:    payee.Type,
+					ValueRaw: payee.Account,
+				})
+		}
+		if callName == "set_controller" {
+			//早期的runtime中有controller参数，之后没有参数
+			args, _ := ed.me.MV.FindCallArgs(ed.CallIndex)
+			if len(args) > 0 {
+				param, err := ed.decodeAccountArg(decoder)
+				if err != nil {
+					return fmt.Errorf("decode call: decode Staking.set_controller.controller error: %v", err)
+				}
+				param.Name = "controller"
+				ed.Params = append(ed.Params, param)
+			}
+		}
+	case "PhragmenElection", "Elections", "ElectionsPhragmen":
+		if callName == "vote" {
+			// 0--> votes  Vec<AccountId>
+			var votes []types.AccountID
+			err = decoder.Decode(&votes)
+			if err != nil {
+				return fmt.Errorf("decode call: decode %s.vote.votes error: %v", modName, err)
+			}
+			candidates := make([]string, len(votes))
+			for i, vote := range votes {
+				candidates[i] = utils.BytesToHex(vote[:])
+			}
+			ed.Params = append(ed.Params,
+				ExtrinsicParam{
+					Name:  "votes",
+					Type:  "Vec<AccountId>",
+					Value: candidates,
+				})
+			// 1--> value  Compact<BalanceOf>
+			var value types.UCompact
+			err = decoder.Decode(&value)
+			if err != nil {
+				return fmt.Errorf("decode call: decode %s.vote.value error: %v", modName, err)
+			}
+			ed.Params = append(ed.Params,
+				ExtrinsicParam{
+					Name:  "value",
+					Type:  "Compact<BalanceOf>",
+					Value: utils.UCompactToBigInt(value).String(),
+				})
+		}
+		if callName == "submit_candidacy" {
+			// 0--> candidate_count  Compact<u32>
+			var count types.UCompact
+			err = decoder.Decode(&count)
+			if err != nil {
+				return fmt.Errorf("decode call: decode %s.submit_candidacy.candidate_count error: %v", modName, err)
+			}
+			ed.Params = append(ed.Params,
+				ExtrinsicParam{
+					Name:  "candidate_count",
+					Type:  "Compact<u32>",
+					Value: utils.UCompactToBigInt(count).Uint64(),
+				})
+		}
+	case "Assets":
+		if callName == "mint" || callName == "burn" || callName == "force_transfer" {
+			//其它链上同名的Assets模块（比如bifrost自己的assets）参数不同，按照自定义类型解析
+			if !ed.isPalletAssetsCall(callName) {
+				return ed.decodeRegisteredCall(decoder)
+			}
+			// 0--> id  Compact<AssetId>
+			var id types.UCompact
+			err = decoder.Decode(&id)
+			if err != nil {
+				return fmt.Errorf("decode call: decode Assets.%s.id error: %v", callName, err)
+			}
+			ed.Params = append(ed.Params,
+				ExtrinsicParam{
+					Name:  "id",
+					Type:  "Compact<AssetId>",
+					Value: utils.UCompactToBigInt(id).Uint64(),
+				})
+			// mint: 1--> beneficiary，burn: 1--> who，force_transfer: 1--> source 2--> dest
+			accounts := []string{"beneficiary"}
+			switch callName {
+			case "burn":
+				accounts = []string{"who"}
+			case "force_transfer":
+				accounts = []string{"source", "dest"}
+			}
+			for _, name := range accounts {
+				var address MultiAddress
+				err = decoder.Decode(&address)
+				if err != nil {
+					return fmt.Errorf("decode call: decode Assets.%s.%s error: %v", callName, name, err)
+				}
+				ed.Params = append(ed.Params, address.ToParam(name))
+			}
+			// amount  Compact<Balance>
+			var amount types.UCompact
+			err = decoder.Decode(&amount)
+			if err != nil {
+				return fmt.Errorf("decode call: decode Assets.%s.amount error: %v", callName, err)
+			}
+			ed.Params = append(ed.Params,
+				ExtrinsicParam{
+					Name:  "amount",
+					Type:  "Compact<Balance>",
+					Value: utils.UCompactToBigInt(amount).String(),
+				})
+		}
+	case "BagsList", "VoterList":
+		if callName == "rebag" || callName == "put_in_front_of" {
+			// rebag: 0--> dislocated  AccountId
+			// put_in_front_of: 0--> lighter  AccountId
+			name := "dislocated"
+			if callName == "put_in_front_of" {
+				name = "lighter"
+			}
+			param, err := ed.decodeAccountArg(decoder)
+			if err != nil {
+				return fmt.Errorf("decode call: decode %s.%s.%s error: %v", modName, callName, name, err)
+			}
+			param.Name = name
+			ed.Params = append(ed.Params, param)
+		}
+	case "PolkadotXcm", "XcmPallet":
+		if callName == "send" {
+			// 0--> dest  VersionedMultiLocation
+			var dest VersionedMultiLocation
+			err = decoder.Decode(&dest)
+			if err != nil {
+				return fmt.Errorf("decode call: decode %s.send.dest error: %v", modName, err)
+			}
+			ed.Params = append(ed.Params,
+				ExtrinsicParam{
+					Name:  "dest",
+					Type:  "VersionedMultiLocation",
+					Value: dest,
+				})
+		}
+		if callName == "send" || callName == "execute" {
+			// send: 1--> message  VersionedXcm
+			// execute: 0--> message  VersionedXcm，之后的max_weight不需要解析，直接跳过
+			var message VersionedXcm
+			err = decoder.Decode(&message)
+			if err != nil {
+				return fmt.Errorf("decode call: decode %s.%s.message error: %v", modName, callName, err)
+			}
+			ed.Params = append(ed.Params,
+				ExtrinsicParam{
+					Name:  "message",
+					Type:  "VersionedXcm",
+					Value: message,
+				})
+			if callName == "execute" {
+				_, err = ed.readCallRemaining(decoder)
+				if err != nil {
+					return fmt.Errorf("decode call: read %s.%s.max_weight error: %v", modName, callName, err)
+				}
+			}
+		}
 	default:
-		// unsopport
-		return nil
+		// 没有硬编码的call，尝试使用注册的自定义类型解析
+		return ed.decodeRegisteredCall(decoder)
+	}
+	return nil
+}
+
+/*
+pallet-assets中mint、burn以及force_transfer的参数名字
+*/
+var palletAssetsCallArgs = map[string][]string{
+	"mint":           {"id", "beneficiary", "amount"},
+	"burn":           {"id", "who", "amount"},
+	"force_transfer": {"id", "source", "dest", "amount"},
+}
+
+func (ed *ExtrinsicDecoder) isPalletAssetsCall(callName string) bool {
+	args, err := ed.me.MV.FindCallArgs(ed.CallIndex)
+	if err != nil || len(args) != len(palletAssetsCallArgs[callName]) {
+		return false
+	}
+	for i, name := range palletAssetsCallArgs[callName] {
+		if string(args[i].Name) != name {
+			return false
+		}
+	}
+	return true
+}
 
+/*
+解析call的第一个账户参数，早期的runtime中为AccountId，之后改为AccountIdLookupOf（MultiAddress）
+根据metadata中参数的类型判断
+*/
+func (ed *ExtrinsicDecoder) decodeAccountArg(decoder scale.Decoder) (ExtrinsicParam, error) {
+	args, err := ed.me.MV.FindCallArgs(ed.CallIndex)
+	if err == nil && len(args) > 0 && (args[0].Type == "T::AccountId" || args[0].Type == "AccountId") {
+		var account types.AccountID
+		err = decoder.Decode(&account)
+		if err != nil {
+			return ExtrinsicParam{}, err
+		}
+		raw := utils.BytesToHex(account[:])
+		return ExtrinsicParam{Type: "AccountId", Value: raw, ValueRaw: raw}, nil
+	}
+	var address MultiAddress
+	err = decoder.Decode(&address)
+	if err != nil {
+		return ExtrinsicParam{}, err
+	}
+	return address.ToParam(""), nil
+}
+
+/*
+使用注册表中的类型按metadata中参数的顺序解析call，有参数的类型没有注册时不解析（与不支持的call一样）
+Value为解码后的值，ValueRaw为参数的scale编码
+*/
+func (ed *ExtrinsicDecoder) decodeRegisteredCall(decoder scale.Decoder) error {
+	if ed.registry == nil {
+		return nil
+	}
+	args, err := ed.me.MV.FindCallArgs(ed.CallIndex)
+	if err != nil {
+		return nil
+	}
+	argTypes := make([]reflect.Type, len(args))
+	for i, arg := range args {
+		t, ok := ed.registry.Lookup(string(arg.Type))
+		if !ok {
+			return nil
+		}
+		argTypes[i] = t
+	}
+	for i, arg := range args {
+		value := reflect.New(argTypes[i])
+		err = decoder.Decode(value.Interface())
+		if err != nil {
+			return fmt.Errorf("decode call: decode %s.%s.%s error: %v", ed.CallModule, ed.CallModuleFunction, arg.Name, err)
+		}
+		raw, err := types.EncodeToBytes(value.Interface())
+		if err != nil {
+			return fmt.Errorf("decode call: encode %s.%s.%s error: %v", ed.CallModule, ed.CallModuleFunction, arg.Name, err)
+		}
+		if address, ok := value.Elem().Interface().(MultiAddress); ok {
+			ed.Params = append(ed.Params, address.ToParam(string(arg.Name)))
+			continue
+		}
+		ed.Params = append(ed.Params,
+			ExtrinsicParam{
+				Name:     string(arg.Name),
+				Type:     string(arg.Type),
+				Value:    registeredValue(value.Elem().Interface()),
+				ValueRaw: utils.BytesToHex(raw),
+			})
 	}
 	return nil
 }
+
+/*
+与硬编码的call保持一致：金额转换为字符串，账户以及hash转换为hex
+*/
+func registeredValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case types.UCompact:
+		return utils.UCompactToBigInt(v).String()
+	case types.U128:
+		return v.String()
+	case types.U256:
+		return v.String()
+	case types.I128:
+		return v.String()
+	case types.AccountID:
+		return utils.BytesToHex(v[:])
+	case types.Hash:
+		return v.Hex()
+	case types.Bytes:
+		return utils.BytesToHex(v)
+	}
+	return value
+}
+
+/*
+解析Utility.dispatch_as的origin，返回origin参数以及后面的call数据
+OriginCaller为链上所有origin组成的enum，第一个为frame_system的RawOrigin(Root、Signed(AccountId)、None)，
+其他模块的origin（比如collective）的结构因链而异，只能通过查找后面能完整解析的call来确定origin的长度
+Value为可读的origin（Root、Signed、None或者Origin(下标,0x原始数据)），Signed时ValueRaw为账户的公钥
+*/
+func (ed *ExtrinsicDecoder) decodeDispatchOrigin(data []byte) (ExtrinsicParam, []byte, error) {
+	param := ExtrinsicParam{Name: "as_origin", Type: "PalletsOrigin"}
+	if len(data) < 2 {
+		return param, nil, fmt.Errorf("origin data is too short")
+	}
+	if data[0] == 0 {
+		switch data[1] {
+		case 0:
+			param.Value = "Root"
+			return param, data[2:], nil
+		case 1:
+			if len(data) < 34 {
+				return param, nil, fmt.Errorf("signed origin data is too short")
+			}
+			param.Value = "Signed"
+			param.ValueRaw = utils.BytesToHex(data[2:34])
+			return param, data[34:], nil
+		case 2:
+			param.Value = "None"
+			return param, data[2:], nil
+		}
+	}
+	callData := ed.findCall(data[1:])
+	if callData == nil {
+		return param, nil, fmt.Errorf("unsupported origin caller %d", data[0])
+	}
+	raw := data[1 : len(data)-len(callData)]
+	param.Value = fmt.Sprintf("Origin(%d,0x%s)", data[0], utils.BytesToHex(raw))
+	param.ValueRaw = utils.BytesToHex(raw)
+	return param, callData, nil
+}
+
+/*
+Utility.batch中可以解析的call
+*/
+func isBatchSupportedCall(module, call string) bool {
+	switch module {
+	case "Balances":
+		return call == "transfer" || call == "transfer_keep_alive"
+	case "System":
+		return call == "remark" || call == "remark_with_event"
+	}
+	return false
+}
+
+/*
+解析嵌套的call（例如Utility.as_derivative中的call），返回的结构与Utility.batch中的call保持一致
+*/
+func (ed *ExtrinsicDecoder) decodeInnerCall(decoder scale.Decoder) (map[string]interface{}, error) {
+	callIndex := make([]byte, 2)
+	err := decoder.Read(callIndex)
+	if err != nil {
+		return nil, fmt.Errorf("read inner call index bytes error: %v", err)
+	}
+	sub := new(ExtrinsicDecoder)
+	sub.me = ed.me
+	sub.registry = ed.registry
+	sub.CallIndex = xstrings.RightJustify(utils.IntToHex(callIndex[0]), 2, "0") +
+		xstrings.RightJustify(utils.IntToHex(callIndex[1]), 2, "0")
+	err = sub.decodeCallIndex(decoder)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"call_index":    sub.CallIndex,
+		"call_module":   sub.CallModule,
+		"call_function": sub.CallModuleFunction,
+		"call_args":     sub.Params,
+	}, nil
+}
@@ -13,7 +13,7 @@ func (p KusamaEventRecords) GetSystemExtrinsicSuccess() []types.EventSystemExtri
 	return p.System_ExtrinsicSuccess
 }
 
-func (p KusamaEventRecords) GetSystemExtrinsicFailed() []types.EventSystemExtrinsicFailed {
+func (p KusamaEventRecords) GetSystemExtrinsicFailed() []base.EventSystemExtrinsicFailed {
 	return p.System_ExtrinsicFailed
 }
 
@@ -94,8 +94,6 @@ type KusamaEventRecords struct {
 	Crowdloan_Edited                                []EventCrowdloanEdited
 	Crowdloan_MemoUpdated                           []EventCrowdloanMemoUpdated
 	Crowdloan_AddedToNewRaise                       []EventCrowdloanAddedToNewRaise
-	XcmPallet_Attempted                             []EventXcmPalletAttempted
-	XcmPallet_Sent                                  []EventXcmPalletSent
 }
 type EventElectionProviderMultiPhaseUnsignedPhaseStarted struct {
 	Phase       types.Phase
@@ -593,17 +591,3 @@ type EventCrowdloanAddedToNewRaise struct {
 	Parachain ParaId
 	Topics    []types.Hash
 }
-type EventXcmPalletAttempted struct {
-	Phase   types.Phase
-	Outcome types.U8
-	Topics  []types.Hash
-}
-
-type MultiLocation types.U8
-type EventXcmPalletSent struct {
-	Phase          types.Phase
-	MultiLocation1 MultiLocation
-	MultiLocation2 MultiLocation
-	Xcm            types.U8
-	Topics         []types.Hash
-}
@@ -7,6 +7,7 @@ import (
 	"github.com/JFJun/bifrost-go/utils"
 	"github.com/huandu/xstrings"
 	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"math/big"
 )
 
 /*
@@ -21,19 +22,29 @@ type iMetaVersion interface {
 	GetCallIndex(moduleName, fn string) (callIdx string, err error)
 	FindNameByCallIndex(callIdx string) (moduleName, fn string, err error)
 	GetConstants(modName, constantsName string) (constantsType string, constantsValue []byte, err error)
+	FindModuleError(moduleIndex, errorIndex uint8) (moduleName, errorName string, err error)
+	FindCallArgs(callIdx string) ([]types.FunctionArgumentMetadata, error)
 }
 
 func NewMetadataExpand(meta *types.Metadata) (*MetadataExpand, error) {
 	me := new(MetadataExpand)
 	me.meta = meta
-	if meta.IsMetadataV11 {
+	//V8~V11的call、event以及constant格式一致，使用同一个解析
+	switch {
+	case meta.IsMetadataV8:
+		me.MV = newV11(v8ToV10Modules(meta.AsMetadataV8.Modules))
+	case meta.IsMetadataV9:
+		me.MV = newV11(v8ToV10Modules(meta.AsMetadataV9.Modules))
+	case meta.IsMetadataV10:
+		me.MV = newV11(meta.AsMetadataV10.Modules)
+	case meta.IsMetadataV11:
 		me.MV = newV11(meta.AsMetadataV11.Modules)
-	} else if meta.IsMetadataV12 {
+	case meta.IsMetadataV12:
 		me.MV = newV12(meta.AsMetadataV12.Modules)
-	} else if meta.IsMetadataV13 {
+	case meta.IsMetadataV13:
 		me.MV = newV13(meta.AsMetadataV13.Modules)
-	} else {
-		return nil, errors.New("metadata version is not v11 or v12")
+	default:
+		return nil, fmt.Errorf("metadata version %d is not support,only support v8~v13", meta.Version)
 	}
 	return me, nil
 }
@@ -117,6 +128,60 @@ func (v v11) GetConstants(modName, constantsName string) (constantsType string,
 		"constantsName=%s", modName, constantsName)
 }
 
+/*
+V12之前没有模块的index，模块的index即为其在metadata中的位置
+*/
+func (v v11) FindModuleError(moduleIndex, errorIndex uint8) (moduleName, errorName string, err error) {
+	if int(moduleIndex) >= len(v.module) {
+		return "", "", fmt.Errorf("do not find module index %d", moduleIndex)
+	}
+	mod := v.module[moduleIndex]
+	if int(errorIndex) >= len(mod.Errors) {
+		return "", "", fmt.Errorf("do not find error index %d in module %s", errorIndex, mod.Name)
+	}
+	return string(mod.Name), string(mod.Errors[errorIndex].Name), nil
+}
+
+/*
+V8以及V9只有storage的格式与V10不同，解析call、event以及constant时不需要storage
+*/
+func v8ToV10Modules(modules []types.ModuleMetadataV8) []types.ModuleMetadataV10 {
+	result := make([]types.ModuleMetadataV10, len(modules))
+	for i, mod := range modules {
+		result[i] = types.ModuleMetadataV10{
+			Name:      mod.Name,
+			HasCalls:  mod.HasCalls,
+			Calls:     mod.Calls,
+			HasEvents: mod.HasEvents,
+			Events:    mod.Events,
+			Constants: mod.Constants,
+			Errors:    mod.Errors,
+		}
+	}
+	return result
+}
+
+/*
+根据call index获取call的参数定义
+*/
+func (v v11) FindCallArgs(callIdx string) ([]types.FunctionArgumentMetadata, error) {
+	data, err := hex.DecodeString(callIdx)
+	if err != nil || len(data) != 2 {
+		return nil, fmt.Errorf("call index is not 2 bytes hex string: %s", callIdx)
+	}
+	mi := 0
+	for _, mod := range v.module {
+		if !mod.HasCalls {
+			continue
+		}
+		if mi == int(data[0]) && int(data[1]) < len(mod.Calls) {
+			return mod.Calls[data[1]].Args, nil
+		}
+		mi++
+	}
+	return nil, fmt.Errorf("do not find this callInx info: %s", callIdx)
+}
+
 func newV11(module []types.ModuleMetadataV10) *v11 {
 	v := new(v11)
 	v.module = module
@@ -198,6 +263,32 @@ func (v v12) GetConstants(modName, constantsName string) (constantsType string,
 		"constantsName=%s", modName, constantsName)
 }
 
+func (v v12) FindModuleError(moduleIndex, errorIndex uint8) (moduleName, errorName string, err error) {
+	for _, mod := range v.module {
+		if mod.Index != moduleIndex {
+			continue
+		}
+		if int(errorIndex) >= len(mod.Errors) {
+			return "", "", fmt.Errorf("do not find error index %d in module %s", errorIndex, mod.Name)
+		}
+		return string(mod.Name), string(mod.Errors[errorIndex].Name), nil
+	}
+	return "", "", fmt.Errorf("do not find module index %d", moduleIndex)
+}
+
+func (v v12) FindCallArgs(callIdx string) ([]types.FunctionArgumentMetadata, error) {
+	data, err := hex.DecodeString(callIdx)
+	if err != nil || len(data) != 2 {
+		return nil, fmt.Errorf("call index is not 2 bytes hex string: %s", callIdx)
+	}
+	for _, mod := range v.module {
+		if mod.HasCalls && mod.Index == data[0] && int(data[1]) < len(mod.Calls) {
+			return mod.Calls[data[1]].Args, nil
+		}
+	}
+	return nil, fmt.Errorf("do not find this callInx info: %s", callIdx)
+}
+
 func newV12(module []types.ModuleMetadataV12) *v12 {
 	v := new(v12)
 	v.module = module
@@ -279,6 +370,32 @@ func (v v13) GetConstants(modName, constantsName string) (constantsType string,
 		"constantsName=%s", modName, constantsName)
 }
 
+func (v v13) FindModuleError(moduleIndex, errorIndex uint8) (moduleName, errorName string, err error) {
+	for _, mod := range v.module {
+		if mod.Index != moduleIndex {
+			continue
+		}
+		if int(errorIndex) >= len(mod.Errors) {
+			return "", "", fmt.Errorf("do not find error index %d in module %s", errorIndex, mod.Name)
+		}
+		return string(mod.Name), string(mod.Errors[errorIndex].Name), nil
+	}
+	return "", "", fmt.Errorf("do not find module index %d", moduleIndex)
+}
+
+func (v v13) FindCallArgs(callIdx string) ([]types.FunctionArgumentMetadata, error) {
+	data, err := hex.DecodeString(callIdx)
+	if err != nil || len(data) != 2 {
+		return nil, fmt.Errorf("call index is not 2 bytes hex string: %s", callIdx)
+	}
+	for _, mod := range v.module {
+		if mod.HasCalls && mod.Index == data[0] && int(data[1]) < len(mod.Calls) {
+			return mod.Calls[data[1]].Args, nil
+		}
+	}
+	return nil, fmt.Errorf("do not find this callInx info: %s", callIdx)
+}
+
 func newV13(module []types.ModuleMetadataV13) *v13 {
 	v := new(v13)
 	v.module = module
@@ -287,6 +404,7 @@ func newV13(module []types.ModuleMetadataV13) *v13 {
 
 /*
 Balances.transfer
+to可以是ss58地址，也可以是公钥的hex（可以带0x）
 */
 func (e *MetadataExpand) BalanceTransferCall(to string, amount uint64) (types.Call, error) {
 	var (
@@ -296,7 +414,10 @@ func (e *MetadataExpand) BalanceTransferCall(to string, amount uint64) (types.Ca
 	if err != nil {
 		return call, err
 	}
-	recipientPubkey := utils.AddressToPublicKey(to)
+	recipientPubkey := utils.AccountToPublicKey(to)
+	if recipientPubkey == "" {
+		return call, fmt.Errorf("invalid recipient account: %s", to)
+	}
 	var ma MultiAddress
 	ma.SetTypes(0)
 	ma.AccountId = types.NewAccountID(types.MustHexDecodeString(recipientPubkey))
@@ -316,7 +437,10 @@ func (e *MetadataExpand) BalanceTransferKeepAliveCall(to string, amount uint64)
 	if err != nil {
 		return call, err
 	}
-	recipientPubkey := utils.AddressToPublicKey(to)
+	recipientPubkey := utils.AccountToPublicKey(to)
+	if recipientPubkey == "" {
+		return call, fmt.Errorf("invalid recipient account: %s", to)
+	}
 	var ma MultiAddress
 	ma.SetTypes(0)
 	ma.AccountId = types.NewAccountID(types.MustHexDecodeString(recipientPubkey))
@@ -359,6 +483,55 @@ func (e *MetadataExpand) UtilityBatchTxCall(toAmount map[string]uint64, keepAliv
 	return NewCall(callIdx, calls)
 }
 
+/*
+Balances.transfer或者Balances.transfer_keep_alive，金额使用big.Int，支持超过uint64的金额
+*/
+func (e *MetadataExpand) BalanceTransferBigCall(to string, amount *big.Int, keepAlive bool) (types.Call, error) {
+	var (
+		call types.Call
+	)
+	if amount == nil || amount.Sign() < 0 {
+		return call, fmt.Errorf("invalid transfer amount: %v", amount)
+	}
+	fn := "transfer"
+	if keepAlive {
+		fn = "transfer_keep_alive"
+	}
+	callIdx, err := e.MV.GetCallIndex("Balances", fn)
+	if err != nil {
+		return call, err
+	}
+	recipientPubkey := utils.AccountToPublicKey(to)
+	if recipientPubkey == "" {
+		return call, fmt.Errorf("invalid recipient account: %s", to)
+	}
+	var ma MultiAddress
+	ma.SetTypes(0)
+	ma.AccountId = types.NewAccountID(types.MustHexDecodeString(recipientPubkey))
+	return NewCall(callIdx, ma, types.NewUCompact(amount))
+}
+
+/*
+Utility.batch或者Utility.batch_all（batchAll为true时，任意一个call失败则全部回滚）
+*/
+func (e *MetadataExpand) UtilityBatchCall(calls []types.Call, batchAll bool) (types.Call, error) {
+	var (
+		call types.Call
+	)
+	if len(calls) == 0 {
+		return call, errors.New("calls is null")
+	}
+	fn := "batch"
+	if batchAll {
+		fn = "batch_all"
+	}
+	callIdx, err := e.MV.GetCallIndex("Utility", fn)
+	if err != nil {
+		return call, err
+	}
+	return NewCall(callIdx, calls)
+}
+
 /*
 transfer with memo
 */
@@ -381,3 +554,29 @@ func (e *MetadataExpand) UtilityBatchTxWithMemo(to, memo string, amount uint64)
 	}
 	return NewCall(ubCallIdx, btCall, smCall)
 }
+
+/*
+Utility.as_derivative
+index: 派生子账户的索引，inner: 以子账户身份执行的call
+*/
+func (e *MetadataExpand) UtilityAsDerivativeCall(index uint16, inner types.Call) (types.Call, error) {
+	var (
+		call types.Call
+	)
+	callIdx, err := e.MV.GetCallIndex("Utility", "as_derivative")
+	if err != nil {
+		return call, err
+	}
+	return NewCall(callIdx, types.NewU16(index), inner)
+}
+
+/*
+根据metadata创建Utility.as_derivative的call
+*/
+func NewUtilityAsDerivativeCall(meta *types.Metadata, index uint16, inner types.Call) (types.Call, error) {
+	me, err := NewMetadataExpand(meta)
+	if err != nil {
+		return types.Call{}, err
+	}
+	return me.UtilityAsDerivativeCall(index, inner)
+}
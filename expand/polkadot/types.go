@@ -54,7 +54,7 @@ func (p PolkadotEventRecords) GetSystemExtrinsicSuccess() []types.EventSystemExt
 	return p.System_ExtrinsicSuccess
 }
 
-func (p PolkadotEventRecords) GetSystemExtrinsicFailed() []types.EventSystemExtrinsicFailed {
+func (p PolkadotEventRecords) GetSystemExtrinsicFailed() []base.EventSystemExtrinsicFailed {
 	return p.System_ExtrinsicFailed
 }
 
@@ -0,0 +1,271 @@
+package expand
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/huandu/xstrings"
+	"github.com/stafiprotocol/go-substrate-rpc-client/scale"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+/*
+自定义类型的注册表，用于解析库中没有硬编码的call（比如自己链上的pallet）
+key为metadata中参数的类型名，value为可以使用scale解码的go类型
+只有call的所有参数类型都能找到时才会解析这个call
+*/
+type TypeRegistry struct {
+	mu    sync.RWMutex
+	types map[string]reflect.Type
+}
+
+/*
+创建注册表，已经包含了常用的基本类型（u8~u128、bool、AccountId、Balance、Hash、Bytes等）
+*/
+func NewTypeRegistry() *TypeRegistry {
+	r := &TypeRegistry{types: make(map[string]reflect.Type)}
+	for name, value := range map[string]interface{}{
+		"u8":           types.U8(0),
+		"u16":          types.U16(0),
+		"u32":          types.U32(0),
+		"u64":          types.U64(0),
+		"u128":         types.U128{},
+		"bool":         types.Bool(false),
+		"AccountId":    types.AccountID{},
+		"Balance":      types.U128{},
+		"BalanceOf":    types.U128{},
+		"BlockNumber":  types.U32(0),
+		"Moment":       types.U64(0),
+		"Hash":         types.Hash{},
+		"H256":         types.H256{},
+		"Bytes":        types.Bytes{},
+		"Vec<u8>":      types.Bytes{},
+		"LookupSource": MultiAddress{},
+	} {
+		r.types[name] = reflect.TypeOf(value)
+	}
+	return r
+}
+
+/*
+注册一个类型，definition可以是：
+
+	go的值（比如types.U32(0)或者自定义的struct），解析时使用它的类型，struct按字段的顺序解码
+	string，表示name是另一个已经注册的类型的别名（比如"u32"）
+
+newtype（比如type Amount types.U128或者只有一个字段的struct）会按它包装的类型解析
+同名的类型会被覆盖
+*/
+func (r *TypeRegistry) Register(name string, definition interface{}) error {
+	name = normalizeTypeName(name)
+	if name == "" {
+		return fmt.Errorf("type name is empty")
+	}
+	var t reflect.Type
+	switch d := definition.(type) {
+	case nil:
+		return fmt.Errorf("definition of type %s is nil", name)
+	case string:
+		var ok bool
+		t, ok = r.Lookup(d)
+		if !ok {
+			return fmt.Errorf("type %s is alias of unknown type %s", name, d)
+		}
+	case reflect.Type:
+		t = resolveNewtype(d)
+	default:
+		t = reflect.TypeOf(definition)
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		t = resolveNewtype(t)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.types[name] = t
+	return nil
+}
+
+/*
+从json加载类型定义，格式与polkadot.js的types一致的子集：
+
+	{"CurrencyId": "u32", "AssetInfo": {"owner": "AccountId", "amount": "Balance"}}
+
+值为string时表示别名，为object时表示struct，字段按json中的顺序解码
+定义之间可以相互引用，与顺序无关
+*/
+func (r *TypeRegistry) LoadJSON(data []byte) error {
+	defs, err := decodeOrderedObject(data)
+	if err != nil {
+		return fmt.Errorf("json unmarshal type definitions error: %v", err)
+	}
+	pending := defs
+	for len(pending) > 0 {
+		var next []jsonField
+		for _, def := range pending {
+			t, ok := r.resolveDefinition(def.Value)
+			if !ok {
+				next = append(next, def)
+				continue
+			}
+			err = r.Register(def.Name, t)
+			if err != nil {
+				return err
+			}
+		}
+		if len(next) == len(pending) {
+			return fmt.Errorf("type %s refers to unknown type", next[0].Name)
+		}
+		pending = next
+	}
+	return nil
+}
+
+/*
+查找类型，会先去掉T::、<T as Config>::以及<T>等泛型的修饰
+Compact<X>使用types.UCompact解码，Vec<X>在X能找到时解码为切片
+*/
+func (r *TypeRegistry) Lookup(typeName string) (reflect.Type, bool) {
+	name := normalizeTypeName(typeName)
+	r.mu.RLock()
+	t, ok := r.types[name]
+	r.mu.RUnlock()
+	if ok {
+		return t, true
+	}
+	switch {
+	case strings.HasPrefix(name, "Compact<") && strings.HasSuffix(name, ">"):
+		return reflect.TypeOf(types.UCompact{}), true
+	case strings.HasPrefix(name, "Vec<") && strings.HasSuffix(name, ">"):
+		elem, ok := r.Lookup(name[len("Vec<") : len(name)-1])
+		if !ok {
+			return nil, false
+		}
+		return reflect.SliceOf(elem), true
+	case strings.HasPrefix(name, "Box<") && strings.HasSuffix(name, ">"):
+		return r.Lookup(name[len("Box<") : len(name)-1])
+	}
+	return nil, false
+}
+
+/*
+json中的定义转换为go类型，依赖的类型还没有注册时返回false
+*/
+func (r *TypeRegistry) resolveDefinition(raw json.RawMessage) (reflect.Type, bool) {
+	var alias string
+	if json.Unmarshal(raw, &alias) == nil {
+		return r.Lookup(alias)
+	}
+	fields, err := decodeOrderedObject(raw)
+	if err != nil || len(fields) == 0 {
+		return nil, false
+	}
+	structFields := make([]reflect.StructField, 0, len(fields))
+	for _, field := range fields {
+		var typeName string
+		if json.Unmarshal(field.Value, &typeName) != nil {
+			return nil, false
+		}
+		t, ok := r.Lookup(typeName)
+		if !ok {
+			return nil, false
+		}
+		structFields = append(structFields, reflect.StructField{
+			Name: xstrings.FirstRuneToUpper(xstrings.ToCamelCase(field.Name)),
+			Type: t,
+			Tag:  reflect.StructTag(fmt.Sprintf(`json:"%s"`, field.Name)),
+		})
+	}
+	return reflect.StructOf(structFields), true
+}
+
+var (
+	decodeableType = reflect.TypeOf((*scale.Decodeable)(nil)).Elem()
+	typesPkgPath   = reflect.TypeOf(types.U8(0)).PkgPath()
+	//go的newtype可以转换为这些类型时，使用这些类型解码
+	newtypeBases = []reflect.Type{
+		reflect.TypeOf(types.U128{}),
+		reflect.TypeOf(types.U256{}),
+		reflect.TypeOf(types.I128{}),
+		reflect.TypeOf(types.UCompact{}),
+		reflect.TypeOf(types.Hash{}),
+	}
+)
+
+/*
+将newtype解析为它包装的类型：
+go中的newtype（比如type Amount types.U128）不会继承types.U128的Decode方法，按底层的struct解码会出错，
+只有一个字段并且没有实现Decode的struct（比如json中的{"value": "u128"}）按scale编码与字段本身相同
+*/
+func resolveNewtype(t reflect.Type) reflect.Type {
+	for {
+		if t.PkgPath() == typesPkgPath || reflect.PtrTo(t).Implements(decodeableType) {
+			return t
+		}
+		for _, base := range newtypeBases {
+			if t.Kind() == base.Kind() && t.ConvertibleTo(base) {
+				return base
+			}
+		}
+		if t.Kind() != reflect.Struct || t.NumField() != 1 {
+			return t
+		}
+		t = t.Field(0).Type
+	}
+}
+
+type jsonField struct {
+	Name  string
+	Value json.RawMessage
+}
+
+/*
+按顺序解析json object，struct的字段顺序决定了解码的顺序，不能使用map
+*/
+func decodeOrderedObject(data []byte) ([]jsonField, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '{' {
+		return nil, fmt.Errorf("expected json object")
+	}
+	var fields []jsonField
+	for decoder.More() {
+		token, err = decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		var field jsonField
+		field.Name, _ = token.(string)
+		err = decoder.Decode(&field.Value)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+var (
+	traitPrefix   = regexp.MustCompile(`<T as [^<>]+>::`)
+	genericSuffix = regexp.MustCompile(`<T(, ?I)?>$`)
+)
+
+/*
+去掉metadata类型名中与解码无关的修饰，比如T::Balance -> Balance，CurrencyIdOf<T> -> CurrencyIdOf
+*/
+func normalizeTypeName(name string) string {
+	name = strings.TrimSpace(name)
+	if name == "<T::Lookup as StaticLookup>::Source" {
+		return "LookupSource"
+	}
+	name = traitPrefix.ReplaceAllString(name, "")
+	name = strings.ReplaceAll(name, "T::", "")
+	return genericSuffix.ReplaceAllString(name, "")
+}
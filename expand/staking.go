@@ -0,0 +1,137 @@
+package expand
+
+import (
+	"fmt"
+	"github.com/JFJun/bifrost-go/utils"
+	"github.com/stafiprotocol/go-substrate-rpc-client/scale"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+/*
+扩展：Staking中的call
+https://github.com/paritytech/substrate/tree/master/frame/staking/src
+*/
+
+const (
+	RewardDestinationStaked     = "Staked"
+	RewardDestinationStash      = "Stash"
+	RewardDestinationController = "Controller"
+	RewardDestinationAccount    = "Account"
+	RewardDestinationNone       = "None"
+)
+
+var rewardDestinationTypes = []string{
+	RewardDestinationStaked,
+	RewardDestinationStash,
+	RewardDestinationController,
+	RewardDestinationAccount,
+	RewardDestinationNone,
+}
+
+/*
+Staking.set_payee的收益接收方式
+Type为Staked、Stash、Controller、Account以及None，Account时Account为收款地址（ss58地址或者公钥的hex）
+*/
+type RewardDestination struct {
+	Type    string `json:"type"`
+	Account string `json:"account,omitempty"`
+}
+
+func (d *RewardDestination) Decode(decoder scale.Decoder) error {
+	b, err := decoder.ReadOneByte()
+	if err != nil {
+		return fmt.Errorf("decode RewardDestination: read type error: %v", err)
+	}
+	if int(b) >= len(rewardDestinationTypes) {
+		return fmt.Errorf("decode RewardDestination: unsupport type=%d", b)
+	}
+	d.Type = rewardDestinationTypes[b]
+	d.Account = ""
+	if d.Type == RewardDestinationAccount {
+		var account types.AccountID
+		err = decoder.Decode(&account)
+		if err != nil {
+			return fmt.Errorf("decode RewardDestination: decode account error: %v", err)
+		}
+		d.Account = utils.BytesToHex(account[:])
+	}
+	return nil
+}
+
+func (d RewardDestination) Encode(encoder scale.Encoder) error {
+	index := -1
+	for i, name := range rewardDestinationTypes {
+		if name == d.Type {
+			index = i
+		}
+	}
+	if index < 0 {
+		return fmt.Errorf("encode RewardDestination: unsupport type %q", d.Type)
+	}
+	err := encoder.PushByte(byte(index))
+	if err != nil {
+		return err
+	}
+	if d.Type != RewardDestinationAccount {
+		return nil
+	}
+	pubHex := utils.AccountToPublicKey(d.Account)
+	if pubHex == "" {
+		return fmt.Errorf("encode RewardDestination: invalid account: %s", d.Account)
+	}
+	return encoder.Encode(types.NewAccountID(types.MustHexDecodeString(pubHex)))
+}
+
+/*
+Staking.set_payee
+*/
+func (e *MetadataExpand) StakingSetPayeeCall(payee RewardDestination) (types.Call, error) {
+	var (
+		call types.Call
+	)
+	callIdx, err := e.MV.GetCallIndex("Staking", "set_payee")
+	if err != nil {
+		return call, err
+	}
+	return NewCall(callIdx, payee)
+}
+
+/*
+Staking.set_controller，新的runtime中没有参数，controller固定设置为stash本身
+*/
+func (e *MetadataExpand) StakingSetControllerCall() (types.Call, error) {
+	var (
+		call types.Call
+	)
+	callIdx, err := e.MV.GetCallIndex("Staking", "set_controller")
+	if err != nil {
+		return call, err
+	}
+	args, err := e.MV.FindCallArgs(callIdx)
+	if err == nil && len(args) > 0 {
+		return call, fmt.Errorf("Staking.set_controller of this runtime requires %d args", len(args))
+	}
+	return NewCall(callIdx)
+}
+
+/*
+根据metadata创建Staking.set_payee的call
+*/
+func NewStakingSetPayeeCall(meta *types.Metadata, payee RewardDestination) (types.Call, error) {
+	me, err := NewMetadataExpand(meta)
+	if err != nil {
+		return types.Call{}, err
+	}
+	return me.StakingSetPayeeCall(payee)
+}
+
+/*
+根据metadata创建Staking.set_controller的call
+*/
+func NewStakingSetControllerCall(meta *types.Metadata) (types.Call, error) {
+	me, err := NewMetadataExpand(meta)
+	if err != nil {
+		return types.Call{}, err
+	}
+	return me.StakingSetControllerCall()
+}
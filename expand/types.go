@@ -98,13 +98,7 @@ func (t *TransferCall) Decode(decoder scale.Decoder) error {
 	if err != nil {
 		return fmt.Errorf("decode call: decode Balances.transfer.Address error: %v", err)
 	}
-	param = append(param,
-		ExtrinsicParam{
-			Name:     "dest",
-			Type:     "Address",
-			Value:    utils.BytesToHex(address.AccountId[:]),
-			ValueRaw: utils.BytesToHex(address.AccountId[:]),
-		})
+	param = append(param, address.ToParam("dest"))
 	// 1 ----> Compact<Balance>
 	var bb types.UCompact
 
@@ -211,6 +205,19 @@ func (d *WeightToFeeCoefficient) Decode(decoder scale.Decoder) error {
 https://github.com/polkadot-js/api/blob/0e52e8fe23ed029b8101cf8bf82deccd5aa7a790/packages/types/src/generic/MultiAddress.ts
 */
 
+/*
+MultiAddress的类型，与substrate中MultiAddress枚举的下标一致
+*/
+const (
+	MultiAddressId        = 0
+	MultiAddressIndex     = 1
+	MultiAddressRaw       = 2
+	MultiAddressAddress32 = 3
+	MultiAddressAddress20 = 4
+	//没有MultiAddress枚举的旧链使用的Address，只能编码：默认为0xff + AccountId，SetSerDeOptions(true)时只有AccountId
+	AddressAccountId = -1
+)
+
 type MultiAddress struct {
 	GenericMultiAddress
 }
@@ -229,13 +236,15 @@ func (d *GenericMultiAddress) Decode(decoder scale.Decoder) error {
 		return fmt.Errorf("generic MultiAddress read on bytes error: %v", err)
 	}
 	switch int(b) {
-	case 0:
+	case MultiAddressId:
 		err = decoder.Decode(&d.AccountId)
-	case 1:
+	case MultiAddressIndex:
 		err = decoder.Decode(&d.Index)
-	case 2:
+	case MultiAddressRaw:
+		err = decoder.Decode(&d.Raw)
+	case MultiAddressAddress32:
 		err = decoder.Decode(&d.Address32)
-	case 3:
+	case MultiAddressAddress20:
 		err = decoder.Decode(&d.Address20)
 	default:
 		err = fmt.Errorf("generic MultiAddress unsupport type=%d ", b)
@@ -248,31 +257,33 @@ func (d *GenericMultiAddress) Decode(decoder scale.Decoder) error {
 }
 
 func (d GenericMultiAddress) Encode(encoder scale.Encoder) error {
+	if d.types == AddressAccountId {
+		mu.RLock()
+		noPalletIndices := defaultSerDeOptions.SerDe.NoPalletIndices
+		mu.RUnlock()
+		if !noPalletIndices {
+			err := encoder.PushByte(0xff)
+			if err != nil {
+				return err
+			}
+		}
+		return encoder.Write(d.AccountId[:])
+	}
 	t := types.NewU8(uint8(d.types))
 	err := encoder.Encode(t)
 	if err != nil {
 		return err
 	}
 	switch d.types {
-	case 0:
-		if &d.AccountId == nil {
-			err = fmt.Errorf("generic MultiAddress id is null:%v", d.AccountId)
-		}
+	case MultiAddressId:
 		err = encoder.Encode(d.AccountId)
-	case 1:
-		if &d.Index == nil {
-			err = fmt.Errorf("generic MultiAddress index is null:%v", d.Index)
-		}
+	case MultiAddressIndex:
 		err = encoder.Encode(d.Index)
-	case 2:
-		if &d.Address32 == nil {
-			err = fmt.Errorf("generic MultiAddress address32 is null:%v", d.Address32)
-		}
+	case MultiAddressRaw:
+		err = encoder.Encode(d.Raw)
+	case MultiAddressAddress32:
 		err = encoder.Encode(d.Address32)
-	case 3:
-		if &d.Address20 == nil {
-			err = fmt.Errorf("generic MultiAddress address20 is null:%v", d.Address20)
-		}
+	case MultiAddressAddress20:
 		err = encoder.Encode(d.Address20)
 	default:
 		err = fmt.Errorf("generic MultiAddress unsupport this types: %d", d.types)
@@ -305,6 +316,40 @@ func (d *GenericMultiAddress) GetAddress20() types.H160 {
 	return d.Address20
 }
 
+/*
+根据MultiAddress的类型生成解析后的参数，Type为"MultiAddress::<variant>"
+Id、Raw、Address32以及Address20的值为hex，Index的值为账户索引
+*/
+func (d *GenericMultiAddress) ToParam(name string) ExtrinsicParam {
+	param := ExtrinsicParam{Name: name}
+	switch d.types {
+	case MultiAddressId:
+		param.Type = "MultiAddress::Id"
+		param.ValueRaw = utils.BytesToHex(d.AccountId[:])
+		param.Value = param.ValueRaw
+	case MultiAddressIndex:
+		index := utils.UCompactToBigInt(d.Index)
+		param.Type = "MultiAddress::Index"
+		param.ValueRaw = index.String()
+		param.Value = index.Uint64()
+	case MultiAddressRaw:
+		param.Type = "MultiAddress::Raw"
+		param.ValueRaw = utils.BytesToHex(d.Raw)
+		param.Value = param.ValueRaw
+	case MultiAddressAddress32:
+		param.Type = "MultiAddress::Address32"
+		param.ValueRaw = utils.BytesToHex(d.Address32[:])
+		param.Value = param.ValueRaw
+	case MultiAddressAddress20:
+		param.Type = "MultiAddress::Address20"
+		param.ValueRaw = utils.BytesToHex(d.Address20[:])
+		param.Value = param.ValueRaw
+	default:
+		param.Type = "MultiAddress"
+	}
+	return param
+}
+
 func (d *GenericMultiAddress) ToAddress() types.Address {
 	if d.types != 0 {
 		return types.Address{}
@@ -351,3 +396,52 @@ func (this *FeeDetail) FeeTotal() types.U128 {
 
 	return types.NewU128(*result)
 }
+
+/*
+Staking.ActiveEra的值，start为era开始的时间戳（毫秒），era刚开始时可能为空
+*/
+type ActiveEraInfo struct {
+	Index types.U32       `json:"index"`
+	Start types.OptionU64 `json:"start"`
+}
+
+/*
+Assets.Metadata的值
+*/
+type AssetMetadata struct {
+	Deposit  types.U128  `json:"deposit"`
+	Name     types.Bytes `json:"name"`
+	Symbol   types.Bytes `json:"symbol"`
+	Decimals types.U8    `json:"decimals"`
+	IsFrozen types.Bool  `json:"is_frozen"`
+}
+
+/*
+orml asset-registry中AssetRegistry.Metadata的值，只解码前面通用的字段，location以及additional因链而异，忽略
+*/
+type OrmlAssetMetadata struct {
+	Decimals           types.U32   `json:"decimals"`
+	Name               types.Bytes `json:"name"`
+	Symbol             types.Bytes `json:"symbol"`
+	ExistentialDeposit types.U128  `json:"existential_deposit"`
+}
+
+/*
+Democracy.Preimages的值，只解析Available中的data，Missing时IsAvailable为false
+*/
+type DemocracyPreimageStatus struct {
+	IsAvailable bool
+	Data        types.Bytes
+}
+
+func (d *DemocracyPreimageStatus) Decode(decoder scale.Decoder) error {
+	b, err := decoder.ReadOneByte()
+	if err != nil {
+		return err
+	}
+	d.IsAvailable = b == 1
+	if !d.IsAvailable {
+		return nil
+	}
+	return decoder.Decode(&d.Data)
+}
@@ -0,0 +1,26 @@
+package expand
+
+import (
+	"github.com/JFJun/bifrost-go/expand/base"
+)
+
+/*
+XCM相关的类型定义在base中（event也需要解析），这里保留原来的名字
+*/
+
+const (
+	XcmVersion0 = base.XcmVersion0
+	XcmVersion1 = base.XcmVersion1
+	XcmVersion2 = base.XcmVersion2
+	XcmVersion3 = base.XcmVersion3
+)
+
+type (
+	Junction               = base.Junction
+	MultiLocation          = base.MultiLocation
+	MultiAsset             = base.MultiAsset
+	VersionedMultiLocation = base.VersionedMultiLocation
+	VersionedMultiAssets   = base.VersionedMultiAssets
+	VersionedXcm           = base.VersionedXcm
+	XcmInstruction         = base.XcmInstruction
+)
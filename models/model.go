@@ -25,6 +25,8 @@ type BlockResponse struct {
 	BlockHash  string               `json:"block_hash"`
 	Timestamp  int64                `json:"timestamp"`
 	Extrinsic  []*ExtrinsicResponse `json:"extrinsic"`
+	//平行链区块中ParachainSystem.set_validation_data的relay_parent_number，用于对齐平行链与中继链的高度，非平行链为0
+	RelayParentNumber int64 `json:"relay_parent_number,omitempty"`
 }
 
 type ExtrinsicResponse struct {
@@ -35,15 +37,78 @@ type ExtrinsicResponse struct {
 	ToAddress       string `json:"to_address"`
 	Amount          string `json:"amount"`
 	Fee             string `json:"fee"`
+	RawAmount       string `json:"raw_amount"` //最小单位的金额
+	RawFee          string `json:"raw_fee"`    //最小单位的手续费
+	Tip             string `json:"tip"`        //TransactionPayment.TransactionFeePaid中的小费，已经包含在Fee中
+	RawTip          string `json:"raw_tip"`    //最小单位的小费
 	Signature       string `json:"signature"`
-	Nonce           int64  `json:"nonce"`
+	Nonce           uint64 `json:"nonce"`
 	Era             string `json:"era"`
 	ExtrinsicIndex  int    `json:"extrinsic_index"`
 	EventIndex      int    `json:"event_index"`
 	ExtrinsicLength int    `json:"extrinsic_length"`
+	NewAccount      bool   `json:"new_account"`   //转账是否创建了新账户（Balances.Endowed）
+	Memo            string `json:"memo"`          //Utility.batch中System.remark的内容
+	FailReason      string `json:"fail_reason"`   //System.ExtrinsicFailed中的DispatchError，比如"BadOrigin"、"Module(Balances.InsufficientBalance)"
+	DispatchedAs    string `json:"dispatched_as"` //Utility.dispatch_as的origin，比如"Root"、"Signed(地址)"
+	CallHash        string `json:"call_hash"`     //Proxy.announce声明的call hash
+	FeePayer        string `json:"fee_payer"`     //实际支付手续费的账户（Balances.Withdraw），有手续费代付时与FromAddress不同
+	XcmOutcome      string `json:"xcm_outcome"`   //PolkadotXcm.execute的执行结果，比如"Complete"、"Incomplete(TooExpensive)"，send成功时为"Sent"
+	//解析后的era，可以据此计算交易的过期高度，Era无法解析时为nil
+	EraInfo *EraInfo `json:"era_info"`
+	//没有解析的extrinsic（SetIncludeUnparsed）的参数，自定义的call需要先通过RegisterType注册参数的类型
+	//PolkadotXcm.send/execute中为dest以及解析后的xcm消息
+	Params []ExtrinsicDecodeParam `json:"params,omitempty"`
+	//开启SetGroupBatchTransfers时，同一个extrinsic中的多笔转账（比如Utility.batch）合并到这里
+	BatchTransfers []*BatchTransfer `json:"batch_transfers,omitempty"`
+	//extrinsic中产生的Balances.Reserved/Unreserved，比如Identity.set_identity、Proxy.add_proxy的押金
+	Reserves []*ReserveEvent `json:"reserves,omitempty"`
+	//Assets.mint/burn/force_transfer操作的资产，此时Amount为资产的金额
+	Asset *AssetInfo `json:"asset,omitempty"`
+}
+
+/*
+Assets模块中的资产，Symbol以及Decimals来自Assets.Metadata，没有设置元数据时为空
+*/
+type AssetInfo struct {
+	Id       uint32 `json:"id"`
+	Symbol   string `json:"symbol"`
+	Decimals int    `json:"decimals"`
+}
+
+/*
+Balances.Reserved或者Balances.Unreserved，Amount为最小单位的金额
+*/
+type ReserveEvent struct {
+	Event   string `json:"event"` //Reserved或者Unreserved
+	Account string `json:"account"`
+	Amount  string `json:"amount"`
+}
+
+/*
+交易的era，Mortal为false时表示永不过期，其它字段都为0
+Birth和Death为交易所在区块高度对应的有效区间[Birth, Death)，高度达到Death后交易过期
+*/
+type EraInfo struct {
+	Mortal bool   `json:"mortal"`
+	Period uint64 `json:"period"`
+	Phase  uint64 `json:"phase"`
+	Birth  uint64 `json:"birth"`
+	Death  uint64 `json:"death"`
+}
+
+type BatchTransfer struct {
+	ToAddress  string `json:"to_address"`
+	Amount     string `json:"amount"`
+	RawAmount  string `json:"raw_amount"`
+	Status     string `json:"status"`
+	NewAccount bool   `json:"new_account"`
+	Memo       string `json:"memo"`
 }
 
 type EventResult struct {
+	Module       string `json:"module"`
+	Event        string `json:"event"`
 	From         string `json:"from"`
 	To           string `json:"to"`
 	Amount       string `json:"amount"`
@@ -51,19 +116,36 @@ type EventResult struct {
 	EventIdx     int    `json:"event_idx"`
 	Status       string `json:"status"`
 	Weight       int64  `json:"weight"` //权重
+	Phase        string `json:"phase"`  //ApplyExtrinsic、Initialization或者Finalization
+}
+
+/*
+验证人被惩罚相关的event
+Type: slash(Staking.Slash)、offence(Offences.Offence)、offline(ImOnline.SomeOffline)
+*/
+type SlashEvent struct {
+	Type         string `json:"type"`
+	Account      string `json:"account"`
+	Amount       string `json:"amount"`
+	Kind         string `json:"kind"`
+	TimeSlot     string `json:"time_slot"`
+	ExtrinsicIdx int    `json:"extrinsic_idx"` //不是在extrinsic中产生的event为-1
+	Phase        string `json:"phase"`
 }
 
 type ExtrinsicDecodeResponse struct {
-	AccountId          string                 `json:"account_id"`
-	CallCode           string                 `json:"call_code"`
-	CallModule         string                 `json:"call_module"`
-	Era                string                 `json:"era"`
-	Nonce              int64                  `json:"nonce"`
-	VersionInfo        string                 `json:"version_info"`
-	Signature          string                 `json:"signature"`
-	Params             []ExtrinsicDecodeParam `json:"params"`
-	CallModuleFunction string                 `json:"call_module_function"`
-	Length             int                    `json:"length"`
+	AccountId   string                 `json:"account_id"`
+	CallCode    string                 `json:"call_code"`
+	CallModule  string                 `json:"call_module"`
+	Era         string                 `json:"era"`
+	Nonce       uint64                 `json:"nonce"`
+	VersionInfo string                 `json:"version_info"`
+	Signature   string                 `json:"signature"`
+	Params      []ExtrinsicDecodeParam `json:"params"`
+	//签名扩展无法解析时为true
+	SignatureUndecodable bool   `json:"signature_undecodable"`
+	CallModuleFunction   string `json:"call_module_function"`
+	Length               int    `json:"length"`
 }
 
 type ExtrinsicDecodeParam struct {
@@ -86,3 +168,12 @@ type UtilityParamsValueArg struct {
 	Value    interface{} `json:"value"`
 	ValueRaw string      `json:"value_raw"`
 }
+
+/*
+SubmitAndWatchExtrinsic的结果，Status为InBlock或者Finalized，BlockHash为交易所在的区块
+*/
+type ExtrinsicStatus struct {
+	TxHash    string `json:"tx_hash"`
+	Status    string `json:"status"`
+	BlockHash string `json:"block_hash"`
+}
@@ -0,0 +1,161 @@
+package test
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/JFJun/bifrost-go/client"
+	"github.com/JFJun/bifrost-go/expand"
+	"github.com/JFJun/bifrost-go/models"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+/*
+按区块hash返回storage的rpc，System.Events返回区块的events，其它key从storage中查找
+*/
+type storageAtRPC struct {
+	assetBlockRPC
+	//blockHash+key --> value
+	at map[string][]byte
+}
+
+func (m storageAtRPC) Call(result interface{}, method string, args ...interface{}) error {
+	if method == "state_getStorageAt" && len(args) == 2 {
+		key, _ := args[0].(string)
+		hash, _ := args[1].(string)
+		events, err := types.CreateStorageKey(testMetadata(), "System", "Events", nil, nil)
+		if err != nil {
+			return err
+		}
+		if key != events.Hex() {
+			*(result.(*string)) = ""
+			if value, ok := m.at[hash+key]; ok {
+				*(result.(*string)) = types.HexEncodeToString(value)
+			}
+			return nil
+		}
+	}
+	return m.assetBlockRPC.Call(result, method, args...)
+}
+
+/*
+dest为MultiAddress::Index时通过Indices.Accounts查询真实的收款账户
+索引按区块的状态查询：之后被重新分配的索引不能影响历史区块
+*/
+func Test_TransferToAccountIndex_Offline(t *testing.T) {
+	meta := testMetadata()
+	me, err := expand.NewMetadataExpand(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := newTestAccount(t, 1)
+	bob := newTestAccount(t, 2)
+	callIdx, err := me.MV.GetCallIndex("Balances", "transfer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	transferToIndex := func(index uint64) types.Call {
+		var dest expand.MultiAddress
+		dest.SetTypes(expand.MultiAddressIndex)
+		dest.Index = types.NewUCompactFromUInt(index)
+		call, err := expand.NewCall(callIdx, dest, types.NewUCompactFromUInt(1000))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return call
+	}
+	encode := func(v interface{}) []byte {
+		b, err := types.EncodeToBytes(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	key, err := types.CreateStorageKey(meta, "Indices", "Accounts", encode(types.NewU32(7)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key8, err := types.CreateStorageKey(meta, "Indices", "Accounts", encode(types.NewU32(8)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	//(AccountId, deposit, frozen)
+	indexValue := func(who string) []byte {
+		return encode(struct {
+			Who     types.AccountID
+			Deposit types.U128
+			Frozen  bool
+		}{types.NewAccountID(types.MustHexDecodeString(who)), types.NewU128(*big.NewInt(100)), false})
+	}
+	charlie := newTestAccount(t, 3)
+
+	block := &models.SignedBlock{Block: models.Block{
+		Header: models.Header{ParentHash: testBlockHash, Number: "0x64"},
+		Extrinsics: []string{
+			timestampExtrinsic(t, meta, 1620000096000),
+			signedExtrinsic(t, alice, 0, transferToIndex(7)),
+			//没有分配的索引
+			signedExtrinsic(t, alice, 1, transferToIndex(8)),
+		},
+	}}
+	events := eventsHex(t,
+		successEvent(0),
+		transferEvent(1, alice, bob, types.NewU128(*big.NewInt(1000))),
+		successEvent(1),
+		successEvent(2),
+	)
+	rpc := storageAtRPC{
+		assetBlockRPC: assetBlockRPC{
+			fixedBlockRPC: fixedBlockRPC{block: block, events: events},
+			//最新的状态中索引7已经被重新分配给了charlie，索引8被分配
+			storage: storageRPC{values: map[string][]byte{
+				key.Hex():  indexValue(charlie.pubHex),
+				key8.Hex(): indexValue(charlie.pubHex),
+			}},
+		},
+		at: map[string][]byte{testBlockHash + key.Hex(): indexValue(bob.pubHex)},
+	}
+	c, err := client.NewWithRPCCaller(rpc, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetPrefix(testPrefix)
+	c.SetFetchFees(false)
+	resp, err := c.GetBlockByHash(testBlockHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[int]*models.ExtrinsicResponse)
+	for _, e := range resp.Extrinsic {
+		got[e.ExtrinsicIndex] = e
+	}
+	if e := got[1]; e == nil || e.ToAddress != bob.address || e.Status != "success" || e.Amount != "1000" {
+		t.Fatalf("unexpected transfer to index 7: %+v", e)
+	}
+	//索引无法解析时没有收款地址，不能与任何Transfer匹配
+	if e := got[2]; e == nil || e.ToAddress != "" || e.Status != "fail" {
+		t.Fatalf("unexpected transfer to unknown index: %+v", e)
+	}
+
+	//自定义的解析函数中通过ParamAddress得到的也是区块中的账户
+	c.RegisterCallHandler("Balances", "transfer", func(resp models.ExtrinsicDecodeResponse, idx int) (*models.ExtrinsicResponse, error) {
+		for _, param := range resp.Params {
+			if param.Name == "dest" {
+				to, err := c.ParamAddress(param)
+				if err != nil {
+					return nil, err
+				}
+				return &models.ExtrinsicResponse{Type: "transfer", ToAddress: to, Amount: "1000"}, nil
+			}
+		}
+		return nil, errors.New("dest not found")
+	})
+	resp, err = c.GetBlockByHash(testBlockHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Extrinsic) != 1 || resp.Extrinsic[0].ExtrinsicIndex != 1 || resp.Extrinsic[0].ToAddress != bob.address {
+		t.Fatalf("unexpected extrinsics parsed by call handler: %+v", resp.Extrinsic)
+	}
+}
@@ -0,0 +1,123 @@
+package test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/JFJun/bifrost-go/client"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+/*
+System中有指定storage（记录refcount迁移）的metadata
+*/
+type refCountMetaRPC struct {
+	testRPC
+	storages []string
+}
+
+func (m refCountMetaRPC) GetMetadataLatest() (*types.Metadata, error) {
+	meta := testMetadata()
+	system := &meta.AsMetadataV12.Modules[0]
+	for _, name := range m.storages {
+		system.Storage.Items = append(system.Storage.Items, plainStorage(name, "bool"))
+	}
+	return meta, nil
+}
+
+func Test_DetectAccountInfoType_Offline(t *testing.T) {
+	cases := []struct {
+		storages []string
+		want     client.AccountInfoType
+	}{
+		{nil, client.AccountInfoAuto},
+		{[]string{"UpgradedToU32RefCount"}, client.AccountInfoLegacy},
+		{[]string{"UpgradedToU32RefCount", "UpgradedToDualRefCount"}, client.AccountInfoWithProviders},
+		{[]string{"UpgradedToU32RefCount", "UpgradedToTripleRefCount"}, client.AccountInfoWithProviders},
+	}
+	for _, tc := range cases {
+		c, err := client.NewWithRPCCaller(refCountMetaRPC{storages: tc.storages}, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := c.AccountInfoType(); got != tc.want {
+			t.Fatalf("storages %v: got %s, want %s", tc.storages, got, tc.want)
+		}
+		//手动指定的格式优先
+		c.SetAccountInfoType(client.AccountInfoLegacy)
+		if got := c.AccountInfoType(); got != client.AccountInfoLegacy {
+			t.Fatalf("storages %v: override ignored, got %s", tc.storages, got)
+		}
+	}
+}
+
+func Test_SetAccountInfoType_Offline(t *testing.T) {
+	meta := testMetadata()
+	alice := newTestAccount(t, 1)
+	key, err := types.CreateStorageKey(meta, "System", "Account", types.MustHexDecodeString(alice.pubHex), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	//consumers, providers, sufficients的格式
+	rpc := affordRPC{accounts: map[string]string{key.Hex(): accountInfoHex(t, 10000, 0)}}
+	c, err := client.NewWithRPCCaller(rpc, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetPrefix(testPrefix)
+
+	for _, typ := range []client.AccountInfoType{client.AccountInfoAuto, client.AccountInfoWithProviders} {
+		c.SetAccountInfoType(typ)
+		info, err := c.GetAccountInfo(alice.address)
+		if err != nil {
+			t.Fatalf("%s: %v", typ, err)
+		}
+		if info.Nonce != 3 || info.Data.Free.Int64() != 10000 {
+			t.Fatalf("%s: unexpected account info: nonce=%d free=%v", typ, info.Nonce, info.Data.Free)
+		}
+	}
+
+	c.SetAccountInfoType(client.AccountInfoLegacy)
+	if _, err = c.GetAccountInfo(alice.address); !errors.Is(err, client.ErrDecodeFailed) {
+		t.Fatalf("expected ErrDecodeFailed for legacy layout, got %v", err)
+	}
+}
+
+/*
+解析AccountInfo的同时修改格式，需要使用-race运行
+*/
+func Test_SetAccountInfoTypeConcurrent_Offline(t *testing.T) {
+	meta := testMetadata()
+	alice := newTestAccount(t, 1)
+	key, err := types.CreateStorageKey(meta, "System", "Account", types.MustHexDecodeString(alice.pubHex), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rpc := affordRPC{accounts: map[string]string{key.Hex(): accountInfoHex(t, 10000, 0)}}
+	c, err := client.NewWithRPCCaller(rpc, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetPrefix(testPrefix)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			c.SetAccountInfoType(client.AccountInfoWithProviders)
+			c.SetAccountInfoType(client.AccountInfoAuto)
+		}()
+		go func() {
+			defer wg.Done()
+			if typ := c.AccountInfoType(); typ == client.AccountInfoLegacy {
+				t.Errorf("unexpected account info type: %s", typ)
+			}
+			//两种格式都可以解析consumers/providers的数据
+			if _, err := c.GetAccountInfo(alice.address); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,83 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/JFJun/bifrost-go/client"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+/*
+离线测试使用的rpc，返回测试用的runtime版本以及metadata（任意区块的也一样），其它调用都返回错误
+*/
+type testRPC struct{}
+
+func (m testRPC) Call(result interface{}, method string, args ...interface{}) error {
+	switch method {
+	case "state_getRuntimeVersion":
+		v, _ := m.GetRuntimeVersionLatest()
+		*(result.(*types.RuntimeVersion)) = *v
+		return nil
+	case "state_getMetadata":
+		metaHex, err := types.EncodeToHexString(testMetadata())
+		if err != nil {
+			return err
+		}
+		*(result.(*string)) = metaHex
+		return nil
+	}
+	return errors.New("unsupported method " + method)
+}
+
+func (testRPC) GetRuntimeVersionLatest() (*types.RuntimeVersion, error) {
+	return &types.RuntimeVersion{SpecName: "bifrost", SpecVersion: 1}, nil
+}
+
+func (testRPC) GetMetadataLatest() (*types.Metadata, error) {
+	return testMetadata(), nil
+}
+
+func (testRPC) GetStorageLatest(key types.StorageKey, target interface{}) (bool, error) {
+	return false, nil
+}
+
+func (testRPC) GetBlockHash(blockNumber uint64) (types.Hash, error) {
+	return types.Hash{}, nil
+}
+
+/*
+state_getStorage的结果在解析时panic的rpc
+*/
+type panicStorageRPC struct {
+	testRPC
+}
+
+func (m panicStorageRPC) Call(result interface{}, method string, args ...interface{}) error {
+	if method == "state_getStorage" {
+		var data []byte
+		_ = data[1] //模拟解码时的越界
+	}
+	return m.testRPC.Call(result, method, args...)
+}
+
+/*
+解析中的panic需要作为错误返回，不能返回nil, nil
+*/
+func Test_GetAccountInfoPanic_Offline(t *testing.T) {
+	c, err := client.NewWithRPCCaller(panicStorageRPC{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := newTestAccount(t, 1)
+	info, err := c.GetAccountInfo(alice.address)
+	if err == nil {
+		t.Fatal("expected error when decoding panics")
+	}
+	if info != nil {
+		t.Fatalf("expected nil account info, got %+v", info)
+	}
+	if !errors.Is(err, client.ErrDecodeFailed) {
+		t.Fatalf("expected ErrDecodeFailed, got %v", err)
+	}
+}
@@ -0,0 +1,127 @@
+package test
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/JFJun/bifrost-go/client"
+	"github.com/JFJun/bifrost-go/models"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+/*
+返回账户信息以及固定手续费的rpc，accounts的key为System.Account的storage key
+*/
+type affordRPC struct {
+	testRPC
+	accounts map[string]string
+	fee      string
+}
+
+func (m affordRPC) Call(result interface{}, method string, args ...interface{}) error {
+	switch method {
+	case "state_getStorage":
+		*(result.(*string)) = m.accounts[args[0].(string)]
+		return nil
+	case "chain_getFinalizedHead":
+		*(result.(*string)) = testBlockHash
+		return nil
+	case "chain_getHeader":
+		*(result.(*models.Header)) = models.Header{ParentHash: testGenesisHash, Number: "0x64"}
+		return nil
+	case "payment_queryInfo":
+		*(result.(*map[string]interface{})) = map[string]interface{}{"partialFee": m.fee}
+		return nil
+	}
+	return errors.New("unsupported method " + method)
+}
+
+func accountInfoHex(t *testing.T, free, frozen int64) string {
+	info := struct {
+		Nonce, Consumers, Providers, Sufficients types.U32
+		Free, Reserved, MiscFrozen, FeeFrozen    types.U128
+	}{
+		Nonce:      3,
+		Providers:  1,
+		Free:       types.NewU128(*big.NewInt(free)),
+		Reserved:   types.NewU128(*big.NewInt(0)),
+		MiscFrozen: types.NewU128(*big.NewInt(frozen)),
+		FeeFrozen:  types.NewU128(*big.NewInt(0)),
+	}
+	h, err := types.EncodeToHexString(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+func Test_CanAfford_Offline(t *testing.T) {
+	meta := testMetadata()
+	alice := newTestAccount(t, 1)
+	bob := newTestAccount(t, 2)
+	carol := newTestAccount(t, 3)
+	accountKey := func(account testAccount) string {
+		key, err := types.CreateStorageKey(meta, "System", "Account", types.MustHexDecodeString(account.pubHex), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return key.Hex()
+	}
+	rpc := affordRPC{
+		accounts: map[string]string{
+			accountKey(alice): accountInfoHex(t, 10000, 2000),
+			accountKey(bob):   accountInfoHex(t, 1000, 0),
+		},
+		fee: "100",
+	}
+	c, err := client.NewWithRPCCaller(rpc, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetPrefix(testPrefix)
+
+	ed, err := c.GetExistentialDeposit()
+	if err != nil || ed.Int64() != testExistentialDeposit {
+		t.Fatalf("unexpected existential deposit: %v, err=%v", ed, err)
+	}
+
+	cases := []struct {
+		amount    int64
+		keepAlive bool
+		to        testAccount
+		ok        bool
+		shortfall int64
+	}{
+		//10000 - 2000(冻结) - 100(手续费)
+		{amount: 7900, to: bob, ok: true},
+		{amount: 8000, to: bob, shortfall: 100},
+		//keepAlive时ED(500)小于冻结的金额，不影响
+		{amount: 7900, keepAlive: true, to: bob, ok: true},
+		//carol不存在，金额需要不低于ED
+		{amount: 100, to: carol, shortfall: 400},
+		{amount: 500, to: carol, ok: true},
+	}
+	for i, cs := range cases {
+		ok, shortfall, err := c.CanAfford(alice.address, cs.to.address, big.NewInt(cs.amount), cs.keepAlive)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != cs.ok || shortfall.Int64() != cs.shortfall {
+			t.Fatalf("case %d: expected ok=%v shortfall=%d, got ok=%v shortfall=%s", i, cs.ok, cs.shortfall, ok, shortfall)
+		}
+	}
+
+	//bob没有冻结的余额，keepAlive时需要保留ED：1000 - 100 - 500
+	ok, shortfall, err := c.CanAfford(bob.address, alice.address, big.NewInt(401), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok || shortfall.Int64() != 1 {
+		t.Fatalf("expected shortfall 1 with keep alive, got ok=%v shortfall=%s", ok, shortfall)
+	}
+	ok, _, err = c.CanAfford(bob.address, alice.address, big.NewInt(900), false)
+	if err != nil || !ok {
+		t.Fatalf("expected bob to afford transfer without keep alive, ok=%v err=%v", ok, err)
+	}
+}
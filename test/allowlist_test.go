@@ -0,0 +1,73 @@
+package test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/JFJun/bifrost-go/expand"
+	"github.com/JFJun/bifrost-go/utils"
+	"github.com/stafiprotocol/go-substrate-rpc-client/scale"
+)
+
+/*
+不在allowlist中的call只识别模块以及方法，不解析参数；batch中的call不受allowlist影响
+*/
+func Test_CallAllowlist_Offline(t *testing.T) {
+	meta := testMetadata()
+	me, err := expand.NewMetadataExpand(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := newTestAccount(t, 1)
+	bob := newTestAccount(t, 2)
+	transfer, err := me.BalanceTransferCall(bob.address, 12345)
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch, err := me.UtilityBatchTxCall(map[string]uint64{bob.address: 500}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decode := func(extrinsic string, allowlist map[string]bool) *expand.ExtrinsicDecoder {
+		ed, err := expand.NewExtrinsicDecoder(meta)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ed.SetCallAllowlist(allowlist)
+		data, err := hex.DecodeString(utils.Remove0X(extrinsic))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ed.ProcessExtrinsicDecoder(*scale.NewDecoder(bytes.NewReader(data)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ed
+	}
+	transferHex := signedExtrinsic(t, alice, 0, transfer)
+	batchHex := signedExtrinsic(t, alice, 1, batch)
+
+	ed := decode(transferHex, map[string]bool{"Utility": true})
+	if ed.CallModule != "Balances" || ed.CallModuleFunction != "transfer" || len(ed.Params) != 0 {
+		t.Fatalf("expected Balances.transfer without params, got %s.%s %v", ed.CallModule, ed.CallModuleFunction, ed.Params)
+	}
+	if ed = decode(transferHex, map[string]bool{"Balances.transfer": true}); len(ed.Params) != 2 {
+		t.Fatalf("expected 2 params for allowed call, got %v", ed.Params)
+	}
+	if ed = decode(transferHex, nil); len(ed.Params) != 2 {
+		t.Fatalf("expected 2 params without allowlist, got %v", ed.Params)
+	}
+	ed = decode(batchHex, map[string]bool{"Utility": true})
+	if len(ed.Params) != 1 {
+		t.Fatalf("expected batch calls param, got %v", ed.Params)
+	}
+	calls, ok := ed.Params[0].Value.([]interface{})
+	if !ok || len(calls) != 1 {
+		t.Fatalf("expected 1 inner call, got %#v", ed.Params[0].Value)
+	}
+	inner, ok := calls[0].(map[string]interface{})
+	if args, _ := inner["call_args"].([]expand.ExtrinsicParam); !ok || len(args) != 2 {
+		t.Fatalf("expected inner transfer to be fully decoded, got %#v", ed.Params[0].Value)
+	}
+}
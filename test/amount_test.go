@@ -0,0 +1,109 @@
+package test
+
+import (
+	"errors"
+	"math/big"
+	"sync/atomic"
+	"testing"
+
+	"github.com/JFJun/bifrost-go/client"
+	"github.com/JFJun/bifrost-go/expand"
+	"github.com/JFJun/bifrost-go/models"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+/*
+system_properties返回properties的rpc，properties为nil时返回错误，calls记录system_properties的请求次数
+*/
+type decimalsRPC struct {
+	fixedBlockRPC
+	properties map[string]interface{}
+	calls      *int32
+}
+
+func (m decimalsRPC) Call(result interface{}, method string, args ...interface{}) error {
+	if method == "system_properties" {
+		atomic.AddInt32(m.calls, 1)
+		if m.properties == nil {
+			return errors.New("method not found")
+		}
+		*(result.(*map[string]interface{})) = m.properties
+		return nil
+	}
+	return m.fixedBlockRPC.Call(result, method, args...)
+}
+
+/*
+SetHumanReadableAmounts优先使用节点的tokenDecimals（注册表中bifrost为12），精度为0也只请求一次节点，
+获取不到精度时区块照常返回，金额保持原始值
+*/
+func Test_HumanReadableDecimals_Offline(t *testing.T) {
+	meta := testMetadata()
+	me, err := expand.NewMetadataExpand(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := newTestAccount(t, 1)
+	bob := newTestAccount(t, 2)
+	transfer, err := me.BalanceTransferCall(bob.address, 12345)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := fixedBlockRPC{
+		block: &models.SignedBlock{Block: models.Block{
+			Header: models.Header{ParentHash: testBlockHash, Number: "0x64"},
+			Extrinsics: []string{
+				timestampExtrinsic(t, meta, 1620000108000),
+				signedExtrinsic(t, alice, 0, transfer),
+			},
+		}},
+		events: eventsHex(t,
+			successEvent(0),
+			transferEvent(1, alice, bob, types.NewU128(*big.NewInt(12345))),
+			feePaidEvent(1, alice, types.NewU128(*big.NewInt(100)), types.NewU128(*big.NewInt(0))),
+			successEvent(1),
+		),
+	}
+	cases := []struct {
+		name        string
+		properties  map[string]interface{}
+		noRegistry  bool
+		amount, fee string
+	}{
+		{"zero decimals", map[string]interface{}{"tokenDecimals": float64(0)}, false, "12345", "100"},
+		{"node over registry", map[string]interface{}{"tokenDecimals": []interface{}{float64(3), float64(12)}}, false, "12.345", "0.1"},
+		{"registry fallback", map[string]interface{}{"ss58Format": float64(6)}, false, "0.000000012345", "0.0000000001"},
+		{"unresolved", nil, true, "12345", "100"},
+	}
+	for _, tc := range cases {
+		calls := new(int32)
+		c, err := client.NewWithRPCCaller(decimalsRPC{fixedBlockRPC: block, properties: tc.properties, calls: calls}, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.SetPrefix(testPrefix)
+		c.SetFetchFees(false)
+		c.SetHumanReadableAmounts(true)
+		if tc.noRegistry {
+			c.BasicType = nil
+		}
+		//创建client时会从system_properties中获取ss58Format
+		before := atomic.LoadInt32(calls)
+		for i := 0; i < 2; i++ {
+			resp, err := c.GetBlockByHash(testBlockHash)
+			if err != nil {
+				t.Fatalf("%s: %v", tc.name, err)
+			}
+			if len(resp.Extrinsic) != 1 {
+				t.Fatalf("%s: expected 1 extrinsic, got %d", tc.name, len(resp.Extrinsic))
+			}
+			e := resp.Extrinsic[0]
+			if e.Amount != tc.amount || e.Fee != tc.fee || e.RawAmount != "12345" || e.RawFee != "100" {
+				t.Fatalf("%s: unexpected amounts: amount=%s fee=%s raw=%s rawFee=%s", tc.name, e.Amount, e.Fee, e.RawAmount, e.RawFee)
+			}
+		}
+		if n := atomic.LoadInt32(calls) - before; !tc.noRegistry && n != 1 {
+			t.Fatalf("%s: expected system_properties once, got %d", tc.name, n)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/JFJun/bifrost-go/client"
+	"github.com/JFJun/bifrost-go/expand"
+	"github.com/JFJun/bifrost-go/models"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+/*
+返回固定区块以及Assets.Metadata的rpc，system_properties中的精度为12
+*/
+type assetBlockRPC struct {
+	fixedBlockRPC
+	storage storageRPC
+}
+
+func (m assetBlockRPC) Call(result interface{}, method string, args ...interface{}) error {
+	if method == "system_properties" {
+		*(result.(*map[string]interface{})) = map[string]interface{}{"tokenDecimals": float64(12)}
+		return nil
+	}
+	return m.fixedBlockRPC.Call(result, method, args...)
+}
+
+func (m assetBlockRPC) GetStorageLatest(key types.StorageKey, target interface{}) (bool, error) {
+	return m.storage.GetStorageLatest(key, target)
+}
+
+func Test_AssetsMintBurn_Offline(t *testing.T) {
+	meta := testMetadata()
+	me, err := expand.NewMetadataExpand(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := newTestAccount(t, 1)
+	bob := newTestAccount(t, 2)
+	carol := newTestAccount(t, 3)
+	address := func(account testAccount) expand.MultiAddress {
+		var a expand.MultiAddress
+		a.SetTypes(0)
+		a.AccountId = types.NewAccountID(types.MustHexDecodeString(account.pubHex))
+		return a
+	}
+	assetCall := func(name string, args ...interface{}) types.Call {
+		callIdx, err := me.MV.GetCallIndex("Assets", name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		call, err := expand.NewCall(callIdx, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return call
+	}
+	u128 := func(v int64) types.U128 {
+		return types.NewU128(*big.NewInt(v))
+	}
+	mint := assetCall("mint", types.NewUCompactFromUInt(7), address(bob), types.NewUCompactFromUInt(1000))
+	burn := assetCall("burn", types.NewUCompactFromUInt(7), address(carol), types.NewUCompactFromUInt(5000))
+	forceTransfer := assetCall("force_transfer", types.NewUCompactFromUInt(8), address(bob), address(carol),
+		types.NewUCompactFromUInt(200))
+
+	metadataKey, err := types.CreateStorageKey(meta, "Assets", "Metadata", types.MustHexDecodeString("0x07000000"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	usdt, err := types.EncodeToBytes(expand.AssetMetadata{
+		Deposit:  u128(0),
+		Name:     types.NewBytes([]byte("Tether USD")),
+		Symbol:   types.NewBytes([]byte("USDT")),
+		Decimals: 6,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rpc := assetBlockRPC{
+		fixedBlockRPC: fixedBlockRPC{
+			block: &models.SignedBlock{Block: models.Block{
+				Header: models.Header{ParentHash: testBlockHash, Number: "0x64"},
+				Extrinsics: []string{
+					timestampExtrinsic(t, meta, 1620000108000),
+					signedExtrinsic(t, alice, 0, mint),
+					signedExtrinsic(t, alice, 1, burn),
+					signedExtrinsic(t, alice, 2, forceTransfer),
+				},
+			}},
+			events: eventsHex(t,
+				successEvent(0),
+				assetEvent(1, 0, 7, bob, u128(1000)),
+				successEvent(1),
+				//carol的余额只有3000
+				assetEvent(2, 1, 7, carol, u128(3000)),
+				successEvent(2),
+				successEvent(3),
+			),
+		},
+		storage: storageRPC{values: map[string][]byte{metadataKey.Hex(): usdt}},
+	}
+	c, err := client.NewWithRPCCaller(rpc, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetPrefix(testPrefix)
+	c.SetFetchFees(false)
+	c.SetHumanReadableAmounts(true)
+	block, err := c.GetBlockByHash(testBlockHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(block.Extrinsic) != 3 {
+		t.Fatalf("expected 3 asset extrinsics, got %d", len(block.Extrinsic))
+	}
+	expected := []struct {
+		typ, to, amount, rawAmount string
+		asset                      models.AssetInfo
+	}{
+		{"asset_mint", bob.address, "0.001", "1000", models.AssetInfo{Id: 7, Symbol: "USDT", Decimals: 6}},
+		{"asset_burn", carol.address, "0.003", "3000", models.AssetInfo{Id: 7, Symbol: "USDT", Decimals: 6}},
+		//没有元数据的资产不转换精度
+		{"asset_force_transfer", carol.address, "200", "200", models.AssetInfo{Id: 8}},
+	}
+	for i, want := range expected {
+		e := block.Extrinsic[i]
+		if e.Type != want.typ || e.Status != "success" || e.FromAddress != alice.address || e.ToAddress != want.to ||
+			e.Amount != want.amount || e.RawAmount != want.rawAmount || e.Asset == nil || *e.Asset != want.asset {
+			t.Fatalf("unexpected %s: %+v, asset=%+v", want.typ, e, e.Asset)
+		}
+	}
+	var source string
+	for _, p := range block.Extrinsic[2].Params {
+		if p.Name == "source" {
+			source, _ = p.Value.(string)
+		}
+	}
+	if source != bob.address {
+		t.Fatalf("expected force_transfer source %s, got %q", bob.address, source)
+	}
+}
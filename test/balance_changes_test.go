@@ -0,0 +1,91 @@
+package test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/JFJun/bifrost-go/client"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+/*
+Balances.ReserveRepatriated(event=6)，status为资金转入To的free还是reserved
+*/
+func reserveRepatriatedEvent(idx uint32, from, to testAccount, amount types.U128, status types.BalanceStatus) testEvent {
+	return testEvent{phase: applyExtrinsic(idx), module: 2, event: 6, args: []interface{}{
+		types.NewAccountID(types.MustHexDecodeString(from.pubHex)),
+		types.NewAccountID(types.MustHexDecodeString(to.pubHex)),
+		amount,
+		status,
+	}}
+}
+
+/*
+Balances.DustLost(event=7)
+*/
+func dustLostEvent(idx uint32, who testAccount, amount types.U128) testEvent {
+	return testEvent{phase: applyExtrinsic(idx), module: 2, event: 7, args: []interface{}{
+		types.NewAccountID(types.MustHexDecodeString(who.pubHex)),
+		amount,
+	}}
+}
+
+/*
+按extrinsic汇总各个Balances event对free余额的变化，减少为负数，其他extrinsic的event不计算在内
+*/
+func Test_GetExtrinsicBalanceChanges_Offline(t *testing.T) {
+	alice := newTestAccount(t, 1)
+	bob := newTestAccount(t, 2)
+	carol := newTestAccount(t, 3)
+	u128 := func(v int64) types.U128 {
+		return types.NewU128(*big.NewInt(v))
+	}
+	rpc := fixedBlockRPC{events: eventsHex(t,
+		successEvent(0),
+		transferEvent(1, alice, bob, u128(1000)),
+		withdrawEvent(1, alice, u128(30)),
+		reserveEvent(1, 2, alice, u128(200)),
+		reserveEvent(1, 3, bob, u128(50)),
+		depositEvent(1, carol, u128(10)),
+		reserveRepatriatedEvent(1, alice, bob, u128(70), types.Free),
+		//转入reserved时free余额不变
+		reserveRepatriatedEvent(1, alice, carol, u128(40), types.Reserved),
+		dustLostEvent(1, alice, u128(3)),
+		successEvent(1),
+		transferEvent(2, bob, alice, u128(500)),
+		successEvent(2),
+	)}
+	c, err := client.NewWithRPCCaller(rpc, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetPrefix(testPrefix)
+	changes, err := c.GetExtrinsicBalanceChanges(testBlockHash, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]*big.Int{
+		alice.address: big.NewInt(-1000 - 30 - 200 - 3),
+		bob.address:   big.NewInt(1000 + 50 + 70),
+		carol.address: big.NewInt(10),
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("unexpected balance changes: %v", changes)
+	}
+	for address, delta := range want {
+		if changes[address] == nil || changes[address].Cmp(delta) != 0 {
+			t.Fatalf("unexpected balance change of %s: %v, want %v", address, changes[address], delta)
+		}
+	}
+
+	changes, err = c.GetExtrinsicBalanceChanges(testBlockHash, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 {
+		t.Fatalf("expected no balance changes, got %v", changes)
+	}
+	if _, err = c.GetExtrinsicBalanceChanges("0x1234", 1); err == nil {
+		t.Fatal("expected error for invalid block hash")
+	}
+}
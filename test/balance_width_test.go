@@ -0,0 +1,141 @@
+package test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/JFJun/bifrost-go/client"
+	"github.com/JFJun/bifrost-go/expand"
+	"github.com/JFJun/bifrost-go/models"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+/*
+Balance为u64的链的metadata：Balances.ExistentialDeposit按u64编码
+*/
+func u64Metadata() *types.Metadata {
+	meta := testMetadata()
+	for i, module := range meta.AsMetadataV12.Modules {
+		if module.Name != "Balances" {
+			continue
+		}
+		for j, c := range module.Constants {
+			if c.Name == "ExistentialDeposit" {
+				meta.AsMetadataV12.Modules[i].Constants[j] = constant("ExistentialDeposit", "T::Balance", types.U64(testExistentialDeposit))
+			}
+		}
+	}
+	return meta
+}
+
+/*
+返回u64链的metadata以及固定区块的rpc
+*/
+type u64BlockRPC struct {
+	fixedBlockRPC
+}
+
+func (u64BlockRPC) GetMetadataLatest() (*types.Metadata, error) {
+	return u64Metadata(), nil
+}
+
+/*
+u64链上Balances以及TransactionPayment的event中的金额按u64解析
+*/
+func Test_U64BalanceEvents_Offline(t *testing.T) {
+	meta := u64Metadata()
+	me, err := expand.NewMetadataExpand(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := newTestAccount(t, 1)
+	bob := newTestAccount(t, 2)
+	transfer, err := me.BalanceTransferCall(bob.address, 12345)
+	if err != nil {
+		t.Fatal(err)
+	}
+	accountOf := func(a testAccount) types.AccountID {
+		return types.NewAccountID(types.MustHexDecodeString(a.pubHex))
+	}
+	balancesEvent := func(idx uint32, event uint8, args ...interface{}) testEvent {
+		return testEvent{phase: applyExtrinsic(idx), module: 2, event: event, args: args}
+	}
+	block := &models.SignedBlock{Block: models.Block{
+		Header: models.Header{ParentHash: testBlockHash, Number: "0x64"},
+		Extrinsics: []string{
+			timestampExtrinsic(t, meta, 1620000096000),
+			signedExtrinsic(t, alice, 0, transfer),
+		},
+	}}
+	events := eventsHex(t,
+		successEvent(0),
+		//Withdraw(fee)
+		balancesEvent(1, 1, accountOf(alice), types.U64(150)),
+		//Transfer
+		balancesEvent(1, 0, accountOf(alice), accountOf(bob), types.U64(12345)),
+		//Reserved
+		balancesEvent(1, 2, accountOf(alice), types.U64(40)),
+		//Deposit
+		balancesEvent(1, 4, accountOf(bob), types.U64(7)),
+		//TransactionPayment.TransactionFeePaid
+		testEvent{phase: applyExtrinsic(1), module: 9, event: 0, args: []interface{}{accountOf(alice), types.U64(150), types.U64(0)}},
+		successEvent(1),
+	)
+
+	ier, err := expand.DecodeEventRecordsWithBalanceWidth(meta, events, testChainName, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ier.GetBalancesTransfer(); len(got) != 1 || got[0].Value.Int64() != 12345 || got[0].To != accountOf(bob) {
+		t.Fatalf("unexpected transfer events: %+v", got)
+	}
+	if got := ier.GetBalancesWithdraw(); len(got) != 1 || got[0].Balance.Int64() != 150 {
+		t.Fatalf("unexpected withdraw events: %+v", got)
+	}
+	if got := ier.GetBalancesReserved(); len(got) != 1 || got[0].Balance.Int64() != 40 {
+		t.Fatalf("unexpected reserved events: %+v", got)
+	}
+	if got := ier.GetBalancesDeposit(); len(got) != 1 || got[0].Balance.Int64() != 7 {
+		t.Fatalf("unexpected deposit events: %+v", got)
+	}
+	if got := ier.GetTransactionFeePaid(); len(got) != 1 || got[0].ActualFee.Int64() != 150 || got[0].Tip.Int64() != 0 {
+		t.Fatalf("unexpected fee paid events: %+v", got)
+	}
+	if got := ier.GetSystemExtrinsicSuccess(); len(got) != 2 {
+		t.Fatalf("unexpected success events: %+v", got)
+	}
+
+	c, err := client.NewWithRPCCaller(u64BlockRPC{fixedBlockRPC{block: block, events: events}}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetPrefix(testPrefix)
+	c.SetFetchFees(false)
+	if c.BalanceWidth() != 8 {
+		t.Fatalf("expected u64 balance, got width %d", c.BalanceWidth())
+	}
+	resp, err := c.GetBlockByHash(testBlockHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Extrinsic) != 1 || resp.Extrinsic[0].Status != "success" || resp.Extrinsic[0].ToAddress != bob.address ||
+		resp.Extrinsic[0].Amount != "12345" {
+		t.Fatalf("unexpected extrinsics: %+v", resp.Extrinsic)
+	}
+	changes, err := c.GetExtrinsicBalanceChanges(testBlockHash, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]*big.Int{
+		alice.address: big.NewInt(-150 - 12345 - 40),
+		bob.address:   big.NewInt(12345 + 7),
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("unexpected balance changes: %v", changes)
+	}
+	for address, delta := range want {
+		if changes[address] == nil || changes[address].Cmp(delta) != 0 {
+			t.Fatalf("unexpected balance change of %s: %v, want %v", address, changes[address], delta)
+		}
+	}
+}
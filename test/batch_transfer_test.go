@@ -0,0 +1,114 @@
+package test
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/JFJun/bifrost-go/client"
+	"github.com/JFJun/bifrost-go/expand"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+/*
+state_queryStorageAt也从accounts中返回System.Account的rpc
+*/
+type batchTransferRPC struct {
+	affordRPC
+}
+
+func (m batchTransferRPC) Call(result interface{}, method string, args ...interface{}) error {
+	if method == "state_queryStorageAt" {
+		var changeSet types.StorageChangeSet
+		for _, key := range args[0].([]string) {
+			value, ok := m.accounts[key]
+			change := types.KeyValueOption{StorageKey: types.MustHexDecodeString(key), HasStorageData: ok}
+			if ok {
+				change.StorageData = types.MustHexDecodeString(value)
+			}
+			changeSet.Changes = append(changeSet.Changes, change)
+		}
+		*(result.(*[]types.StorageChangeSet)) = []types.StorageChangeSet{changeSet}
+		return nil
+	}
+	return m.affordRPC.Call(result, method, args...)
+}
+
+/*
+BuildBatchTransfer与NewBalanceTransferCall、NewBatchCall构造的call一致（包括SetCallAddressType），
+nonce、era以及版本从链上获取
+*/
+func Test_BuildBatchTransfer_Offline(t *testing.T) {
+	meta := testMetadata()
+	alice := newTestAccount(t, 1)
+	bob := newTestAccount(t, 2)
+	carol := newTestAccount(t, 3)
+	aliceKey, err := types.CreateStorageKey(meta, "System", "Account", types.MustHexDecodeString(alice.pubHex), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rpc := batchTransferRPC{affordRPC{accounts: map[string]string{aliceKey.Hex(): accountInfoHex(t, 1000000, 0)}}}
+	c, err := client.NewWithRPCCaller(rpc, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetPrefix(testPrefix)
+	if err = c.SetGenesisHash(testGenesisHash); err != nil {
+		t.Fatal(err)
+	}
+	recipients := []client.BatchRecipient{
+		{To: bob.address, Amount: big.NewInt(100)},
+		{To: carol.address, Amount: big.NewInt(200)},
+	}
+
+	for _, addressType := range []int{expand.MultiAddressId, expand.MultiAddressAddress32} {
+		c.SetCallAddressType(addressType)
+		for _, keepAlive := range []bool{false, true} {
+			for _, batchAll := range []bool{false, true} {
+				var opts []client.BatchTransferOption
+				if keepAlive {
+					opts = append(opts, client.WithKeepAlive())
+				}
+				if batchAll {
+					opts = append(opts, client.WithBatchAll())
+				}
+				transaction, err := c.BuildBatchTransfer(alice.address, recipients, append(opts, client.WithTip(5))...)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var calls []types.Call
+				for _, r := range recipients {
+					call, err := c.NewBalanceTransferCall(r.To, r.Amount, keepAlive)
+					if err != nil {
+						t.Fatal(err)
+					}
+					calls = append(calls, call)
+				}
+				expected, err := c.NewBatchCall(calls, batchAll)
+				if err != nil {
+					t.Fatal(err)
+				}
+				extrinsic, _, _, err := transaction.ReturnSign()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if encodeCall(t, extrinsic.Method) != encodeCall(t, expected) {
+					t.Fatalf("address type %d keepAlive=%v batchAll=%v: unexpected call %s",
+						addressType, keepAlive, batchAll, encodeCall(t, extrinsic.Method))
+				}
+				if transaction.Nonce != 3 || transaction.Tip != 5 || transaction.EraPeriod != 64 ||
+					transaction.BlockNumber != 100 || transaction.GenesisHash != strings.TrimPrefix(testGenesisHash, "0x") ||
+					transaction.BlockHash != strings.TrimPrefix(testBlockHash, "0x") {
+					t.Fatalf("unexpected transaction: %+v", transaction)
+				}
+			}
+		}
+	}
+
+	if _, err = c.BuildBatchTransfer(alice.address, nil); err == nil {
+		t.Fatal("expected error for empty recipients")
+	}
+	if _, err = c.BuildBatchTransfer(alice.address, []client.BatchRecipient{{To: bob.address, Amount: big.NewInt(-1)}}); err == nil {
+		t.Fatal("expected error for negative amount")
+	}
+}
@@ -0,0 +1,103 @@
+package test
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/JFJun/bifrost-go/client"
+	"github.com/JFJun/bifrost-go/models"
+	"github.com/JFJun/bifrost-go/utils"
+)
+
+func Test_Unit_ParseBlockNumber(t *testing.T) {
+	cases := map[string]uint64{"0x64": 100, "0X1f": 31, "100": 100, "0": 0}
+	for number, expected := range cases {
+		n, err := utils.ParseBlockNumber(number)
+		if err != nil || n != expected {
+			t.Fatalf("parse %q: expected %d, got %d (err=%v)", number, expected, n, err)
+		}
+	}
+	if _, err := utils.ParseBlockNumber("1f"); err == nil {
+		t.Fatal("expected error for hex without 0x prefix")
+	}
+}
+
+/*
+部分节点返回的区块头高度为十进制
+*/
+func Test_DecimalBlockNumber_Offline(t *testing.T) {
+	meta := testMetadata()
+	rpc := fixedBlockRPC{
+		block: &models.SignedBlock{Block: models.Block{
+			Header:     models.Header{ParentHash: testBlockHash, Number: "1234"},
+			Extrinsics: []string{timestampExtrinsic(t, meta, 1620000090000)},
+		}},
+		events: eventsHex(t, successEvent(0)),
+	}
+	c, err := client.NewWithRPCCaller(rpc, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetFetchFees(false)
+	resp, err := c.GetBlockByHash(testBlockHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Height != 1234 {
+		t.Fatalf("expected height 1234, got %d", resp.Height)
+	}
+}
+
+/*
+GetBlock按参数的类型分发：整数以及十进制字符串为高度，0x开头的32字节hex为区块hash
+*/
+func Test_GetBlockDispatch_Offline(t *testing.T) {
+	meta := testMetadata()
+	rpc := heightBlocksRPC{blocks: map[uint64]*models.SignedBlock{}, events: map[uint64]string{}, latest: 5}
+	rpc.blocks[5] = &models.SignedBlock{Block: models.Block{
+		Header:     models.Header{Number: "0x5"},
+		Extrinsics: []string{timestampExtrinsic(t, meta, 1620000090000)},
+	}}
+	rpc.events[5] = eventsHex(t, successEvent(0))
+	c, err := client.NewWithRPCCaller(rpc, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetFetchFees(false)
+	hash := heightHash(5)
+	for _, numberOrHash := range []interface{}{
+		5, int32(5), int64(5), uint(5), uint32(5), uint64(5), "5", hash, hash.Hex(),
+	} {
+		resp, err := c.GetBlock(numberOrHash)
+		if err != nil {
+			t.Fatalf("%T %v: %v", numberOrHash, numberOrHash, err)
+		}
+		if resp.Height != 5 {
+			t.Fatalf("%T %v: expected height 5, got %d", numberOrHash, numberOrHash, resp.Height)
+		}
+	}
+
+	for _, numberOrHash := range []interface{}{
+		//超过int64的高度
+		uint64(math.MaxUint64),
+		uint(math.MaxInt64) + 1,
+		-1,
+		//长度为66但不是hex
+		"0x" + strings.Repeat("zz", 32),
+		//hex但是长度不是32字节
+		"0x" + strings.Repeat("00", 31),
+		"0x05",
+		"",
+		"-5",
+		"99999999999999999999",
+		//不支持的类型
+		5.0,
+		[]byte{5},
+		nil,
+	} {
+		if resp, err := c.GetBlock(numberOrHash); err == nil {
+			t.Fatalf("%T %v: expected error, got block %d", numberOrHash, numberOrHash, resp.Height)
+		}
+	}
+}
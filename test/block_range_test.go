@@ -0,0 +1,345 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/JFJun/bifrost-go/client"
+	"github.com/JFJun/bifrost-go/expand"
+	"github.com/JFJun/bifrost-go/models"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+func Test_GetBlocksByRange_Offline(t *testing.T) {
+	meta := testMetadata()
+	me, err := expand.NewMetadataExpand(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := newTestAccount(t, 1)
+	bob := newTestAccount(t, 2)
+	amount := types.NewU128(*big.NewInt(12345))
+	transfer, err := me.BalanceTransferCall(bob.address, 12345)
+	if err != nil {
+		t.Fatal(err)
+	}
+	extrinsic := signedExtrinsic(t, alice, 0, transfer)
+	events := eventsHex(t,
+		successEvent(0),
+		transferEvent(1, alice, bob, amount),
+		feePaidEvent(1, alice, types.NewU128(*big.NewInt(100)), types.NewU128(*big.NewInt(0))),
+		successEvent(1),
+	)
+	rpc := heightBlocksRPC{blocks: map[uint64]*models.SignedBlock{}, events: map[uint64]string{}, latest: 20}
+	for height := uint64(1); height <= 20; height++ {
+		//高度7以及13的区块不存在
+		if height == 7 || height == 13 {
+			continue
+		}
+		rpc.blocks[height] = &models.SignedBlock{Block: models.Block{
+			Header:     models.Header{Number: fmt.Sprintf("0x%x", height)},
+			Extrinsics: []string{timestampExtrinsic(t, meta, 1620000096000), extrinsic},
+		}}
+		rpc.events[height] = events
+	}
+	c, err := client.NewWithRPCCaller(rpc, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetPrefix(testPrefix)
+
+	blocks, err := c.GetBlocksByRange(context.Background(), 1, 20, 4)
+	var rangeErr *client.BlockRangeError
+	if !errors.As(err, &rangeErr) {
+		t.Fatalf("expected BlockRangeError, got %v", err)
+	}
+	if heights := fmt.Sprint(rangeErr.Heights()); heights != "[7 13]" {
+		t.Fatalf("unexpected failed heights: %s", heights)
+	}
+	if len(blocks) != 20 {
+		t.Fatalf("expected 20 blocks, got %d", len(blocks))
+	}
+	for i, block := range blocks {
+		height := int64(i + 1)
+		if height == 7 || height == 13 {
+			if block != nil {
+				t.Fatalf("height %d: expected nil block", height)
+			}
+			continue
+		}
+		if block == nil || block.Height != height || len(block.Extrinsic) != 1 || block.Extrinsic[0].Status != "success" {
+			t.Fatalf("height %d: unexpected block %+v", height, block)
+		}
+	}
+
+	blocks, err = c.GetBlocksByRange(context.Background(), 14, 20, 0)
+	if err != nil || len(blocks) != 7 || blocks[6].Height != 20 {
+		t.Fatalf("unexpected range result: %d blocks, err=%v", len(blocks), err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err = c.GetBlocksByRange(ctx, 1, 20, 4); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if _, err = c.GetBlocksByRange(context.Background(), 5, 1, 4); err == nil {
+		t.Fatal("expected error for invalid range")
+	}
+}
+
+/*
+获取高度为upgradeEvery的倍数的区块时runtime升级的rpc，每次升级spec version加1，metadata多一个模块
+*/
+type rangeUpgradeRPC struct {
+	heightBlocksRPC
+	upgradeEvery uint64
+	upgrades     *int32
+	client       **client.Client
+}
+
+func (m rangeUpgradeRPC) Call(result interface{}, method string, args ...interface{}) error {
+	if method == "chain_getBlock" {
+		hash := types.MustHexDecodeString(args[0].(string))
+		if new(big.Int).SetBytes(hash).Uint64()%m.upgradeEvery == 0 {
+			atomic.AddInt32(m.upgrades, 1)
+			if err := (*m.client).RefreshRuntime(); err != nil {
+				return err
+			}
+		}
+	}
+	return m.heightBlocksRPC.Call(result, method, args...)
+}
+
+func (m rangeUpgradeRPC) GetRuntimeVersionLatest() (*types.RuntimeVersion, error) {
+	version := 1 + atomic.LoadInt32(m.upgrades)
+	return &types.RuntimeVersion{SpecName: "bifrost", SpecVersion: types.U32(version), TransactionVersion: 1}, nil
+}
+
+func (m rangeUpgradeRPC) GetMetadataLatest() (*types.Metadata, error) {
+	meta := testMetadata()
+	if atomic.LoadInt32(m.upgrades) > 0 {
+		meta.AsMetadataV12.Modules = append(meta.AsMetadataV12.Modules, types.ModuleMetadataV12{Name: "Upgraded", Index: 18})
+	}
+	return meta, nil
+}
+
+/*
+GetBlocksByRange并发解析区块的过程中runtime升级，同时读取runtime信息以及metadata，需要使用-race运行
+*/
+func Test_GetBlocksByRangeRuntimeUpgrade_Offline(t *testing.T) {
+	meta := testMetadata()
+	me, err := expand.NewMetadataExpand(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := newTestAccount(t, 1)
+	bob := newTestAccount(t, 2)
+	transfer, err := me.BalanceTransferCall(bob.address, 12345)
+	if err != nil {
+		t.Fatal(err)
+	}
+	extrinsic := signedExtrinsic(t, alice, 0, transfer)
+	events := eventsHex(t,
+		successEvent(0),
+		transferEvent(1, alice, bob, types.NewU128(*big.NewInt(12345))),
+		successEvent(1),
+	)
+	var (
+		upgrades int32
+		c        *client.Client
+	)
+	rpc := rangeUpgradeRPC{
+		heightBlocksRPC: heightBlocksRPC{blocks: map[uint64]*models.SignedBlock{}, events: map[uint64]string{}, latest: 40},
+		upgradeEvery:    5,
+		upgrades:        &upgrades,
+		client:          &c,
+	}
+	for height := uint64(1); height <= 40; height++ {
+		rpc.blocks[height] = &models.SignedBlock{Block: models.Block{
+			Header:     models.Header{Number: fmt.Sprintf("0x%x", height)},
+			Extrinsics: []string{timestampExtrinsic(t, meta, 1620000096000), extrinsic},
+		}}
+		rpc.events[height] = events
+	}
+	c, err = client.NewWithRPCCaller(rpc, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetPrefix(testPrefix)
+	c.SetFetchFees(false)
+	//升级只通过rpc中的RefreshRuntime触发，读取时不先检查runtime
+	c.SetAutoRuntimeCheck(false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for ctx.Err() == nil {
+			c.RuntimeInfo()
+			c.AccountInfoType()
+			c.BalanceWidth()
+			//没有对应的storage，只检查读取metadata时没有data race
+			c.GetActiveEra()
+			c.GetCurrentSession()
+			c.GetAccountInfo(alice.address)
+			if _, err := c.MetadataHex(); err != nil {
+				t.Error(err)
+				return
+			}
+			if _, err := c.BuildStorageKey("System", "Account", types.MustHexDecodeString(alice.pubHex)); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	blocks, err := c.GetBlocksByRange(context.Background(), 1, 40, 8)
+	cancel()
+	wg.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, block := range blocks {
+		if block == nil || block.Height != int64(i+1) || len(block.Extrinsic) != 1 ||
+			block.Extrinsic[0].Status != "success" || block.Extrinsic[0].Amount != "12345" {
+			t.Fatalf("height %d: unexpected block %+v", i+1, block)
+		}
+	}
+	if info := c.RuntimeInfo(); info.SpecVersion != 9 {
+		t.Fatalf("expected 8 runtime upgrades during range, got %+v", info)
+	}
+}
+
+/*
+记录同时进行的chain_getBlock请求数量的rpc，每个请求延迟delay
+*/
+type prefetchRPC struct {
+	heightBlocksRPC
+	delay       time.Duration
+	inflight    *int32
+	maxInflight *int32
+}
+
+func (m prefetchRPC) Call(result interface{}, method string, args ...interface{}) error {
+	if method == "chain_getBlock" {
+		n := atomic.AddInt32(m.inflight, 1)
+		defer atomic.AddInt32(m.inflight, -1)
+		for {
+			max := atomic.LoadInt32(m.maxInflight)
+			if n <= max || atomic.CompareAndSwapInt32(m.maxInflight, max, n) {
+				break
+			}
+		}
+		time.Sleep(m.delay)
+	}
+	return m.heightBlocksRPC.Call(result, method, args...)
+}
+
+/*
+IterateBlocks按高度顺序返回区块，出错的区块返回Err后继续，预先获取的区块数量不超过depth，取消ctx后chan关闭
+*/
+func Test_IterateBlocks_Offline(t *testing.T) {
+	meta := testMetadata()
+	me, err := expand.NewMetadataExpand(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := newTestAccount(t, 1)
+	bob := newTestAccount(t, 2)
+	transfer, err := me.BalanceTransferCall(bob.address, 12345)
+	if err != nil {
+		t.Fatal(err)
+	}
+	extrinsic := signedExtrinsic(t, alice, 0, transfer)
+	events := eventsHex(t,
+		successEvent(0),
+		transferEvent(1, alice, bob, types.NewU128(*big.NewInt(12345))),
+		successEvent(1),
+	)
+	var inflight, maxInflight int32
+	rpc := prefetchRPC{
+		heightBlocksRPC: heightBlocksRPC{blocks: map[uint64]*models.SignedBlock{}, events: map[uint64]string{}, latest: 12},
+		delay:           5 * time.Millisecond,
+		inflight:        &inflight,
+		maxInflight:     &maxInflight,
+	}
+	for height := uint64(1); height <= 12; height++ {
+		//高度5的区块不存在
+		if height == 5 {
+			continue
+		}
+		rpc.blocks[height] = &models.SignedBlock{Block: models.Block{
+			Header:     models.Header{Number: fmt.Sprintf("0x%x", height)},
+			Extrinsics: []string{timestampExtrinsic(t, meta, 1620000096000), extrinsic},
+		}}
+		rpc.events[height] = events
+	}
+	c, err := client.NewWithRPCCaller(rpc, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetPrefix(testPrefix)
+	c.SetFetchFees(false)
+
+	for _, depth := range []int{0, 1, 3} {
+		c.SetPrefetchDepth(depth)
+		atomic.StoreInt32(&maxInflight, 0)
+		height := int64(1)
+		for result := range c.IterateBlocks(context.Background(), 1, 12) {
+			if result.Height != height {
+				t.Fatalf("depth %d: expected height %d, got %d", depth, height, result.Height)
+			}
+			if height == 5 {
+				if !errors.Is(result.Err, client.ErrBlockNotFound) || result.Block != nil {
+					t.Fatalf("depth %d: expected ErrBlockNotFound for height 5, got %+v", depth, result)
+				}
+			} else if result.Err != nil || result.Block == nil || result.Block.Height != height ||
+				len(result.Block.Extrinsic) != 1 || result.Block.Extrinsic[0].Amount != "12345" {
+				t.Fatalf("depth %d: unexpected result %+v", depth, result)
+			}
+			height++
+		}
+		if height != 13 {
+			t.Fatalf("depth %d: expected 12 results, got %d", depth, height-1)
+		}
+		//正在等待的depth个区块以及正在被取出的一个区块
+		limit := int32(depth) + 1
+		if depth <= 0 {
+			limit = 4 + 1
+		}
+		if max := atomic.LoadInt32(&maxInflight); max > limit || (depth != 1 && max < 2) {
+			t.Fatalf("depth %d: unexpected concurrent fetches %d", depth, max)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	results := c.IterateBlocks(ctx, 1, 12)
+	first := <-results
+	cancel()
+	if first.Height != 1 || first.Err != nil {
+		t.Fatalf("unexpected first result %+v", first)
+	}
+	received := 1
+	timeout := time.After(5 * time.Second)
+	for done := false; !done; {
+		select {
+		case _, ok := <-results:
+			if !ok {
+				done = true
+				break
+			}
+			received++
+		case <-timeout:
+			t.Fatal("chan is not closed after ctx is canceled")
+		}
+	}
+	if received == 12 {
+		t.Fatal("expected iteration to stop after ctx is canceled")
+	}
+}
@@ -0,0 +1,150 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync/atomic"
+	"testing"
+
+	"github.com/JFJun/bifrost-go/client"
+	"github.com/JFJun/bifrost-go/expand"
+	"github.com/JFJun/bifrost-go/models"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+/*
+spec version 2的metadata：Balances的模块索引从2改为20，使用错误的metadata时转账无法解析
+*/
+func upgradedBalancesMetadata() *types.Metadata {
+	meta := testMetadata()
+	for i, module := range meta.AsMetadataV12.Modules {
+		if module.Name == "Balances" {
+			meta.AsMetadataV12.Modules[i].Index = 20
+		}
+	}
+	return meta
+}
+
+/*
+高度小于等于oldHeight的区块属于spec version 1，之后的区块以及最新的runtime为spec version 2，
+metaCalls记录state_getMetadata的请求次数
+*/
+type specBlocksRPC struct {
+	heightBlocksRPC
+	oldHeight uint64
+	metaCalls *int32
+}
+
+func (m specBlocksRPC) specOf(blockHash string) types.U32 {
+	if new(big.Int).SetBytes(types.MustHexDecodeString(blockHash)).Uint64() <= m.oldHeight {
+		return 1
+	}
+	return 2
+}
+
+func (m specBlocksRPC) Call(result interface{}, method string, args ...interface{}) error {
+	switch method {
+	case "state_getRuntimeVersion":
+		*(result.(*types.RuntimeVersion)) = types.RuntimeVersion{SpecName: testChainName, SpecVersion: m.specOf(args[0].(string)), TransactionVersion: 1}
+		return nil
+	case "state_getMetadata":
+		atomic.AddInt32(m.metaCalls, 1)
+		meta := upgradedBalancesMetadata()
+		if m.specOf(args[0].(string)) == 1 {
+			meta = testMetadata()
+		}
+		metaHex, err := types.EncodeToHexString(meta)
+		if err != nil {
+			return err
+		}
+		*(result.(*string)) = metaHex
+		return nil
+	}
+	return m.heightBlocksRPC.Call(result, method, args...)
+}
+
+func (m specBlocksRPC) GetRuntimeVersionLatest() (*types.RuntimeVersion, error) {
+	return &types.RuntimeVersion{SpecName: testChainName, SpecVersion: 2, TransactionVersion: 1}, nil
+}
+
+func (m specBlocksRPC) GetMetadataLatest() (*types.Metadata, error) {
+	return upgradedBalancesMetadata(), nil
+}
+
+/*
+runtime升级之前的区块使用区块所在spec version的metadata解析，metadata按spec version缓存
+*/
+func Test_BlockRuntimeBySpecVersion_Offline(t *testing.T) {
+	alice := newTestAccount(t, 1)
+	bob := newTestAccount(t, 2)
+	var metaCalls int32
+	rpc := specBlocksRPC{
+		heightBlocksRPC: heightBlocksRPC{blocks: map[uint64]*models.SignedBlock{}, events: map[uint64]string{}, latest: 4},
+		oldHeight:       2,
+		metaCalls:       &metaCalls,
+	}
+	for height := uint64(1); height <= 4; height++ {
+		meta, balancesIndex := testMetadata(), uint8(2)
+		if height > rpc.oldHeight {
+			meta, balancesIndex = upgradedBalancesMetadata(), 20
+		}
+		me, err := expand.NewMetadataExpand(meta)
+		if err != nil {
+			t.Fatal(err)
+		}
+		transfer, err := me.BalanceTransferCall(bob.address, 1000+height)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rpc.blocks[height] = &models.SignedBlock{Block: models.Block{
+			Header:     models.Header{Number: fmt.Sprintf("0x%x", height)},
+			Extrinsics: []string{timestampExtrinsic(t, meta, 1620000096000), signedExtrinsic(t, alice, height, transfer)},
+		}}
+		transferred := transferEvent(1, alice, bob, types.NewU128(*new(big.Int).SetUint64(1000 + height)))
+		transferred.module = balancesIndex
+		rpc.events[height] = eventsHex(t, successEvent(0), transferred, successEvent(1))
+	}
+	c, err := client.NewWithRPCCaller(rpc, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetPrefix(testPrefix)
+	c.SetFetchFees(false)
+	check := func(block *models.BlockResponse, height int64) {
+		t.Helper()
+		if block == nil || block.Height != height || len(block.Extrinsic) != 1 {
+			t.Fatalf("height %d: unexpected block %+v", height, block)
+		}
+		e := block.Extrinsic[0]
+		if e.Status != "success" || e.ToAddress != bob.address || e.Amount != fmt.Sprint(1000+height) {
+			t.Fatalf("height %d: unexpected transfer %+v", height, e)
+		}
+	}
+
+	block, err := c.GetBlockByHash(heightHash(1).Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(block, 1)
+	blocks, err := c.GetBlocksByRange(context.Background(), 1, 4, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, block := range blocks {
+		check(block, int64(i+1))
+	}
+	for result := range c.IterateBlocks(context.Background(), 1, 4) {
+		if result.Err != nil {
+			t.Fatalf("height %d: %v", result.Height, result.Err)
+		}
+		check(result.Block, result.Height)
+	}
+	//spec version 1的metadata只请求一次，spec version 2为当前的runtime，不需要请求
+	if n := atomic.LoadInt32(&metaCalls); n != 1 {
+		t.Fatalf("expected metadata of spec version 1 to be fetched once, got %d", n)
+	}
+	if info := c.RuntimeInfo(); info.SpecVersion != 2 {
+		t.Fatalf("current runtime should stay at spec version 2, got %+v", info)
+	}
+}
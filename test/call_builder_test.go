@@ -0,0 +1,129 @@
+package test
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/JFJun/bifrost-go/client"
+	"github.com/JFJun/bifrost-go/expand"
+	"github.com/JFJun/bifrost-go/tx"
+	"github.com/JFJun/go-substrate-crypto/crypto"
+	"github.com/JFJun/go-substrate-crypto/ss58"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+func Test_CallBuilder_Offline(t *testing.T) {
+	meta := testMetadata()
+	me, err := expand.NewMetadataExpand(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := newTestAccount(t, 1)
+	bob := newTestAccount(t, 2)
+	c, err := client.NewWithRPCCaller(testRPC{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetPrefix(testPrefix)
+	amount := big.NewInt(12345)
+
+	for _, keepAlive := range []bool{false, true} {
+		call, err := c.NewBalanceTransferCall(bob.address, amount, keepAlive)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, err := me.BalanceTransferBigCall(bob.address, amount, keepAlive)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if encodeCall(t, call) != encodeCall(t, expected) {
+			t.Fatalf("keepAlive=%v: unexpected call %s", keepAlive, encodeCall(t, call))
+		}
+	}
+	//公钥的hex也可以作为收款地址
+	if _, err = c.NewBalanceTransferCall("0x"+bob.pubHex, amount, false); err != nil {
+		t.Fatal(err)
+	}
+	//其它链的地址
+	kusamaAddress, err := ss58.Encode(types.MustHexDecodeString(bob.pubHex), ss58.KsmPrefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.NewBalanceTransferCall(kusamaAddress, amount, false); err == nil {
+		t.Fatal("expected error for address of another chain")
+	}
+	if _, err = c.NewBalanceTransferCall(bob.address, big.NewInt(-1), false); err == nil {
+		t.Fatal("expected error for negative amount")
+	}
+
+	//地址参数的编码：Balances.transfer的call index为0x0200
+	for _, tc := range []struct {
+		addressType int
+		prefix      string
+	}{
+		{expand.MultiAddressId, "020000"},
+		{expand.MultiAddressAddress32, "020003"},
+		{expand.AddressAccountId, "0200ff"},
+	} {
+		c.SetCallAddressType(tc.addressType)
+		call, err := c.NewBalanceTransferCall(bob.address, amount, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if encoded := encodeCall(t, call); !strings.HasPrefix(encoded, tc.prefix+bob.pubHex) {
+			t.Fatalf("address type %d: unexpected call %s", tc.addressType, encoded)
+		}
+	}
+	c.SetCallAddressType(expand.MultiAddressIndex)
+	if _, err = c.NewBalanceTransferCall(bob.address, amount, false); err == nil {
+		t.Fatal("expected error for unsupported address type")
+	}
+	c.SetCallAddressType(expand.MultiAddressId)
+
+	transfer, err := c.NewBalanceTransferCall(bob.address, amount, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, batchAll := range []bool{false, true} {
+		call, err := c.NewBatchCall([]types.Call{transfer, transfer}, batchAll)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, err := me.UtilityBatchCall([]types.Call{transfer, transfer}, batchAll)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if encodeCall(t, call) != encodeCall(t, expected) {
+			t.Fatalf("batchAll=%v: unexpected call %s", batchAll, encodeCall(t, call))
+		}
+	}
+	if _, err = c.NewBatchCall(nil, false); err == nil {
+		t.Fatal("expected error for empty batch")
+	}
+
+	//构造的call可以直接签名并解析出转账
+	signed, err := tx.NewSubstrateTransaction(alice.address, 0).
+		SetGenesisHashAndBlockHash(testGenesisHash, testGenesisHash).
+		SetSpecAndTxVersion(1, 1).
+		SetCall(transfer).
+		SignTransaction(alice.seed, crypto.Ed25519Type)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := tx.DecodeSignedExtrinsic(meta, signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.CallModule != "Balances" || decoded.CallModuleFunction != "transfer" {
+		t.Fatalf("unexpected decoded call: %s.%s", decoded.CallModule, decoded.CallModuleFunction)
+	}
+}
+
+func encodeCall(t *testing.T, call types.Call) string {
+	encoded, err := types.EncodeToHexString(call)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimPrefix(encoded, "0x")
+}
@@ -0,0 +1,219 @@
+package test
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/JFJun/bifrost-go/client"
+	"github.com/JFJun/bifrost-go/expand"
+	"github.com/JFJun/bifrost-go/models"
+	"github.com/JFJun/bifrost-go/utils"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+/*
+注册的解析函数可以解析内置之外的call，解析函数panic时只跳过对应的extrinsic
+*/
+func Test_RegisterCallHandler_Offline(t *testing.T) {
+	meta := testMetadata()
+	me, err := expand.NewMetadataExpand(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := newTestAccount(t, 1)
+	bob := newTestAccount(t, 2)
+	carol := newTestAccount(t, 3)
+	currenciesIdx, err := me.MV.GetCallIndex("Currencies", "transfer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	currenciesTransfer, err := expand.NewCall(currenciesIdx, multiAddress(carol), types.NewU32(7), types.NewUCompactFromUInt(500))
+	if err != nil {
+		t.Fatal(err)
+	}
+	remarkIdx, err := me.MV.GetCallIndex("System", "remark")
+	if err != nil {
+		t.Fatal(err)
+	}
+	remark, err := expand.NewCall(remarkIdx, types.NewBytes([]byte("hello")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	transfer, err := me.BalanceTransferBigCall(bob.address, big.NewInt(100), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rpc := fixedBlockRPC{
+		block: &models.SignedBlock{Block: models.Block{
+			Header: models.Header{ParentHash: testBlockHash, Number: "0x64"},
+			Extrinsics: []string{
+				timestampExtrinsic(t, meta, 1620000114000),
+				signedExtrinsic(t, alice, 0, currenciesTransfer),
+				signedExtrinsic(t, alice, 1, remark),
+				signedExtrinsic(t, alice, 2, transfer),
+			},
+		}},
+		events: eventsHex(t,
+			successEvent(0),
+			successEvent(1),
+			successEvent(2),
+			transferEvent(3, alice, bob, types.NewU128(*big.NewInt(100))),
+			successEvent(3),
+		),
+	}
+	c, err := client.NewWithRPCCaller(rpc, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetPrefix(testPrefix)
+	c.SetFetchFees(false)
+	if err := c.RegisterType("CurrencyIdOf<T>", "u32"); err != nil {
+		t.Fatal(err)
+	}
+	c.RegisterCallHandler("Currencies", "transfer", func(resp models.ExtrinsicDecodeResponse, idx int) (*models.ExtrinsicResponse, error) {
+		e := &models.ExtrinsicResponse{Type: "currencies_transfer"}
+		for _, param := range resp.Params {
+			switch param.Name {
+			case "dest":
+				to, err := c.ParamAddress(param)
+				if err != nil {
+					return nil, err
+				}
+				e.ToAddress = to
+			case "amount":
+				e.Amount, _ = utils.ValueToString(param.Value)
+			case "currency_id":
+				e.Params = append(e.Params, param)
+			}
+		}
+		if e.ToAddress == "" {
+			return nil, errors.New("dest not found")
+		}
+		return e, nil
+	})
+	c.RegisterCallHandler("System", "remark", func(resp models.ExtrinsicDecodeResponse, idx int) (*models.ExtrinsicResponse, error) {
+		panic("broken handler")
+	})
+
+	block, err := c.GetBlockByHash(testBlockHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(block.Extrinsic) != 2 {
+		t.Fatalf("expected 2 extrinsics, got %d", len(block.Extrinsic))
+	}
+	currencies := block.Extrinsic[0]
+	if currencies.Type != "currencies_transfer" || currencies.Status != "success" || currencies.ExtrinsicIndex != 1 ||
+		currencies.FromAddress != alice.address || currencies.ToAddress != carol.address || currencies.Amount != "500" ||
+		currencies.Txid == "" || currencies.Signature == "" || currencies.ExtrinsicLength == 0 || len(currencies.Params) != 1 {
+		t.Fatalf("unexpected Currencies.transfer: %+v", currencies)
+	}
+	//System.remark的解析函数panic不影响之后的转账
+	if e := block.Extrinsic[1]; e.Type != "transfer" || e.ExtrinsicIndex != 3 || e.ToAddress != bob.address || e.Amount != "100" {
+		t.Fatalf("unexpected transfer: %+v", e)
+	}
+
+	//取消注册后Currencies.transfer不再产生ExtrinsicResponse
+	c.RegisterCallHandler("Currencies", "transfer", nil)
+	block, err = c.GetBlockByHash(testBlockHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(block.Extrinsic) != 1 || block.Extrinsic[0].ExtrinsicIndex != 3 {
+		t.Fatalf("unexpected extrinsics after unregister: %+v", block.Extrinsic)
+	}
+
+	//内置的解析函数与注册的解析函数使用同一个入口，可以被覆盖或者取消
+	c.RegisterCallHandler("Balances", "transfer", func(resp models.ExtrinsicDecodeResponse, idx int) (*models.ExtrinsicResponse, error) {
+		return &models.ExtrinsicResponse{Type: "custom_transfer", ToAddress: bob.address, Amount: "1"}, nil
+	})
+	block, err = c.GetBlockByHash(testBlockHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(block.Extrinsic) != 1 || block.Extrinsic[0].Type != "custom_transfer" {
+		t.Fatalf("unexpected extrinsics after override: %+v", block.Extrinsic)
+	}
+	c.RegisterCallHandler("Balances", "transfer", nil)
+	block, err = c.GetBlockByHash(testBlockHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(block.Extrinsic) != 0 {
+		t.Fatalf("unexpected extrinsics after unregister built-in: %+v", block.Extrinsic)
+	}
+}
+
+/*
+Staking、Assets、Proxy等内置的解析也通过注册表查找，可以被覆盖或者取消
+*/
+func Test_OverrideBuiltinStakingHandler_Offline(t *testing.T) {
+	meta := testMetadata()
+	me, err := expand.NewMetadataExpand(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := newTestAccount(t, 1)
+	unbondIdx, err := me.MV.GetCallIndex("Staking", "unbond")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unbond, err := expand.NewCall(unbondIdx, types.NewUCompactFromUInt(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rpc := fixedBlockRPC{
+		block: &models.SignedBlock{Block: models.Block{
+			Header: models.Header{ParentHash: testBlockHash, Number: "0x64"},
+			Extrinsics: []string{
+				timestampExtrinsic(t, meta, 1620000096000),
+				signedExtrinsic(t, alice, 0, unbond),
+			},
+		}},
+		events: eventsHex(t, successEvent(0), successEvent(1)),
+	}
+	c, err := client.NewWithRPCCaller(rpc, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetPrefix(testPrefix)
+	c.SetFetchFees(false)
+
+	block, err := c.GetBlockByHash(testBlockHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(block.Extrinsic) != 1 || block.Extrinsic[0].Type != "staking_unbond" || block.Extrinsic[0].Amount != "1000" ||
+		block.Extrinsic[0].FromAddress != alice.address || block.Timestamp != 1620000096000 {
+		t.Fatalf("unexpected built-in staking extrinsics: %+v", block.Extrinsic)
+	}
+
+	c.RegisterCallHandler("Staking", "unbond", func(resp models.ExtrinsicDecodeResponse, idx int) (*models.ExtrinsicResponse, error) {
+		return &models.ExtrinsicResponse{Type: "custom_unbond"}, nil
+	})
+	block, err = c.GetBlockByHash(testBlockHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(block.Extrinsic) != 1 || block.Extrinsic[0].Type != "custom_unbond" || block.Extrinsic[0].FromAddress != alice.address {
+		t.Fatalf("unexpected extrinsics after override: %+v", block.Extrinsic)
+	}
+
+	//取消内置的解析后，开启SetIncludeUnparsed时作为没有解析的extrinsic返回
+	c.RegisterCallHandler("Staking", "unbond", nil)
+	c.SetIncludeUnparsed(true)
+	block, err = c.GetBlockByHash(testBlockHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var unbondResp *models.ExtrinsicResponse
+	for _, e := range block.Extrinsic {
+		if e.ExtrinsicIndex == 1 {
+			unbondResp = e
+		}
+	}
+	if unbondResp == nil || unbondResp.Type != "Staking.unbond" {
+		t.Fatalf("unexpected extrinsics after unregister: %+v", block.Extrinsic)
+	}
+}
@@ -0,0 +1,45 @@
+package test
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/JFJun/bifrost-go/expand"
+)
+
+/*
+账户参数为ss58地址或者公钥hex时构建出相同的call，无法识别的账户返回错误
+*/
+func Test_Unit_TransferCallAccountArg(t *testing.T) {
+	me, err := expand.NewMetadataExpand(testMetadata())
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob := newTestAccount(t, 2)
+	byAddress, err := me.BalanceTransferCall(bob.address, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, account := range []string{bob.pubHex, "0x" + bob.pubHex} {
+		byPubkey, err := me.BalanceTransferCall(account, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(byAddress, byPubkey) {
+			t.Fatalf("call built from %s differs from call built from address", account)
+		}
+	}
+	bigCall, err := me.BalanceTransferBigCall("0x"+bob.pubHex, big.NewInt(100), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(byAddress, bigCall) {
+		t.Fatal("BalanceTransferBigCall differs from BalanceTransferCall")
+	}
+	for _, account := range []string{"", "0x1234", "not-an-address"} {
+		if _, err = me.BalanceTransferCall(account, 100); err == nil {
+			t.Fatalf("expected error for account %q", account)
+		}
+	}
+}
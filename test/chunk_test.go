@@ -0,0 +1,106 @@
+package test
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/JFJun/bifrost-go/client"
+)
+
+/*
+payment_queryInfo返回的weight为交易长度（字节）的weightPerByte倍
+*/
+type chunkRPC struct {
+	affordRPC
+	weightPerByte float64
+}
+
+func (m chunkRPC) Call(result interface{}, method string, args ...interface{}) error {
+	if method == "payment_queryInfo" {
+		length := len(strings.TrimPrefix(args[0].(string), "0x")) / 2
+		*(result.(*map[string]interface{})) = map[string]interface{}{
+			"weight":     m.weightPerByte * float64(length),
+			"partialFee": "100",
+		}
+		return nil
+	}
+	return m.affordRPC.Call(result, method, args...)
+}
+
+func chunkSizes(chunks [][]client.BatchRecipient) []int {
+	sizes := make([]int, 0, len(chunks))
+	for _, chunk := range chunks {
+		sizes = append(sizes, len(chunk))
+	}
+	return sizes
+}
+
+func testRecipients(to string, n int) []client.BatchRecipient {
+	recipients := make([]client.BatchRecipient, n)
+	for i := range recipients {
+		recipients[i] = client.BatchRecipient{To: to, Amount: big.NewInt(int64(i%50 + 1))}
+	}
+	return recipients
+}
+
+func Test_ChunkBatchTransfers_Offline(t *testing.T) {
+	bob := newTestAccount(t, 2)
+	c, err := client.NewWithRPCCaller(testRPC{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipients := testRecipients(bob.address, 10)
+	if got := fmt.Sprint(chunkSizes(c.ChunkBatchTransfers(recipients, 3))); got != "[3 3 3 1]" {
+		t.Fatalf("unexpected chunks: %s", got)
+	}
+	if got := fmt.Sprint(chunkSizes(c.ChunkBatchTransfers(recipients, 0))); got != "[10]" {
+		t.Fatalf("unexpected chunks without limit: %s", got)
+	}
+	if chunks := c.ChunkBatchTransfers(nil, 3); chunks != nil {
+		t.Fatalf("expected no chunks, got %d", len(chunks))
+	}
+}
+
+func Test_ChunkBatchTransfersByWeight_Offline(t *testing.T) {
+	alice := newTestAccount(t, 1)
+	bob := newTestAccount(t, 2)
+	/*
+		每笔转账36字节，batch交易本身106字节；weight上限为100000*75%=75000，长度上限为5000*75%=3750
+	*/
+	cases := []struct {
+		weightPerByte float64
+		count         int
+		sizes         string
+	}{
+		//weight限制：(75000-106*200)/(36*200)=7
+		{weightPerByte: 200, count: 10, sizes: "[7 3]"},
+		//长度限制：(3750-106)/36=101
+		{weightPerByte: 1, count: 250, sizes: "[101 101 48]"},
+	}
+	for _, cs := range cases {
+		c, err := client.NewWithRPCCaller(chunkRPC{weightPerByte: cs.weightPerByte}, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.SetPrefix(testPrefix)
+		chunks, err := c.ChunkBatchTransfersByWeight(alice.address, testRecipients(bob.address, cs.count))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := fmt.Sprint(chunkSizes(chunks)); got != cs.sizes {
+			t.Fatalf("weight per byte %v: expected %s, got %s", cs.weightPerByte, cs.sizes, got)
+		}
+	}
+
+	c, err := client.NewWithRPCCaller(chunkRPC{weightPerByte: 1000}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetPrefix(testPrefix)
+	_, err = c.ChunkBatchTransfersByWeight(alice.address, testRecipients(bob.address, 2))
+	if err == nil {
+		t.Fatal("expected error when a single transfer exceeds the weight limit")
+	}
+}
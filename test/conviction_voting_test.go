@@ -0,0 +1,166 @@
+package test
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/JFJun/bifrost-go/client"
+	"github.com/JFJun/bifrost-go/expand"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+func u128Hex(t *testing.T, v int64) string {
+	b, err := types.EncodeToBytes(types.NewU128(*big.NewInt(v)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+/*
+Casting：votes(Vec<(PollIndex, AccountVote)>) + delegations + prior
+*/
+func castingHex(t *testing.T) string {
+	return "0x00" + "0c" +
+		//Standard，aye，conviction 3
+		"01000000" + "00" + "83" + u128Hex(t, 100) +
+		"02000000" + "01" + u128Hex(t, 10) + u128Hex(t, 20) +
+		"03000000" + "02" + u128Hex(t, 1) + u128Hex(t, 2) + u128Hex(t, 3) +
+		u128Hex(t, 5) + u128Hex(t, 6) +
+		"64000000" + u128Hex(t, 7)
+}
+
+/*
+Delegating：balance + target + conviction + delegations + prior
+*/
+func delegatingHex(t *testing.T, target testAccount) string {
+	return "0x01" + u128Hex(t, 500) + target.pubHex + "02" +
+		u128Hex(t, 0) + u128Hex(t, 0) +
+		"00000000" + u128Hex(t, 0)
+}
+
+func Test_Unit_ConvictionVotingDecode(t *testing.T) {
+	bob := newTestAccount(t, 2)
+	var casting expand.ConvictionVoting
+	err := types.DecodeFromHexString(castingHex(t), &casting)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !casting.IsCasting || casting.IsDelegating || len(casting.Votes) != 3 {
+		t.Fatalf("unexpected casting: %+v", casting)
+	}
+	standard := casting.Votes[0]
+	if standard.PollIndex != 1 || standard.Vote.Type != "Standard" || !standard.Vote.Aye ||
+		standard.Vote.Conviction != 3 || standard.Vote.Balance.Int64() != 100 {
+		t.Fatalf("unexpected standard vote: %+v", standard)
+	}
+	split := casting.Votes[1].Vote
+	if split.Type != "Split" || split.AyeAmount.Int64() != 10 || split.NayAmount.Int64() != 20 {
+		t.Fatalf("unexpected split vote: %+v", split)
+	}
+	abstain := casting.Votes[2].Vote
+	if abstain.Type != "SplitAbstain" || abstain.AyeAmount.Int64() != 1 || abstain.NayAmount.Int64() != 2 ||
+		abstain.Abstain.Int64() != 3 {
+		t.Fatalf("unexpected split abstain vote: %+v", abstain)
+	}
+	if casting.Delegations.Votes.Int64() != 5 || casting.Delegations.Capital.Int64() != 6 ||
+		casting.Prior.UnlockAt != 100 || casting.Prior.Balance.Int64() != 7 {
+		t.Fatalf("unexpected casting delegations or prior: %+v %+v", casting.Delegations, casting.Prior)
+	}
+
+	var delegating expand.ConvictionVoting
+	err = types.DecodeFromHexString(delegatingHex(t, bob), &delegating)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !delegating.IsDelegating || delegating.IsCasting || delegating.Balance.Int64() != 500 ||
+		delegating.Target != "0x"+bob.pubHex || delegating.Conviction != 2 || len(delegating.Votes) != 0 {
+		t.Fatalf("unexpected delegating: %+v", delegating)
+	}
+
+	//nay，没有conviction（0.1x）
+	var nay expand.AccountVote
+	err = types.DecodeFromHexString("0x0000"+u128Hex(t, 9), &nay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nay.Aye || nay.Conviction != 0 || nay.Balance.Int64() != 9 {
+		t.Fatalf("unexpected nay vote: %+v", nay)
+	}
+
+	failures := map[string]string{
+		"unknown voting":    "0x02",
+		"unknown vote":      "0x00" + "04" + "01000000" + "03",
+		"truncated casting": castingHex(t)[:100],
+		"missing prior":     delegatingHex(t, bob)[:len(delegatingHex(t, bob))-40],
+		"empty":             "0x",
+	}
+	for name, data := range failures {
+		var voting expand.ConvictionVoting
+		if err := types.DecodeFromHexString(data, &voting); err == nil {
+			t.Fatalf("%s: expected error, got %+v", name, voting)
+		}
+	}
+}
+
+/*
+按ClassLocksFor中的每个class读取VotingFor
+*/
+func Test_GetConvictionVotingLocks_Offline(t *testing.T) {
+	meta := testMetadata()
+	alice := newTestAccount(t, 1)
+	bob := newTestAccount(t, 2)
+	pub := types.MustHexDecodeString(alice.pubHex)
+	key := func(method string, args ...[]byte) string {
+		var arg2 []byte
+		if len(args) > 1 {
+			arg2 = args[1]
+		}
+		k, err := types.CreateStorageKey(meta, "ConvictionVoting", method, args[0], arg2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return k.Hex()
+	}
+	class := func(c uint16) []byte {
+		b, err := types.EncodeToBytes(types.NewU16(c))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	//class 0锁定100，class 1锁定500，class 2没有投票记录
+	locks := "0x0c" + "0000" + u128Hex(t, 100) + "0100" + u128Hex(t, 500) + "0200" + u128Hex(t, 1)
+	rpc := storageRPC{values: map[string][]byte{
+		key("ClassLocksFor", pub):       types.MustHexDecodeString(locks),
+		key("VotingFor", pub, class(0)): types.MustHexDecodeString(castingHex(t)),
+		key("VotingFor", pub, class(1)): types.MustHexDecodeString(delegatingHex(t, bob)),
+	}}
+	c, err := client.NewWithRPCCaller(rpc, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := c.GetConvictionVotingLocks(alice.address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.ClassLocks) != 3 || result.ClassLocks[1].Class != 1 || result.ClassLocks[1].Balance.Int64() != 500 {
+		t.Fatalf("unexpected class locks: %+v", result.ClassLocks)
+	}
+	if len(result.Voting) != 2 || !result.Voting[0].IsCasting || !result.Voting[1].IsDelegating || result.Voting[2] != nil {
+		t.Fatalf("unexpected voting: %+v", result.Voting)
+	}
+
+	//没有锁定的账户
+	result, err = c.GetConvictionVotingLocks(bob.address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.ClassLocks) != 0 || len(result.Voting) != 0 {
+		t.Fatalf("expected no locks, got %+v", result)
+	}
+	if _, err := c.GetConvictionVotingLocks("invalid"); err == nil {
+		t.Fatal("expected error for invalid address")
+	}
+}
@@ -0,0 +1,67 @@
+package test
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/JFJun/bifrost-go/client"
+	"github.com/JFJun/bifrost-go/expand"
+	"github.com/JFJun/bifrost-go/expand/base"
+	"github.com/JFJun/bifrost-go/models"
+)
+
+/*
+metadata中找不到的Module错误记录下标，也可以通过SetModuleErrorResolver自定义
+*/
+func Test_UnknownModuleError_Offline(t *testing.T) {
+	meta := testMetadata()
+	me, err := expand.NewMetadataExpand(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := newTestAccount(t, 1)
+	bob := newTestAccount(t, 2)
+	transfer, err := me.BalanceTransferBigCall(bob.address, big.NewInt(100), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rpc := fixedBlockRPC{
+		block: &models.SignedBlock{Block: models.Block{
+			Header:     models.Header{ParentHash: testBlockHash, Number: "0x64"},
+			Extrinsics: []string{timestampExtrinsic(t, meta, 1620000090000), signedExtrinsic(t, alice, 0, transfer)},
+		}},
+		events: eventsHex(t,
+			successEvent(0),
+			failedEvent(1, base.DispatchError{Variant: 3, Module: base.ModuleError{Index: 50, Error: 7}}),
+		),
+	}
+	c, err := client.NewWithRPCCaller(rpc, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetPrefix(testPrefix)
+	c.SetFetchFees(false)
+
+	resp, err := c.GetBlockByHash(testBlockHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Extrinsic) != 1 || resp.Extrinsic[0].Status != "fail" || resp.Extrinsic[0].FailReason != "module:50 error:7 (unknown)" {
+		t.Fatalf("unexpected failed extrinsic: %+v", resp.Extrinsic)
+	}
+
+	c.SetModuleErrorResolver(func(moduleIndex, errorIndex uint8) (string, bool) {
+		if moduleIndex != 50 {
+			return "", false
+		}
+		return fmt.Sprintf("Module(NewPallet.Error%d)", errorIndex), true
+	})
+	resp, err = c.GetBlockByHash(testBlockHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Extrinsic[0].FailReason != "Module(NewPallet.Error7)" {
+		t.Fatalf("unexpected resolved fail reason: %s", resp.Extrinsic[0].FailReason)
+	}
+}
@@ -0,0 +1,67 @@
+package test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/JFJun/bifrost-go/utils"
+)
+
+/*
+编码与substrate中Era::mortal的测试用例一致
+*/
+func Test_Unit_DecodeEra(t *testing.T) {
+	mortal, period, phase, err := utils.DecodeEra("")
+	if err != nil || mortal || period != 0 || phase != 0 {
+		t.Fatalf("empty era should be immortal, got mortal=%v period=%d phase=%d err=%v", mortal, period, phase, err)
+	}
+
+	mortal, period, phase, err = utils.DecodeEra("0xa502") //Era::mortal(64, 42)
+	if err != nil || !mortal || period != 64 || phase != 42 {
+		t.Fatalf("unexpected era: mortal=%v period=%d phase=%d err=%v", mortal, period, phase, err)
+	}
+	birth, death := utils.EraRange(period, phase, 1000000)
+	if birth != 999978 || death != 1000042 {
+		t.Fatalf("unexpected era range: [%d, %d)", birth, death)
+	}
+
+	//period大于4096时phase会被量化
+	mortal, period, phase, err = utils.DecodeEra("4e9c") //Era::mortal(32768, 20000)
+	if err != nil || !mortal || period != 32768 || phase != 20000 {
+		t.Fatalf("unexpected quantized era: mortal=%v period=%d phase=%d err=%v", mortal, period, phase, err)
+	}
+
+	if _, _, _, err = utils.DecodeEra("a5"); err == nil {
+		t.Fatal("expected error for truncated era")
+	}
+}
+
+/*
+编码结果与substrate/polkadot.js中Era::mortal的测试用例一致
+*/
+func Test_Unit_EncodeEra(t *testing.T) {
+	cases := []struct {
+		period, current uint64
+		encoded         string
+		wantPeriod      uint64
+		wantPhase       uint64
+	}{
+		{64, 42, "a502", 64, 42},
+		{32768, 20000, "4e9c", 32768, 20000},
+		{200, 513, "1700", 256, 1},               //period向上取2的幂
+		{2, 1, "1100", 4, 1},                     //period最小为4
+		{4, 5, "1100", 4, 1},                     //phase为current % period
+		{1000000, 1000001, "4f42", 65536, 16960}, //period最大为65536，phase量化为16的倍数
+		{64, 12345678, "e500", 64, 12345678 % 64},
+	}
+	for _, c := range cases {
+		encoded := utils.EncodeEra(c.period, c.current)
+		if got := hex.EncodeToString(encoded[:]); got != c.encoded {
+			t.Fatalf("EncodeEra(%d, %d) = %s, want %s", c.period, c.current, got, c.encoded)
+		}
+		mortal, period, phase, err := utils.DecodeEra(c.encoded)
+		if err != nil || !mortal || period != c.wantPeriod || phase != c.wantPhase {
+			t.Fatalf("DecodeEra(%s): mortal=%v period=%d phase=%d err=%v", c.encoded, mortal, period, phase, err)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package test
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/JFJun/bifrost-go/client"
+	"github.com/JFJun/bifrost-go/expand"
+	"github.com/JFJun/bifrost-go/models"
+	"github.com/JFJun/bifrost-go/utils"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+/*
+System.Events无法解析时（被截断、未知的module或者event）返回错误，而不是把交易当作没有event处理
+*/
+func Test_MalformedEvents_Offline(t *testing.T) {
+	meta := testMetadata()
+	me, err := expand.NewMetadataExpand(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := newTestAccount(t, 1)
+	bob := newTestAccount(t, 2)
+	transfer, err := me.BalanceTransferCall(bob.address, 12345)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := &models.SignedBlock{Block: models.Block{
+		Header:     models.Header{ParentHash: testBlockHash, Number: "0x64"},
+		Extrinsics: []string{timestampExtrinsic(t, meta, 1620000096000), signedExtrinsic(t, alice, 0, transfer)},
+	}}
+	valid := eventsHex(t, successEvent(0), transferEvent(1, alice, bob, types.NewU128(*big.NewInt(12345))), successEvent(1))
+	cases := map[string]string{
+		//最后一个event的参数被截断
+		"truncated": valid[:len(valid)-8],
+		//module为0xff的event，phase为ApplyExtrinsic(1)
+		"unknown module": "0x04" + "0001000000" + "ff00" + "00",
+		//System中不存在的event
+		"unknown event": "0x04" + "0001000000" + "00ff" + "00",
+		//event数量与数据不一致
+		"bad count": "0x10" + utils.Remove0X(valid)[2:],
+	}
+	for name, events := range cases {
+		c, err := client.NewWithRPCCaller(fixedBlockRPC{block: block, events: events}, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.SetPrefix(testPrefix)
+		c.SetFetchFees(false)
+		resp, err := c.GetBlockByHash(testBlockHash)
+		if err == nil {
+			t.Fatalf("%s: expected error for malformed events, got %+v", name, resp)
+		}
+	}
+
+	//正常的event可以解析，确认上面的错误来自event本身
+	c, err := client.NewWithRPCCaller(fixedBlockRPC{block: block, events: valid}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetPrefix(testPrefix)
+	c.SetFetchFees(false)
+	resp, err := c.GetBlockByHash(testBlockHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Extrinsic) != 1 || resp.Extrinsic[0].Status != "success" {
+		t.Fatalf("unexpected extrinsics: %+v", resp.Extrinsic)
+	}
+}
+
+/*
+库中没有实现的event
+*/
+type vestingUpdatedEvent struct {
+	Phase    types.Phase
+	Account  types.AccountID
+	Unvested types.U128
+	Topics   []types.Hash
+}
+
+/*
+DecodeEventInto把库中没有实现的event解析为调用方的结构，其他event照常解析；hex不合法时返回错误而不是panic
+*/
+func Test_DecodeEventInto_Offline(t *testing.T) {
+	meta := testMetadata()
+	meta.AsMetadataV12.Modules = append(meta.AsMetadataV12.Modules, types.ModuleMetadataV12{
+		Name:      "Vesting",
+		HasEvents: true,
+		Events: []types.EventMetadataV4{
+			ev("VestingUpdated", "AccountId", "Balance"),
+		},
+		Index: 30,
+	})
+	alice, bob := newTestAccount(t, 1), newTestAccount(t, 2)
+	raw := eventsHex(t,
+		transferEvent(1, alice, bob, types.NewU128(*big.NewInt(100))),
+		testEvent{phase: applyExtrinsic(1), module: 30, event: 0, args: []interface{}{
+			types.NewAccountID(types.MustHexDecodeString(bob.pubHex)),
+			types.NewU128(*big.NewInt(42)),
+		}},
+		successEvent(1),
+	)
+	for _, target := range []interface{}{vestingUpdatedEvent{}, &vestingUpdatedEvent{}} {
+		events, err := expand.DecodeEventInto(meta, raw, testChainName, "Vesting", "VestingUpdated", target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(events) != 1 {
+			t.Fatalf("events: %d", len(events))
+		}
+		var event vestingUpdatedEvent
+		if ptr, ok := events[0].(*vestingUpdatedEvent); ok {
+			event = *ptr
+		} else {
+			event = events[0].(vestingUpdatedEvent)
+		}
+		if !event.Phase.IsApplyExtrinsic || event.Phase.AsApplyExtrinsic != 1 {
+			t.Fatalf("phase: %+v", event.Phase)
+		}
+		if event.Account != types.NewAccountID(types.MustHexDecodeString(bob.pubHex)) {
+			t.Fatalf("account: %x", event.Account)
+		}
+		if event.Unvested.String() != "42" {
+			t.Fatalf("unvested: %s", event.Unvested.String())
+		}
+	}
+
+	if _, err := expand.DecodeEventInto(meta, "0xzz", testChainName, "Vesting", "VestingUpdated", vestingUpdatedEvent{}); err == nil {
+		t.Fatal("invalid hex must return an error")
+	}
+	if _, err := expand.DecodeEventRecords(meta, "0xzz", testChainName); err == nil {
+		t.Fatal("invalid hex must return an error")
+	}
+}
+
+/*
+SetMaxEvents限制一个区块的event数量以及events数据的字节数（每个event按1KB估算），n<=0时使用默认值
+*/
+func Test_SetMaxEvents_Offline(t *testing.T) {
+	meta := testMetadata()
+	me, err := expand.NewMetadataExpand(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := newTestAccount(t, 1)
+	bob := newTestAccount(t, 2)
+	transfer, err := me.BalanceTransferCall(bob.address, 12345)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := &models.SignedBlock{Block: models.Block{
+		Header:     models.Header{ParentHash: testBlockHash, Number: "0x64"},
+		Extrinsics: []string{timestampExtrinsic(t, meta, 1620000096000), signedExtrinsic(t, alice, 0, transfer)},
+	}}
+	//4个event
+	events := eventsHex(t,
+		successEvent(0),
+		transferEvent(1, alice, bob, types.NewU128(*big.NewInt(12345))),
+		withdrawEvent(1, alice, types.NewU128(*big.NewInt(150))),
+		successEvent(1),
+	)
+	//1个event，但是数据超过1KB
+	large := eventsHex(t, testEvent{phase: applyExtrinsic(0), module: 0, event: 0, args: []interface{}{
+		types.DispatchInfo{Weight: 1000, Class: types.DispatchClass{IsNormal: true}, PaysFee: true},
+		make([]byte, 1100),
+	}})
+	cases := []struct {
+		name      string
+		maxEvents int
+		events    string
+		err       string
+	}{
+		{"default", 0, events, ""},
+		{"negative uses default", -1, events, ""},
+		{"exact", 4, events, ""},
+		{"too many events", 3, events, "too many events"},
+		{"too large", 1, large, "too large"},
+	}
+	for _, tc := range cases {
+		c, err := client.NewWithRPCCaller(fixedBlockRPC{block: block, events: tc.events}, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.SetPrefix(testPrefix)
+		c.SetFetchFees(false)
+		c.SetMaxEvents(tc.maxEvents)
+		resp, err := c.GetBlockByHash(testBlockHash)
+		if tc.err != "" {
+			if !errors.Is(err, client.ErrDecodeFailed) || !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("%s: expected ErrDecodeFailed (%s), got %v", tc.name, tc.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if len(resp.Extrinsic) != 1 || resp.Extrinsic[0].Status != "success" {
+			t.Fatalf("%s: unexpected extrinsics: %+v", tc.name, resp.Extrinsic)
+		}
+	}
+}
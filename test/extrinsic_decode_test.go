@@ -0,0 +1,182 @@
+package test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/JFJun/bifrost-go/client"
+	"github.com/JFJun/bifrost-go/expand"
+	"github.com/JFJun/bifrost-go/models"
+	"github.com/JFJun/bifrost-go/utils"
+	"github.com/stafiprotocol/go-substrate-rpc-client/scale"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+/*
+在已签名的extrinsic的tip之后（call之前）插入未知的签名扩展数据，并重新计算长度前缀
+*/
+func withUnknownSignedExtension(t *testing.T, signed string, call types.Call, extension []byte) string {
+	callBytes, err := types.EncodeToBytes(call)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var length types.UCompact
+	data := types.MustHexDecodeString(signed)
+	reader := bytes.NewReader(data)
+	err = scale.NewDecoder(reader).Decode(&length)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := data[len(data)-reader.Len():]
+	if !bytes.HasSuffix(body, callBytes) {
+		t.Fatal("extrinsic does not end with the call")
+	}
+	head := body[:len(body)-len(callBytes)]
+	body = append(append(append([]byte{}, head...), extension...), callBytes...)
+	prefix, err := types.EncodeToBytes(types.NewUCompactFromUInt(uint64(len(body))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "0x" + hex.EncodeToString(append(prefix, body...))
+}
+
+func decodeExtrinsic(t *testing.T, meta *types.Metadata, extrinsic string) (*expand.ExtrinsicDecoder, error) {
+	ed, err := expand.NewExtrinsicDecoder(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ed.ProcessExtrinsicDecoder(*scale.NewDecoder(bytes.NewReader(types.MustHexDecodeString(extrinsic))))
+	return ed, err
+}
+
+/*
+签名扩展中有未知的数据（比如runtime新增的CheckMetadataHash）时，仍然可以解析出call，同时标记签名扩展无法解析
+*/
+func Test_UnknownSignedExtension_Offline(t *testing.T) {
+	meta := testMetadata()
+	me, err := expand.NewMetadataExpand(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := newTestAccount(t, 1)
+	bob := newTestAccount(t, 2)
+	transfer, err := me.BalanceTransferCall(bob.address, 12345)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := signedExtrinsic(t, alice, 5, transfer)
+	//未知的扩展数据之后不是合法的call index
+	unknown := withUnknownSignedExtension(t, signed, transfer, []byte{0xff, 0xee, 0x01})
+
+	ed, err := decodeExtrinsic(t, meta, signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ed.SignatureUndecodable || ed.Nonce != 5 || ed.CallModuleFunction != "transfer" {
+		t.Fatalf("unexpected decode of normal extrinsic: %+v", ed)
+	}
+
+	ed, err = decodeExtrinsic(t, meta, unknown)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ed.SignatureUndecodable {
+		t.Fatal("expected signature undecodable for unknown signed extension")
+	}
+	if ed.CallModule != "Balances" || ed.CallModuleFunction != "transfer" || ed.Address != alice.pubHex {
+		t.Fatalf("unexpected call of extrinsic with unknown extension: %+v", ed)
+	}
+	if ed.Nonce != 0 || ed.Era != "" || ed.Tip != "" {
+		t.Fatalf("signed extra should be empty when undecodable: nonce=%d era=%q tip=%q", ed.Nonce, ed.Era, ed.Tip)
+	}
+	var value string
+	for _, param := range ed.Params {
+		if param.Name == "value" {
+			value, _ = utils.ValueToString(param.Value)
+		}
+	}
+	if value != "12345" {
+		t.Fatalf("unexpected transfer params: %+v", ed.Params)
+	}
+
+	//call被截断（缺少金额）时找不到可以完整解析的call，返回错误
+	truncated := withUnknownSignedExtension(t, signed, transfer, []byte{0xff, 0xee, 0x01})
+	truncated = truncated[:len(truncated)-4]
+	if ed, err := decodeExtrinsic(t, meta, truncated); err == nil {
+		t.Fatalf("expected error when the call cannot be found, got %s.%s %+v", ed.CallModule, ed.CallModuleFunction, ed.Params)
+	}
+
+	//区块解析中仍然可以识别为转账
+	block := &models.SignedBlock{Block: models.Block{
+		Header:     models.Header{ParentHash: testBlockHash, Number: "0x64"},
+		Extrinsics: []string{timestampExtrinsic(t, meta, 1620000096000), unknown},
+	}}
+	events := eventsHex(t,
+		successEvent(0),
+		transferEvent(1, alice, bob, types.NewU128(*big.NewInt(12345))),
+		successEvent(1),
+	)
+	c, err := client.NewWithRPCCaller(fixedBlockRPC{block: block, events: events}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetPrefix(testPrefix)
+	c.SetFetchFees(false)
+	resp, err := c.GetBlockByHash(testBlockHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Extrinsic) != 1 || resp.Extrinsic[0].ToAddress != bob.address || resp.Extrinsic[0].Status != "success" ||
+		!strings.EqualFold(resp.Extrinsic[0].FromAddress, alice.address) {
+		t.Fatalf("unexpected extrinsics: %+v", resp.Extrinsic)
+	}
+}
+
+/*
+未知签名扩展的第一个字节为0x00（比如CheckMetadataHash的mode为Disabled）时，tip之后的数据会被当作System模块的call，
+需要通过完整解析确认后面才是真正的call
+*/
+func Test_ZeroByteUnknownSignedExtension_Offline(t *testing.T) {
+	meta := testMetadata()
+	me, err := expand.NewMetadataExpand(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := newTestAccount(t, 1)
+	bob := newTestAccount(t, 2)
+	transfer, err := me.BalanceTransferCall(bob.address, 12345)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := signedExtrinsic(t, alice, 5, transfer)
+	unknown := withUnknownSignedExtension(t, signed, transfer, []byte{0x00})
+
+	ed, err := decodeExtrinsic(t, meta, unknown)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ed.SignatureUndecodable {
+		t.Fatalf("expected signature undecodable for 1-byte unknown signed extension, got %s.%s", ed.CallModule, ed.CallModuleFunction)
+	}
+	if ed.CallModule != "Balances" || ed.CallModuleFunction != "transfer" || ed.Address != alice.pubHex {
+		t.Fatalf("unexpected call of extrinsic with unknown extension: %s.%s %+v", ed.CallModule, ed.CallModuleFunction, ed.Params)
+	}
+	var value string
+	for _, param := range ed.Params {
+		if param.Name == "value" {
+			value, _ = utils.ValueToString(param.Value)
+		}
+	}
+	if value != "12345" {
+		t.Fatalf("unexpected transfer params: %+v", ed.Params)
+	}
+
+	//没有未知签名扩展、call本身无法解析时返回错误，不能当作解析成功
+	truncated := signed[:len(signed)-40]
+	if ed, err := decodeExtrinsic(t, meta, truncated); err == nil {
+		t.Fatalf("expected error for truncated call, got %s.%s %+v", ed.CallModule, ed.CallModuleFunction, ed.Params)
+	}
+}
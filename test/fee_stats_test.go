@@ -0,0 +1,125 @@
+package test
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/JFJun/bifrost-go/client"
+	"github.com/JFJun/bifrost-go/expand"
+	"github.com/JFJun/bifrost-go/models"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+/*
+按高度返回区块以及event的rpc，区块的hash为高度
+*/
+type heightBlocksRPC struct {
+	testRPC
+	blocks map[uint64]*models.SignedBlock
+	events map[uint64]string
+	latest uint64
+}
+
+func heightHash(height uint64) types.Hash {
+	var hash types.Hash
+	big.NewInt(int64(height)).FillBytes(hash[24:])
+	return hash
+}
+
+func (m heightBlocksRPC) GetBlockHash(blockNumber uint64) (types.Hash, error) {
+	return heightHash(blockNumber), nil
+}
+
+func (m heightBlocksRPC) Call(result interface{}, method string, args ...interface{}) error {
+	switch method {
+	case "chain_getFinalizedHead":
+		*(result.(*string)) = heightHash(m.latest).Hex()
+		return nil
+	case "chain_getHeader":
+		*(result.(*models.Header)) = models.Header{Number: fmt.Sprintf("0x%x", m.latest)}
+		return nil
+	case "chain_getBlock":
+		hash := types.MustHexDecodeString(args[0].(string))
+		*(result.(**models.SignedBlock)) = m.blocks[new(big.Int).SetBytes(hash).Uint64()]
+		return nil
+	case "state_getStorageAt":
+		hash := types.MustHexDecodeString(args[1].(string))
+		*(result.(*string)) = m.events[new(big.Int).SetBytes(hash).Uint64()]
+		return nil
+	}
+	return m.testRPC.Call(result, method, args...)
+}
+
+func Test_RecentFeeStats_Offline(t *testing.T) {
+	meta := testMetadata()
+	me, err := expand.NewMetadataExpand(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := newTestAccount(t, 1)
+	bob := newTestAccount(t, 2)
+	transfer, err := me.BalanceTransferCall(bob.address, 12345)
+	if err != nil {
+		t.Fatal(err)
+	}
+	extrinsic := signedExtrinsic(t, alice, 0, transfer)
+	u128 := func(v int64) types.U128 {
+		return types.NewU128(*big.NewInt(v))
+	}
+	rpc := heightBlocksRPC{blocks: map[uint64]*models.SignedBlock{}, events: map[uint64]string{}, latest: 12}
+	//高度10不在统计范围内
+	for height := uint64(10); height <= 12; height++ {
+		rpc.blocks[height] = &models.SignedBlock{Block: models.Block{
+			Header:     models.Header{Number: fmt.Sprintf("0x%x", height)},
+			Extrinsics: []string{timestampExtrinsic(t, meta, 1620000096000), extrinsic, extrinsic},
+		}}
+	}
+	rpc.events[10] = eventsHex(t,
+		successEvent(0),
+		feePaidEvent(1, alice, u128(99999), u128(99999)),
+		successEvent(1),
+		feePaidEvent(2, alice, u128(99999), u128(99999)),
+		successEvent(2),
+	)
+	rpc.events[11] = eventsHex(t,
+		successEvent(0),
+		feePaidEvent(1, alice, u128(1000), u128(0)),
+		successEvent(1),
+		feePaidEvent(2, alice, u128(3000), u128(500)),
+		successEvent(2),
+	)
+	//没有TransactionFeePaid时使用Withdraw减去退回的Deposit，小费从交易中获取
+	rpc.events[12] = eventsHex(t,
+		successEvent(0),
+		withdrawEvent(1, alice, u128(2500)),
+		depositEvent(1, alice, u128(500)),
+		successEvent(1),
+		feePaidEvent(2, alice, u128(4000), u128(1000)),
+		successEvent(2),
+	)
+	c, err := client.NewWithRPCCaller(rpc, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats, err := c.RecentFeeStats(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.StartHeight != 11 || stats.EndHeight != 12 || stats.Extrinsics != 4 {
+		t.Fatalf("unexpected fee stats: %+v", stats)
+	}
+	//手续费：1000 2000 3000 4000，小费：0 0 500 1000
+	fee := fmt.Sprint(stats.Fee.Min, stats.Fee.P25, stats.Fee.Median, stats.Fee.P75, stats.Fee.P90, stats.Fee.Max)
+	if fee != "1000 1000 2000 3000 4000 4000" {
+		t.Fatalf("unexpected fee distribution: %s", fee)
+	}
+	tip := fmt.Sprint(stats.Tip.Min, stats.Tip.Median, stats.Tip.P75, stats.Tip.Max)
+	if tip != "0 0 500 1000" {
+		t.Fatalf("unexpected tip distribution: %s", tip)
+	}
+
+	if _, err = c.RecentFeeStats(0); err == nil {
+		t.Fatal("expected error for invalid block count")
+	}
+}
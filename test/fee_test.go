@@ -0,0 +1,144 @@
+package test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/JFJun/bifrost-go/client"
+	"github.com/JFJun/bifrost-go/expand"
+	"github.com/JFJun/bifrost-go/models"
+	"github.com/JFJun/bifrost-go/utils"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+/*
+手续费按TransactionFeePaid拆分为base、len以及weight，没有这个event时使用Withdraw减去退回的Deposit
+*/
+func Test_GetFeeBreakdown_Offline(t *testing.T) {
+	meta := testMetadata()
+	me, err := expand.NewMetadataExpand(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := newTestAccount(t, 1)
+	bob := newTestAccount(t, 2)
+	transfer, err := me.BalanceTransferCall(bob.address, 12345)
+	if err != nil {
+		t.Fatal(err)
+	}
+	extrinsic := signedExtrinsic(t, alice, 0, transfer)
+	length := len(utils.Remove0X(extrinsic)) / 2
+	//base(100) + len(10 * length) + weight(5000)
+	total := int64(testExtrinsicBaseWeight + testTransactionByteFee*length + 5000)
+	block := &models.SignedBlock{Block: models.Block{
+		Header:     models.Header{ParentHash: testBlockHash, Number: "0x64"},
+		Extrinsics: []string{timestampExtrinsic(t, meta, 1620000096000), extrinsic, extrinsic},
+	}}
+	events := eventsHex(t,
+		successEvent(0),
+		feePaidEvent(1, alice, types.NewU128(*big.NewInt(total)), types.NewU128(*big.NewInt(0))),
+		successEvent(1),
+		withdrawEvent(2, alice, types.NewU128(*big.NewInt(9000))),
+		depositEvent(2, alice, types.NewU128(*big.NewInt(1000))),
+		successEvent(2),
+	)
+	c, err := client.NewWithRPCCaller(fixedBlockRPC{block: block, events: events}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	breakdown, err := c.GetFeeBreakdown(testBlockHash, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if breakdown.Total.Int64() != total || breakdown.Tip.Sign() != 0 || breakdown.Weight != 1000 || breakdown.Length != length {
+		t.Fatalf("unexpected fee breakdown: %+v", breakdown)
+	}
+	if breakdown.BaseFee == nil || breakdown.BaseFee.Int64() != testExtrinsicBaseWeight ||
+		breakdown.LenFee.Int64() != int64(testTransactionByteFee*length) || breakdown.WeightFee.Int64() != 5000 {
+		t.Fatalf("unexpected fee components: %+v", breakdown)
+	}
+
+	breakdown, err = c.GetFeeBreakdown(testBlockHash, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	//Withdraw 9000减去退回的1000
+	if breakdown.Total.Int64() != 8000 || breakdown.WeightFee == nil ||
+		breakdown.WeightFee.Int64() != int64(8000-testExtrinsicBaseWeight-testTransactionByteFee*length) {
+		t.Fatalf("unexpected fee breakdown from withdraw: %+v", breakdown)
+	}
+
+	if _, err = c.GetFeeBreakdown(testBlockHash, 0); err == nil {
+		t.Fatal("expected error for unsigned extrinsic")
+	}
+}
+
+/*
+预估手续费固定为queryFee的rpc
+*/
+type queryFeeRPC struct {
+	fixedBlockRPC
+	queryFee string
+}
+
+func (m queryFeeRPC) Call(result interface{}, method string, args ...interface{}) error {
+	if method == "payment_queryInfo" {
+		*(result.(*map[string]interface{})) = map[string]interface{}{"partialFee": m.queryFee}
+		return nil
+	}
+	return m.fixedBlockRPC.Call(result, method, args...)
+}
+
+/*
+有TransactionFeePaid时Fee为实际支付的手续费，没有时使用payment_queryInfo的预估值
+*/
+func Test_ActualFeeFromEvent_Offline(t *testing.T) {
+	meta := testMetadata()
+	me, err := expand.NewMetadataExpand(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := newTestAccount(t, 1)
+	bob := newTestAccount(t, 2)
+	transfer, err := me.BalanceTransferCall(bob.address, 12345)
+	if err != nil {
+		t.Fatal(err)
+	}
+	amount := types.NewU128(*big.NewInt(12345))
+	block := &models.SignedBlock{Block: models.Block{
+		Header: models.Header{ParentHash: testBlockHash, Number: "0x64"},
+		Extrinsics: []string{
+			timestampExtrinsic(t, meta, 1620000096000),
+			signedExtrinsic(t, alice, 0, transfer),
+			signedExtrinsic(t, alice, 1, transfer),
+		},
+	}}
+	events := eventsHex(t,
+		successEvent(0),
+		transferEvent(1, alice, bob, amount),
+		feePaidEvent(1, alice, types.NewU128(*big.NewInt(150)), types.NewU128(*big.NewInt(20))),
+		successEvent(1),
+		transferEvent(2, alice, bob, amount),
+		successEvent(2),
+	)
+	c, err := client.NewWithRPCCaller(queryFeeRPC{fixedBlockRPC{block: block, events: events}, "777"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetPrefix(testPrefix)
+	resp, err := c.GetBlockByHash(testBlockHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Extrinsic) != 2 {
+		t.Fatalf("expected 2 transfers, got %d", len(resp.Extrinsic))
+	}
+	paid, estimated := resp.Extrinsic[0], resp.Extrinsic[1]
+	if paid.Fee != "150" || paid.RawFee != "150" || paid.Tip != "20" || paid.RawTip != "20" {
+		t.Fatalf("unexpected actual fee: fee=%s tip=%s", paid.Fee, paid.Tip)
+	}
+	if estimated.Fee != "777" || estimated.Tip != "" {
+		t.Fatalf("unexpected estimated fee: fee=%s tip=%s", estimated.Fee, estimated.Tip)
+	}
+}
@@ -0,0 +1,533 @@
+package test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/JFJun/bifrost-go/client"
+	"github.com/JFJun/bifrost-go/expand"
+	"github.com/JFJun/bifrost-go/expand/base"
+	"github.com/JFJun/bifrost-go/models"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"golang.org/x/crypto/blake2b"
+)
+
+/*
+golden测试：离线解析区块，并与testdata下的结果进行比较
+结果有变化且确认无误时，使用 go test ./test -run Golden -update 更新golden文件
+*/
+var update = flag.Bool("update", false, "update golden files")
+
+type goldenBlock struct {
+	header     models.Header
+	extrinsics []string
+	events     string
+}
+
+func goldenBlocks(t *testing.T) map[string]goldenBlock {
+	meta := testMetadata()
+	me, err := expand.NewMetadataExpand(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := newTestAccount(t, 1)
+	bob := newTestAccount(t, 2)
+	header := models.Header{ParentHash: testBlockHash, Number: "0x64"}
+
+	transfer, err := me.BalanceTransferCall(bob.address, 12345)
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch, err := me.UtilityBatchTxCall(map[string]uint64{bob.address: 500}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	memoBatch := batchWithRemark(t, me, transfer, "deposit-42")
+	carol := newTestAccount(t, 3)
+	dispatchAsIdx, err := me.MV.GetCallIndex("Utility", "dispatch_as")
+	if err != nil {
+		t.Fatal(err)
+	}
+	//以frame_system的Signed(carol)身份执行转账
+	dispatchAs, err := expand.NewCall(dispatchAsIdx, types.U8(0), types.U8(1),
+		types.NewAccountID(types.MustHexDecodeString(carol.pubHex)), transfer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	remarkIdx, err := me.MV.GetCallIndex("System", "remark")
+	if err != nil {
+		t.Fatal(err)
+	}
+	inherent, err := expand.NewCall(remarkIdx, types.NewBytes([]byte("inherent")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	//bob作为carol的延迟代理，先声明再执行向alice的转账
+	transferToAlice, err := me.BalanceTransferCall(alice.address, 12345)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transferToAliceHash := types.NewHash(types.MustHexDecodeString(hashCall(t, transferToAlice)))
+	announceIdx, err := me.MV.GetCallIndex("Proxy", "announce")
+	if err != nil {
+		t.Fatal(err)
+	}
+	announce, err := expand.NewCall(announceIdx, multiAddress(carol), transferToAliceHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxyAnnouncedIdx, err := me.MV.GetCallIndex("Proxy", "proxy_announced")
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxyAnnounced, err := expand.NewCall(proxyAnnouncedIdx, multiAddress(bob), multiAddress(carol),
+		types.NewOptionBoolEmpty(), transferToAlice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unbondIdx, err := me.MV.GetCallIndex("Staking", "unbond")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unbond, err := expand.NewCall(unbondIdx, types.NewUCompactFromUInt(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	withdrawIdx, err := me.MV.GetCallIndex("Staking", "withdraw_unbonded")
+	if err != nil {
+		t.Fatal(err)
+	}
+	withdraw, err := expand.NewCall(withdrawIdx, types.NewU32(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokensIdx, err := me.MV.GetCallIndex("Tokens", "transfer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokensTransfer, err := expand.NewCall(tokensIdx, multiAddress(bob), types.NewU32(7), types.NewUCompactFromUInt(500))
+	if err != nil {
+		t.Fatal(err)
+	}
+	transferToCarol, err := me.BalanceTransferCall(carol.address, 777)
+	if err != nil {
+		t.Fatal(err)
+	}
+	xcmExecuteIdx, err := me.MV.GetCallIndex("PolkadotXcm", "execute")
+	if err != nil {
+		t.Fatal(err)
+	}
+	xcmExecute, err := expand.NewCall(xcmExecuteIdx, types.Data(xcmTransferMessage(t, bob)), types.NewU64(1000000000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	xcmSendIdx, err := me.MV.GetCallIndex("PolkadotXcm", "send")
+	if err != nil {
+		t.Fatal(err)
+	}
+	//发往中继链(parents=1,Here)的V2消息[ClearOrigin]
+	relay := []byte{0x01, 0x00}
+	clearOrigin := []byte{0x04, 0x0a}
+	xcmSend, err := expand.NewCall(xcmSendIdx, types.Data(append([]byte{0x01}, relay...)),
+		types.Data(append([]byte{0x02}, clearOrigin...)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	//Junctions::X1(AccountId32{network: Any, id: alice})
+	aliceLocation := append([]byte{0x00, 0x01, 0x01, 0x00}, types.MustHexDecodeString(alice.pubHex)...)
+	//超过2^53的金额，经过float64时会丢失精度
+	largeToBob, _ := new(big.Int).SetString("18446744073709551617", 10)
+	largeToCarol, _ := new(big.Int).SetString("1180591620717411303427", 10)
+	largeTransferToBob, err := me.BalanceTransferBigCall(bob.address, largeToBob, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	largeTransferToCarol, err := me.BalanceTransferBigCall(carol.address, largeToCarol, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	largeBatch, err := me.UtilityBatchCall([]types.Call{largeTransferToBob, largeTransferToCarol}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rebagIdx, err := me.MV.GetCallIndex("VoterList", "rebag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rebag, err := expand.NewCall(rebagIdx, types.U8(0), types.NewAccountID(types.MustHexDecodeString(carol.pubHex)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	putInFrontOfIdx, err := me.MV.GetCallIndex("VoterList", "put_in_front_of")
+	if err != nil {
+		t.Fatal(err)
+	}
+	putInFrontOf, err := expand.NewCall(putInFrontOfIdx, types.U8(0), types.NewAccountID(types.MustHexDecodeString(bob.pubHex)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	voteIdx, err := me.MV.GetCallIndex("PhragmenElection", "vote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	councilVote, err := expand.NewCall(voteIdx, []types.AccountID{
+		types.NewAccountID(types.MustHexDecodeString(bob.pubHex)),
+		types.NewAccountID(types.MustHexDecodeString(carol.pubHex)),
+	}, types.NewUCompactFromUInt(5000000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	candidacyIdx, err := me.MV.GetCallIndex("PhragmenElection", "submit_candidacy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	candidacy, err := expand.NewCall(candidacyIdx, types.NewUCompactFromUInt(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string]goldenBlock{
+		"balances_transfer": {
+			header: header,
+			extrinsics: []string{
+				timestampExtrinsic(t, meta, 1620000000000),
+				signedExtrinsic(t, alice, 0, transfer),
+			},
+			events: eventsHex(t,
+				successEvent(0),
+				transferEvent(1, alice, bob, types.NewU128(*big.NewInt(12345))),
+				successEvent(1),
+			),
+		},
+		"utility_batch": {
+			header: header,
+			extrinsics: []string{
+				timestampExtrinsic(t, meta, 1620000006000),
+				signedExtrinsic(t, alice, 1, batch),
+			},
+			events: eventsHex(t,
+				successEvent(0),
+				transferEvent(1, alice, bob, types.NewU128(*big.NewInt(500))),
+				successEvent(1),
+			),
+		},
+		"utility_batch_large_amount": {
+			header: header,
+			extrinsics: []string{
+				timestampExtrinsic(t, meta, 1620000078000),
+				signedExtrinsic(t, alice, 16, largeBatch),
+			},
+			//向carol的转账失败（Utility.BatchInterrupted），金额来自call的参数
+			events: eventsHex(t,
+				successEvent(0),
+				transferEvent(1, alice, bob, types.NewU128(*largeToBob)),
+				successEvent(1),
+			),
+		},
+		"balances_transfer_failed": {
+			header: header,
+			extrinsics: []string{
+				timestampExtrinsic(t, meta, 1620000018000),
+				signedExtrinsic(t, alice, 3, transfer),
+				signedExtrinsic(t, alice, 4, transfer),
+			},
+			events: eventsHex(t,
+				successEvent(0),
+				failedEvent(1, base.DispatchError{Variant: 3, Module: base.ModuleError{Index: 2, Error: 2}}),
+				failedEvent(2, base.DispatchError{Variant: 2}),
+			),
+		},
+		//inherent在前面时，转账的extrinsic_index仍然是链上的下标，并且能与event正确关联
+		"inherents_before_transfers": {
+			header: header,
+			extrinsics: []string{
+				timestampExtrinsic(t, meta, 1620000024000),
+				unsignedExtrinsic(t, inherent),
+				signedExtrinsic(t, alice, 5, transfer),
+				signedExtrinsic(t, alice, 6, batch),
+			},
+			events: eventsHex(t,
+				successEvent(0),
+				successEvent(1),
+				transferEvent(2, alice, bob, types.NewU128(*big.NewInt(12345))),
+				successEvent(2),
+				transferEvent(3, alice, bob, types.NewU128(*big.NewInt(500))),
+				successEvent(3),
+			),
+		},
+		//不支持的模块（Tokens）没有对应的ExtrinsicResponse，之后的转账仍然使用链上的下标关联event
+		//Tokens.transfer失败不能影响下标为2的转账，下标为2和3的转账金额也不能错位
+		"unsupported_module_between": {
+			header: header,
+			extrinsics: []string{
+				timestampExtrinsic(t, meta, 1620000060000),
+				signedExtrinsic(t, alice, 8, tokensTransfer),
+				signedExtrinsic(t, alice, 9, transferToCarol),
+				signedExtrinsic(t, alice, 10, transfer),
+			},
+			events: eventsHex(t,
+				successEvent(0),
+				failedEvent(1, base.DispatchError{Variant: 2}),
+				transferEvent(2, alice, carol, types.NewU128(*big.NewInt(777))),
+				successEvent(2),
+				transferEvent(3, alice, bob, types.NewU128(*big.NewInt(12345))),
+				successEvent(3),
+			),
+		},
+		"xcm_send_execute": {
+			header: header,
+			extrinsics: []string{
+				timestampExtrinsic(t, meta, 1620000066000),
+				signedExtrinsic(t, alice, 11, xcmExecute),
+				signedExtrinsic(t, alice, 12, xcmExecute),
+				signedExtrinsic(t, alice, 13, xcmSend),
+			},
+			events: eventsHex(t,
+				successEvent(0),
+				//Outcome::Complete(weight)
+				xcmAttemptedEvent(1, []byte{0x00, 0x00, 0xca, 0x9a, 0x3b, 0, 0, 0, 0}),
+				successEvent(1),
+				//Outcome::Incomplete(weight, Error::TooExpensive)
+				xcmAttemptedEvent(2, []byte{0x01, 0x00, 0x65, 0xcd, 0x1d, 0, 0, 0, 0, 20}),
+				successEvent(2),
+				xcmSentEvent(3, aliceLocation, relay, clearOrigin),
+				successEvent(3),
+			),
+		},
+		"bags_list": {
+			header: header,
+			extrinsics: []string{
+				timestampExtrinsic(t, meta, 1620000072000),
+				signedExtrinsic(t, alice, 14, rebag),
+				signedExtrinsic(t, alice, 15, rebag),
+				signedExtrinsic(t, carol, 0, putInFrontOf),
+			},
+			events: eventsHex(t,
+				successEvent(0),
+				rebaggedEvent(1, carol, 10000000000, 20000000000),
+				successEvent(1),
+				//carol已经在正确的bag中
+				successEvent(2),
+				successEvent(3),
+			),
+		},
+		"council_election": {
+			header: header,
+			extrinsics: []string{
+				timestampExtrinsic(t, meta, 1620000102000),
+				signedExtrinsic(t, alice, 16, councilVote),
+				signedExtrinsic(t, bob, 4, candidacy),
+			},
+			//投票押金以及候选人押金
+			events: eventsHex(t,
+				successEvent(0),
+				reserveEvent(1, 2, alice, types.NewU128(*big.NewInt(1000))),
+				successEvent(1),
+				reserveEvent(2, 2, bob, types.NewU128(*big.NewInt(100000))),
+				successEvent(2),
+			),
+		},
+		"utility_dispatch_as": {
+			header: header,
+			extrinsics: []string{
+				timestampExtrinsic(t, meta, 1620000030000),
+				signedExtrinsic(t, alice, 7, dispatchAs),
+			},
+			events: eventsHex(t,
+				successEvent(0),
+				transferEvent(1, carol, bob, types.NewU128(*big.NewInt(12345))),
+				successEvent(1),
+			),
+		},
+		"proxy_announce": {
+			header: header,
+			extrinsics: []string{
+				timestampExtrinsic(t, meta, 1620000036000),
+				signedExtrinsic(t, bob, 0, announce),
+				signedExtrinsic(t, bob, 1, announce),
+			},
+			events: eventsHex(t,
+				successEvent(0),
+				announcedEvent(1, carol, bob, transferToAliceHash),
+				successEvent(1),
+				//第二次声明没有产生Proxy.Announced
+				successEvent(2),
+			),
+		},
+		"balances_reserved": {
+			header: header,
+			extrinsics: []string{
+				timestampExtrinsic(t, meta, 1620000084000),
+				signedExtrinsic(t, bob, 2, announce),
+				signedExtrinsic(t, bob, 3, announce),
+			},
+			//声明时锁定押金，第二次声明替换了第一次的声明并释放了之前的押金
+			events: eventsHex(t,
+				successEvent(0),
+				reserveEvent(1, 2, bob, types.NewU128(*big.NewInt(2000))),
+				announcedEvent(1, carol, bob, transferToAliceHash),
+				successEvent(1),
+				reserveEvent(2, 3, bob, types.NewU128(*big.NewInt(2000))),
+				reserveEvent(2, 2, bob, types.NewU128(*big.NewInt(3000))),
+				announcedEvent(2, carol, bob, transferToAliceHash),
+				successEvent(2),
+			),
+		},
+		"proxy_announced": {
+			header: header,
+			extrinsics: []string{
+				timestampExtrinsic(t, meta, 1620000042000),
+				signedExtrinsic(t, bob, 2, proxyAnnounced),
+			},
+			events: eventsHex(t,
+				successEvent(0),
+				transferEvent(1, carol, alice, types.NewU128(*big.NewInt(12345))),
+				successEvent(1),
+			),
+		},
+		"sponsored_fee": {
+			header: header,
+			extrinsics: []string{
+				timestampExtrinsic(t, meta, 1620000048000),
+				signedExtrinsic(t, alice, 4, transfer),
+			},
+			events: eventsHex(t,
+				successEvent(0),
+				//手续费由carol代付
+				withdrawEvent(1, carol, types.NewU128(*big.NewInt(125000000))),
+				transferEvent(1, alice, bob, types.NewU128(*big.NewInt(12345))),
+				successEvent(1),
+			),
+		},
+		"staking_unbond_withdraw": {
+			header: header,
+			extrinsics: []string{
+				timestampExtrinsic(t, meta, 1620000054000),
+				signedExtrinsic(t, alice, 5, unbond),
+				signedExtrinsic(t, alice, 6, withdraw),
+				signedExtrinsic(t, alice, 7, withdraw),
+			},
+			events: eventsHex(t,
+				successEvent(0),
+				//只有800可以解绑
+				stakingEvent(1, 0, alice, types.NewU128(*big.NewInt(800))),
+				successEvent(1),
+				stakingEvent(2, 1, alice, types.NewU128(*big.NewInt(500))),
+				successEvent(2),
+				//没有到期的解绑，不产生Staking.Withdrawn
+				successEvent(3),
+			),
+		},
+		"utility_batch_memo": {
+			header: header,
+			extrinsics: []string{
+				timestampExtrinsic(t, meta, 1620000012000),
+				signedExtrinsic(t, alice, 2, memoBatch),
+			},
+			events: eventsHex(t,
+				successEvent(0),
+				transferEvent(1, alice, bob, types.NewU128(*big.NewInt(12345))),
+				successEvent(1),
+			),
+		},
+	}
+}
+
+/*
+V2的xcm消息：[WithdrawAsset(1 DOT), BuyExecution(0.01 DOT, Unlimited), DepositAsset(All, 1, beneficiary)]
+资产为中继链的原生币(parents=1,Here)
+*/
+func xcmTransferMessage(t *testing.T, beneficiary testAccount) []byte {
+	compact := func(v uint64) []byte {
+		b, err := types.EncodeToBytes(types.NewUCompactFromUInt(v))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	relayAsset := func(amount uint64) []byte {
+		return append([]byte{0x00, 0x01, 0x00, 0x00}, compact(amount)...)
+	}
+	message := []byte{0x02, 0x0c}
+	message = append(append(message, 0x00, 0x04), relayAsset(10000000000)...)
+	message = append(append(append(message, 0x13), relayAsset(100000000)...), 0x00)
+	message = append(message, 0x0d, 0x01, 0x00, 0x04, 0x00, 0x01, 0x01, 0x00)
+	return append(message, types.MustHexDecodeString(beneficiary.pubHex)...)
+}
+
+/*
+Utility.batch([transfer, System.remark(memo)])
+*/
+func batchWithRemark(t *testing.T, me *expand.MetadataExpand, transfer types.Call, memo string) types.Call {
+	remarkIdx, err := me.MV.GetCallIndex("System", "remark")
+	if err != nil {
+		t.Fatal(err)
+	}
+	remark, err := expand.NewCall(remarkIdx, types.NewBytes([]byte(memo)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	batchIdx, err := me.MV.GetCallIndex("Utility", "batch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch, err := expand.NewCall(batchIdx, []types.Call{transfer, remark})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return batch
+}
+
+func Test_ParseBlockOffline_Golden(t *testing.T) {
+	meta := testMetadata()
+	for name, block := range goldenBlocks(t) {
+		t.Run(name, func(t *testing.T) {
+			resp, err := client.ParseBlockOffline(meta, testPrefix, testChainName, block.extrinsics, block.events, block.header)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := json.MarshalIndent(resp, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden := filepath.Join("testdata", name+".golden.json")
+			if *update {
+				err = ioutil.WriteFile(golden, append(got, '\n'), 0644)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := ioutil.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("read golden file error: %v (run with -update to create it)", err)
+			}
+			if !bytes.Equal(bytes.TrimSpace(got), bytes.TrimSpace(want)) {
+				t.Errorf("block response mismatch\ngot:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+/*
+call的blake2_256，即Proxy.announce中的call_hash
+*/
+func hashCall(t *testing.T, call types.Call) string {
+	data, err := types.EncodeToBytes(call)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := blake2b.Sum256(data)
+	return hex.EncodeToString(h[:])
+}
+
+func multiAddress(account testAccount) expand.MultiAddress {
+	var ma expand.MultiAddress
+	ma.SetTypes(0)
+	ma.AccountId = types.NewAccountID(types.MustHexDecodeString(account.pubHex))
+	return ma
+}
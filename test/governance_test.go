@@ -0,0 +1,180 @@
+package test
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/JFJun/bifrost-go/client"
+	"github.com/JFJun/bifrost-go/expand"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"golang.org/x/crypto/blake2b"
+)
+
+/*
+preimage为Preimage.PreimageFor（preimageModule为true）或者Democracy.Preimages的metadata
+*/
+func preimageMetadata(preimageModule bool) *types.Metadata {
+	meta := testMetadata()
+	module := types.ModuleMetadataV12{
+		Name:       "Preimage",
+		HasStorage: true,
+		Storage: types.StorageMetadataV10{
+			Prefix: "Preimage",
+			Items: []types.StorageFunctionMetadataV10{
+				mapStorage("PreimageFor", "T::Hash", "BoundedVec<u8, ConstU32<MAX_SIZE>>",
+					types.StorageHasherV10{IsIdentity: true}),
+			},
+		},
+		Index: 19,
+	}
+	if !preimageModule {
+		module = types.ModuleMetadataV12{
+			Name:       "Democracy",
+			HasStorage: true,
+			Storage: types.StorageMetadataV10{
+				Prefix: "Democracy",
+				Items: []types.StorageFunctionMetadataV10{
+					mapStorage("Preimages", "T::Hash", "PreimageStatus<T::AccountId, BalanceOf<T>, T::BlockNumber>",
+						types.StorageHasherV10{IsIdentity: true}),
+				},
+			},
+			Index: 19,
+		}
+	}
+	meta.AsMetadataV12.Modules = append(meta.AsMetadataV12.Modules, module)
+	return meta
+}
+
+/*
+storage中保存了preimage的rpc，storage的key以及value都是hex
+*/
+type preimageRPC struct {
+	testRPC
+	meta    *types.Metadata
+	storage map[string]string
+}
+
+func (m preimageRPC) GetMetadataLatest() (*types.Metadata, error) {
+	return m.meta, nil
+}
+
+func (m preimageRPC) GetStorageLatest(key types.StorageKey, target interface{}) (bool, error) {
+	value, ok := m.storage[key.Hex()]
+	if !ok {
+		return false, nil
+	}
+	return true, types.DecodeFromHexString(value, target)
+}
+
+func (m preimageRPC) Call(result interface{}, method string, args ...interface{}) error {
+	switch method {
+	case "state_getKeysPaged":
+		prefix, count, startKey := args[0].(string), args[1].(int), args[2].(string)
+		var keys []string
+		for key := range m.storage {
+			if strings.HasPrefix(key, prefix) && key > startKey {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		if len(keys) > count {
+			keys = keys[:count]
+		}
+		*(result.(*[]string)) = keys
+		return nil
+	case "state_getStorage":
+		*(result.(*string)) = m.storage[args[0].(string)]
+		return nil
+	}
+	return m.testRPC.Call(result, method, args...)
+}
+
+/*
+GetPreimage从Preimage.PreimageFor（旧版本的key为hash，新版本为(hash, len)）或者Democracy.Preimages中读取call
+*/
+func Test_GetPreimage_Offline(t *testing.T) {
+	bob := newTestAccount(t, 2)
+	me, err := expand.NewMetadataExpand(testMetadata())
+	if err != nil {
+		t.Fatal(err)
+	}
+	transfer, err := me.BalanceTransferCall(bob.address, 12345)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := types.EncodeToBytes(transfer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blake2Hash := func(data []byte) types.Hash {
+		return types.Hash(blake2b.Sum256(data))
+	}
+	hash := blake2Hash(encoded)
+	bytesHex := func(data []byte) string {
+		h, err := types.EncodeToHexString(types.NewBytes(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+	//Identity的key就是hash本身
+	storageKey := func(meta *types.Metadata, module, fn string, h types.Hash) string {
+		key, err := types.CreateStorageKey(meta, module, fn, h[:], nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return key.Hex()
+	}
+	preimageMeta := preimageMetadata(true)
+	democracyMeta := preimageMetadata(false)
+	notCall := blake2Hash([]byte{0xff, 0xff})
+	cases := []struct {
+		name    string
+		meta    *types.Metadata
+		storage map[string]string
+		notCall bool //storage中是否有不是call的preimage
+	}{
+		{"PreimageFor hash key", preimageMeta, map[string]string{
+			storageKey(preimageMeta, "Preimage", "PreimageFor", hash): bytesHex(encoded),
+		}, false},
+		{"PreimageFor (hash, len) key", preimageMeta, map[string]string{
+			//(hash, len)的key，len为u32，查找时不需要知道
+			storageKey(preimageMeta, "Preimage", "PreimageFor", hash) + "2a000000":    bytesHex(encoded),
+			storageKey(preimageMeta, "Preimage", "PreimageFor", notCall) + "02000000": bytesHex([]byte{0xff, 0xff}),
+		}, true},
+		{"Democracy.Preimages", democracyMeta, map[string]string{
+			//PreimageStatus::Available
+			storageKey(democracyMeta, "Democracy", "Preimages", hash):    "0x01" + strings.TrimPrefix(bytesHex(encoded), "0x"),
+			storageKey(democracyMeta, "Democracy", "Preimages", notCall): "0x01" + strings.TrimPrefix(bytesHex([]byte{0xff, 0xff}), "0x"),
+		}, true},
+	}
+	for _, tc := range cases {
+		c, err := client.NewWithRPCCaller(preimageRPC{meta: tc.meta, storage: tc.storage}, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.SetPrefix(testPrefix)
+		call, err := c.GetPreimage(hash.Hex())
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if encodeCall(t, call) != encodeCall(t, transfer) {
+			t.Fatalf("%s: unexpected call %s", tc.name, encodeCall(t, call))
+		}
+		missing := blake2Hash([]byte("missing"))
+		if _, err = c.GetPreimage(missing.Hex()); err == nil || !strings.Contains(err.Error(), "not found") {
+			t.Fatalf("%s: expected not found error, got %v", tc.name, err)
+		}
+		if _, err = c.GetPreimage("0x1234"); err == nil {
+			t.Fatalf("%s: expected error for invalid hash", tc.name)
+		}
+		if !tc.notCall {
+			continue
+		}
+		if _, err = c.GetPreimage(notCall.Hex()); !errors.Is(err, client.ErrDecodeFailed) {
+			t.Fatalf("%s: expected ErrDecodeFailed for preimage that is not a call, got %v", tc.name, err)
+		}
+	}
+}
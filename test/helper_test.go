@@ -0,0 +1,616 @@
+package test
+
+import (
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/JFJun/bifrost-go/expand"
+	"github.com/JFJun/bifrost-go/expand/base"
+	"github.com/JFJun/bifrost-go/tx"
+	"github.com/JFJun/go-substrate-crypto/crypto"
+	"github.com/JFJun/go-substrate-crypto/ss58"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+/*
+离线测试用到的公共数据：构造一个只包含解析区块所需模块的metadata，以及对应的extrinsic和event
+*/
+
+const (
+	testGenesisHash = "0xb0a8d493285c2df73290dfb7e61f870f17b41801197a149ca93654499ea3dafe"
+	testBlockHash   = "0x5a1a8b7d3cbe5d9a5fbbd0e1f6e0d4c3b2a1908f7e6d5c4b3a29180f7e6d5c4b"
+	testChainName   = "bifrost"
+)
+
+var testPrefix = ss58.PolkadotPrefix
+
+func fn(name string, args ...string) types.FunctionMetadataV4 {
+	f := types.FunctionMetadataV4{Name: types.Text(name)}
+	for _, arg := range args {
+		kv := strings.SplitN(arg, ":", 2)
+		f.Args = append(f.Args, types.FunctionArgumentMetadata{Name: types.Text(kv[0]), Type: types.Type(kv[1])})
+	}
+	return f
+}
+
+func ev(name string, args ...string) types.EventMetadataV4 {
+	e := types.EventMetadataV4{Name: types.Text(name)}
+	for _, arg := range args {
+		e.Args = append(e.Args, types.Type(arg))
+	}
+	return e
+}
+
+func plainStorage(name, typ string) types.StorageFunctionMetadataV10 {
+	return types.StorageFunctionMetadataV10{
+		Name:     types.Text(name),
+		Modifier: types.StorageFunctionModifierV0{IsDefault: true},
+		Type:     types.StorageFunctionTypeV10{IsType: true, AsType: types.Type(typ)},
+	}
+}
+
+func mapStorage(name, key, value string, hasher types.StorageHasherV10) types.StorageFunctionMetadataV10 {
+	return types.StorageFunctionMetadataV10{
+		Name:     types.Text(name),
+		Modifier: types.StorageFunctionModifierV0{IsDefault: true},
+		Type: types.StorageFunctionTypeV10{IsMap: true, AsMap: types.MapTypeV10{
+			Hasher: hasher,
+			Key:    types.Type(key),
+			Value:  types.Type(value),
+		}},
+	}
+}
+
+const (
+	testExtrinsicBaseWeight = 100
+	testTransactionByteFee  = 10
+	testExistentialDeposit  = 500
+	testMaximumBlockWeight  = 100000
+	testMaximumBlockLength  = 5000
+	testAvailableBlockRatio = 750000000
+)
+
+func constant(name, typ string, value interface{}) types.ModuleConstantMetadataV6 {
+	data, err := types.EncodeToBytes(value)
+	if err != nil {
+		panic(err)
+	}
+	return types.ModuleConstantMetadataV6{Name: types.Text(name), Type: types.Type(typ), Value: data}
+}
+
+/*
+模块索引：System=0,Timestamp=1,Balances=2,Utility=3,Proxy=4,Tokens=5（自定义pallet）,Staking=6,PolkadotXcm=7,VoterList=8,TransactionPayment=9,ParachainSystem=10,PhragmenElection=11,Assets=12
+*/
+func testModules() []types.ModuleMetadataV12 {
+	return []types.ModuleMetadataV12{
+		{
+			Name:       "System",
+			HasStorage: true,
+			Storage: types.StorageMetadataV10{
+				Prefix: "System",
+				Items: []types.StorageFunctionMetadataV10{
+					mapStorage("Account", "AccountId", "AccountInfo", types.StorageHasherV10{IsBlake2_128Concat: true}),
+					plainStorage("Events", "Vec<EventRecord<Event, Hash>>"),
+				},
+			},
+			HasCalls: true,
+			Calls: []types.FunctionMetadataV4{
+				fn("remark", "_remark:Vec<u8>"),
+				fn("set_heap_pages", "pages:u64"),
+				fn("set_code", "code:Vec<u8>"),
+			},
+			HasEvents: true,
+			Events: []types.EventMetadataV4{
+				ev("ExtrinsicSuccess", "DispatchInfo"),
+				ev("ExtrinsicFailed", "DispatchError", "DispatchInfo"),
+			},
+			Constants: []types.ModuleConstantMetadataV6{
+				constant("ExtrinsicBaseWeight", "Weight", types.U64(testExtrinsicBaseWeight)),
+				constant("MaximumBlockWeight", "Weight", types.U64(testMaximumBlockWeight)),
+				constant("MaximumBlockLength", "u32", types.U32(testMaximumBlockLength)),
+				constant("AvailableBlockRatio", "Perbill", types.U32(testAvailableBlockRatio)),
+			},
+			Index: 0,
+		},
+		{
+			Name:       "Timestamp",
+			HasStorage: true,
+			Storage: types.StorageMetadataV10{
+				Prefix: "Timestamp",
+				Items:  []types.StorageFunctionMetadataV10{plainStorage("Now", "T::Moment")},
+			},
+			HasCalls: true,
+			Calls:    []types.FunctionMetadataV4{fn("set", "now:Compact<T::Moment>")},
+			Index:    1,
+		},
+		{
+			Name:       "Balances",
+			HasStorage: true,
+			Storage: types.StorageMetadataV10{
+				Prefix: "Balances",
+				Items:  []types.StorageFunctionMetadataV10{plainStorage("TotalIssuance", "T::Balance")},
+			},
+			HasCalls: true,
+			Calls: []types.FunctionMetadataV4{
+				fn("transfer", "dest:<T::Lookup as StaticLookup>::Source", "value:Compact<T::Balance>"),
+				fn("transfer_keep_alive", "dest:<T::Lookup as StaticLookup>::Source", "value:Compact<T::Balance>"),
+			},
+			HasEvents: true,
+			Events: []types.EventMetadataV4{
+				ev("Transfer", "AccountId", "AccountId", "Balance"),
+				ev("Withdraw", "AccountId", "Balance"),
+				ev("Reserved", "AccountId", "Balance"),
+				ev("Unreserved", "AccountId", "Balance"),
+				ev("Deposit", "AccountId", "Balance"),
+				ev("Endowed", "AccountId", "Balance"),
+				ev("ReserveRepatriated", "AccountId", "AccountId", "Balance", "Status"),
+				ev("DustLost", "AccountId", "Balance"),
+			},
+			Constants: []types.ModuleConstantMetadataV6{
+				constant("ExistentialDeposit", "T::Balance", types.NewU128(*big.NewInt(testExistentialDeposit))),
+			},
+			Errors: []types.ErrorMetadataV8{
+				{Name: "VestingBalance"},
+				{Name: "LiquidityRestrictions"},
+				{Name: "InsufficientBalance"},
+			},
+			Index: 2,
+		},
+		{
+			Name:     "Utility",
+			HasCalls: true,
+			Calls: []types.FunctionMetadataV4{
+				fn("batch", "calls:Vec<<T as Config>::Call>"),
+				fn("as_derivative", "index:u16", "call:Box<<T as Config>::Call>"),
+				fn("dispatch_as", "as_origin:Box<T::PalletsOrigin>", "call:Box<<T as Config>::Call>"),
+				fn("batch_all", "calls:Vec<<T as Config>::Call>"),
+			},
+			Index: 3,
+		},
+		{
+			Name:     "Proxy",
+			HasCalls: true,
+			Calls: []types.FunctionMetadataV4{
+				fn("announce", "real:AccountId", "call_hash:CallHashOf<T>"),
+				fn("proxy_announced", "delegate:AccountId", "real:AccountId",
+					"force_proxy_type:Option<T::ProxyType>", "call:Box<<T as Config>::Call>"),
+			},
+			HasEvents: true,
+			Events: []types.EventMetadataV4{
+				ev("Announced", "AccountId", "AccountId", "Hash"),
+			},
+			Index: 4,
+		},
+		{
+			Name:     "Tokens",
+			HasCalls: true,
+			Calls: []types.FunctionMetadataV4{
+				fn("transfer", "dest:<T::Lookup as StaticLookup>::Source", "currency_id:T::CurrencyId",
+					"amount:Compact<T::Balance>"),
+			},
+			Index: 5,
+		},
+		{
+			Name:       "Staking",
+			HasStorage: true,
+			Storage: types.StorageMetadataV10{
+				Prefix: "Staking",
+				Items: []types.StorageFunctionMetadataV10{
+					mapStorage("ErasTotalStake", "EraIndex", "BalanceOf<T>", types.StorageHasherV10{IsTwox64Concat: true}),
+					plainStorage("ActiveEra", "Option<ActiveEraInfo>"),
+					{
+						Name:     "ErasStakers",
+						Modifier: types.StorageFunctionModifierV0{IsDefault: true},
+						Type: types.StorageFunctionTypeV10{IsDoubleMap: true, AsDoubleMap: types.DoubleMapTypeV10{
+							Hasher:     types.StorageHasherV10{IsTwox64Concat: true},
+							Key1:       "EraIndex",
+							Key2:       "T::AccountId",
+							Value:      "Exposure<T::AccountId, BalanceOf<T>>",
+							Key2Hasher: types.StorageHasherV10{IsTwox64Concat: true},
+						}},
+					},
+				},
+			},
+			HasCalls: true,
+			Calls: []types.FunctionMetadataV4{
+				fn("unbond", "value:Compact<BalanceOf<T>>"),
+				fn("withdraw_unbonded", "num_slashing_spans:u32"),
+				fn("set_payee", "payee:RewardDestination<T::AccountId>"),
+				fn("set_controller"),
+			},
+			HasEvents: true,
+			Events: []types.EventMetadataV4{
+				ev("Unbonded", "AccountId", "Balance"),
+				ev("Withdrawn", "AccountId", "Balance"),
+				ev("Slashed", "AccountId", "Balance"),
+			},
+			Index: 6,
+		},
+		{
+			Name:     "PolkadotXcm",
+			HasCalls: true,
+			Calls: []types.FunctionMetadataV4{
+				fn("send", "dest:Box<VersionedMultiLocation>", "message:Box<VersionedXcm<()>>"),
+				fn("execute", "message:Box<VersionedXcm<<T as SysConfig>::Call>>", "max_weight:Weight"),
+			},
+			HasEvents: true,
+			Events: []types.EventMetadataV4{
+				ev("Attempted", "xcm::latest::Outcome"),
+				ev("Sent", "MultiLocation", "MultiLocation", "Xcm<()>"),
+			},
+			Index: 7,
+		},
+		{
+			Name:     "VoterList",
+			HasCalls: true,
+			Calls: []types.FunctionMetadataV4{
+				fn("rebag", "dislocated:AccountIdLookupOf<T>"),
+				fn("put_in_front_of", "lighter:AccountIdLookupOf<T>"),
+			},
+			HasEvents: true,
+			Events: []types.EventMetadataV4{
+				ev("Rebagged", "AccountId", "VoteWeight", "VoteWeight"),
+			},
+			Index: 8,
+		},
+		{
+			Name:      "TransactionPayment",
+			HasEvents: true,
+			Events: []types.EventMetadataV4{
+				ev("TransactionFeePaid", "AccountId", "BalanceOf", "BalanceOf"),
+			},
+			Constants: []types.ModuleConstantMetadataV6{
+				constant("TransactionByteFee", "BalanceOf<T>", types.NewU128(*big.NewInt(testTransactionByteFee))),
+				//WeightToFee为IdentityFee：1 * weight
+				constant("WeightToFee", "Vec<WeightToFeeCoefficient<BalanceOf<T>>>", []struct {
+					CoeffInteger types.U128
+					CoeffFrac    types.U32
+					Negative     bool
+					Degree       types.U8
+				}{{CoeffInteger: types.NewU128(*big.NewInt(1)), Degree: 1}}),
+			},
+			Index: 9,
+		},
+		{
+			Name:     "ParachainSystem",
+			HasCalls: true,
+			Calls: []types.FunctionMetadataV4{
+				fn("set_validation_data", "data:ParachainInherentData"),
+			},
+			Index: 10,
+		},
+		{
+			Name:     "PhragmenElection",
+			HasCalls: true,
+			Calls: []types.FunctionMetadataV4{
+				fn("vote", "votes:Vec<T::AccountId>", "value:Compact<BalanceOf<T>>"),
+				fn("submit_candidacy", "candidate_count:Compact<u32>"),
+			},
+			Index: 11,
+		},
+		{
+			Name:       "Assets",
+			HasStorage: true,
+			Storage: types.StorageMetadataV10{
+				Prefix: "Assets",
+				Items: []types.StorageFunctionMetadataV10{
+					mapStorage("Metadata", "T::AssetId", "AssetMetadata", types.StorageHasherV10{IsBlake2_128Concat: true}),
+				},
+			},
+			HasCalls: true,
+			Calls: []types.FunctionMetadataV4{
+				fn("mint", "id:Compact<T::AssetId>", "beneficiary:<T::Lookup as StaticLookup>::Source",
+					"amount:Compact<T::Balance>"),
+				fn("burn", "id:Compact<T::AssetId>", "who:<T::Lookup as StaticLookup>::Source",
+					"amount:Compact<T::Balance>"),
+				fn("force_transfer", "id:Compact<T::AssetId>", "source:<T::Lookup as StaticLookup>::Source",
+					"dest:<T::Lookup as StaticLookup>::Source", "amount:Compact<T::Balance>"),
+			},
+			HasEvents: true,
+			Events: []types.EventMetadataV4{
+				ev("Issued", "AssetId", "AccountId", "Balance"),
+				ev("Burned", "AssetId", "AccountId", "Balance"),
+			},
+			Index: 12,
+		},
+		{
+			Name:       "Indices",
+			HasStorage: true,
+			Storage: types.StorageMetadataV10{
+				Prefix: "Indices",
+				Items: []types.StorageFunctionMetadataV10{
+					mapStorage("Accounts", "T::AccountIndex", "(T::AccountId, BalanceOf<T>, bool)",
+						types.StorageHasherV10{IsBlake2_128Concat: true}),
+				},
+			},
+			Index: 13,
+		},
+		{
+			Name:      "Offences",
+			HasEvents: true,
+			Events: []types.EventMetadataV4{
+				ev("Offence", "Kind", "OpaqueTimeSlot"),
+			},
+			Index: 14,
+		},
+		{
+			Name:      "ImOnline",
+			HasEvents: true,
+			Events: []types.EventMetadataV4{
+				ev("HeartbeatReceived", "AuthorityId"),
+				ev("AllGood"),
+				ev("SomeOffline", "Vec<IdentificationTuple>"),
+			},
+			Index: 15,
+		},
+		{
+			Name:       "ConvictionVoting",
+			HasStorage: true,
+			Storage: types.StorageMetadataV10{
+				Prefix: "ConvictionVoting",
+				Items: []types.StorageFunctionMetadataV10{
+					{
+						Name:     "VotingFor",
+						Modifier: types.StorageFunctionModifierV0{IsDefault: true},
+						Type: types.StorageFunctionTypeV10{IsDoubleMap: true, AsDoubleMap: types.DoubleMapTypeV10{
+							Hasher:     types.StorageHasherV10{IsTwox64Concat: true},
+							Key1:       "T::AccountId",
+							Key2:       "ClassOf<T, I>",
+							Value:      "VotingOf<T, I>",
+							Key2Hasher: types.StorageHasherV10{IsTwox64Concat: true},
+						}},
+					},
+					mapStorage("ClassLocksFor", "T::AccountId", "BoundedVec<(ClassOf<T, I>, BalanceOf<T, I>)>",
+						types.StorageHasherV10{IsTwox64Concat: true}),
+				},
+			},
+			Index: 16,
+		},
+		{
+			Name:     "Currencies",
+			HasCalls: true,
+			Calls: []types.FunctionMetadataV4{
+				fn("transfer", "dest:<T::Lookup as StaticLookup>::Source", "currency_id:CurrencyIdOf<T>",
+					"amount:Compact<BalanceOf<T>>"),
+			},
+			Index: 17,
+		},
+	}
+}
+
+func testMetadata() *types.Metadata {
+	meta := types.NewMetadataV12()
+	meta.MagicNumber = types.MagicNumber
+	meta.AsMetadataV12.Modules = testModules()
+	meta.AsMetadataV12.Extrinsic = types.ExtrinsicV11{Version: 4}
+	return meta
+}
+
+/*
+测试账户，使用ed25519保证签名结果是确定的
+*/
+type testAccount struct {
+	seed    string
+	pubHex  string
+	address string
+}
+
+func newTestAccount(t *testing.T, seedByte byte) testAccount {
+	seed := make([]byte, 32)
+	for i := range seed {
+		seed[i] = seedByte
+	}
+	pub, err := crypto.GenerateSubstrateKeyBySeed(seed, crypto.Ed25519Type)
+	if err != nil {
+		t.Fatal(err)
+	}
+	address, err := ss58.Encode(pub, testPrefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testAccount{seed: hex.EncodeToString(seed), pubHex: hex.EncodeToString(pub), address: address}
+}
+
+/*
+未签名的Timestamp.set
+*/
+func timestampExtrinsic(t *testing.T, meta *types.Metadata, now uint64) string {
+	me, err := expand.NewMetadataExpand(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	callIdx, err := me.MV.GetCallIndex("Timestamp", "set")
+	if err != nil {
+		t.Fatal(err)
+	}
+	call, err := expand.NewCall(callIdx, types.NewUCompactFromUInt(now))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ext := expand.NewExtrinsic(call)
+	h, err := types.EncodeToHexString(ext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+/*
+未签名的extrinsic，用来模拟Timestamp.set以外的inherent
+*/
+func unsignedExtrinsic(t *testing.T, call types.Call) string {
+	h, err := types.EncodeToHexString(expand.NewExtrinsic(call))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+func signedExtrinsic(t *testing.T, from testAccount, nonce uint64, call types.Call) string {
+	transaction := tx.NewSubstrateTransaction(from.address, nonce)
+	transaction.SetGenesisHashAndBlockHash(testGenesisHash, testGenesisHash).
+		SetSpecAndTxVersion(1, 1).
+		SetCall(call)
+	sig, err := transaction.SignTransaction(from.seed, crypto.Ed25519Type)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sig
+}
+
+/*
+测试用的event
+*/
+type testEvent struct {
+	phase  types.Phase
+	module uint8
+	event  uint8
+	args   []interface{}
+}
+
+func applyExtrinsic(idx uint32) types.Phase {
+	return types.Phase{IsApplyExtrinsic: true, AsApplyExtrinsic: idx}
+}
+
+func successEvent(idx uint32) testEvent {
+	return testEvent{phase: applyExtrinsic(idx), module: 0, event: 0, args: []interface{}{
+		types.DispatchInfo{Weight: 1000, Class: types.DispatchClass{IsNormal: true}, PaysFee: true},
+	}}
+}
+
+func failedEvent(idx uint32, dispatchErr base.DispatchError) testEvent {
+	return testEvent{phase: applyExtrinsic(idx), module: 0, event: 1, args: []interface{}{
+		dispatchErr,
+		types.DispatchInfo{Weight: 1000, Class: types.DispatchClass{IsNormal: true}, PaysFee: true},
+	}}
+}
+
+func transferEvent(idx uint32, from, to testAccount, amount types.U128) testEvent {
+	return testEvent{phase: applyExtrinsic(idx), module: 2, event: 0, args: []interface{}{
+		types.NewAccountID(types.MustHexDecodeString(from.pubHex)),
+		types.NewAccountID(types.MustHexDecodeString(to.pubHex)),
+		amount,
+	}}
+}
+
+func withdrawEvent(idx uint32, who testAccount, amount types.U128) testEvent {
+	return testEvent{phase: applyExtrinsic(idx), module: 2, event: 1, args: []interface{}{
+		types.NewAccountID(types.MustHexDecodeString(who.pubHex)),
+		amount,
+	}}
+}
+
+/*
+Balances.Reserved(event=2)或者Balances.Unreserved(event=3)
+*/
+func reserveEvent(idx uint32, event uint8, who testAccount, amount types.U128) testEvent {
+	return testEvent{phase: applyExtrinsic(idx), module: 2, event: event, args: []interface{}{
+		types.NewAccountID(types.MustHexDecodeString(who.pubHex)),
+		amount,
+	}}
+}
+
+func depositEvent(idx uint32, who testAccount, amount types.U128) testEvent {
+	return testEvent{phase: applyExtrinsic(idx), module: 2, event: 4, args: []interface{}{
+		types.NewAccountID(types.MustHexDecodeString(who.pubHex)),
+		amount,
+	}}
+}
+
+/*
+Balances.Endowed，转账创建了新账户
+*/
+func endowedEvent(idx uint32, who testAccount, amount types.U128) testEvent {
+	return testEvent{phase: applyExtrinsic(idx), module: 2, event: 5, args: []interface{}{
+		types.NewAccountID(types.MustHexDecodeString(who.pubHex)),
+		amount,
+	}}
+}
+
+func feePaidEvent(idx uint32, who testAccount, actualFee, tip types.U128) testEvent {
+	return testEvent{phase: applyExtrinsic(idx), module: 9, event: 0, args: []interface{}{
+		types.NewAccountID(types.MustHexDecodeString(who.pubHex)),
+		actualFee,
+		tip,
+	}}
+}
+
+/*
+event: 0为Assets.Issued，1为Assets.Burned
+*/
+func assetEvent(idx uint32, event uint8, assetId uint32, who testAccount, amount types.U128) testEvent {
+	return testEvent{phase: applyExtrinsic(idx), module: 12, event: event, args: []interface{}{
+		types.NewU32(assetId),
+		types.NewAccountID(types.MustHexDecodeString(who.pubHex)),
+		amount,
+	}}
+}
+
+func stakingEvent(idx uint32, event uint8, stash testAccount, amount types.U128) testEvent {
+	return testEvent{phase: applyExtrinsic(idx), module: 6, event: event, args: []interface{}{
+		types.NewAccountID(types.MustHexDecodeString(stash.pubHex)),
+		amount,
+	}}
+}
+
+func announcedEvent(idx uint32, real, proxy testAccount, callHash types.Hash) testEvent {
+	return testEvent{phase: applyExtrinsic(idx), module: 4, event: 0, args: []interface{}{
+		types.NewAccountID(types.MustHexDecodeString(real.pubHex)),
+		types.NewAccountID(types.MustHexDecodeString(proxy.pubHex)),
+		callHash,
+	}}
+}
+
+/*
+PolkadotXcm.Attempted，outcome为编码后的xcm::v2::Outcome
+*/
+func xcmAttemptedEvent(idx uint32, outcome []byte) testEvent {
+	return testEvent{phase: applyExtrinsic(idx), module: 7, event: 0, args: []interface{}{types.Data(outcome)}}
+}
+
+/*
+PolkadotXcm.Sent，origin、dest以及message为编码后的数据
+*/
+func xcmSentEvent(idx uint32, origin, dest, message []byte) testEvent {
+	return testEvent{phase: applyExtrinsic(idx), module: 7, event: 1, args: []interface{}{
+		types.Data(origin), types.Data(dest), types.Data(message),
+	}}
+}
+
+/*
+VoterList.Rebagged，from和to为bag的上限
+*/
+func rebaggedEvent(idx uint32, who testAccount, from, to uint64) testEvent {
+	return testEvent{phase: applyExtrinsic(idx), module: 8, event: 0, args: []interface{}{
+		types.NewAccountID(types.MustHexDecodeString(who.pubHex)),
+		types.NewU64(from),
+		types.NewU64(to),
+	}}
+}
+
+func eventsHex(t *testing.T, events ...testEvent) string {
+	data, err := types.EncodeToBytes(types.NewUCompactFromUInt(uint64(len(events))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range events {
+		phase, err := types.EncodeToBytes(e.phase)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data = append(data, phase...)
+		data = append(data, e.module, e.event)
+		for _, arg := range e.args {
+			b, err := types.EncodeToBytes(arg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data = append(data, b...)
+		}
+		//topics
+		data = append(data, 0)
+	}
+	return "0x" + hex.EncodeToString(data)
+}
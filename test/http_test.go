@@ -0,0 +1,167 @@
+package test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/JFJun/bifrost-go/client"
+	"github.com/JFJun/bifrost-go/expand"
+	"github.com/JFJun/bifrost-go/models"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+type httpRPCRequest struct {
+	ID     uint64        `json:"id"`
+	Method string        `json:"method"`
+	Params []interface{} `json:"params"`
+}
+
+type httpRPCResponse struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      uint64      `json:"id"`
+	Result  interface{} `json:"result,omitempty"`
+	Error   interface{} `json:"error,omitempty"`
+}
+
+/*
+json-rpc的http节点，batch为false时批量请求返回一个错误对象（而不是数组），
+批量请求的结果按倒序返回，batches记录批量请求的次数
+*/
+type httpNode struct {
+	block   *models.SignedBlock
+	events  string
+	batch   bool
+	batches int32
+}
+
+func (n *httpNode) respond(req httpRPCRequest) httpRPCResponse {
+	resp := httpRPCResponse{JSONRPC: "2.0", ID: req.ID}
+	switch req.Method {
+	case "state_getRuntimeVersion":
+		resp.Result = types.RuntimeVersion{SpecName: testChainName, SpecVersion: 1, TransactionVersion: 1}
+	case "state_getMetadata":
+		metaHex, err := types.EncodeToHexString(testMetadata())
+		if err != nil {
+			resp.Error = map[string]interface{}{"code": -32000, "message": err.Error()}
+			break
+		}
+		resp.Result = metaHex
+	case "system_properties":
+		resp.Result = map[string]interface{}{"ss58Format": 0, "tokenDecimals": 12}
+	case "chain_getBlock":
+		resp.Result = n.block
+	case "state_getStorageAt":
+		resp.Result = n.events
+	default:
+		resp.Error = map[string]interface{}{"code": -32601, "message": "Method not found"}
+	}
+	return resp
+}
+
+func (n *httpNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil || r.Method != http.MethodPost {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if strings.HasPrefix(strings.TrimSpace(string(body)), "[") {
+		atomic.AddInt32(&n.batches, 1)
+		if !n.batch {
+			_ = json.NewEncoder(w).Encode(httpRPCResponse{JSONRPC: "2.0",
+				Error: map[string]interface{}{"code": -32600, "message": "Invalid request"}})
+			return
+		}
+		var reqs []httpRPCRequest
+		if err = json.Unmarshal(body, &reqs); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		resps := make([]httpRPCResponse, 0, len(reqs))
+		for i := len(reqs) - 1; i >= 0; i-- {
+			resps = append(resps, n.respond(reqs[i]))
+		}
+		_ = json.NewEncoder(w).Encode(resps)
+		return
+	}
+	var req httpRPCRequest
+	if err = json.Unmarshal(body, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(n.respond(req))
+}
+
+/*
+http(s)的节点地址使用http的json-rpc：初始化、批量获取区块以及events、节点不支持批量请求时退回单个请求、
+json-rpc错误以及http状态码错误
+*/
+func Test_HTTPTransport_Offline(t *testing.T) {
+	meta := testMetadata()
+	me, err := expand.NewMetadataExpand(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := newTestAccount(t, 1)
+	bob := newTestAccount(t, 2)
+	transfer, err := me.BalanceTransferCall(bob.address, 12345)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := &models.SignedBlock{Block: models.Block{
+		Header: models.Header{ParentHash: testBlockHash, Number: "0x64"},
+		Extrinsics: []string{
+			timestampExtrinsic(t, meta, 1620000108000),
+			signedExtrinsic(t, alice, 0, transfer),
+		},
+	}}
+	events := eventsHex(t,
+		successEvent(0),
+		transferEvent(1, alice, bob, types.NewU128(*big.NewInt(12345))),
+		successEvent(1),
+	)
+
+	for _, batch := range []bool{true, false} {
+		node := &httpNode{block: block, events: events, batch: batch}
+		server := httptest.NewServer(node)
+		defer server.Close()
+		c, err := client.New(server.URL, false)
+		if err != nil {
+			t.Fatalf("batch=%v: %v", batch, err)
+		}
+		c.SetFetchFees(false)
+		for i := 0; i < 2; i++ {
+			resp, err := c.GetBlockByHash(testBlockHash)
+			if err != nil {
+				t.Fatalf("batch=%v: %v", batch, err)
+			}
+			//ss58Format从节点的system_properties中获取
+			if len(resp.Extrinsic) != 1 || resp.Extrinsic[0].Status != "success" ||
+				resp.Extrinsic[0].FromAddress != alice.address || resp.Extrinsic[0].Amount != "12345" {
+				t.Fatalf("batch=%v: unexpected extrinsics %+v", batch, resp.Extrinsic)
+			}
+		}
+		//不支持批量请求时只尝试一次
+		if batches := atomic.LoadInt32(&node.batches); (batch && batches != 2) || (!batch && batches != 1) {
+			t.Fatalf("batch=%v: unexpected batch requests %d", batch, batches)
+		}
+		_, err = c.GetNoncesBatch([]string{alice.address})
+		if err == nil || !strings.Contains(err.Error(), "Method not found") {
+			t.Fatalf("batch=%v: expected json-rpc error, got %v", batch, err)
+		}
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	if _, err = client.New(server.URL, false); err == nil || !strings.Contains(err.Error(), "status=503") {
+		t.Fatalf("expected http status error, got %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/JFJun/bifrost-go/client"
+	"github.com/JFJun/bifrost-go/models"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+/*
+在fixedBlockRPC的基础上返回Timestamp.Now
+*/
+type timestampStorageRPC struct {
+	fixedBlockRPC
+	nowKey string
+	now    string
+}
+
+func (m timestampStorageRPC) Call(result interface{}, method string, args ...interface{}) error {
+	if method == "state_getStorageAt" && len(args) > 0 && args[0] == m.nowKey {
+		*(result.(*string)) = m.now
+		return nil
+	}
+	return m.fixedBlockRPC.Call(result, method, args...)
+}
+
+/*
+只有inherent的区块也要解析区块时间，extrinsics字段为空时从Timestamp.Now读取
+*/
+func Test_InherentOnlyBlock_Offline(t *testing.T) {
+	meta := testMetadata()
+	nowKey, err := types.CreateStorageKey(meta, "Timestamp", "Now", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now, err := types.EncodeToHexString(types.NewU64(1620000099000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rpc := timestampStorageRPC{
+		fixedBlockRPC: fixedBlockRPC{
+			block: &models.SignedBlock{Block: models.Block{
+				Header:     models.Header{ParentHash: testBlockHash, Number: "0x64"},
+				Extrinsics: []string{timestampExtrinsic(t, meta, 1620000090000)},
+			}},
+			events: eventsHex(t, successEvent(0)),
+		},
+		nowKey: nowKey.Hex(),
+		now:    now,
+	}
+	c, err := client.NewWithRPCCaller(rpc, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetPrefix(testPrefix)
+	c.SetFetchFees(false)
+
+	resp, err := c.GetBlockByHash(testBlockHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Timestamp != 1620000090000 || resp.Extrinsic == nil || len(resp.Extrinsic) != 0 {
+		t.Fatalf("unexpected inherent-only block: %+v", resp)
+	}
+
+	c.SetIncludeUnparsed(true)
+	resp, err = c.GetBlockByHash(testBlockHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Extrinsic) != 1 || resp.Extrinsic[0].Type != "Timestamp.set" || resp.Extrinsic[0].Status != "success" {
+		t.Fatalf("inherent events not decoded: %+v", resp.Extrinsic)
+	}
+
+	//extrinsics字段为null
+	rpc.block = &models.SignedBlock{Block: models.Block{
+		Header: models.Header{ParentHash: testBlockHash, Number: "0x65"},
+	}}
+	c, err = client.NewWithRPCCaller(rpc, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetFetchFees(false)
+	resp, err = c.GetBlockByHash(testBlockHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Timestamp != 1620000099000 || resp.Extrinsic == nil || len(resp.Extrinsic) != 0 {
+		t.Fatalf("unexpected block without extrinsics: %+v", resp)
+	}
+}
@@ -0,0 +1,61 @@
+package test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/JFJun/bifrost-go/client"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+/*
+保存的metadata hex加载后，离线解析的结果与原来的metadata一致
+*/
+func Test_DecodeMetadataHex_Offline(t *testing.T) {
+	meta := testMetadata()
+	metaHex, err := types.EncodeToHexString(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := client.DecodeMetadataHex(metaHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := goldenBlocks(t)["balances_transfer"]
+	want, err := client.ParseBlockOffline(meta, testPrefix, testChainName, block.extrinsics, block.events, block.header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := client.ParseBlockOffline(loaded, testPrefix, testChainName, block.extrinsics, block.events, block.header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantJSON, _ := json.Marshal(want)
+	gotJSON, _ := json.Marshal(got)
+	if string(gotJSON) != string(wantJSON) {
+		t.Fatalf("block response mismatch\ngot:  %s\nwant: %s", gotJSON, wantJSON)
+	}
+
+	if _, err = client.DecodeMetadataHex("0x1234"); err == nil {
+		t.Fatal("expected error for invalid metadata")
+	}
+}
+
+/*
+GetMetadataLatest返回没有任何模块的metadata
+*/
+type emptyMetadataRPC struct {
+	testRPC
+}
+
+func (emptyMetadataRPC) GetMetadataLatest() (*types.Metadata, error) {
+	return types.NewMetadataV12(), nil
+}
+
+func Test_EmptyMetadata_Offline(t *testing.T) {
+	_, err := client.NewWithRPCCaller(emptyMetadataRPC{}, false)
+	if !errors.Is(err, client.ErrMetadataUnavailable) {
+		t.Fatalf("expected ErrMetadataUnavailable, got %v", err)
+	}
+}
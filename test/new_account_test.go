@@ -0,0 +1,78 @@
+package test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/JFJun/bifrost-go/client"
+	"github.com/JFJun/bifrost-go/expand"
+	"github.com/JFJun/bifrost-go/models"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+/*
+同一个extrinsic中有收款地址的Balances.Endowed时，转账标记为创建了新账户
+*/
+func Test_TransferNewAccount_Offline(t *testing.T) {
+	meta := testMetadata()
+	me, err := expand.NewMetadataExpand(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := newTestAccount(t, 1)
+	bob := newTestAccount(t, 2)
+	carol := newTestAccount(t, 3)
+	toBob, err := me.BalanceTransferCall(bob.address, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	toCarol, err := me.BalanceTransferCall(carol.address, 2000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u128 := func(v int64) types.U128 {
+		return types.NewU128(*big.NewInt(v))
+	}
+	block := &models.SignedBlock{Block: models.Block{
+		Header: models.Header{ParentHash: testBlockHash, Number: "0x64"},
+		Extrinsics: []string{
+			timestampExtrinsic(t, meta, 1620000096000),
+			signedExtrinsic(t, alice, 0, toBob),
+			signedExtrinsic(t, alice, 1, toCarol),
+			signedExtrinsic(t, alice, 2, toCarol),
+		},
+	}}
+	events := eventsHex(t,
+		successEvent(0),
+		//bob是新账户
+		endowedEvent(1, bob, u128(1000)),
+		transferEvent(1, alice, bob, u128(1000)),
+		successEvent(1),
+		//同一个extrinsic中创建的是其它账户，carol已经存在
+		endowedEvent(2, bob, u128(1)),
+		transferEvent(2, alice, carol, u128(2000)),
+		successEvent(2),
+		//carol的Endowed在另一个extrinsic中
+		transferEvent(3, alice, carol, u128(2000)),
+		successEvent(3),
+	)
+	c, err := client.NewWithRPCCaller(fixedBlockRPC{block: block, events: events}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetPrefix(testPrefix)
+	c.SetFetchFees(false)
+	resp, err := c.GetBlockByHash(testBlockHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Extrinsic) != 3 {
+		t.Fatalf("expected 3 transfers, got %d", len(resp.Extrinsic))
+	}
+	expected := map[int]bool{1: true, 2: false, 3: false}
+	for _, e := range resp.Extrinsic {
+		if e.NewAccount != expected[e.ExtrinsicIndex] {
+			t.Fatalf("extrinsic %d to %s: expected new account %v", e.ExtrinsicIndex, e.ToAddress, expected[e.ExtrinsicIndex])
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/JFJun/bifrost-go/client"
+	"github.com/JFJun/go-substrate-crypto/ss58"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+/*
+state_queryStorageAt从accounts中返回System.Account，queries记录每次请求的key
+*/
+type nonceRPC struct {
+	testRPC
+	accounts map[string][]byte
+	queries  *[][]string
+}
+
+func (m nonceRPC) Call(result interface{}, method string, args ...interface{}) error {
+	if method == "state_queryStorageAt" {
+		keys := args[0].([]string)
+		*m.queries = append(*m.queries, keys)
+		var changeSet types.StorageChangeSet
+		for _, key := range keys {
+			value, ok := m.accounts[key]
+			changeSet.Changes = append(changeSet.Changes, types.KeyValueOption{
+				StorageKey:     types.MustHexDecodeString(key),
+				HasStorageData: ok,
+				StorageData:    value,
+			})
+		}
+		*(result.(*[]types.StorageChangeSet)) = []types.StorageChangeSet{changeSet}
+		return nil
+	}
+	return m.testRPC.Call(result, method, args...)
+}
+
+/*
+一次state_queryStorageAt获取多个地址的nonce，同一个账户的不同格式的地址只查询一次，不存在的账户nonce为0
+*/
+func Test_GetNoncesBatch_Offline(t *testing.T) {
+	meta := testMetadata()
+	alice := newTestAccount(t, 1)
+	bob := newTestAccount(t, 2)
+	carol := newTestAccount(t, 3)
+	accountKey := func(account testAccount) string {
+		key, err := types.CreateStorageKey(meta, "System", "Account", types.MustHexDecodeString(account.pubHex), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return key.Hex()
+	}
+	var queries [][]string
+	rpc := nonceRPC{
+		accounts: map[string][]byte{
+			accountKey(alice): types.MustHexDecodeString(accountInfoHex(t, 1000, 0)),
+			accountKey(bob):   accountInfoData(t, []byte{0, 0, 0, 0}, 1000),
+		},
+		queries: &queries,
+	}
+	c, err := client.NewWithRPCCaller(rpc, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetPrefix(testPrefix)
+	aliceKusama, err := ss58.Encode(types.MustHexDecodeString(alice.pubHex), ss58.KsmPrefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonces, err := c.GetNoncesBatch([]string{alice.address, bob.address, carol.address, aliceKusama})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]uint64{alice.address: 3, bob.address: 1, carol.address: 0, aliceKusama: 3}
+	if len(nonces) != len(expected) {
+		t.Fatalf("unexpected nonces: %v", nonces)
+	}
+	for address, nonce := range expected {
+		if n, ok := nonces[address]; !ok || n != nonce {
+			t.Fatalf("unexpected nonce of %s: %d, want %d", address, n, nonce)
+		}
+	}
+	if len(queries) != 1 || len(queries[0]) != 3 {
+		t.Fatalf("expected one query with 3 keys, got %v", queries)
+	}
+
+	nonces, err = c.GetNoncesBatch(nil)
+	if err != nil || len(nonces) != 0 || len(queries) != 1 {
+		t.Fatalf("expected no query for empty addresses, got %v, %v", nonces, err)
+	}
+	if _, err = c.GetNoncesBatch([]string{"invalid"}); err == nil {
+		t.Fatal("expected error for invalid address")
+	}
+	rpc.accounts[accountKey(carol)] = []byte{1}
+	if _, err = c.GetNoncesBatch([]string{carol.address}); !errors.Is(err, client.ErrDecodeFailed) {
+		t.Fatalf("expected ErrDecodeFailed, got %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+package test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/JFJun/bifrost-go/client"
+	"github.com/JFJun/bifrost-go/models"
+	"github.com/btcsuite/btcutil/base58"
+	"golang.org/x/crypto/blake2b"
+)
+
+/*
+system_properties中返回指定ss58Format的rpc，ss58Format为nil时不返回该字段
+*/
+type propertiesRPC struct {
+	testRPC
+	ss58Format interface{}
+}
+
+func (m propertiesRPC) Call(result interface{}, method string, args ...interface{}) error {
+	if method == "system_properties" {
+		properties := map[string]interface{}{"tokenDecimals": float64(12)}
+		if m.ss58Format != nil {
+			properties["ss58Format"] = m.ss58Format
+		}
+		*(result.(*map[string]interface{})) = properties
+		return nil
+	}
+	return m.testRPC.Call(result, method, args...)
+}
+
+/*
+按ss58规范从地址中解出ss58Format以及公钥，同时校验checksum
+*/
+func decodeSS58(t *testing.T, address string) (uint16, []byte) {
+	data := base58.Decode(address)
+	if len(data) < 35 {
+		t.Fatalf("address %s too short", address)
+	}
+	prefixLen := 1
+	format := uint16(data[0])
+	if data[0]&0x40 != 0 {
+		prefixLen = 2
+		format = uint16(data[0]&0x3f)<<2 | uint16(data[1]>>6) | uint16(data[1]&0x3f)<<8
+	}
+	payload := data[:len(data)-2]
+	if len(payload) != prefixLen+32 {
+		t.Fatalf("address %s has unexpected length %d", address, len(data))
+	}
+	ck := blake2b.Sum512(append([]byte("SS58PRE"), payload...))
+	if !bytes.Equal(ck[:2], data[len(data)-2:]) {
+		t.Fatalf("address %s has invalid checksum", address)
+	}
+	return format, payload[prefixLen:]
+}
+
+func Test_FetchPrefixFromNode_Offline(t *testing.T) {
+	cases := []struct {
+		name       string
+		ss58Format interface{}
+		want       uint16
+		wantErr    bool
+	}{
+		{name: "polkadot", ss58Format: float64(0), want: 0},
+		{name: "bifrost", ss58Format: float64(6), want: 6},
+		{name: "max", ss58Format: float64(16383), want: 16383},
+		{name: "missing", wantErr: true},
+		{name: "negative", ss58Format: float64(-1), wantErr: true},
+		{name: "too large", ss58Format: float64(16384), wantErr: true},
+		{name: "string", ss58Format: "6", wantErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := client.NewWithRPCCaller(propertiesRPC{ss58Format: tc.ss58Format}, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := c.FetchPrefixFromNode()
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %d", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Fatalf("expected %d, got %d", tc.want, got)
+			}
+		})
+	}
+}
+
+/*
+节点返回的ss58Format需要按规范转换为地址前缀，大于等于64时使用两个字节
+*/
+func Test_PrefixFromNode_Offline(t *testing.T) {
+	alice := newTestAccount(t, 1)
+	pub, err := hex.DecodeString(alice.pubHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		ss58Format uint16
+		prefix     []byte
+	}{
+		{ss58Format: 0, prefix: []byte{0x00}},
+		{ss58Format: 6, prefix: []byte{0x06}},
+		{ss58Format: 42, prefix: []byte{0x2a}},
+		{ss58Format: 63, prefix: []byte{0x3f}},
+		{ss58Format: 64, prefix: []byte{0x50, 0x00}},
+		{ss58Format: 255, prefix: []byte{0x7f, 0xc0}},
+		{ss58Format: 256, prefix: []byte{0x40, 0x01}},
+		{ss58Format: 1284, prefix: []byte{0x41, 0x05}},
+		{ss58Format: 16383, prefix: []byte{0x7f, 0xff}},
+	}
+	for _, tc := range cases {
+		c, err := client.NewWithRPCCaller(propertiesRPC{ss58Format: float64(tc.ss58Format)}, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		address, err := c.ParamAddress(models.ExtrinsicDecodeParam{Type: "AccountId", ValueRaw: alice.pubHex})
+		if err != nil {
+			t.Fatalf("ss58Format %d: %v", tc.ss58Format, err)
+		}
+		if data := base58.Decode(address); !bytes.HasPrefix(data, tc.prefix) {
+			t.Fatalf("ss58Format %d: expected prefix %x, got %x", tc.ss58Format, tc.prefix, data[:len(tc.prefix)])
+		}
+		format, gotPub := decodeSS58(t, address)
+		if format != tc.ss58Format {
+			t.Fatalf("ss58Format %d: address %s decodes to format %d", tc.ss58Format, address, format)
+		}
+		if !bytes.Equal(gotPub, pub) {
+			t.Fatalf("ss58Format %d: address %s decodes to pub %x", tc.ss58Format, address, gotPub)
+		}
+	}
+}
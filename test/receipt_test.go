@@ -0,0 +1,130 @@
+package test
+
+import (
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/JFJun/bifrost-go/client"
+	"github.com/JFJun/bifrost-go/expand"
+	"github.com/JFJun/bifrost-go/expand/base"
+	"github.com/JFJun/bifrost-go/models"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"golang.org/x/crypto/blake2b"
+)
+
+/*
+交易hash，即extrinsic编码的blake2_256
+*/
+func extrinsicHash(extrinsic string) string {
+	h := blake2b.Sum256(types.MustHexDecodeString(extrinsic))
+	return "0x" + hex.EncodeToString(h[:])
+}
+
+/*
+只返回交易所在extrinsic的event，并按event类型填充地址、金额以及状态
+*/
+func Test_GetEventsByExtrinsicHash_Offline(t *testing.T) {
+	meta := testMetadata()
+	me, err := expand.NewMetadataExpand(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := newTestAccount(t, 1)
+	bob := newTestAccount(t, 2)
+	carol := newTestAccount(t, 3)
+	u128 := func(v int64) types.U128 {
+		return types.NewU128(*big.NewInt(v))
+	}
+	toBob, err := me.BalanceTransferCall(bob.address, 12345)
+	if err != nil {
+		t.Fatal(err)
+	}
+	toCarol, err := me.BalanceTransferCall(carol.address, 500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	success := signedExtrinsic(t, alice, 0, toBob)
+	failed := signedExtrinsic(t, alice, 1, toCarol)
+	rpc := fixedBlockRPC{
+		block: &models.SignedBlock{Block: models.Block{
+			Header:     models.Header{ParentHash: testBlockHash, Number: "0x64"},
+			Extrinsics: []string{timestampExtrinsic(t, meta, 1620000108000), success, failed},
+		}},
+		events: eventsHex(t,
+			successEvent(0),
+			withdrawEvent(1, alice, u128(150)),
+			transferEvent(1, alice, bob, u128(12345)),
+			depositEvent(1, carol, u128(150)),
+			successEvent(1),
+			withdrawEvent(2, alice, u128(140)),
+			failedEvent(2, base.DispatchError{Variant: 2}),
+		),
+	}
+	c, err := client.NewWithRPCCaller(rpc, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetPrefix(testPrefix)
+
+	events, err := c.GetEventsByExtrinsicHash(extrinsicHash(success), testBlockHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []models.EventResult{
+		{Module: "Balances", Event: "Withdraw", From: alice.address, Amount: "150"},
+		{Module: "Balances", Event: "Transfer", From: alice.address, To: bob.address, Amount: "12345"},
+		{Module: "Balances", Event: "Deposit", To: carol.address, Amount: "150"},
+		{Module: "System", Event: "ExtrinsicSuccess", Status: "success", Weight: 1000},
+	}
+	checkEventResults(t, events, expected, 1)
+
+	//交易hash不区分大小写
+	events, err = c.GetEventsByExtrinsicHash(strings.ToUpper(extrinsicHash(failed)), testBlockHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = []models.EventResult{
+		{Module: "Balances", Event: "Withdraw", From: alice.address, Amount: "140"},
+		{Module: "System", Event: "ExtrinsicFailed", Status: "fail", Weight: 1000},
+	}
+	checkEventResults(t, events, expected, 2)
+
+	if _, err = c.GetEventsByExtrinsicHash(extrinsicHash("0x00"), testBlockHash); err == nil {
+		t.Fatal("expected error for extrinsic not in block")
+	}
+	if _, err = c.GetEventsByExtrinsicHash(extrinsicHash(success), "0x1234"); err == nil {
+		t.Fatal("expected error for invalid block hash")
+	}
+	rpc.block = nil
+	c, err = client.NewWithRPCCaller(rpc, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.GetEventsByExtrinsicHash(extrinsicHash(success), testBlockHash); !errors.Is(err, client.ErrBlockNotFound) {
+		t.Fatalf("expected ErrBlockNotFound, got %v", err)
+	}
+}
+
+/*
+event的顺序与event records结构中字段的顺序有关，只比较内容
+*/
+func checkEventResults(t *testing.T, events, expected []models.EventResult, extrinsicIdx int) {
+	if len(events) != len(expected) {
+		t.Fatalf("extrinsic %d: expected %d events, got %+v", extrinsicIdx, len(expected), events)
+	}
+	got := make(map[models.EventResult]int)
+	for _, e := range events {
+		got[e]++
+	}
+	for _, want := range expected {
+		want.ExtrinsicIdx = extrinsicIdx
+		want.Phase = "ApplyExtrinsic"
+		if got[want] == 0 {
+			t.Fatalf("extrinsic %d: expected event %+v, got %+v", extrinsicIdx, want, events)
+		}
+		got[want]--
+	}
+}
@@ -0,0 +1,149 @@
+package test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/JFJun/bifrost-go/expand"
+	"github.com/JFJun/bifrost-go/utils"
+	"github.com/stafiprotocol/go-substrate-rpc-client/scale"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+/*
+自定义pallet的call在注册参数类型之前没有参数，注册之后按metadata中的参数顺序解析
+*/
+func Test_RegisterType_Offline(t *testing.T) {
+	meta := testMetadata()
+	me, err := expand.NewMetadataExpand(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := newTestAccount(t, 1)
+	bob := newTestAccount(t, 2)
+	callIdx, err := me.MV.GetCallIndex("Tokens", "transfer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	currencyID := struct {
+		Kind types.U8
+		ID   types.U32
+	}{Kind: 1, ID: 7}
+	call, err := expand.NewCall(callIdx, multiAddress(bob), currencyID, types.NewUCompactFromUInt(500))
+	if err != nil {
+		t.Fatal(err)
+	}
+	extrinsic := signedExtrinsic(t, alice, 0, call)
+
+	decode := func(registry *expand.TypeRegistry) []expand.ExtrinsicParam {
+		ed, err := expand.NewExtrinsicDecoder(meta)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ed.SetTypeRegistry(registry)
+		data, err := hex.DecodeString(utils.Remove0X(extrinsic))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ed.ProcessExtrinsicDecoder(*scale.NewDecoder(bytes.NewReader(data)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ed.Params
+	}
+
+	if params := decode(nil); len(params) != 0 {
+		t.Fatalf("expected no params without registered types, got %v", params)
+	}
+	registry := expand.NewTypeRegistry()
+	if params := decode(registry); len(params) != 0 {
+		t.Fatalf("expected no params while CurrencyId is unknown, got %v", params)
+	}
+	err = registry.LoadJSON([]byte(`{"CurrencyId": {"kind": "CurrencyKind", "id": "u32"}, "CurrencyKind": "u8"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := decode(registry)
+	if len(params) != 3 {
+		t.Fatalf("expected 3 params, got %v", params)
+	}
+	if params[0].Name != "dest" || params[1].Name != "currency_id" || params[2].Name != "amount" {
+		t.Fatalf("unexpected param names: %v", params)
+	}
+	if params[1].ValueRaw != "0107000000" {
+		t.Fatalf("unexpected currency_id raw value: %s", params[1].ValueRaw)
+	}
+	d, err := json.Marshal(params[1].Value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(d) != `{"kind":1,"id":7}` {
+		t.Fatalf("unexpected currency_id value: %s", d)
+	}
+	amount, ok := utils.ValueToString(params[2].Value)
+	if !ok || amount != "500" {
+		t.Fatalf("unexpected amount: %v", params[2].Value)
+	}
+
+	if err := registry.Register("T::Unknown", "NotRegistered"); err == nil {
+		t.Fatal("expected error for alias of unknown type")
+	}
+}
+
+type testCurrencyId types.U128
+
+/*
+包装u128的newtype按u128解析，Value与u128一致
+*/
+func Test_RegisterNewtype_Offline(t *testing.T) {
+	meta := testMetadata()
+	me, err := expand.NewMetadataExpand(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := newTestAccount(t, 1)
+	bob := newTestAccount(t, 2)
+	callIdx, err := me.MV.GetCallIndex("Tokens", "transfer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	call, err := expand.NewCall(callIdx, multiAddress(bob), types.NewU128(*big.NewInt(7)), types.NewUCompactFromUInt(500))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := hex.DecodeString(utils.Remove0X(signedExtrinsic(t, alice, 0, call)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, register := range map[string]func(r *expand.TypeRegistry) error{
+		"go newtype": func(r *expand.TypeRegistry) error {
+			return r.Register("CurrencyId", testCurrencyId{})
+		},
+		"json newtype": func(r *expand.TypeRegistry) error {
+			return r.LoadJSON([]byte(`{"CurrencyId": {"id": "u128"}}`))
+		},
+	} {
+		registry := expand.NewTypeRegistry()
+		if err := register(registry); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		ed, err := expand.NewExtrinsicDecoder(meta)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ed.SetTypeRegistry(registry)
+		err = ed.ProcessExtrinsicDecoder(*scale.NewDecoder(bytes.NewReader(data)))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(ed.Params) != 3 || ed.Params[1].Name != "currency_id" {
+			t.Fatalf("%s: unexpected params: %v", name, ed.Params)
+		}
+		if ed.Params[1].Value != "7" || ed.Params[1].ValueRaw != "07000000000000000000000000000000" {
+			t.Fatalf("%s: unexpected currency_id: %v %s", name, ed.Params[1].Value, ed.Params[1].ValueRaw)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/JFJun/bifrost-go/client"
+	"github.com/JFJun/bifrost-go/expand"
+	"github.com/JFJun/bifrost-go/models"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+/*
+平行链区块的ParachainSystem.set_validation_data，只编码PersistedValidationData以及空的relay_chain_state等
+*/
+func validationDataExtrinsic(t *testing.T, meta *types.Metadata, relayParent uint32, root types.Hash) string {
+	me, err := expand.NewMetadataExpand(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	callIdx, err := me.MV.GetCallIndex("ParachainSystem", "set_validation_data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	call, err := expand.NewCall(callIdx,
+		types.NewBytes([]byte{0x01, 0x02}), //parent_head
+		types.NewU32(relayParent),
+		root,
+		types.NewU32(5242880),        //max_pov_size
+		types.NewUCompactFromUInt(0), //relay_chain_state
+		types.NewUCompactFromUInt(0), //downward_messages
+		types.NewUCompactFromUInt(0), //horizontal_messages
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return unsignedExtrinsic(t, call)
+}
+
+func Test_RelayParentNumber_Offline(t *testing.T) {
+	meta := testMetadata()
+	root := types.NewHash(bytes.Repeat([]byte{0xab}, 32))
+	rpc := fixedBlockRPC{
+		block: &models.SignedBlock{Block: models.Block{
+			Header: models.Header{ParentHash: testBlockHash, Number: "0x64"},
+			Extrinsics: []string{
+				timestampExtrinsic(t, meta, 1620000090000),
+				validationDataExtrinsic(t, meta, 9876543, root),
+			},
+		}},
+		events: eventsHex(t, successEvent(0), successEvent(1)),
+	}
+	c, err := client.NewWithRPCCaller(rpc, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetPrefix(testPrefix)
+	c.SetFetchFees(false)
+	//只解析Balances时也要解析set_validation_data
+	c.SetCallAllowlist([]string{"Balances"})
+
+	resp, err := c.GetBlockByHash(testBlockHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.RelayParentNumber != 9876543 || resp.Timestamp != 1620000090000 {
+		t.Fatalf("unexpected block: relay parent %d, timestamp %d", resp.RelayParentNumber, resp.Timestamp)
+	}
+
+	c.SetIncludeUnparsed(true)
+	resp, err = c.GetBlockByHash(testBlockHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Extrinsic) != 2 || resp.Extrinsic[1].Type != "ParachainSystem.set_validation_data" {
+		t.Fatalf("unexpected extrinsics: %+v", resp.Extrinsic)
+	}
+	found := false
+	for _, param := range resp.Extrinsic[1].Params {
+		if param.Name == "relay_parent_storage_root" {
+			found = param.Value == root.Hex()
+		}
+	}
+	if !found {
+		t.Fatalf("relay_parent_storage_root not decoded: %+v", resp.Extrinsic[1].Params)
+	}
+}
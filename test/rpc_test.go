@@ -9,7 +9,7 @@ import (
 )
 
 func Test_GetBlockByNumber(t *testing.T) {
-	c, err := client.New("wss://rpc.polkadot.io")
+	c, err := client.New("wss://rpc.polkadot.io", false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -24,7 +24,7 @@ func Test_GetBlockByNumber(t *testing.T) {
 }
 
 func Test_GetAccountInfo(t *testing.T) {
-	c, err := client.New("wss://rpc.polkadot.io")
+	c, err := client.New("wss://rpc.polkadot.io", false)
 	if err != nil {
 		t.Fatal(err)
 	}
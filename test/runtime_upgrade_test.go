@@ -0,0 +1,355 @@
+package test
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/JFJun/bifrost-go/client"
+	"github.com/JFJun/bifrost-go/expand"
+	"github.com/JFJun/bifrost-go/models"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+/*
+在获取System.Events时runtime升级的rpc，升级后的metadata中System的event顺序不同
+*/
+type upgradeDuringBlockRPC struct {
+	fixedBlockRPC
+	upgraded *bool
+	client   **client.Client
+}
+
+func (m upgradeDuringBlockRPC) Call(result interface{}, method string, args ...interface{}) error {
+	if method == "state_getStorageAt" && !*m.upgraded {
+		*m.upgraded = true
+		if err := (*m.client).RefreshRuntime(); err != nil {
+			return err
+		}
+	}
+	return m.fixedBlockRPC.Call(result, method, args...)
+}
+
+func (m upgradeDuringBlockRPC) GetRuntimeVersionLatest() (*types.RuntimeVersion, error) {
+	if *m.upgraded {
+		return &types.RuntimeVersion{SpecName: "bifrost", SpecVersion: 2}, nil
+	}
+	return m.fixedBlockRPC.GetRuntimeVersionLatest()
+}
+
+func (m upgradeDuringBlockRPC) GetMetadataLatest() (*types.Metadata, error) {
+	meta := testMetadata()
+	if *m.upgraded {
+		events := meta.AsMetadataV12.Modules[0].Events
+		events[0], events[1] = events[1], events[0]
+	}
+	return meta, nil
+}
+
+/*
+解析区块的过程中runtime升级，同一个区块仍然使用开始解析时的metadata
+*/
+func Test_RuntimeUpgradeDuringBlock_Offline(t *testing.T) {
+	meta := testMetadata()
+	me, err := expand.NewMetadataExpand(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := newTestAccount(t, 1)
+	bob := newTestAccount(t, 2)
+	transfer, err := me.BalanceTransferBigCall(bob.address, big.NewInt(100), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		upgraded bool
+		c        *client.Client
+	)
+	rpc := upgradeDuringBlockRPC{
+		fixedBlockRPC: fixedBlockRPC{
+			block: &models.SignedBlock{Block: models.Block{
+				Header:     models.Header{ParentHash: testBlockHash, Number: "0x64"},
+				Extrinsics: []string{timestampExtrinsic(t, meta, 1620000090000), signedExtrinsic(t, alice, 0, transfer)},
+			}},
+			events: eventsHex(t,
+				successEvent(0),
+				transferEvent(1, alice, bob, types.NewU128(*big.NewInt(100))),
+				successEvent(1),
+			),
+		},
+		upgraded: &upgraded,
+		client:   &c,
+	}
+	c, err = client.NewWithRPCCaller(rpc, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetPrefix(testPrefix)
+	c.SetFetchFees(false)
+
+	resp, err := c.GetBlockByHash(testBlockHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !upgraded || c.SpecVersion != 2 {
+		t.Fatalf("expected runtime upgrade during block decode, spec version %d", c.SpecVersion)
+	}
+	if len(resp.Extrinsic) != 1 || resp.Extrinsic[0].Status != "success" || resp.Extrinsic[0].Amount != "100" {
+		t.Fatalf("block decoded with mixed metadata: %+v", resp.Extrinsic)
+	}
+}
+
+/*
+返回可以修改的runtime版本的rpc
+*/
+type versionRPC struct {
+	testRPC
+	version *types.RuntimeVersion
+}
+
+func (m versionRPC) GetRuntimeVersionLatest() (*types.RuntimeVersion, error) {
+	v := *m.version
+	return &v, nil
+}
+
+func Test_OnRuntimeUpgrade_Offline(t *testing.T) {
+	version := &types.RuntimeVersion{SpecName: "bifrost", SpecVersion: 1, TransactionVersion: 1}
+	c, err := client.NewWithRPCCaller(versionRPC{version: version}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var upgrades [][2]client.RuntimeInfo
+	c.OnRuntimeUpgrade(func(old, new client.RuntimeInfo) {
+		upgrades = append(upgrades, [2]client.RuntimeInfo{old, new})
+	})
+	if err := c.RefreshRuntime(); err != nil {
+		t.Fatal(err)
+	}
+	if len(upgrades) != 0 {
+		t.Fatalf("unexpected upgrade without version change: %+v", upgrades)
+	}
+
+	version.TransactionVersion = 2
+	if err := c.RefreshRuntime(); err != nil {
+		t.Fatal(err)
+	}
+	version.SpecVersion = 2
+	if err := c.RefreshRuntime(); err != nil {
+		t.Fatal(err)
+	}
+	if len(upgrades) != 2 {
+		t.Fatalf("expected 2 upgrades, got %d", len(upgrades))
+	}
+	if upgrades[0][0].TransactionVersion != 1 || upgrades[0][1].TransactionVersion != 2 || upgrades[0][1].SpecVersion != 1 {
+		t.Fatalf("unexpected transaction version upgrade: %+v", upgrades[0])
+	}
+	if upgrades[1][0].SpecVersion != 1 || upgrades[1][1].SpecVersion != 2 || upgrades[1][1].TransactionVersion != 2 {
+		t.Fatalf("unexpected spec version upgrade: %+v", upgrades[1])
+	}
+	if upgrades[1][1].GenesisHash == "" || upgrades[1][1].GenesisHash != upgrades[1][0].GenesisHash {
+		t.Fatalf("unexpected genesis hash: %+v", upgrades[1])
+	}
+	if info := c.RuntimeInfo(); info.SpecVersion != 2 || info.TransactionVersion != 2 {
+		t.Fatalf("unexpected runtime info: %+v", info)
+	}
+}
+
+/*
+第一次查询Indices.Accounts时runtime升级的rpc，升级后的metadata中没有Indices
+*/
+type upgradeOnIndexRPC struct {
+	storageAtRPC
+	upgraded *bool
+	client   **client.Client
+}
+
+func (m upgradeOnIndexRPC) Call(result interface{}, method string, args ...interface{}) error {
+	if method == "state_getStorageAt" && len(args) == 2 && args[0] != m.eventsKey() && !*m.upgraded {
+		*m.upgraded = true
+		if err := (*m.client).RefreshRuntime(); err != nil {
+			return err
+		}
+	}
+	return m.storageAtRPC.Call(result, method, args...)
+}
+
+func (m upgradeOnIndexRPC) eventsKey() string {
+	key, _ := types.CreateStorageKey(testMetadata(), "System", "Events", nil, nil)
+	return key.Hex()
+}
+
+func (m upgradeOnIndexRPC) GetRuntimeVersionLatest() (*types.RuntimeVersion, error) {
+	if *m.upgraded {
+		return &types.RuntimeVersion{SpecName: "bifrost", SpecVersion: 2}, nil
+	}
+	return m.storageAtRPC.GetRuntimeVersionLatest()
+}
+
+func (m upgradeOnIndexRPC) GetMetadataLatest() (*types.Metadata, error) {
+	meta := testMetadata()
+	if *m.upgraded {
+		var modules []types.ModuleMetadataV12
+		for _, module := range meta.AsMetadataV12.Modules {
+			if module.Name != "Indices" {
+				modules = append(modules, module)
+			}
+		}
+		meta.AsMetadataV12.Modules = modules
+	}
+	return meta, nil
+}
+
+/*
+解析区块中的账户索引时runtime升级，之后的索引仍然使用区块开始解析时的metadata创建storage key
+*/
+func Test_RuntimeUpgradeDuringIndexLookup_Offline(t *testing.T) {
+	meta := testMetadata()
+	me, err := expand.NewMetadataExpand(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := newTestAccount(t, 1)
+	bob := newTestAccount(t, 2)
+	callIdx, err := me.MV.GetCallIndex("Balances", "transfer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dest expand.MultiAddress
+	dest.SetTypes(expand.MultiAddressIndex)
+	dest.Index = types.NewUCompactFromUInt(7)
+	transfer, err := expand.NewCall(callIdx, dest, types.NewUCompactFromUInt(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	index, err := types.EncodeToBytes(types.NewU32(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := types.CreateStorageKey(meta, "Indices", "Accounts", index, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, err := types.EncodeToBytes(struct {
+		Who     types.AccountID
+		Deposit types.U128
+		Frozen  bool
+	}{types.NewAccountID(types.MustHexDecodeString(bob.pubHex)), types.NewU128(*big.NewInt(100)), false})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		upgraded bool
+		c        *client.Client
+	)
+	rpc := upgradeOnIndexRPC{
+		storageAtRPC: storageAtRPC{assetBlockRPC: assetBlockRPC{
+			fixedBlockRPC: fixedBlockRPC{
+				block: &models.SignedBlock{Block: models.Block{
+					Header: models.Header{ParentHash: testBlockHash, Number: "0x64"},
+					Extrinsics: []string{
+						timestampExtrinsic(t, meta, 1620000096000),
+						signedExtrinsic(t, alice, 0, transfer),
+						signedExtrinsic(t, alice, 1, transfer),
+					},
+				}},
+				events: eventsHex(t,
+					successEvent(0),
+					transferEvent(1, alice, bob, types.NewU128(*big.NewInt(1000))),
+					successEvent(1),
+					transferEvent(2, alice, bob, types.NewU128(*big.NewInt(1000))),
+					successEvent(2),
+				),
+			},
+		}, at: map[string][]byte{testBlockHash + key.Hex(): value}},
+		upgraded: &upgraded,
+		client:   &c,
+	}
+	c, err = client.NewWithRPCCaller(rpc, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetPrefix(testPrefix)
+	c.SetFetchFees(false)
+
+	resp, err := c.GetBlockByHash(testBlockHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !upgraded || c.RuntimeInfo().SpecVersion != 2 {
+		t.Fatal("expected runtime upgrade during index lookup")
+	}
+	if len(resp.Extrinsic) != 2 {
+		t.Fatalf("unexpected extrinsics: %+v", resp.Extrinsic)
+	}
+	for _, e := range resp.Extrinsic {
+		if e.ToAddress != bob.address || e.Status != "success" {
+			t.Fatalf("index resolved with upgraded metadata: %+v", e)
+		}
+	}
+	//新的runtime中没有Indices
+	if _, err = c.BuildStorageKey("Indices", "Accounts", index); err == nil {
+		t.Fatal("expected error for removed Indices module")
+	}
+}
+
+/*
+第一次请求创世区块hash失败的rpc，calls记录请求的次数
+*/
+type genesisRPC struct {
+	testRPC
+	calls *int32
+}
+
+func (m genesisRPC) GetBlockHash(blockNumber uint64) (types.Hash, error) {
+	if atomic.AddInt32(m.calls, 1) == 1 {
+		return types.Hash{}, errors.New("connection reset")
+	}
+	return types.NewHash(types.MustHexDecodeString(testGenesisHash)), nil
+}
+
+/*
+并发调用GetGenesisHash以及SetGenesisHash，失败的请求不被缓存，成功后只请求一次节点
+*/
+func Test_GenesisHashConcurrent_Offline(t *testing.T) {
+	var calls int32
+	c, err := client.NewWithRPCCaller(genesisRPC{calls: &calls}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash := c.GetGenesisHash(); hash != "" {
+		t.Fatalf("expected empty genesis hash on error, got %s", hash)
+	}
+	override := "0x" + strings.Repeat("11", 32)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if hash := c.GetGenesisHash(); hash != testGenesisHash && hash != override {
+				t.Errorf("unexpected genesis hash: %s", hash)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if err := c.SetGenesisHash(override); err != nil {
+				t.Error(err)
+			}
+			if err := c.SetGenesisHash(""); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if hash := c.GetGenesisHash(); hash != testGenesisHash {
+		t.Fatalf("unexpected genesis hash: %s", hash)
+	}
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Fatalf("expected 2 genesis hash requests, got %d", n)
+	}
+	if err := c.SetGenesisHash("0x1234"); err == nil {
+		t.Fatal("expected error for invalid genesis hash")
+	}
+}
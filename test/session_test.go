@@ -0,0 +1,28 @@
+package test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/JFJun/bifrost-go/client"
+)
+
+func Test_Unit_SplitSessionKeys(t *testing.T) {
+	keys := append(bytes.Repeat([]byte{0x01}, 32), bytes.Repeat([]byte{0x02}, 32)...)
+	split, err := client.SplitSessionKeys(keys, []string{"gran", "babe"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(split) != 2 || split[0].KeyType != "gran" || split[1].KeyType != "babe" {
+		t.Fatalf("unexpected keys: %v", split)
+	}
+	if split[1].PublicKey != "0202020202020202020202020202020202020202020202020202020202020202" {
+		t.Fatalf("unexpected babe key: %s", split[1].PublicKey)
+	}
+	if _, err := client.SplitSessionKeys(keys, []string{"gran"}); err == nil {
+		t.Fatal("expected error for extra bytes")
+	}
+	if _, err := client.SplitSessionKeys(keys, []string{"gran", "babe", "imon"}); err == nil {
+		t.Fatal("expected error for missing key")
+	}
+}
@@ -0,0 +1,100 @@
+package test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/JFJun/bifrost-go/client"
+	"github.com/JFJun/bifrost-go/models"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+func identificationTuple(validator testAccount, total int64) struct {
+	ValidatorID        types.AccountID
+	FullIdentification types.Exposure
+} {
+	return struct {
+		ValidatorID        types.AccountID
+		FullIdentification types.Exposure
+	}{
+		ValidatorID: types.NewAccountID(types.MustHexDecodeString(validator.pubHex)),
+		FullIdentification: types.Exposure{
+			Total: types.NewUCompact(big.NewInt(total)),
+			Own:   types.NewUCompact(big.NewInt(total)),
+		},
+	}
+}
+
+/*
+Staking.Slashed、Offences.Offence以及ImOnline.SomeOffline（每个验证人一条）
+*/
+func Test_GetSlashEvents_Offline(t *testing.T) {
+	alice := newTestAccount(t, 1)
+	bob := newTestAccount(t, 2)
+	carol := newTestAccount(t, 3)
+	finalization := types.Phase{IsFinalization: true}
+	var kind types.Bytes16
+	copy(kind[:], "im-online:offlin")
+	events := eventsHex(t,
+		successEvent(0),
+		testEvent{phase: applyExtrinsic(1), module: 14, event: 0, args: []interface{}{kind, types.NewBytes([]byte{7, 0, 0, 0})}},
+		successEvent(1),
+		testEvent{phase: finalization, module: 6, event: 2, args: []interface{}{
+			types.NewAccountID(types.MustHexDecodeString(alice.pubHex)), types.NewU128(*big.NewInt(5000)),
+		}},
+		testEvent{phase: finalization, module: 15, event: 2, args: []interface{}{
+			[]struct {
+				ValidatorID        types.AccountID
+				FullIdentification types.Exposure
+			}{identificationTuple(bob, 100), identificationTuple(carol, 200)},
+		}},
+	)
+	c, err := client.NewWithRPCCaller(fixedBlockRPC{events: events}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetPrefix(testPrefix)
+	slashes, err := c.GetSlashEvents(testBlockHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []models.SlashEvent{
+		{Type: "slash", Account: alice.address, Amount: "5000", ExtrinsicIdx: -1, Phase: "Finalization"},
+		{Type: "offence", Kind: "im-online:offlin", TimeSlot: "0x07000000", ExtrinsicIdx: 1, Phase: "ApplyExtrinsic"},
+		{Type: "offline", Account: bob.address, ExtrinsicIdx: -1, Phase: "Finalization"},
+		{Type: "offline", Account: carol.address, ExtrinsicIdx: -1, Phase: "Finalization"},
+	}
+	if len(slashes) != len(expected) {
+		t.Fatalf("expected %d slash events, got %+v", len(expected), slashes)
+	}
+	for i := range expected {
+		if slashes[i] != expected[i] {
+			t.Fatalf("slash event %d: expected %+v, got %+v", i, expected[i], slashes[i])
+		}
+	}
+
+	//没有惩罚的区块以及没有离线验证人的SomeOffline
+	events = eventsHex(t,
+		successEvent(0),
+		testEvent{phase: finalization, module: 15, event: 2, args: []interface{}{
+			[]struct {
+				ValidatorID        types.AccountID
+				FullIdentification types.Exposure
+			}{},
+		}},
+	)
+	c, err = client.NewWithRPCCaller(fixedBlockRPC{events: events}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slashes, err = c.GetSlashEvents(testBlockHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(slashes) != 0 {
+		t.Fatalf("expected no slash events, got %+v", slashes)
+	}
+	if _, err := c.GetSlashEvents("100"); err == nil {
+		t.Fatal("expected error for block height instead of hash")
+	}
+}
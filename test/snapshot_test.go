@@ -0,0 +1,200 @@
+package test
+
+import (
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/JFJun/bifrost-go/client"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+/*
+按state_getKeysPaged分页返回System.Account的rpc，记录每次请求的startKey
+*/
+type snapshotRPC struct {
+	testRPC
+	accounts  map[string][]byte
+	startKeys []string
+}
+
+func (m *snapshotRPC) Call(result interface{}, method string, args ...interface{}) error {
+	switch method {
+	case "state_getKeysPaged":
+		prefix, pageSize, startKey := args[0].(string), args[1].(int), args[2].(string)
+		m.startKeys = append(m.startKeys, startKey)
+		var keys []string
+		for key := range m.accounts {
+			if strings.HasPrefix(key, prefix) && key > startKey {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		if len(keys) > pageSize {
+			keys = keys[:pageSize]
+		}
+		*(result.(*[]string)) = keys
+		return nil
+	case "state_queryStorageAt":
+		var changeSet types.StorageChangeSet
+		for _, key := range args[0].([]string) {
+			changeSet.Changes = append(changeSet.Changes, types.KeyValueOption{
+				StorageKey:     types.MustHexDecodeString(key),
+				HasStorageData: true,
+				StorageData:    m.accounts[key],
+			})
+		}
+		*(result.(*[]types.StorageChangeSet)) = []types.StorageChangeSet{changeSet}
+		return nil
+	}
+	return m.testRPC.Call(result, method, args...)
+}
+
+/*
+nonce + refcounts + AccountData(free, reserved, misc_frozen, fee_frozen)，refcounts为各个版本的refcount编码
+*/
+func accountInfoData(t *testing.T, refcounts []byte, free int64) []byte {
+	data := []byte{1, 0, 0, 0}
+	data = append(data, refcounts...)
+	for _, balance := range []int64{free, 0, 0, 0} {
+		b, err := types.EncodeToBytes(types.NewU128(*big.NewInt(balance)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		data = append(data, b...)
+	}
+	return data
+}
+
+func Test_SnapshotBalancesPaging_Offline(t *testing.T) {
+	meta := testMetadata()
+	layouts := map[string][]byte{
+		"u8 refcount":                     {1},
+		"u32 refcount":                    {1, 0, 0, 0},
+		"consumers+providers":             {0, 0, 0, 0, 1, 0, 0, 0},
+		"consumers+providers+sufficients": {2, 0, 0, 0, 1, 0, 0, 0, 1, 0, 0, 0},
+		"sufficients only":                {0, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0},
+	}
+	newRPC := func() (*snapshotRPC, map[string]int64) {
+		rpc := &snapshotRPC{accounts: make(map[string][]byte)}
+		expected := make(map[string]int64)
+		names := make([]string, 0, len(layouts))
+		for name := range layouts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for i, name := range names {
+			account := newTestAccount(t, byte(i+1))
+			key, err := types.CreateStorageKey(meta, "System", "Account", types.MustHexDecodeString(account.pubHex), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			free := int64(1000 * (i + 1))
+			rpc.accounts[key.Hex()] = accountInfoData(t, layouts[name], free)
+			expected[account.address] = free
+		}
+		return rpc, expected
+	}
+
+	cases := []struct {
+		pageSize int
+		requests int
+	}{
+		//2 + 2 + 1，最后一页不满时不再请求
+		{2, 3},
+		//5个账户刚好一页，需要再请求一次确认没有更多的key
+		{5, 2},
+		{10, 1},
+	}
+	for _, tc := range cases {
+		rpc, expected := newRPC()
+		c, err := client.NewWithRPCCaller(rpc, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.SetPrefix(testPrefix)
+		balances, err := c.SnapshotBalances(testBlockHash, tc.pageSize)
+		if err != nil {
+			t.Fatalf("page size %d: %v", tc.pageSize, err)
+		}
+		if len(balances) != len(expected) {
+			t.Fatalf("page size %d: expected %d accounts, got %d", tc.pageSize, len(expected), len(balances))
+		}
+		for address, free := range expected {
+			if balances[address] == nil || balances[address].Int64() != free {
+				t.Fatalf("page size %d: unexpected balance of %s: %v", tc.pageSize, address, balances[address])
+			}
+		}
+		if len(rpc.startKeys) != tc.requests {
+			t.Fatalf("page size %d: expected %d requests, got %d", tc.pageSize, tc.requests, len(rpc.startKeys))
+		}
+		//第一页从前缀开始，之后从上一页的最后一个key开始
+		prefix := "0x26aa394eea5630e07c48ae0c9558cef7b99d880ec681799c0cf30e8886371da9"
+		if rpc.startKeys[0] != prefix {
+			t.Fatalf("page size %d: unexpected first start key %s", tc.pageSize, rpc.startKeys[0])
+		}
+	}
+
+	//回调返回错误时停止遍历
+	rpc, _ := newRPC()
+	c, err := client.NewWithRPCCaller(rpc, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetPrefix(testPrefix)
+	stop := errors.New("stop")
+	calls := 0
+	err = c.SnapshotBalancesFunc(testBlockHash, 2, func(address string, free *big.Int) error {
+		calls++
+		if calls == 3 {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) || calls != 3 || len(rpc.startKeys) != 2 {
+		t.Fatalf("expected stop after 3 accounts in 2 pages, got err=%v calls=%d pages=%d", err, calls, len(rpc.startKeys))
+	}
+
+	if err := c.SnapshotBalancesFunc(testBlockHash, 2, nil); err == nil {
+		t.Fatal("expected error for nil callback")
+	}
+	if err := c.SnapshotBalancesFunc("0x1234", 2, func(string, *big.Int) error { return nil }); err == nil {
+		t.Fatal("expected error for invalid block hash")
+	}
+}
+
+/*
+数据的长度与任何格式都不一致，或者与指定的格式不一致时返回ErrDecodeFailed
+*/
+func Test_SnapshotBalancesLayoutMismatch_Offline(t *testing.T) {
+	meta := testMetadata()
+	alice := newTestAccount(t, 1)
+	key, err := types.CreateStorageKey(meta, "System", "Account", types.MustHexDecodeString(alice.pubHex), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		refcounts []byte
+		layout    client.AccountInfoType
+	}{
+		{[]byte{0, 0}, client.AccountInfoAuto},
+		{[]byte{0, 0, 0, 0, 1, 0, 0, 0}, client.AccountInfoLegacy},
+		{[]byte{1, 0, 0, 0}, client.AccountInfoWithProviders},
+	}
+	for _, tc := range cases {
+		rpc := &snapshotRPC{accounts: map[string][]byte{key.Hex(): accountInfoData(t, tc.refcounts, 1000)}}
+		c, err := client.NewWithRPCCaller(rpc, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.SetPrefix(testPrefix)
+		c.SetAccountInfoType(tc.layout)
+		_, err = c.SnapshotBalances(testBlockHash, 10)
+		if !errors.Is(err, client.ErrDecodeFailed) {
+			t.Fatalf("refcounts %s with %s layout: expected ErrDecodeFailed, got %v", hex.EncodeToString(tc.refcounts), tc.layout, err)
+		}
+	}
+}
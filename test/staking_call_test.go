@@ -0,0 +1,85 @@
+package test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/JFJun/bifrost-go/expand"
+	"github.com/JFJun/bifrost-go/utils"
+	"github.com/stafiprotocol/go-substrate-rpc-client/scale"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+/*
+Staking.set_payee以及set_controller构建后能够被解析回来
+*/
+func Test_StakingPayeeControllerCall_Offline(t *testing.T) {
+	meta := testMetadata()
+	alice := newTestAccount(t, 1)
+	bob := newTestAccount(t, 2)
+
+	decode := func(call types.Call) *expand.ExtrinsicDecoder {
+		ed, err := expand.NewExtrinsicDecoder(meta)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := hex.DecodeString(utils.Remove0X(signedExtrinsic(t, alice, 0, call)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ed.ProcessExtrinsicDecoder(*scale.NewDecoder(bytes.NewReader(data)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ed
+	}
+
+	payees := []expand.RewardDestination{
+		{Type: expand.RewardDestinationStaked},
+		{Type: expand.RewardDestinationStash},
+		{Type: expand.RewardDestinationController},
+		{Type: expand.RewardDestinationAccount, Account: bob.address},
+		{Type: expand.RewardDestinationNone},
+	}
+	for i, payee := range payees {
+		call, err := expand.NewStakingSetPayeeCall(meta, payee)
+		if err != nil {
+			t.Fatal(err)
+		}
+		//variant的下标
+		if call.Args[0] != byte(i) {
+			t.Fatalf("unexpected %s variant index: %d", payee.Type, call.Args[0])
+		}
+		ed := decode(call)
+		if ed.CallModuleFunction != "set_payee" || len(ed.Params) != 1 || ed.Params[0].Value != payee.Type {
+			t.Fatalf("unexpected decoded set_payee: %+v", ed.Params)
+		}
+		expectedAccount := ""
+		if payee.Type == expand.RewardDestinationAccount {
+			expectedAccount = bob.pubHex
+		}
+		if ed.Params[0].ValueRaw != expectedAccount {
+			t.Fatalf("unexpected payee account: %s", ed.Params[0].ValueRaw)
+		}
+	}
+
+	if _, err := expand.NewStakingSetPayeeCall(meta, expand.RewardDestination{Type: "Unknown"}); err == nil {
+		t.Fatal("expected error for unknown reward destination")
+	}
+	if _, err := expand.NewStakingSetPayeeCall(meta, expand.RewardDestination{Type: expand.RewardDestinationAccount}); err == nil {
+		t.Fatal("expected error for account reward destination without account")
+	}
+
+	call, err := expand.NewStakingSetControllerCall(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(call.Args) != 0 {
+		t.Fatalf("set_controller should have no args, got %x", call.Args)
+	}
+	ed := decode(call)
+	if ed.CallModule != "Staking" || ed.CallModuleFunction != "set_controller" || len(ed.Params) != 0 {
+		t.Fatalf("unexpected decoded set_controller: %s.%s %+v", ed.CallModule, ed.CallModuleFunction, ed.Params)
+	}
+}
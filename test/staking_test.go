@@ -0,0 +1,61 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/JFJun/bifrost-go/client"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+/*
+Staking.ActiveEra为Option<ActiveEraInfo>，其中start为Option<u64>，era刚开始时为None
+*/
+func Test_GetActiveEra_Offline(t *testing.T) {
+	meta := testMetadata()
+	key, err := types.CreateStorageKey(meta, "Staking", "ActiveEra", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name  string
+		value string
+		index uint32
+		start uint64
+	}{
+		//index 42，start Some(1620000000000)
+		{"with start", "2a000000" + "01" + "0048862f79010000", 42, 1620000000000},
+		//index 43，start None
+		{"start none", "2b000000" + "00", 43, 0},
+	}
+	for _, tc := range cases {
+		rpc := storageRPC{values: map[string][]byte{key.Hex(): types.MustHexDecodeString("0x" + tc.value)}}
+		c, err := client.NewWithRPCCaller(rpc, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		index, start, err := c.GetActiveEra()
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if index != tc.index || start != tc.start {
+			t.Fatalf("%s: expected era %d start %d, got %d %d", tc.name, tc.index, tc.start, index, start)
+		}
+	}
+
+	failures := map[string]map[string][]byte{
+		//storage为None（没有值）
+		"none": {},
+		//start的Option标志位之后缺少数据
+		"truncated start": {key.Hex(): types.MustHexDecodeString("0x2a00000001")},
+		"truncated index": {key.Hex(): types.MustHexDecodeString("0x2a00")},
+	}
+	for name, values := range failures {
+		c, err := client.NewWithRPCCaller(storageRPC{values: values}, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if index, start, err := c.GetActiveEra(); err == nil {
+			t.Fatalf("%s: expected error, got era %d start %d", name, index, start)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/JFJun/bifrost-go/client"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+/*
+按storage key返回固定值的rpc，值为scale编码
+*/
+type storageRPC struct {
+	testRPC
+	values map[string][]byte
+}
+
+func (m storageRPC) GetStorageLatest(key types.StorageKey, target interface{}) (bool, error) {
+	value, ok := m.values[key.Hex()]
+	if !ok {
+		return false, nil
+	}
+	return true, types.DecodeFromBytes(value, target)
+}
+
+func Test_TotalIssuanceAndStaked_Offline(t *testing.T) {
+	meta := testMetadata()
+	encode := func(v interface{}) []byte {
+		b, err := types.EncodeToBytes(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	issuanceKey, err := types.CreateStorageKey(meta, "Balances", "TotalIssuance", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stakeKey, err := types.CreateStorageKey(meta, "Staking", "ErasTotalStake", encode(types.NewU32(42)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuance, _ := new(big.Int).SetString("12000000000000000000000000", 10)
+	rpc := storageRPC{values: map[string][]byte{
+		issuanceKey.Hex(): encode(types.NewU128(*issuance)),
+		stakeKey.Hex():    encode(types.NewU128(*big.NewInt(5000000000))),
+	}}
+	c, err := client.NewWithRPCCaller(rpc, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	total, err := c.GetTotalIssuance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total.Cmp(issuance) != 0 {
+		t.Fatalf("unexpected total issuance: %s", total)
+	}
+	staked, err := c.GetTotalStaked(42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if staked.Int64() != 5000000000 {
+		t.Fatalf("unexpected total staked: %s", staked)
+	}
+	//超出HistoryDepth的era没有数据
+	staked, err = c.GetTotalStaked(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if staked.Sign() != 0 {
+		t.Fatalf("expected zero stake for pruned era, got %s", staked)
+	}
+}
@@ -0,0 +1,116 @@
+package test
+
+import (
+	"encoding/hex"
+	"errors"
+	"hash"
+	"testing"
+
+	"github.com/JFJun/bifrost-go/client"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"github.com/stafiprotocol/go-substrate-rpc-client/xxhash"
+	"golang.org/x/crypto/blake2b"
+)
+
+/*
+V13的NMap storage entry，hashers为每一个key的hasher
+*/
+type nMapEntry struct {
+	hashers []types.StorageHasherV10
+}
+
+func (nMapEntry) IsPlain() bool     { return false }
+func (nMapEntry) IsMap() bool       { return false }
+func (nMapEntry) IsDoubleMap() bool { return false }
+func (nMapEntry) IsNMap() bool      { return true }
+
+func (nMapEntry) Hasher() (hash.Hash, error)  { return nil, errors.New("not a map") }
+func (nMapEntry) Hasher2() (hash.Hash, error) { return nil, errors.New("not a double map") }
+
+func (e nMapEntry) Hashers() ([]hash.Hash, error) {
+	var hs []hash.Hash
+	for _, hasher := range e.hashers {
+		h, err := hasher.HashFunc()
+		if err != nil {
+			return nil, err
+		}
+		hs = append(hs, h)
+	}
+	return hs, nil
+}
+
+func blake2128Concat(data []byte) string {
+	h, _ := blake2b.New(16, nil)
+	h.Write(data)
+	return hex.EncodeToString(append(h.Sum(nil), data...))
+}
+
+func twox64Concat(data []byte) string {
+	return hex.EncodeToString(append(xxhash.New64(data).Sum(nil), data...))
+}
+
+/*
+plain、map、double map以及NMap的storage key为twox128(module)+twox128(method)+每一个key按各自的hasher编码
+*/
+func Test_Unit_BuildStorageKey(t *testing.T) {
+	c, err := client.NewWithRPCCaller(testRPC{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := newTestAccount(t, 1)
+	pub, err := hex.DecodeString(alice.pubHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	era, err := types.EncodeToBytes(types.NewU32(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const (
+		balancesTotalIssuance = "c2261276cc9d1f8598ea4b6a74b15c2f57c875e4cff74148e4628f264b974c80"
+		systemAccount         = "26aa394eea5630e07c48ae0c9558cef7b99d880ec681799c0cf30e8886371da9"
+		stakingErasStakers    = "5f3e4907f716ac89b6347d15ececedca8bde0a0ea8864605e3b68ed9cb2da01b"
+	)
+	cases := []struct {
+		name     string
+		module   string
+		method   string
+		args     [][]byte
+		expected string
+	}{
+		{"plain", "Balances", "TotalIssuance", nil, balancesTotalIssuance},
+		{"map", "System", "Account", [][]byte{pub}, systemAccount + blake2128Concat(pub)},
+		{"double map", "Staking", "ErasStakers", [][]byte{era, pub}, stakingErasStakers + twox64Concat(era) + twox64Concat(pub)},
+	}
+	for _, tc := range cases {
+		key, err := c.BuildStorageKey(tc.module, tc.method, tc.args...)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if hex.EncodeToString(key) != tc.expected {
+			t.Fatalf("%s: expected key %s, got %x", tc.name, tc.expected, key)
+		}
+	}
+
+	//NMap的hasher从entry中获取，每一个key使用不同的hasher
+	entry := nMapEntry{hashers: []types.StorageHasherV10{{IsTwox64Concat: true}, {IsBlake2_128Concat: true}, {IsIdentity: true}}}
+	key, err := client.BuildStorageKeyFromEntry("Staking", "ErasStakers", entry, era, pub, []byte{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := stakingErasStakers + twox64Concat(era) + blake2128Concat(pub) + "01"
+	if hex.EncodeToString(key) != expected {
+		t.Fatalf("nmap: expected key %s, got %x", expected, key)
+	}
+
+	//key的个数与hasher不一致
+	if _, err := c.BuildStorageKey("System", "Account"); err == nil {
+		t.Fatal("expected error for missing map key")
+	}
+	if _, err := client.BuildStorageKeyFromEntry("Staking", "ErasStakers", entry, era, pub); err == nil {
+		t.Fatal("expected error for missing nmap key")
+	}
+	if _, err := c.BuildStorageKey("System", "NotExist"); err == nil {
+		t.Fatal("expected error for unknown storage")
+	}
+}
@@ -0,0 +1,216 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/JFJun/bifrost-go/client"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+/*
+记录author_submitExtrinsic参数的rpc
+*/
+type submitRPC struct {
+	testRPC
+	submitted *string
+}
+
+func (m submitRPC) Call(result interface{}, method string, args ...interface{}) error {
+	if method != "author_submitExtrinsic" {
+		return m.testRPC.Call(result, method, args...)
+	}
+	*m.submitted = args[0].(string)
+	return nil
+}
+
+func Test_SubmitExtrinsic_Offline(t *testing.T) {
+	var submitted string
+	c, err := client.NewWithRPCCaller(submitRPC{submitted: &submitted}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txHash, err := c.SubmitExtrinsic("0000086869")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if submitted != "0x0000086869" {
+		t.Fatalf("unexpected submitted extrinsic: %s", submitted)
+	}
+	//blake2b-256(0x0000086869)
+	if txHash != "0x89efc84be36b223a83707f2e577f86a99a79cac6b6d5c4c09b231384605ef27b" {
+		t.Fatalf("unexpected tx hash: %s", txHash)
+	}
+
+	//没有websocket连接时不能订阅交易状态
+	if _, err = c.SubmitAndWatchExtrinsic(context.Background(), "0x0000086869"); !errors.Is(err, client.ErrSubscriptionUnsupported) {
+		t.Fatalf("expected ErrSubscriptionUnsupported, got %v", err)
+	}
+}
+
+/*
+Unsubscribe后关闭unsubscribed的订阅
+*/
+type testSubscription struct {
+	err          chan error
+	unsubscribed chan struct{}
+	once         sync.Once
+}
+
+func newTestSubscription() *testSubscription {
+	return &testSubscription{err: make(chan error), unsubscribed: make(chan struct{})}
+}
+
+func (s *testSubscription) Err() <-chan error {
+	return s.err
+}
+
+func (s *testSubscription) Unsubscribe() {
+	s.once.Do(func() { close(s.unsubscribed) })
+}
+
+/*
+author_submitAndWatchExtrinsic按顺序推送statuses，之后err不为空时推送订阅错误
+*/
+type trackRPC struct {
+	testRPC
+	statuses []types.ExtrinsicStatus
+	err      error
+	sub      *testSubscription
+}
+
+func (m trackRPC) Subscribe(ctx context.Context, namespace, subscribeMethod, unsubscribeMethod, notificationMethod string,
+	channel interface{}, args ...interface{}) (client.Subscription, error) {
+	if namespace+"_"+subscribeMethod != "author_submitAndWatchExtrinsic" {
+		return nil, errors.New("unsupported subscription " + subscribeMethod)
+	}
+	updates := channel.(chan types.ExtrinsicStatus)
+	go func() {
+		for _, status := range m.statuses {
+			select {
+			case updates <- status:
+			case <-m.sub.unsubscribed:
+				return
+			}
+		}
+		if m.err != nil {
+			select {
+			case m.sub.err <- m.err:
+			case <-m.sub.unsubscribed:
+			}
+		}
+	}()
+	return m.sub, nil
+}
+
+func collectStatuses(t *testing.T, statuses <-chan client.TxStatus) []client.TxStatus {
+	var result []client.TxStatus
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case status, ok := <-statuses:
+			if !ok {
+				return result
+			}
+			result = append(result, status)
+		case <-timeout:
+			t.Fatal("status chan is not closed")
+		}
+	}
+}
+
+func waitUnsubscribed(t *testing.T, sub *testSubscription) {
+	select {
+	case <-sub.unsubscribed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("subscription is not unsubscribed")
+	}
+}
+
+func Test_SubmitAndTrack_Offline(t *testing.T) {
+	blockHash := types.NewHash(types.MustHexDecodeString(testBlockHash))
+	rpc := trackRPC{
+		statuses: []types.ExtrinsicStatus{
+			{IsReady: true},
+			{IsInBlock: true, AsInBlock: blockHash},
+			{IsFinalized: true, AsFinalized: blockHash},
+			//最终状态之后的推送会被忽略
+			{IsReady: true},
+		},
+		sub: newTestSubscription(),
+	}
+	c, err := client.NewWithRPCCaller(rpc, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses, err := c.SubmitAndTrack(context.Background(), "0x0000086869")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := collectStatuses(t, statuses)
+	if len(result) != 3 || result[0].Status != "Ready" || result[1].Status != "InBlock" ||
+		result[2].Status != "Finalized" || result[2].BlockHash != testBlockHash {
+		t.Fatalf("unexpected statuses: %+v", result)
+	}
+	waitUnsubscribed(t, rpc.sub)
+
+	//订阅出错时推送Error后关闭
+	rpc = trackRPC{statuses: []types.ExtrinsicStatus{{IsReady: true}}, err: errors.New("connection lost"),
+		sub: newTestSubscription()}
+	c, err = client.NewWithRPCCaller(rpc, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses, err = c.SubmitAndTrack(context.Background(), "0x0000086869")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result = collectStatuses(t, statuses)
+	if len(result) != 2 || result[1].Status != "Error" || result[1].Err == nil {
+		t.Fatalf("unexpected statuses: %+v", result)
+	}
+	waitUnsubscribed(t, rpc.sub)
+}
+
+/*
+调用者不再读取chan时，ctx结束后goroutine退出、取消订阅并关闭chan
+*/
+func Test_SubmitAndTrackCancel_Offline(t *testing.T) {
+	rpc := trackRPC{sub: newTestSubscription()}
+	for i := 0; i < 64; i++ {
+		rpc.statuses = append(rpc.statuses, types.ExtrinsicStatus{IsReady: true})
+	}
+	c, err := client.NewWithRPCCaller(rpc, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	statuses, err := c.SubmitAndTrack(ctx, "0x0000086869")
+	if err != nil {
+		t.Fatal(err)
+	}
+	//等待chan的缓冲被写满，goroutine阻塞在写入
+	for i := 0; i < 100 && len(statuses) < cap(statuses); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(statuses) != cap(statuses) {
+		t.Fatalf("expected full status chan, got %d", len(statuses))
+	}
+	cancel()
+	waitUnsubscribed(t, rpc.sub)
+	if result := collectStatuses(t, statuses); len(result) > cap(statuses) {
+		t.Fatalf("unexpected statuses after cancel: %d", len(result))
+	}
+
+	//没有websocket连接时不能订阅
+	c, err = client.NewWithRPCCaller(testRPC{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.SubmitAndTrack(context.Background(), "0x0000086869"); !errors.Is(err, client.ErrSubscriptionUnsupported) {
+		t.Fatalf("expected ErrSubscriptionUnsupported, got %v", err)
+	}
+}
@@ -0,0 +1,280 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/JFJun/bifrost-go/client"
+	"github.com/JFJun/bifrost-go/models"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+/*
+没有websocket连接时（http节点或者自定义的RPCCaller）不能订阅区块头
+*/
+func Test_SubscribeHeadsUnsupported_Offline(t *testing.T) {
+	c, err := client.NewWithRPCCaller(testRPC{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.SubscribeNewHeads(context.Background()); !errors.Is(err, client.ErrSubscriptionUnsupported) {
+		t.Fatalf("expected ErrSubscriptionUnsupported, got %v", err)
+	}
+	if _, err = c.SubscribeFinalizedHeads(context.Background()); !errors.Is(err, client.ErrSubscriptionUnsupported) {
+		t.Fatalf("expected ErrSubscriptionUnsupported, got %v", err)
+	}
+}
+
+/*
+模拟的websocket节点，每次Reconnect创建一个新的连接
+第i个连接上的订阅依次推送heads[i]中高度的区块头，drop[i]为true时推送完后订阅出错
+*/
+type testNetwork struct {
+	mu         sync.Mutex
+	blocks     heightBlocksRPC
+	heads      [][]uint32
+	drop       []bool
+	conns      []*connRPC
+	reconnects int32
+}
+
+func (n *testNetwork) connect() *connRPC {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	conn := &connRPC{heightBlocksRPC: n.blocks, net: n, id: len(n.conns)}
+	n.conns = append(n.conns, conn)
+	return conn
+}
+
+func (n *testNetwork) conn(id int) *connRPC {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if id >= len(n.conns) {
+		return nil
+	}
+	return n.conns[id]
+}
+
+type connRPC struct {
+	heightBlocksRPC
+	net    *testNetwork
+	id     int
+	dead   int32 //为1时GetRuntimeVersionLatest返回连接断开
+	closed int32
+	subs   []*testSubscription
+}
+
+func (m *connRPC) GetRuntimeVersionLatest() (*types.RuntimeVersion, error) {
+	if atomic.LoadInt32(&m.dead) == 1 {
+		return nil, fmt.Errorf("%w: use of closed network connection", client.ErrConnectionClosed)
+	}
+	return m.heightBlocksRPC.GetRuntimeVersionLatest()
+}
+
+func (m *connRPC) Reconnect() (client.RPCCaller, error) {
+	atomic.AddInt32(&m.net.reconnects, 1)
+	return m.net.connect(), nil
+}
+
+func (m *connRPC) Close() {
+	atomic.StoreInt32(&m.closed, 1)
+}
+
+func (m *connRPC) isClosed() bool {
+	return atomic.LoadInt32(&m.closed) == 1
+}
+
+func (m *connRPC) subscription() *testSubscription {
+	m.net.mu.Lock()
+	defer m.net.mu.Unlock()
+	if len(m.subs) == 0 {
+		return nil
+	}
+	return m.subs[len(m.subs)-1]
+}
+
+func (m *connRPC) Subscribe(ctx context.Context, namespace, subscribeMethod, unsubscribeMethod, notificationMethod string,
+	channel interface{}, args ...interface{}) (client.Subscription, error) {
+	if m.isClosed() {
+		return nil, client.ErrConnectionClosed
+	}
+	heads := channel.(chan types.Header)
+	sub := newTestSubscription()
+	m.net.mu.Lock()
+	m.subs = append(m.subs, sub)
+	var (
+		numbers []uint32
+		drop    bool
+	)
+	if m.id < len(m.net.heads) {
+		numbers, drop = m.net.heads[m.id], m.net.drop[m.id]
+	}
+	m.net.mu.Unlock()
+	go func() {
+		for _, number := range numbers {
+			select {
+			case heads <- types.Header{Number: types.BlockNumber(number)}:
+			case <-sub.unsubscribed:
+				return
+			}
+		}
+		if drop {
+			select {
+			case sub.err <- errors.New("connection lost"):
+			case <-sub.unsubscribed:
+			}
+		}
+	}()
+	return sub, nil
+}
+
+func newTestNetwork(t *testing.T, latest uint64) *testNetwork {
+	meta := testMetadata()
+	blocks := heightBlocksRPC{blocks: map[uint64]*models.SignedBlock{}, events: map[uint64]string{}, latest: latest}
+	for height := uint64(1); height <= latest; height++ {
+		blocks.blocks[height] = &models.SignedBlock{Block: models.Block{
+			Header:     models.Header{Number: fmt.Sprintf("0x%x", height)},
+			Extrinsics: []string{timestampExtrinsic(t, meta, 1620000096000+height)},
+		}}
+		blocks.events[height] = eventsHex(t, successEvent(0))
+	}
+	return &testNetwork{blocks: blocks}
+}
+
+func receiveBlock(t *testing.T, blocks <-chan *models.BlockResponse) *models.BlockResponse {
+	select {
+	case block := <-blocks:
+		return block
+	case <-time.After(5 * time.Second):
+		t.Fatal("no block received")
+	}
+	return nil
+}
+
+/*
+订阅出错后立即在新的连接上重新订阅，旧的连接被关闭，重连期间错过的finalized区块按顺序补上
+*/
+func Test_SubscribeFinalizedHeadsResubscribe_Offline(t *testing.T) {
+	network := newTestNetwork(t, 5)
+	network.heads = [][]uint32{{1}, {3, 5}}
+	network.drop = []bool{true, false}
+	c, err := client.NewWithRPCCaller(network.connect(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetPrefix(testPrefix)
+	c.SetFetchFees(false)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	blocks, err := c.SubscribeFinalizedHeads(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for height := int64(1); height <= 5; height++ {
+		if block := receiveBlock(t, blocks); block == nil || block.Height != height {
+			t.Fatalf("expected block %d, got %+v", height, block)
+		}
+	}
+	first, second := network.conn(0), network.conn(1)
+	if second == nil || atomic.LoadInt32(&network.reconnects) != 1 {
+		t.Fatalf("expected 1 reconnect, got %d", atomic.LoadInt32(&network.reconnects))
+	}
+	if !first.isClosed() || second.isClosed() {
+		t.Fatal("expected old connection to be closed")
+	}
+	waitUnsubscribed(t, first.subscription())
+
+	//ctx结束后goroutine退出：chan被关闭并且取消新连接上的订阅
+	cancel()
+	select {
+	case _, ok := <-blocks:
+		if ok {
+			t.Fatal("unexpected block after cancel")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("block chan is not closed")
+	}
+	waitUnsubscribed(t, second.subscription())
+}
+
+/*
+ctx结束时订阅的goroutine退出，关闭chan并取消订阅，没有读取的区块被丢弃
+*/
+func Test_SubscribeHeadsCancel_Offline(t *testing.T) {
+	network := newTestNetwork(t, 3)
+	network.heads = [][]uint32{{1, 2, 3}}
+	network.drop = []bool{false}
+	conn := network.connect()
+	c, err := client.NewWithRPCCaller(conn, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetFetchFees(false)
+	ctx, cancel := context.WithCancel(context.Background())
+	blocks, err := c.SubscribeFinalizedHeads(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if block := receiveBlock(t, blocks); block == nil || block.Height != 1 {
+		t.Fatalf("unexpected block: %+v", block)
+	}
+	cancel()
+	waitUnsubscribed(t, conn.subscription())
+	for range blocks {
+	}
+	if atomic.LoadInt32(&network.reconnects) != 0 {
+		t.Fatal("unexpected reconnect")
+	}
+}
+
+/*
+多个goroutine同时发现连接断开时只重连一次，之后都使用新的连接
+*/
+func Test_ReconnectOnce_Offline(t *testing.T) {
+	network := newTestNetwork(t, 1)
+	conn := network.connect()
+	c, err := client.NewWithRPCCaller(conn, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	atomic.StoreInt32(&conn.dead, 1)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.RefreshRuntime(); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := atomic.LoadInt32(&network.reconnects); n != 1 {
+		t.Fatalf("expected 1 reconnect, got %d", n)
+	}
+	if !conn.isClosed() || network.conn(1).isClosed() {
+		t.Fatal("expected only the old connection to be closed")
+	}
+
+	//不支持重连的RPCCaller返回ErrConnectionClosed
+	c, err = client.NewWithRPCCaller(deadRPC{}, false)
+	if err == nil {
+		t.Fatal("expected error for dead connection")
+	}
+	if !errors.Is(err, client.ErrConnectionClosed) {
+		t.Fatalf("expected ErrConnectionClosed, got %v", err)
+	}
+}
+
+type deadRPC struct {
+	testRPC
+}
+
+func (deadRPC) GetRuntimeVersionLatest() (*types.RuntimeVersion, error) {
+	return nil, client.ErrConnectionClosed
+}
@@ -0,0 +1,41 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/JFJun/bifrost-go/utils"
+	"golang.org/x/crypto/blake2b"
+)
+
+/*
+节点的编码与sp-trie中codec_trie_*的测试用例一致，root为编码后根节点的blake2_256
+*/
+func Test_Unit_TrieRoot(t *testing.T) {
+	empty := utils.TrieRoot(nil, nil, false)
+	if utils.BytesToHex(empty[:]) != "03170a2e7597b7b7e3d84c05391d139a62b157e78786d8c082f29dcf4c111314" {
+		t.Fatalf("unexpected empty trie root: %x", empty)
+	}
+
+	single := utils.TrieRoot([][]byte{{0xaa}}, [][]byte{{0xbb}}, false)
+	if single != blake2b.Sum256([]byte{0x42, 0xaa, 0x04, 0xbb}) {
+		t.Fatalf("unexpected single leaf trie root: %x", single)
+	}
+
+	disjoint := utils.TrieRoot([][]byte{{0x48, 0x19}, {0x13, 0x14}}, [][]byte{{0xfe}, {0xff}}, false)
+	want := blake2b.Sum256([]byte{
+		0x80, 0x12, 0x00, //没有value的分支，子节点1和4
+		0x14, 0x43, 0x03, 0x14, 0x04, 0xff, //内联的叶子节点
+		0x14, 0x43, 0x08, 0x19, 0x04, 0xfe,
+	})
+	if disjoint != want {
+		t.Fatalf("unexpected branch trie root: %x", disjoint)
+	}
+
+	//state_version=1时长度不小于33字节的value使用哈希
+	large := make([]byte, 33)
+	hashed := utils.TrieRoot([][]byte{{0xaa}}, [][]byte{large}, true)
+	valueHash := blake2b.Sum256(large)
+	if hashed != blake2b.Sum256(append([]byte{0x22, 0xaa}, valueHash[:]...)) {
+		t.Fatalf("unexpected hashed value trie root: %x", hashed)
+	}
+}
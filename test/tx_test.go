@@ -1,17 +1,24 @@
 package test
 
 import (
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"github.com/JFJun/bifrost-go/client"
 	"github.com/JFJun/bifrost-go/expand"
 	"github.com/JFJun/bifrost-go/tx"
+	"github.com/JFJun/bifrost-go/utils"
 	"github.com/JFJun/go-substrate-crypto/crypto"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"golang.org/x/crypto/blake2b"
+	"math/big"
+	"strings"
 	"testing"
 )
 
 func Test_Tx2(t *testing.T) {
 	// 1. 初始化rpc客户端
-	c, err := client.New("")
+	c, err := client.New("", false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -70,3 +77,383 @@ func Test_Tx2(t *testing.T) {
 	txid := result.(string)
 	fmt.Println(txid)
 }
+
+func Test_Unit_NewUnsignedExtrinsic(t *testing.T) {
+	meta := testMetadata()
+	me, err := expand.NewMetadataExpand(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	callIdx, err := me.MV.GetCallIndex("System", "remark")
+	if err != nil {
+		t.Fatal(err)
+	}
+	call, err := expand.NewCall(callIdx, types.NewBytes([]byte("hi")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	unsigned, err := tx.NewUnsignedExtrinsic(call)
+	if err != nil {
+		t.Fatal(err)
+	}
+	//长度(compact) + 版本(0x04，没有签名标志0x80) + call
+	if unsigned != "0x18040000086869" {
+		t.Fatalf("unexpected unsigned extrinsic: %s", unsigned)
+	}
+	var ext expand.Extrinsic
+	if err := types.DecodeFromHexString(unsigned, &ext); err != nil {
+		t.Fatal(err)
+	}
+	if ext.IsSigned() || ext.Version != expand.ExtrinsicVersion4 {
+		t.Fatalf("unexpected extrinsic version: %d", ext.Version)
+	}
+}
+
+func Test_Unit_CallHash(t *testing.T) {
+	meta := testMetadata()
+	me, err := expand.NewMetadataExpand(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	callIdx, err := me.MV.GetCallIndex("System", "remark")
+	if err != nil {
+		t.Fatal(err)
+	}
+	call, err := expand.NewCall(callIdx, types.NewBytes([]byte("hi")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := tx.CallHash(call)
+	if err != nil {
+		t.Fatal(err)
+	}
+	//blake2b-256(0x0000086869)
+	if hash != "0x89efc84be36b223a83707f2e577f86a99a79cac6b6d5c4c09b231384605ef27b" {
+		t.Fatalf("unexpected call hash: %s", hash)
+	}
+}
+
+func Test_Unit_DecodeSignedExtrinsic(t *testing.T) {
+	meta := testMetadata()
+	me, err := expand.NewMetadataExpand(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := newTestAccount(t, 1)
+	bob := newTestAccount(t, 2)
+	amount, _ := new(big.Int).SetString("18446744073709551617", 10)
+	call, err := me.BalanceTransferBigCall(bob.address, amount, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := signedExtrinsic(t, alice, 7, call)
+	resp, err := tx.DecodeSignedExtrinsic(meta, signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.CallModule != "Balances" || resp.CallModuleFunction != "transfer" || resp.Nonce != 7 || resp.Signature == "" {
+		t.Fatalf("unexpected decoded extrinsic: %+v", resp)
+	}
+	if utils.Remove0X(resp.AccountId) != alice.pubHex {
+		t.Fatalf("unexpected signer: %s", resp.AccountId)
+	}
+	if len(resp.Params) != 2 || resp.Params[0].ValueRaw != bob.pubHex || fmt.Sprint(resp.Params[1].Value) != amount.String() {
+		t.Fatalf("unexpected params: %+v", resp.Params)
+	}
+
+	unsigned, err := tx.NewUnsignedExtrinsic(call)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = tx.DecodeSignedExtrinsic(meta, unsigned); err == nil {
+		t.Fatal("expected error for unsigned extrinsic")
+	}
+}
+
+func Test_Unit_TxMortalEra(t *testing.T) {
+	meta := testMetadata()
+	me, err := expand.NewMetadataExpand(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	callIdx, err := me.MV.GetCallIndex("System", "remark")
+	if err != nil {
+		t.Fatal(err)
+	}
+	call, err := expand.NewCall(callIdx, types.NewBytes([]byte("hi")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := "0x" + strings.Repeat("11", 32)
+	transaction := tx.NewSubstrateTransaction("", 0).
+		SetGenesisHashAndBlockHash(hash, hash).
+		SetEra(42, 64).
+		SetCall(call)
+	_, o, _, err := transaction.ReturnSign()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !o.Era.IsMortalEra || o.Era.AsMortalEra.First != 0xa5 || o.Era.AsMortalEra.Second != 0x02 {
+		t.Fatalf("unexpected era: %+v", o.Era)
+	}
+}
+
+/*
+同一个payload使用不同类型的签名者地址签名，只有签名者的编码不同
+*/
+func Test_Unit_SignerAddressType(t *testing.T) {
+	meta := testMetadata()
+	me, err := expand.NewMetadataExpand(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	callIdx, err := me.MV.GetCallIndex("System", "remark")
+	if err != nil {
+		t.Fatal(err)
+	}
+	call, err := expand.NewCall(callIdx, types.NewBytes([]byte("hi")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := newTestAccount(t, 1)
+	sign := func(signerType int) (string, error) {
+		transaction := tx.NewSubstrateTransaction(alice.address, 0).
+			SetGenesisHashAndBlockHash(testGenesisHash, testGenesisHash).
+			SetSpecAndTxVersion(1, 1).
+			SetSignerAddressType(signerType).
+			SetCall(call)
+		return transaction.SignTransaction(alice.seed, crypto.Ed25519Type)
+	}
+	pub := utils.RemoveHex0x(alice.pubHex)
+	var rest string
+	for _, tc := range []struct {
+		signerType int
+		signer     string
+	}{
+		{expand.MultiAddressId, "00" + pub},
+		{expand.MultiAddressAddress32, "03" + pub},
+		{expand.AddressAccountId, "ff" + pub},
+	} {
+		signed, err := sign(tc.signerType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		//0x + compact长度(2字节) + 版本(0x84) + 签名者
+		prefix := "0x" + signed[2:6] + "84" + tc.signer
+		if !strings.HasPrefix(signed, prefix) {
+			t.Fatalf("signer type %d: unexpected extrinsic %s", tc.signerType, signed)
+		}
+		if rest == "" {
+			rest = signed[len(prefix):]
+		} else if signed[len(prefix):] != rest {
+			t.Fatalf("signer type %d: signature or extra changed", tc.signerType)
+		}
+	}
+
+	//Raw为Vec<u8>，有compact长度
+	signed, err := sign(expand.MultiAddressRaw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(signed, "840280"+pub) {
+		t.Fatalf("unexpected Raw signer: %s", signed)
+	}
+	//公钥为32字节时不能使用Address20
+	if _, err = sign(expand.MultiAddressAddress20); err == nil {
+		t.Fatal("expected error for Address20 signer with 32 bytes public key")
+	}
+	//SetSerDeOptions(true)时旧的Address没有0xff前缀
+	expand.SetSerDeOptions(true)
+	signed, err = sign(expand.AddressAccountId)
+	expand.SetSerDeOptions(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(signed, "84"+pub+rest[:2]) {
+		t.Fatalf("unexpected AccountId signer without pallet indices: %s", signed)
+	}
+}
+
+/*
+ExtrinsicPayloadHash的已知结果：payload为call、era、nonce、tip、specVersion、transactionVersion、genesisHash以及blockHash
+*/
+func Test_Unit_ExtrinsicPayloadHash(t *testing.T) {
+	meta := testMetadata()
+	me, err := expand.NewMetadataExpand(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	callIdx, err := me.MV.GetCallIndex("System", "remark")
+	if err != nil {
+		t.Fatal(err)
+	}
+	call, err := expand.NewCall(callIdx, types.NewBytes([]byte("hi")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := newTestAccount(t, 1)
+	genesis := utils.Remove0X(testGenesisHash)
+	blockHash := "0x" + strings.Repeat("22", 32)
+	sign := func(transaction *tx.SubstrateTransaction) string {
+		sig, err := transaction.SignTransaction(alice.seed, crypto.Ed25519Type)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sig
+	}
+	immortal := sign(tx.NewSubstrateTransaction(alice.address, 5).
+		SetGenesisHashAndBlockHash(testGenesisHash, testGenesisHash).
+		SetSpecAndTxVersion(1, 1).
+		SetCall(call))
+	mortal := sign(tx.NewSubstrateTransaction(alice.address, 7).
+		SetGenesisHashAndBlockHash(testGenesisHash, blockHash).
+		SetSpecAndTxVersion(1, 1).
+		SetTip(3).
+		SetEra(42, 64).
+		SetCall(call))
+
+	cases := []struct {
+		name      string
+		signed    string
+		blockHash string
+		payload   string
+		hash      string
+	}{
+		//immortal的era为0x00，blockHash为genesisHash，传入的blockHash被忽略
+		{"immortal", immortal, blockHash,
+			"0000" + "086869" + "00" + "14" + "00" + "01000000" + "01000000" + genesis + genesis,
+			"0x2c466ecaba118f294f5b799c59c4a5befaabc2a4c5bcba05e1ae7758cae7d6a6"},
+		//period 64、当前区块42的era为0xa502，nonce 7，tip 3
+		{"mortal", mortal, blockHash,
+			"0000" + "086869" + "a502" + "1c" + "0c" + "01000000" + "01000000" + genesis + utils.Remove0X(blockHash),
+			"0xc4134f7545c9b5812445a839dc60a3cbf31c270dbc4e8e374ff92d2a8b39aa00"},
+	}
+	for _, c := range cases {
+		payload, err := hex.DecodeString(c.payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h := blake2b.Sum256(payload)
+		expected := "0x" + hex.EncodeToString(h[:])
+		if expected != c.hash {
+			t.Fatalf("%s: payload hash %s does not match known answer %s", c.name, expected, c.hash)
+		}
+		got, err := tx.ExtrinsicPayloadHash(c.signed, testGenesisHash, c.blockHash, 1, 1)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if got != c.hash {
+			t.Fatalf("%s: expected payload hash %s, got %s", c.name, c.hash, got)
+		}
+	}
+
+	//mortal的交易没有blockHash时无法得到正确的payload
+	if _, err := tx.ExtrinsicPayloadHash(mortal, testGenesisHash, "", 1, 1); err == nil {
+		t.Fatal("expected error for mortal extrinsic without block hash")
+	}
+	if _, err := tx.ExtrinsicPayloadHash(immortal, "0xzz", "", 1, 1); err == nil {
+		t.Fatal("expected error for invalid genesis hash")
+	}
+	if _, err := tx.ExtrinsicPayloadHash(mortal, testGenesisHash, "0x1234", 1, 1); err == nil {
+		t.Fatal("expected error for invalid block hash")
+	}
+}
+
+/*
+链上信息固定的ChainClient
+*/
+type fakeChainClient struct {
+	genesisHash string
+	blockHash   string
+	blockNumber uint64
+	err         error
+}
+
+func (f fakeChainClient) GetGenesisHash() string {
+	return f.genesisHash
+}
+
+func (f fakeChainClient) GetSpecAndTxVersion() (uint32, uint32, error) {
+	return 7, 3, nil
+}
+
+func (f fakeChainClient) GetLatestBlock() (string, uint64, error) {
+	return f.blockHash, f.blockNumber, f.err
+}
+
+/*
+immortal的交易区块hash为genesis hash；mortal的交易以finalized区块为era的起始区块
+*/
+func Test_Unit_FillFromChain(t *testing.T) {
+	meta := testMetadata()
+	me, err := expand.NewMetadataExpand(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	callIdx, err := me.MV.GetCallIndex("System", "remark")
+	if err != nil {
+		t.Fatal(err)
+	}
+	call, err := expand.NewCall(callIdx, types.NewBytes([]byte("hi")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	finalized := "0x" + strings.Repeat("22", 32)
+	chain := fakeChainClient{genesisHash: testGenesisHash, blockHash: finalized, blockNumber: 42}
+
+	immortal := tx.NewSubstrateTransaction("", 0).SetCall(call)
+	_, o, _, err := immortal.ReturnSignWithChain(chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.BlockHash.Hex() != testGenesisHash || o.GenesisHash.Hex() != testGenesisHash || o.Era.IsMortalEra {
+		t.Fatalf("unexpected immortal options: %+v", o)
+	}
+	if o.SpecVersion != 7 || o.TransactionVersion != 3 {
+		t.Fatalf("unexpected versions: %d %d", o.SpecVersion, o.TransactionVersion)
+	}
+
+	//SetEra中的区块高度被finalized区块的高度覆盖
+	mortal := tx.NewSubstrateTransaction("", 0).SetEra(1, 64).SetCall(call)
+	_, o, _, err = mortal.ReturnSignWithChain(chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mortal.BlockNumber != 42 || o.BlockHash.Hex() != finalized || o.GenesisHash.Hex() != testGenesisHash {
+		t.Fatalf("unexpected mortal options: block %d %+v", mortal.BlockNumber, o)
+	}
+	if !o.Era.IsMortalEra || o.Era.AsMortalEra.First != 0xa5 || o.Era.AsMortalEra.Second != 0x02 {
+		t.Fatalf("unexpected mortal era: %+v", o.Era)
+	}
+
+	failures := map[string]fakeChainClient{
+		"no genesis":  {blockHash: finalized, blockNumber: 42},
+		"block error": {genesisHash: testGenesisHash, err: errors.New("connection refused")},
+	}
+	for name, chain := range failures {
+		if err := tx.NewSubstrateTransaction("", 0).SetCall(call).FillFromChain(chain); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
+
+/*
+Client.GetLatestBlock返回finalized区块，而不是可能被回滚的best区块
+*/
+func Test_GetLatestBlockFinalized_Offline(t *testing.T) {
+	c, err := client.NewWithRPCCaller(affordRPC{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blockHash, blockNumber, err := c.GetLatestBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blockHash != testBlockHash || blockNumber != 100 {
+		t.Fatalf("unexpected latest block: %s %d", blockHash, blockNumber)
+	}
+	height, err := c.FinalizedHeight()
+	if err != nil || height != 100 {
+		t.Fatalf("unexpected finalized height: %d %v", height, err)
+	}
+}
@@ -0,0 +1,99 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/JFJun/bifrost-go/client"
+	"github.com/JFJun/bifrost-go/expand"
+	"github.com/JFJun/bifrost-go/models"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+/*
+返回固定区块以及event的rpc
+*/
+type fixedBlockRPC struct {
+	testRPC
+	block  *models.SignedBlock
+	events string
+}
+
+func (m fixedBlockRPC) Call(result interface{}, method string, args ...interface{}) error {
+	switch method {
+	case "chain_getBlock":
+		*(result.(**models.SignedBlock)) = m.block
+		return nil
+	case "state_getStorageAt":
+		*(result.(*string)) = m.events
+		return nil
+	}
+	return m.testRPC.Call(result, method, args...)
+}
+
+/*
+开启SetIncludeUnparsed时，没有转账的区块也会返回每一个extrinsic
+*/
+func Test_IncludeUnparsedEveryExtrinsic_Offline(t *testing.T) {
+	meta := testMetadata()
+	me, err := expand.NewMetadataExpand(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := newTestAccount(t, 1)
+	remarkIdx, err := me.MV.GetCallIndex("System", "remark")
+	if err != nil {
+		t.Fatal(err)
+	}
+	remark, err := expand.NewCall(remarkIdx, types.NewBytes([]byte("hello")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	//只有remark的batch，没有转账
+	batch, err := me.UtilityBatchCall([]types.Call{remark}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rpc := fixedBlockRPC{
+		block: &models.SignedBlock{Block: models.Block{
+			Header: models.Header{ParentHash: testBlockHash, Number: "0x64"},
+			Extrinsics: []string{
+				timestampExtrinsic(t, meta, 1620000090000),
+				signedExtrinsic(t, alice, 0, remark),
+				signedExtrinsic(t, alice, 1, batch),
+			},
+		}},
+		events: eventsHex(t, successEvent(0), successEvent(1), successEvent(2)),
+	}
+	c, err := client.NewWithRPCCaller(rpc, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetPrefix(testPrefix)
+	c.SetFetchFees(false)
+
+	resp, err := c.GetBlockByHash(testBlockHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Extrinsic) != 0 {
+		t.Fatalf("expected no extrinsic without include unparsed, got %d", len(resp.Extrinsic))
+	}
+
+	c.SetIncludeUnparsed(true)
+	resp, err = c.GetBlockByHash(testBlockHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"Timestamp.set", "System.remark", "Utility.batch"}
+	if len(resp.Extrinsic) != len(expected) {
+		t.Fatalf("expected %d extrinsics, got %d", len(expected), len(resp.Extrinsic))
+	}
+	for i, e := range resp.Extrinsic {
+		if e.Type != expected[i] || e.ExtrinsicIndex != i || e.Status != "success" {
+			t.Fatalf("unexpected extrinsic %d: %+v", i, e)
+		}
+		if i > 0 && e.FromAddress != alice.address {
+			t.Fatalf("unexpected signer of extrinsic %d: %s", i, e.FromAddress)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/JFJun/bifrost-go/models"
+	"github.com/JFJun/bifrost-go/utils"
+)
+
+/*
+Option以及enum包装的参数直接用.(string)断言会panic，使用utils.ValueToString可以取出里面的值
+*/
+func Test_ValueToString_Option(t *testing.T) {
+	var param models.ExtrinsicDecodeParam
+	err := json.Unmarshal([]byte(`{"name":"value","type":"Option<Balance>","value":{"Some":"1000000000000"}}`), &param)
+	if err != nil {
+		t.Fatal(err)
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("expected type assertion on Option value to panic")
+			}
+		}()
+		_ = param.Value.(string)
+	}()
+	amount, ok := utils.ValueToString(param.Value)
+	if !ok || amount != "1000000000000" {
+		t.Fatalf("unexpected amount: %q %v", amount, ok)
+	}
+
+	cases := []struct {
+		value string
+		want  string
+		ok    bool
+	}{
+		{`{"Some":{"Id":"0xd43593c715fdd31c61141abd04a99fd6822c8558854ccde39a5684e7a56da27d"}}`, "0xd43593c715fdd31c61141abd04a99fd6822c8558854ccde39a5684e7a56da27d", true},
+		{`[{"Some":12345}]`, "12345", true},
+		{`1e+21`, "1000000000000000000000", true},
+		{`null`, "", false},
+		{`{"a":"1","b":"2"}`, "", false},
+	}
+	for _, c := range cases {
+		var v interface{}
+		err = json.Unmarshal([]byte(c.value), &v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, ok := utils.ValueToString(v)
+		if got != c.want || ok != c.ok {
+			t.Errorf("ValueToString(%s) = %q, %v; want %q, %v", c.value, got, ok, c.want, c.ok)
+		}
+	}
+
+	now, ok := utils.ValueToFloat64(map[string]interface{}{"Some": float64(1600000000000)})
+	if !ok || now != 1600000000000 {
+		t.Fatalf("unexpected float value: %v %v", now, ok)
+	}
+}
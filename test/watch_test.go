@@ -0,0 +1,111 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/JFJun/bifrost-go/client"
+	"github.com/JFJun/bifrost-go/expand"
+	"github.com/JFJun/bifrost-go/models"
+	"github.com/JFJun/go-substrate-crypto/ss58"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+/*
+WatchAddresses返回from或者to为监听地址的转账，地址按公钥比较，跳过的finalized区块按顺序补上，订阅出错后chan被关闭
+*/
+func Test_WatchAddresses_Offline(t *testing.T) {
+	meta := testMetadata()
+	me, err := expand.NewMetadataExpand(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := newTestAccount(t, 1)
+	bob := newTestAccount(t, 2)
+	carol := newTestAccount(t, 3)
+	dave := newTestAccount(t, 4)
+	transfers := []struct {
+		from, to testAccount
+		amount   int64
+	}{
+		{alice, bob, 100},
+		//与监听的地址无关
+		{carol, dave, 200},
+		{bob, carol, 300},
+		{dave, alice, 400},
+	}
+	network := newTestNetwork(t, uint64(len(transfers)))
+	for i, tr := range transfers {
+		height := uint64(i + 1)
+		call, err := me.BalanceTransferCall(tr.to.address, uint64(tr.amount))
+		if err != nil {
+			t.Fatal(err)
+		}
+		network.blocks.blocks[height] = &models.SignedBlock{Block: models.Block{
+			Header: models.Header{Number: fmt.Sprintf("0x%x", height)},
+			Extrinsics: []string{
+				timestampExtrinsic(t, meta, 1620000096000+height),
+				signedExtrinsic(t, tr.from, 0, call),
+			},
+		}}
+		network.blocks.events[height] = eventsHex(t,
+			successEvent(0),
+			transferEvent(1, tr.from, tr.to, types.NewU128(*big.NewInt(tr.amount))),
+			successEvent(1),
+		)
+	}
+	//高度2、3的区块头没有推送，推送完后订阅出错
+	network.heads = [][]uint32{{1, 4}}
+	network.drop = []bool{true}
+	conn := network.connect()
+	c, err := client.NewWithRPCCaller(conn, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetPrefix(testPrefix)
+	c.SetFetchFees(false)
+	if _, err = c.WatchAddresses(context.Background(), []string{"invalid"}); err == nil {
+		t.Fatal("expected error for invalid watch address")
+	}
+	//其他链格式的地址也按公钥匹配
+	bobKusama, err := ss58.Encode(types.MustHexDecodeString(bob.pubHex), ss58.KsmPrefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	results, err := c.WatchAddresses(ctx, []string{alice.address, bobKusama})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []models.EventResult
+	timeout := time.After(5 * time.Second)
+	for done := false; !done; {
+		select {
+		case r, ok := <-results:
+			if !ok {
+				done = true
+				break
+			}
+			got = append(got, r)
+		case <-timeout:
+			t.Fatal("results chan is not closed after subscription error")
+		}
+	}
+	expected := []int{0, 2, 3}
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d transfers, got %+v", len(expected), got)
+	}
+	for i, idx := range expected {
+		tr := transfers[idx]
+		want := models.EventResult{Module: "Balances", Event: "Transfer", From: tr.from.address, To: tr.to.address,
+			Amount: fmt.Sprint(tr.amount), ExtrinsicIdx: 1, Status: "success", Phase: "ApplyExtrinsic"}
+		if got[i] != want {
+			t.Fatalf("transfer %d: expected %+v, got %+v", i, want, got[i])
+		}
+	}
+	waitUnsubscribed(t, conn.subscription())
+}
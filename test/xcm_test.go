@@ -0,0 +1,190 @@
+package test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/JFJun/bifrost-go/expand"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+var (
+	xcmAccount32 = strings.Repeat("11", 32)
+	xcmAccount20 = strings.Repeat("22", 20)
+	xcmAbstract  = strings.Repeat("33", 32)
+)
+
+/*
+VersionedMultiLocation的SCALE编码：version + (V0: junctions) / (V1及以上: parents + junctions)
+*/
+func Test_Unit_VersionedMultiLocation(t *testing.T) {
+	cases := []struct {
+		name     string
+		data     string
+		expected expand.VersionedMultiLocation
+	}{
+		{"v0 parent as junction", "0x00" + "02" + "00" + "01a10f",
+			expand.VersionedMultiLocation{Version: 0, Location: expand.MultiLocation{Parents: 1,
+				Interior: []expand.Junction{{Type: "Parachain", Value: "1000"}}}}},
+		{"v0 account key20", "0x00" + "01" + "04" + "03" + xcmAccount20,
+			expand.VersionedMultiLocation{Version: 0, Location: expand.MultiLocation{
+				Interior: []expand.Junction{{Type: "AccountKey20", Network: "Kusama", Value: xcmAccount20}}}}},
+		{"v1 here", "0x01" + "01" + "00",
+			expand.VersionedMultiLocation{Version: 1, Location: expand.MultiLocation{Parents: 1}}},
+		{"v1 parachain", "0x01" + "01" + "01" + "00a10f",
+			expand.VersionedMultiLocation{Version: 1, Location: expand.MultiLocation{Parents: 1,
+				Interior: []expand.Junction{{Type: "Parachain", Value: "1000"}}}}},
+		{"v1 plurality", "0x01" + "00" + "01" + "08" + "00" + "00",
+			expand.VersionedMultiLocation{Version: 1, Location: expand.MultiLocation{
+				Interior: []expand.Junction{{Type: "Plurality", Value: "Unit,Voice"}}}}},
+		//V2的MultiLocation与V1一致，共用tag 1
+		{"v2 parachain account", "0x01" + "00" + "02" + "00411f" + "0100" + xcmAccount32,
+			expand.VersionedMultiLocation{Version: 1, Location: expand.MultiLocation{
+				Interior: []expand.Junction{
+					{Type: "Parachain", Value: "2000"},
+					{Type: "AccountId32", Network: "Any", Value: xcmAccount32},
+				}}}},
+		{"v2 named network", "0x01" + "00" + "01" + "02" + "01" + "08abcd" + "04",
+			expand.VersionedMultiLocation{Version: 1, Location: expand.MultiLocation{
+				Interior: []expand.Junction{{Type: "AccountIndex64", Network: "Named:abcd", Value: "1"}}}}},
+		//资产的位置：平行链中pallet的第1984个资产
+		{"v3 nested interior", "0x03" + "01" + "03" + "00411f" + "0432" + "05011f",
+			expand.VersionedMultiLocation{Version: 3, Location: expand.MultiLocation{Parents: 1,
+				Interior: []expand.Junction{
+					{Type: "Parachain", Value: "2000"},
+					{Type: "PalletInstance", Value: "50"},
+					{Type: "GeneralIndex", Value: "1984"},
+				}}}},
+		{"v3 account with network", "0x03" + "00" + "01" + "01" + "0102" + xcmAccount32,
+			expand.VersionedMultiLocation{Version: 3, Location: expand.MultiLocation{
+				Interior: []expand.Junction{{Type: "AccountId32", Network: "Polkadot", Value: xcmAccount32}}}}},
+		{"v3 account without network", "0x03" + "00" + "01" + "01" + "00" + xcmAccount32,
+			expand.VersionedMultiLocation{Version: 3, Location: expand.MultiLocation{
+				Interior: []expand.Junction{{Type: "AccountId32", Value: xcmAccount32}}}}},
+		{"v3 global consensus", "0x03" + "02" + "02" + "0903" + "00411f",
+			expand.VersionedMultiLocation{Version: 3, Location: expand.MultiLocation{Parents: 2,
+				Interior: []expand.Junction{
+					{Type: "GlobalConsensus", Network: "Kusama"},
+					{Type: "Parachain", Value: "2000"},
+				}}}},
+		//Plurality之后的junction需要对齐
+		{"v1 plurality named", "0x01" + "00" + "02" + "08" + "01" + "08abcd" + "00" + "00411f",
+			expand.VersionedMultiLocation{Version: 1, Location: expand.MultiLocation{
+				Interior: []expand.Junction{
+					{Type: "Plurality", Value: "Named:abcd,Voice"},
+					{Type: "Parachain", Value: "2000"},
+				}}}},
+		{"v3 plurality moniker", "0x03" + "01" + "02" + "08" + "01" + "74727379" + "00" + "00411f",
+			expand.VersionedMultiLocation{Version: 3, Location: expand.MultiLocation{Parents: 1,
+				Interior: []expand.Junction{
+					{Type: "Plurality", Value: "Moniker:74727379,Voice"},
+					{Type: "Parachain", Value: "2000"},
+				}}}},
+		{"v3 plurality treasury", "0x03" + "00" + "02" + "08" + "09" + "010c" + "0432",
+			expand.VersionedMultiLocation{Version: 3, Location: expand.MultiLocation{
+				Interior: []expand.Junction{
+					{Type: "Plurality", Value: "Treasury,Members:3"},
+					{Type: "PalletInstance", Value: "50"},
+				}}}},
+		{"v3 general key", "0x03" + "00" + "01" + "06" + "02" + "abcd" + strings.Repeat("00", 30),
+			expand.VersionedMultiLocation{Version: 3, Location: expand.MultiLocation{
+				Interior: []expand.Junction{{Type: "GeneralKey", Value: "abcd"}}}}},
+	}
+	for _, c := range cases {
+		var got expand.VersionedMultiLocation
+		err := types.DecodeFromHexString(c.data, &got)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if !reflect.DeepEqual(got, c.expected) {
+			t.Fatalf("%s: expected %+v, got %+v", c.name, c.expected, got)
+		}
+	}
+
+	failures := map[string]string{
+		"unknown version":           "0x04" + "00" + "00",
+		"v0 too many junctions":     "0x00" + "09",
+		"v1 too many junctions":     "0x01" + "00" + "09",
+		"v1 unknown junction":       "0x01" + "00" + "01" + "0a",
+		"v1 unknown network":        "0x01" + "00" + "01" + "01" + "04" + xcmAccount32,
+		"v3 unknown junction":       "0x03" + "00" + "01" + "0a",
+		"v3 truncated account":      "0x03" + "00" + "01" + "01" + "00" + "1111",
+		"v3 truncated interior":     "0x03" + "01" + "02" + "00411f",
+		"missing version":           "0x",
+		"v3 unknown network":        "0x03" + "00" + "01" + "09" + "0a",
+		"v3 unknown plurality":      "0x03" + "00" + "01" + "08" + "0a" + "00",
+		"v2 versioned tag":          "0x02" + "00" + "01" + "00411f",
+		"v3 truncated moniker":      "0x03" + "00" + "01" + "08" + "01" + "7472",
+		"v1 unknown plurality part": "0x01" + "00" + "01" + "08" + "00" + "05",
+	}
+	for name, data := range failures {
+		var got expand.VersionedMultiLocation
+		if err := types.DecodeFromHexString(data, &got); err == nil {
+			t.Fatalf("%s: expected error, got %+v", name, got)
+		}
+	}
+}
+
+/*
+VersionedMultiAssets的SCALE编码：version + Vec<MultiAsset>，V0的MultiAsset为包含通配符的enum
+*/
+func Test_Unit_VersionedMultiAssets(t *testing.T) {
+	here := &expand.MultiLocation{}
+	parent := &expand.MultiLocation{Parents: 1}
+	cases := []struct {
+		name     string
+		data     string
+		expected expand.VersionedMultiAssets
+	}{
+		{"v0 wildcard", "0x00" + "04" + "01",
+			expand.VersionedMultiAssets{Version: 0, Assets: []expand.MultiAsset{{Wildcard: "All"}}}},
+		{"v0 concrete fungible", "0x00" + "04" + "0a" + "00" + "9101",
+			expand.VersionedMultiAssets{Version: 0, Assets: []expand.MultiAsset{{Concrete: here, Fungible: true, Amount: "100"}}}},
+		{"v0 concrete parent", "0x00" + "04" + "0a" + "0100" + "04",
+			expand.VersionedMultiAssets{Version: 0, Assets: []expand.MultiAsset{{Concrete: parent, Fungible: true, Amount: "1"}}}},
+		{"v1 concrete fungible", "0x01" + "04" + "00" + "0100" + "00" + "214e",
+			expand.VersionedMultiAssets{Version: 1, Assets: []expand.MultiAsset{{Concrete: parent, Fungible: true, Amount: "5000"}}}},
+		{"v2 abstract non fungible", "0x01" + "04" + "01" + "08abcd" + "01" + "011c",
+			expand.VersionedMultiAssets{Version: 1, Assets: []expand.MultiAsset{{Abstract: "abcd", Instance: "7"}}}},
+		{"v3 nested concrete and abstract", "0x03" + "08" +
+			"00" + "00" + "02" + "0432" + "05011f" + "00" + "04" +
+			"01" + xcmAbstract + "01" + "02" + "01020304",
+			expand.VersionedMultiAssets{Version: 3, Assets: []expand.MultiAsset{
+				{Concrete: &expand.MultiLocation{Interior: []expand.Junction{
+					{Type: "PalletInstance", Value: "50"},
+					{Type: "GeneralIndex", Value: "1984"},
+				}}, Fungible: true, Amount: "1"},
+				{Abstract: xcmAbstract, Instance: "01020304"},
+			}}},
+		{"empty", "0x03" + "00",
+			expand.VersionedMultiAssets{Version: 3}},
+	}
+	for _, c := range cases {
+		var got expand.VersionedMultiAssets
+		err := types.DecodeFromHexString(c.data, &got)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if !reflect.DeepEqual(got, c.expected) {
+			t.Fatalf("%s: expected %+v, got %+v", c.name, c.expected, got)
+		}
+	}
+
+	failures := map[string]string{
+		"unknown version":        "0x04" + "04" + "00" + "00" + "00" + "00" + "04",
+		"v0 unknown asset":       "0x00" + "04" + "0c",
+		"v2 versioned tag":       "0x02" + "04" + "01" + "08abcd" + "01" + "011c",
+		"v1 unknown asset id":    "0x01" + "04" + "02",
+		"v3 unknown fungibility": "0x03" + "04" + "00" + "00" + "00" + "02",
+		"v3 unknown instance":    "0x03" + "04" + "01" + xcmAbstract + "01" + "06",
+		"v3 truncated asset":     "0x03" + "08" + "00" + "00" + "00" + "00" + "04",
+		"too many assets":        "0x03" + "a19c",
+	}
+	for name, data := range failures {
+		var got expand.VersionedMultiAssets
+		if err := types.DecodeFromHexString(data, &got); err == nil {
+			t.Fatalf("%s: expected error, got %+v", name, got)
+		}
+	}
+}
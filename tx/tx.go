@@ -1,11 +1,16 @@
 package tx
 
 import (
+	"bytes"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/JFJun/bifrost-go/utils"
 	"github.com/JFJun/bifrost-go/expand"
+	"github.com/JFJun/bifrost-go/models"
 	"github.com/JFJun/go-substrate-crypto/crypto"
+	"github.com/stafiprotocol/go-substrate-rpc-client/scale"
 	"github.com/stafiprotocol/go-substrate-rpc-client/types"
 	"golang.org/x/crypto/blake2b"
 	"strings"
@@ -26,12 +31,15 @@ type SubstrateTransaction struct {
 	Tip                uint64 `json:"tip"`          //小费
 	BlockNumber        uint64 `json:"block_Number"` //最新区块高度
 	EraPeriod          uint64 `json:"era_period"`   // 存活最大区块
+	genesisOverride    string //FillFromChain时优先使用的genesis hash
+	signerType         int    //签名者地址的类型，expand.MultiAddressId等
+	signerIndex        uint64 //signerType为expand.MultiAddressIndex时签名者的账户索引
 	call               types.Call
 }
 
 func NewSubstrateTransaction(from string, nonce uint64) *SubstrateTransaction {
 	st := new(SubstrateTransaction)
-	st.SenderPubkey = utils.AddressToPublicKey(from)
+	st.SenderPubkey = utils.AccountToPublicKey(from)
 	st.Nonce = nonce
 	return st
 }
@@ -45,6 +53,16 @@ func (tx *SubstrateTransaction) SetGenesisHashAndBlockHash(genesisHash, blockHas
 	return tx
 }
 
+/*
+固定签名使用的genesis hash，FillFromChain时不再使用链上获取的genesis hash
+用于fork出来的链或者本地开发链，签名需要的genesis hash与GetBlockHash(0)不一致的情况
+*/
+func (tx *SubstrateTransaction) SetGenesisHashOverride(genesisHash string) *SubstrateTransaction {
+	tx.genesisOverride = utils.Remove0X(genesisHash)
+	tx.GenesisHash = tx.genesisOverride
+	return tx
+}
+
 /*
 设置链的版本以及交易版本
 */
@@ -79,6 +97,25 @@ func (tx *SubstrateTransaction) SetEra(blockNumber, eraPeriod uint64) *Substrate
 	tx.EraPeriod = eraPeriod
 	return tx
 }
+/*
+设置签名者地址的类型，默认为expand.MultiAddressId
+Raw以及Address32使用签名者的公钥，Address20要求公钥为20字节，Index需要通过SetSignerIndex设置账户索引，
+没有MultiAddress的旧链使用expand.AddressAccountId，编码时是否有0xff前缀由expand.SetSerDeOptions决定
+*/
+func (tx *SubstrateTransaction) SetSignerAddressType(t int) *SubstrateTransaction {
+	tx.signerType = t
+	return tx
+}
+
+/*
+使用账户索引（Indices模块）作为签名者的地址
+*/
+func (tx *SubstrateTransaction) SetSignerIndex(index uint64) *SubstrateTransaction {
+	tx.signerType = expand.MultiAddressIndex
+	tx.signerIndex = index
+	return tx
+}
+
 func (tx *SubstrateTransaction) SetCall(call types.Call) *SubstrateTransaction {
 	tx.call = call
 	return tx
@@ -102,26 +139,10 @@ func (tx *SubstrateTransaction) ReturnSign() (*expand.Extrinsic,types.SignatureO
 	if ext.Type() != types.ExtrinsicVersion4 {
 		return &expand.Extrinsic{}, types.SignatureOptions{},nil,fmt.Errorf("unsupported extrinsic version: %v (isSigned: %v, type: %v)", ext.Version, ext.IsSigned(), ext.Type())
 	}
-	mb, err := types.EncodeToBytes(ext.Method)
+	payload, err := buildPayload(ext.Method, o)
 	if err != nil {
 		return &expand.Extrinsic{}, types.SignatureOptions{},nil,err
 	}
-	eras := o.Era
-	if !o.Era.IsMortalEra {
-		eras = types.ExtrinsicEra{IsImmortalEra: true}
-	}
-	payload := types.ExtrinsicPayloadV4{
-		ExtrinsicPayloadV3: types.ExtrinsicPayloadV3{
-			Method:      mb,
-			Era:         eras,
-			Nonce:       o.Nonce,
-			Tip:         o.Tip,
-			SpecVersion: o.SpecVersion,
-			GenesisHash: o.GenesisHash,
-			BlockHash:   o.BlockHash,
-		},
-		TransactionVersion: o.TransactionVersion,
-	}
 	data, err := types.EncodeToBytes(payload)
 	if err != nil {
 		return &expand.Extrinsic{}, types.SignatureOptions{},nil,fmt.Errorf("encode payload error: %v", err)
@@ -161,26 +182,11 @@ func (tx *SubstrateTransaction) signTx(e *expand.Extrinsic, o types.SignatureOpt
 	if e.Type() != types.ExtrinsicVersion4 {
 		return fmt.Errorf("unsupported extrinsic version: %v (isSigned: %v, type: %v)", e.Version, e.IsSigned(), e.Type())
 	}
-	mb, err := types.EncodeToBytes(e.Method)
+	payload, err := buildPayload(e.Method, o)
 	if err != nil {
 		return err
 	}
-	era := o.Era
-	if !o.Era.IsMortalEra {
-		era = types.ExtrinsicEra{IsImmortalEra: true}
-	}
-	payload := types.ExtrinsicPayloadV4{
-		ExtrinsicPayloadV3: types.ExtrinsicPayloadV3{
-			Method:      mb,
-			Era:         era,
-			Nonce:       o.Nonce,
-			Tip:         o.Tip,
-			SpecVersion: o.SpecVersion,
-			GenesisHash: o.GenesisHash,
-			BlockHash:   o.BlockHash,
-		},
-		TransactionVersion: o.TransactionVersion,
-	}
+	era := payload.Era
 	// sign
 	data, err := types.EncodeToBytes(payload)
 	if err != nil {
@@ -203,10 +209,10 @@ func (tx *SubstrateTransaction) signTx(e *expand.Extrinsic, o types.SignatureOpt
 		return fmt.Errorf("sign error: %v", err)
 	}
 
-	var ma expand.MultiAddress
-	ma.SetTypes(0)
-	ma.AccountId = types.NewAccountID(types.MustHexDecodeString(
-		tx.SenderPubkey))
+	ma, err := tx.signer()
+	if err != nil {
+		return err
+	}
 
 	var ss types.MultiSignature
 	if signType == crypto.Ed25519Type {
@@ -229,32 +235,259 @@ func (tx *SubstrateTransaction) signTx(e *expand.Extrinsic, o types.SignatureOpt
 	e.Version |= types.ExtrinsicBitSigned
 	return nil
 }
+/*
+根据signerType生成签名者的地址
+*/
+func (tx *SubstrateTransaction) signer() (expand.MultiAddress, error) {
+	var ma expand.MultiAddress
+	pub, err := hex.DecodeString(strings.TrimPrefix(tx.SenderPubkey, "0x"))
+	if err != nil {
+		return ma, fmt.Errorf("hex decode sender public key error: %v", err)
+	}
+	ma.SetTypes(tx.signerType)
+	switch tx.signerType {
+	case expand.MultiAddressId, expand.AddressAccountId:
+		ma.AccountId = types.NewAccountID(pub)
+	case expand.MultiAddressIndex:
+		ma.Index = types.NewUCompactFromUInt(tx.signerIndex)
+	case expand.MultiAddressRaw:
+		ma.Raw = types.NewBytes(pub)
+	case expand.MultiAddressAddress32:
+		ma.Address32 = types.NewH256(pub)
+	case expand.MultiAddressAddress20:
+		if len(pub) != 20 {
+			return ma, fmt.Errorf("Address20 signer requires a 20 bytes public key, got %d bytes", len(pub))
+		}
+		ma.Address20 = types.NewH160(pub)
+	default:
+		return ma, fmt.Errorf("unsupported signer address type: %d", tx.signerType)
+	}
+	return ma, nil
+}
+
 func (tx *SubstrateTransaction) getEra() *types.ExtrinsicEra {
 	if tx.BlockNumber == 0 || tx.EraPeriod == 0 {
 		return nil
 	}
-	phase := tx.BlockNumber % tx.EraPeriod
-	index := uint64(6)
-	trailingZero := index - 1
+	encoded := utils.EncodeEra(tx.EraPeriod, tx.BlockNumber)
+	era := new(types.ExtrinsicEra)
+	era.IsMortalEra = true
+	era.AsMortalEra.First = encoded[0]
+	era.AsMortalEra.Second = encoded[1]
+	return era
+}
 
-	var encoded uint64
-	if trailingZero > 1 {
-		encoded = trailingZero
-	} else {
-		encoded = 1
+/*
+根据call以及签名参数构造签名的payload
+*/
+func buildPayload(method types.Call, o types.SignatureOptions) (types.ExtrinsicPayloadV4, error) {
+	mb, err := types.EncodeToBytes(method)
+	if err != nil {
+		return types.ExtrinsicPayloadV4{}, err
+	}
+	era := o.Era
+	if !o.Era.IsMortalEra {
+		era = types.ExtrinsicEra{IsImmortalEra: true}
+	}
+	return types.ExtrinsicPayloadV4{
+		ExtrinsicPayloadV3: types.ExtrinsicPayloadV3{
+			Method:      mb,
+			Era:         era,
+			Nonce:       o.Nonce,
+			Tip:         o.Tip,
+			SpecVersion: o.SpecVersion,
+			GenesisHash: o.GenesisHash,
+			BlockHash:   o.BlockHash,
+		},
+		TransactionVersion: o.TransactionVersion,
+	}, nil
+}
+
+/*
+根据已签名的extrinsic重新构造签名时的payload，并返回payload的blake2b-256 hash
+blockHash: mortal era的起始区块hash，mortal的交易必须指定；immortal的交易使用genesisHash，blockHash被忽略
+*/
+func ExtrinsicPayloadHash(signedHex, genesisHash, blockHash string, specVersion, transactionVersion uint32) (string, error) {
+	var ext expand.Extrinsic
+	err := types.DecodeFromHexString(signedHex, &ext)
+	if err != nil {
+		return "", fmt.Errorf("decode extrinsic error: %v", err)
+	}
+	if !ext.IsSigned() {
+		return "", errors.New("extrinsic is not signed")
+	}
+	if !ext.Signature.Era.IsMortalEra {
+		blockHash = genesisHash
+	} else if blockHash == "" {
+		//mortal的交易签名的是era起始区块的hash，使用genesisHash会得到错误的hash
+		return "", errors.New("block hash is required for mortal extrinsic")
+	}
+	genesis, err := types.HexDecodeString(genesisHash)
+	if err != nil {
+		return "", fmt.Errorf("decode genesis hash error: %v", err)
+	}
+	block, err := types.HexDecodeString(blockHash)
+	if err != nil {
+		return "", fmt.Errorf("decode block hash error: %v", err)
+	}
+	if len(genesis) != 32 || len(block) != 32 {
+		return "", fmt.Errorf("invalid hash length: genesis %d, block %d", len(genesis), len(block))
+	}
+	o := types.SignatureOptions{
+		BlockHash:          types.NewHash(block),
+		GenesisHash:        types.NewHash(genesis),
+		Era:                ext.Signature.Era,
+		Nonce:              ext.Signature.Nonce,
+		SpecVersion:        types.NewU32(specVersion),
+		Tip:                ext.Signature.Tip,
+		TransactionVersion: types.NewU32(transactionVersion),
+	}
+	payload, err := buildPayload(ext.Method, o)
+	if err != nil {
+		return "", err
+	}
+	data, err := types.EncodeToBytes(payload)
+	if err != nil {
+		return "", fmt.Errorf("encode payload error: %v", err)
+	}
+	h := blake2b.Sum256(data)
+	return "0x" + hex.EncodeToString(h[:]), nil
+}
+
+/*
+构造未签名的extrinsic（v4，没有签名标志位），返回0x开头的hex
+用于接受unsigned交易并自己校验的模块（比如Claims.claim、ImOnline.heartbeat），可以通过Client.SubmitUnsigned提交
+*/
+func NewUnsignedExtrinsic(call types.Call) (string, error) {
+	ext := expand.NewExtrinsic(call)
+	h, err := types.EncodeToHexString(ext)
+	if err != nil {
+		return "", fmt.Errorf("encode unsigned extrinsic error: %v", err)
+	}
+	return h, nil
+}
+
+/*
+call编码后的blake2b-256 hash，返回0x开头的hex
+Preimage.note_preimage、Multisig.as_multi以及Proxy.announce中引用的都是这个hash，可以在构造交易之前计算
+*/
+func CallHash(call types.Call) (string, error) {
+	data, err := types.EncodeToBytes(call)
+	if err != nil {
+		return "", fmt.Errorf("encode call error: %v", err)
+	}
+	h := blake2b.Sum256(data)
+	return "0x" + hex.EncodeToString(h[:]), nil
+}
+
+/*
+解析已签名的extrinsic，返回签名者（AccountId为公钥的hex）、签名以及call的模块、方法和参数
+用于广播前核对SignTransaction的结果，比如显示"Balances.transfer到X，金额Y"
+*/
+func DecodeSignedExtrinsic(meta *types.Metadata, signedHex string) (*models.ExtrinsicDecodeResponse, error) {
+	data, err := hex.DecodeString(utils.Remove0X(signedHex))
+	if err != nil {
+		return nil, fmt.Errorf("hex decode extrinsic error: %v", err)
+	}
+	ed, err := expand.NewExtrinsicDecoder(meta)
+	if err != nil {
+		return nil, fmt.Errorf("new extrinsic decode error: %v", err)
+	}
+	err = ed.ProcessExtrinsicDecoder(*scale.NewDecoder(bytes.NewReader(data)))
+	if err != nil {
+		return nil, fmt.Errorf("decode extrinsic error: %v", err)
+	}
+	d, err := json.Marshal(ed.Value)
+	if err != nil {
+		return nil, fmt.Errorf("json marshal extrinsic decode error: %v", err)
 	}
+	var resp models.ExtrinsicDecodeResponse
+	//金额可能超过float64的精度
+	decoder := json.NewDecoder(bytes.NewReader(d))
+	decoder.UseNumber()
+	err = decoder.Decode(&resp)
+	if err != nil {
+		return nil, fmt.Errorf("json unmarshal extrinsic decode error: %v", err)
+	}
+	if resp.Signature == "" {
+		return nil, errors.New("extrinsic is not signed")
+	}
+	return &resp, nil
+}
+
+/*
+可以提供链上信息的客户端，client.Client实现了该接口
+*/
+type ChainClient interface {
+	GetGenesisHash() string
+	GetSpecAndTxVersion() (specVersion, transactionVersion uint32, err error)
+	//最新的finalized区块，mortal交易的era起始区块
+	GetLatestBlock() (blockHash string, blockNumber uint64, err error)
+}
 
-	if trailingZero < 15 {
-		encoded = trailingZero
+/*
+从链上获取spec version、transaction version、genesis hash以及最新的finalized区块并设置到交易中
+如果设置了EraPeriod，则使用finalized区块作为era的起始区块，否则区块hash为genesis hash
+*/
+func (tx *SubstrateTransaction) FillFromChain(c ChainClient) error {
+	specVersion, transactionVersion, err := c.GetSpecAndTxVersion()
+	if err != nil {
+		return fmt.Errorf("get runtime version error: %v", err)
+	}
+	genesisHash := tx.genesisOverride
+	if genesisHash == "" {
+		genesisHash = c.GetGenesisHash()
+	}
+	if genesisHash == "" {
+		return errors.New("get genesis hash error")
+	}
+	blockHash, blockNumber, err := c.GetLatestBlock()
+	if err != nil {
+		return fmt.Errorf("get latest block error: %v", err)
+	}
+	if tx.EraPeriod == 0 {
+		//immortal的交易使用genesis hash
+		blockHash = genesisHash
 	} else {
-		encoded = 15
+		tx.BlockNumber = blockNumber
 	}
-	encoded += phase / 1 << 4
-	first := byte(encoded >> 8)
-	second := byte(encoded & 0xff)
-	era := new(types.ExtrinsicEra)
-	era.IsMortalEra = true
-	era.AsMortalEra.First = first
-	era.AsMortalEra.Second = second
-	return era
+	tx.SetGenesisHashAndBlockHash(genesisHash, blockHash)
+	tx.SetSpecAndTxVersion(specVersion, transactionVersion)
+	return nil
+}
+
+/*
+与ReturnSign相同，但是会先从链上自动设置版本、genesis hash以及区块hash
+*/
+func (tx *SubstrateTransaction) ReturnSignWithChain(c ChainClient) (*expand.Extrinsic, types.SignatureOptions, []byte, error) {
+	err := tx.FillFromChain(c)
+	if err != nil {
+		return &expand.Extrinsic{}, types.SignatureOptions{}, nil, err
+	}
+	return tx.ReturnSign()
+}
+
+/*
+与SignTransaction相同，但是会先从链上自动设置版本、genesis hash以及区块hash
+*/
+func (tx *SubstrateTransaction) SignTransactionWithChain(c ChainClient, privateKey string, signType int) (string, error) {
+	err := tx.FillFromChain(c)
+	if err != nil {
+		return "", err
+	}
+	return tx.SignTransaction(privateKey, signType)
+}
+
+/*
+可以推断默认签名类型的客户端，client.Client实现了该接口
+*/
+type SignTypeProvider interface {
+	DefaultSignType() int
+}
+
+/*
+与SignTransaction相同，但是签名类型使用链的默认签名类型
+*/
+func (tx *SubstrateTransaction) SignTransactionWithDefaultType(p SignTypeProvider, privateKey string) (string, error) {
+	return tx.SignTransaction(privateKey, p.DefaultSignType())
 }
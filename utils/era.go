@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/bits"
+)
+
+/*
+解析交易的era，eraHex为ExtrinsicResponse.Era（mortal era的2个字节）
+为空或者为"00"时表示immortal，返回的period和phase都为0
+*/
+func DecodeEra(eraHex string) (mortal bool, period, phase uint64, err error) {
+	eraHex = Remove0X(eraHex)
+	if eraHex == "" || eraHex == "00" {
+		return false, 0, 0, nil
+	}
+	data, err := hex.DecodeString(eraHex)
+	if err != nil {
+		return false, 0, 0, fmt.Errorf("decode era hex error: %v", err)
+	}
+	if len(data) != 2 {
+		return false, 0, 0, fmt.Errorf("mortal era must be 2 bytes, but is %d", len(data))
+	}
+	//与substrate的Era::decode一致：低4位为log2(period)-1，高12位为量化后的phase
+	encoded := uint64(data[0]) + uint64(data[1])<<8
+	period = 2 << (encoded % 16)
+	quantizeFactor := period >> 12
+	if quantizeFactor < 1 {
+		quantizeFactor = 1
+	}
+	phase = (encoded >> 4) * quantizeFactor
+	if period < 4 || phase >= period {
+		return false, 0, 0, fmt.Errorf("invalid mortal era: period %d, phase %d", period, phase)
+	}
+	return true, period, phase, nil
+}
+
+/*
+编码mortal era，与substrate的Era::mortal一致：period向上取2的幂并限制在[4, 65536]，
+phase为current % period，period大于4096时phase会被量化，返回小端的2个字节
+*/
+func EncodeEra(period, current uint64) [2]byte {
+	p := uint64(4)
+	for p < period && p < 65536 {
+		p <<= 1
+	}
+	phase := current % p
+	quantizeFactor := p >> 12
+	if quantizeFactor < 1 {
+		quantizeFactor = 1
+	}
+	//低4位为log2(period)-1，限制在[1, 15]
+	low := uint64(bits.TrailingZeros64(p)) - 1
+	if low < 1 {
+		low = 1
+	} else if low > 15 {
+		low = 15
+	}
+	encoded := low | (phase/quantizeFactor)<<4
+	return [2]byte{byte(encoded & 0xff), byte(encoded >> 8)}
+}
+
+/*
+mortal era在current高度时的有效区间[birth, death)，current为交易所在的区块高度
+*/
+func EraRange(period, phase, current uint64) (birth, death uint64) {
+	if current < phase {
+		current = phase
+	}
+	birth = (current-phase)/period*period + phase
+	return birth, birth + period
+}
@@ -0,0 +1,170 @@
+package utils
+
+import (
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"golang.org/x/crypto/blake2b"
+)
+
+/*
+substrate的Merkle-Patricia trie（sp-trie的NodeCodec，没有extension节点，哈希为blake2_256）
+只用于计算trie root，例如区块头中的extrinsicsRoot
+*/
+
+const trieValueHashThreshold = 33 //state_version为1时，长度不小于33字节的value只保存其哈希
+
+/*
+按下标计算trie root，key为下标的Compact编码，与substrate的ordered_trie_root一致
+hashLargeValues对应state_version=1，为false时对应state_version=0
+*/
+func OrderedTrieRoot(values [][]byte, hashLargeValues bool) [32]byte {
+	keys := make([][]byte, len(values))
+	for i := range values {
+		keys[i], _ = types.EncodeToBytes(types.NewUCompactFromUInt(uint64(i)))
+	}
+	return TrieRoot(keys, values, hashLargeValues)
+}
+
+/*
+计算trie root，keys与values一一对应，key不能重复
+*/
+func TrieRoot(keys, values [][]byte, hashLargeValues bool) [32]byte {
+	if len(keys) == 0 {
+		return blake2b.Sum256([]byte{0})
+	}
+	entries := make([]trieEntry, len(keys))
+	for i, key := range keys {
+		entries[i] = trieEntry{nibbles: toNibbles(key), value: values[i]}
+	}
+	return blake2b.Sum256(encodeTrieNode(entries, 0, hashLargeValues))
+}
+
+type trieEntry struct {
+	nibbles []byte
+	value   []byte
+}
+
+func toNibbles(key []byte) []byte {
+	nibbles := make([]byte, 0, len(key)*2)
+	for _, b := range key {
+		nibbles = append(nibbles, b>>4, b&0x0f)
+	}
+	return nibbles
+}
+
+/*
+编码depth之后的部分，entries的key在depth之前的部分都相同
+*/
+func encodeTrieNode(entries []trieEntry, depth int, hashLargeValues bool) []byte {
+	if len(entries) == 1 {
+		partial := entries[0].nibbles[depth:]
+		value := entries[0].value
+		var node []byte
+		if hashLargeValues && len(value) >= trieValueHashThreshold {
+			node = trieHeader(0x20, 3, len(partial))
+		} else {
+			node = trieHeader(0x40, 2, len(partial))
+		}
+		node = append(node, packNibbles(partial)...)
+		return append(node, encodeTrieValue(value, hashLargeValues)...)
+	}
+	//所有key在depth之后的公共前缀
+	common := len(entries[0].nibbles) - depth
+	for _, e := range entries[1:] {
+		n := 0
+		for n < common && depth+n < len(e.nibbles) && e.nibbles[depth+n] == entries[0].nibbles[depth+n] {
+			n++
+		}
+		common = n
+	}
+	split := depth + common
+	var (
+		value    []byte
+		hasValue bool
+		children [16][]trieEntry
+	)
+	for _, e := range entries {
+		if len(e.nibbles) == split {
+			value, hasValue = e.value, true
+			continue
+		}
+		children[e.nibbles[split]] = append(children[e.nibbles[split]], e)
+	}
+	var node []byte
+	switch {
+	case !hasValue:
+		node = trieHeader(0x80, 2, common)
+	case hashLargeValues && len(value) >= trieValueHashThreshold:
+		node = trieHeader(0x10, 4, common)
+	default:
+		node = trieHeader(0xc0, 2, common)
+	}
+	node = append(node, packNibbles(entries[0].nibbles[depth:split])...)
+	var bitmap uint16
+	for i, child := range children {
+		if len(child) > 0 {
+			bitmap |= 1 << uint(i)
+		}
+	}
+	node = append(node, byte(bitmap), byte(bitmap>>8))
+	if hasValue {
+		node = append(node, encodeTrieValue(value, hashLargeValues)...)
+	}
+	for _, child := range children {
+		if len(child) == 0 {
+			continue
+		}
+		//编码后不小于32字节的子节点使用哈希引用，否则直接内联
+		ref := encodeTrieNode(child, split+1, hashLargeValues)
+		if len(ref) >= 32 {
+			h := blake2b.Sum256(ref)
+			ref = h[:]
+		}
+		node = append(node, encodeTrieBytes(ref)...)
+	}
+	return node
+}
+
+/*
+节点头：前缀加上partial key的nibble数量，数量超出前缀剩余的位时在后面追加字节
+*/
+func trieHeader(prefix byte, prefixBits uint, nibbleCount int) []byte {
+	maxValue := int(0xff >> prefixBits)
+	if nibbleCount < maxValue {
+		return []byte{prefix + byte(nibbleCount)}
+	}
+	header := []byte{prefix + byte(maxValue)}
+	rest := nibbleCount - maxValue
+	for rest >= 255 {
+		header = append(header, 255)
+		rest -= 255
+	}
+	return append(header, byte(rest))
+}
+
+/*
+nibble个数为奇数时，第一个字节只保存第一个nibble
+*/
+func packNibbles(nibbles []byte) []byte {
+	packed := make([]byte, 0, (len(nibbles)+1)/2)
+	if len(nibbles)%2 == 1 {
+		packed = append(packed, nibbles[0])
+		nibbles = nibbles[1:]
+	}
+	for i := 0; i+1 < len(nibbles); i += 2 {
+		packed = append(packed, nibbles[i]<<4|nibbles[i+1])
+	}
+	return packed
+}
+
+func encodeTrieValue(value []byte, hashLargeValues bool) []byte {
+	if hashLargeValues && len(value) >= trieValueHashThreshold {
+		h := blake2b.Sum256(value)
+		return h[:]
+	}
+	return encodeTrieBytes(value)
+}
+
+func encodeTrieBytes(data []byte) []byte {
+	encoded, _ := types.EncodeToBytes(types.NewUCompactFromUInt(uint64(len(data))))
+	return append(encoded, data...)
+}
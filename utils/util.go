@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"github.com/JFJun/go-substrate-crypto/ss58"
 	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"golang.org/x/crypto/blake2b"
 	"math/big"
+	"strconv"
 	"strings"
 )
 
@@ -17,6 +19,16 @@ func RemoveHex0x(hexStr string)string{
 	return hexStr
 }
 
+/*
+解析区块头中的高度，0x开头时为hex（标准的substrate节点），否则按十进制解析（部分非标准的节点）
+*/
+func ParseBlockNumber(number string) (uint64, error) {
+	if strings.HasPrefix(number, "0x") || strings.HasPrefix(number, "0X") {
+		return strconv.ParseUint(number[2:], 16, 64)
+	}
+	return strconv.ParseUint(number, 10, 64)
+}
+
 func BytesToHex(b []byte) string {
 	c := make([]byte, hex.EncodedLen(len(b)))
 	hex.Encode(c, b)
@@ -61,6 +73,20 @@ func CheckStructData(object interface{}){
 	fmt.Println(string(d))
 }
 
+/*
+账户参数转换为不带0x的公钥hex，account可以是ss58地址，也可以是32字节的公钥hex（可以带0x）
+无法识别时返回空字符串
+*/
+func AccountToPublicKey(account string) string {
+	pubHex := Remove0X(account)
+	if len(pubHex) == 64 {
+		if _, err := hex.DecodeString(pubHex); err == nil {
+			return strings.ToLower(pubHex)
+		}
+	}
+	return AddressToPublicKey(account)
+}
+
 func AddressToPublicKey(address string) string {
 	if address == "" {
 		return ""
@@ -90,3 +116,86 @@ func ZeroBytes(data []byte){
 	}
 }
 
+
+/*
+根据Utility.as_derivative的规则计算派生子账户的公钥
+blake2_256(b"modlpy/utilisuba" ++ who ++ index(u16 le))
+*/
+func DeriveSubAccount(pubHex string, index uint16) (string, error) {
+	pub, err := hex.DecodeString(RemoveHex0x(pubHex))
+	if err != nil {
+		return "", fmt.Errorf("hex decode public key error: %v", err)
+	}
+	if len(pub) != 32 {
+		return "", fmt.Errorf("public key length is not equal 32,len=%d", len(pub))
+	}
+	data := append([]byte("modlpy/utilisuba"), pub...)
+	data = append(data, byte(index), byte(index>>8))
+	h := blake2b.Sum256(data)
+	return hex.EncodeToString(h[:]), nil
+}
+
+/*
+剥去Option以及enum的包装，返回最里面的值
+Option::None为nil，Option::Some以及enum的变体解码成json后为只有一个key的map（比如{"Some":"100"}），
+或者只有一个元素的数组
+*/
+func UnwrapValue(value interface{}) interface{} {
+	for {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if len(v) != 1 {
+				return value
+			}
+			for _, inner := range v {
+				value = inner
+			}
+		case []interface{}:
+			if len(v) != 1 {
+				return value
+			}
+			value = v[0]
+		default:
+			return value
+		}
+	}
+}
+
+/*
+将参数的值转换为string，会先剥去Option以及enum的包装
+数字会转换为不带小数点以及指数的字符串，Option::None或者无法转换时返回false
+*/
+func ValueToString(value interface{}) (string, bool) {
+	switch v := UnwrapValue(value).(type) {
+	case string:
+		return v, true
+	case float64:
+		return new(big.Float).SetFloat64(v).Text('f', -1), true
+	case json.Number:
+		return v.String(), true
+	default:
+		return "", false
+	}
+}
+
+/*
+将参数的值转换为float64，会先剥去Option以及enum的包装
+*/
+func ValueToFloat64(value interface{}) (float64, bool) {
+	switch v := UnwrapValue(value).(type) {
+	case float64:
+		return v, true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		f, ok := new(big.Float).SetString(v)
+		if !ok {
+			return 0, false
+		}
+		r, _ := f.Float64()
+		return r, true
+	default:
+		return 0, false
+	}
+}